@@ -12,19 +12,24 @@ import (
 	"strings"
 
 	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/mcp"
+	"github.com/fourhu/eino-ai-agent/internal/progress"
 	"github.com/spf13/cobra"
 )
 
 var (
-	clientServerURL string
-	clientSession   string
-	clientModel     string
+	clientServerURL  string
+	clientSession    string
+	clientModel      string
+	clientReasoning  bool
+	clientAdminToken string
 )
 
 // Message represents a chat message
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role             string `json:"role"`
+	Content          string `json:"content"`
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 // ChatRequest represents a chat completion request
@@ -46,6 +51,11 @@ type ChatResponse struct {
 		Delta        Message `json:"delta"`
 		FinishReason string  `json:"finish_reason"`
 	} `json:"choices"`
+
+	// Error is set when the server failed after the stream started
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
 }
 
 func init() {
@@ -53,6 +63,8 @@ func init() {
 	clientCmd.Flags().StringVarP(&clientServerURL, "server", "s", "http://localhost:8000", "Server URL")
 	clientCmd.Flags().StringVarP(&clientSession, "session", "n", "", "Session ID (auto-generated if not provided)")
 	clientCmd.Flags().StringVarP(&clientModel, "model", "m", "glm-4.7", "Model name")
+	clientCmd.Flags().BoolVar(&clientReasoning, "show-reasoning", true, "Show the model's reasoning in dim text")
+	clientCmd.Flags().StringVar(&clientAdminToken, "admin-token", "", "Admin token for /tools (not needed on loopback without a token)")
 }
 
 var clientCmd = &cobra.Command{
@@ -91,6 +103,7 @@ func runClient() error {
 	fmt.Println("Commands:")
 	fmt.Println("  /new    - Start a new session")
 	fmt.Println("  /clear  - Clear screen")
+	fmt.Println("  /tools  - List the MCP tools")
 	fmt.Println("  /help   - Show help")
 	fmt.Println()
 
@@ -118,6 +131,11 @@ func runClient() error {
 		case "/clear":
 			fmt.Print("\033[H\033[2J")
 			continue
+		case "/tools":
+			if err := printTools(); err != nil {
+				fmt.Printf("Error: %v\n\n", err)
+			}
+			continue
 		case "/help":
 			printHelp()
 			continue
@@ -150,11 +168,70 @@ func printHelp() {
 	fmt.Println("\nCommands:")
 	fmt.Println("  /new    - Start a new session")
 	fmt.Println("  /clear  - Clear screen")
+	fmt.Println("  /tools  - List the MCP tools")
 	fmt.Println("  /help   - Show this help")
 	fmt.Println("  exit    - Exit the client")
 	fmt.Println()
 }
 
+// printTools lists the MCP tools by server, marking the tools of servers that are down and the mutating ones
+func printTools() error {
+	httpReq, err := http.NewRequest("GET", clientServerURL+"/admin/mcp/tools", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if clientAdminToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+clientAdminToken)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned error: %s - %s", resp.Status, string(body))
+	}
+	var list struct {
+		Data []mcp.ToolInfo `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return fmt.Errorf("failed to decode tools: %w", err)
+	}
+
+	if len(list.Data) == 0 {
+		fmt.Print("\nNo MCP tools loaded\n\n")
+		return nil
+	}
+	const dim, reset = "\033[2m", "\033[0m"
+	server := ""
+	for _, t := range list.Data {
+		if t.Server != server {
+			server = t.Server
+			fmt.Printf("\n%s:\n", server)
+		}
+		var marks []string
+		if !t.Enabled {
+			marks = append(marks, "unavailable")
+		}
+		if t.Mutating {
+			marks = append(marks, "mutating")
+		}
+		line := "  " + t.Name
+		if len(marks) > 0 {
+			line += fmt.Sprintf(" %s[%s]%s", dim, strings.Join(marks, ", "), reset)
+		}
+		if t.Description != "" {
+			line += " - " + strings.SplitN(t.Description, "\n", 2)[0]
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+	return nil
+}
+
 func sendStreamMessage(message string) error {
 	req := ChatRequest{
 		Model:   clientModel,
@@ -193,6 +270,8 @@ func sendStreamMessage(message string) error {
 	fmt.Print("\nAssistant: ")
 	reader := bufio.NewReader(resp.Body)
 	contentReceived := false
+	inReasoning := false
+	eventName := ""
 	for {
 		line, err := reader.ReadString('\n')
 		if err == io.EOF {
@@ -205,8 +284,18 @@ func sendStreamMessage(message string) error {
 		line = strings.TrimSpace(line)
 		logger.Debugf("Received line: %q", line)
 
-		// Skip empty lines and non-data lines
-		if line == "" || !strings.HasPrefix(line, "data:") {
+		// Track named events; an empty line ends the current event
+		if line == "" {
+			eventName = ""
+			continue
+		}
+		if strings.HasPrefix(line, "event:") {
+			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			continue
+		}
+
+		// Skip non-data lines
+		if !strings.HasPrefix(line, "data:") {
 			continue
 		}
 
@@ -217,6 +306,11 @@ func sendStreamMessage(message string) error {
 			break
 		}
 
+		if eventName != "" {
+			printToolActivity(eventName, data)
+			continue
+		}
+
 		var streamResp ChatResponse
 		if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
 			logger.Debugf("Failed to unmarshal stream data: %v, data: %s", err, data)
@@ -224,9 +318,23 @@ func sendStreamMessage(message string) error {
 		}
 
 		logger.Debugf("Parsed response: %+v", streamResp)
+		if streamResp.Error != nil {
+			return fmt.Errorf("server error: %s", streamResp.Error.Message)
+		}
 
 		if len(streamResp.Choices) > 0 {
+			if reasoning := streamResp.Choices[0].Delta.ReasoningContent; reasoning != "" && clientReasoning {
+				if !inReasoning {
+					fmt.Print("\033[2m")
+					inReasoning = true
+				}
+				fmt.Print(reasoning)
+			}
 			content := streamResp.Choices[0].Delta.Content
+			if content != "" && inReasoning {
+				fmt.Print("\033[0m\n\n")
+				inReasoning = false
+			}
 			if content != "" {
 				// Skip MCP tool result JSON format
 				if isMCPToolResult(content) {
@@ -243,14 +351,77 @@ func sendStreamMessage(message string) error {
 			}
 		}
 	}
+	if inReasoning {
+		fmt.Print("\033[0m")
+	}
 	if !contentReceived {
 		fmt.Print("(no content received)")
 	}
-	fmt.Println("\n")
+	fmt.Print("\n\n")
 
 	return nil
 }
 
+// toolActivity mirrors the server's tool activity SSE payload
+type toolActivity struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result"`
+
+	// Progress events
+	Progress *progress.Update `json:"progress"`
+
+	// Approval events use the agent's field names
+	CallID   string `json:"call_id"`
+	ToolName string `json:"tool_name"`
+
+	// Guardrail events
+	Check  string `json:"check"`
+	Reason string `json:"reason"`
+
+	// Limit events
+	Limit string  `json:"limit"`
+	Max   float64 `json:"max"`
+}
+
+// printToolActivity renders a tool call, progress or result event in dim text
+func printToolActivity(eventName, data string) {
+	var activity toolActivity
+	if err := json.Unmarshal([]byte(data), &activity); err != nil {
+		logger.Debugf("Failed to unmarshal tool event: %v, data: %s", err, data)
+		return
+	}
+
+	const dim, reset = "\033[2m", "\033[0m"
+	switch eventName {
+	case "tool_call_started":
+		fmt.Printf("\n%s[calling %s %s]%s\n", dim, activity.Name, activity.Arguments, reset)
+	case "tool_progress":
+		if p := activity.Progress; p != nil {
+			status := fmt.Sprintf("%g", p.Progress)
+			if p.Total > 0 {
+				status = fmt.Sprintf("%.0f%%", 100*p.Progress/p.Total)
+			}
+			if p.Message != "" {
+				status = p.Message + " " + status
+			}
+			fmt.Printf("%s[%s: %s]%s\n", dim, activity.Name, status, reset)
+		}
+	case "tool_result":
+		fmt.Printf("%s[%s returned %d bytes]%s\n", dim, activity.Name, len(activity.Result), reset)
+	case "cancelled":
+		fmt.Printf("\n%s[cancelled]%s\n", dim, reset)
+	case "limit_exceeded":
+		fmt.Printf("\n%s[stopped: session limit %s (%g) exceeded]%s\n", dim, activity.Limit, activity.Max, reset)
+	case "guardrail_blocked":
+		fmt.Printf("\n%s[answer blocked by guardrail %s: %s]%s\n", dim, activity.Check, activity.Reason, reset)
+	case "approval_required":
+		fmt.Printf("\n%s[%s %s is waiting for approval: POST /v1/sessions/<session>/approvals/%s]%s\n",
+			dim, activity.ToolName, activity.Arguments, activity.CallID, reset)
+	}
+}
+
 func generateSessionID() string {
 	// Simple session ID generation
 	return fmt.Sprintf("session-%d", os.Getpid())
@@ -1,22 +1,32 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 
 	"github.com/spf13/cobra"
 
 	openaiModel "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
 
 	"github.com/fourhu/eino-ai-agent/internal/agent"
 	"github.com/fourhu/eino-ai-agent/internal/api"
+	"github.com/fourhu/eino-ai-agent/internal/audit"
 	"github.com/fourhu/eino-ai-agent/internal/config"
+	"github.com/fourhu/eino-ai-agent/internal/files"
 	"github.com/fourhu/eino-ai-agent/internal/logger"
 	"github.com/fourhu/eino-ai-agent/internal/mcp"
 	"github.com/fourhu/eino-ai-agent/internal/memory"
+	"github.com/fourhu/eino-ai-agent/internal/rag"
+	"github.com/fourhu/eino-ai-agent/internal/tasks"
+	localtools "github.com/fourhu/eino-ai-agent/internal/tools"
 )
 
 var (
@@ -116,18 +126,43 @@ func runServer(cmd *cobra.Command, args []string) error {
 	// Initialize memory store
 	var memStore memory.Store
 	var redisStore *memory.RedisStore
+	codec, err := memory.NewCodec(cfg.Memory.Encoding)
+	if err != nil {
+		return fmt.Errorf("invalid memory configuration: %w", err)
+	}
 	switch cfg.Memory.Type {
 	case "redis":
 		if cfg.Memory.Address == "" {
 			return fmt.Errorf("redis address is required when memory type is 'redis'")
 		}
 		var err error
-		redisStore, err = memory.NewRedisStoreFromAddress(ctx, cfg.Memory.Address, cfg.Memory.Prefix)
+		redisStore, err = memory.NewRedisStoreFromAddress(ctx, cfg.Memory.Address, cfg.Memory.Prefix,
+			memory.WithTTL(cfg.Memory.TTL), memory.WithTenantTTLs(cfg.Memory.TenantTTLs), memory.WithCodec(codec))
 		if err != nil {
 			return fmt.Errorf("failed to initialize Redis store: %w", err)
 		}
 		memStore = redisStore
 		logger.Infof("Initialized Redis memory store at %s", cfg.Memory.Address)
+		if cfg.Memory.TTL > 0 {
+			logger.Infof("Sessions expire after %s of inactivity", cfg.Memory.TTL)
+		}
+	case "file":
+		if cfg.Memory.Dir == "" {
+			return fmt.Errorf("memory dir is required when memory type is 'file'")
+		}
+		fileStore, err := memory.NewFileStore(cfg.Memory.Dir)
+		if err != nil {
+			return fmt.Errorf("failed to initialize file store: %w", err)
+		}
+		memStore = fileStore
+		logger.Infof("Initialized file memory store in %s", cfg.Memory.Dir)
+	case "s3":
+		s3Store, err := memory.NewS3Store(ctx, cfg.Memory.S3, codec)
+		if err != nil {
+			return fmt.Errorf("failed to initialize S3 store: %w", err)
+		}
+		memStore = s3Store
+		logger.Infof("Initialized S3 memory store in bucket %s", cfg.Memory.S3.Bucket)
 	case "inmem":
 		memStore = memory.NewInMemoryStore()
 		logger.Info("Initialized in-memory store")
@@ -143,8 +178,22 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	// Initialize MCP manager
-	mcpManager := mcp.NewManager(cfg.GetEnabledMCPServers())
+	// Open the tool call audit log
+	var auditSink audit.Sink
+	if cfg.Audit.Enabled {
+		if auditSink, err = newAuditSink(ctx, cfg); err != nil {
+			return err
+		}
+		defer func() {
+			if err := auditSink.Close(); err != nil {
+				logger.Warnf("Failed to close audit log: %v", err)
+			}
+		}()
+		logger.Infof("Recording tool calls to %s audit log", cmp.Or(cfg.Audit.Type, "file"))
+	}
+
+	// Initialize MCP manager; disabled servers are kept so they can be enabled at runtime
+	mcpManager := mcp.NewManager(slices.Clone(cfg.MCP.Servers))
 	if len(cfg.GetEnabledMCPServers()) > 0 {
 		logger.Info("Initializing MCP servers...")
 		if err := mcpManager.Initialize(ctx); err != nil {
@@ -156,35 +205,305 @@ func runServer(cmd *cobra.Command, args []string) error {
 		logger.Info("No MCP servers configured")
 	}
 	defer mcpManager.Close()
+	if cfg.MCP.Health.Enabled {
+		health := mcp.HealthPolicy{
+			Interval:         cfg.MCP.Health.Interval,
+			Timeout:          cfg.MCP.Health.Timeout,
+			DegradedLatency:  cfg.MCP.Health.DegradedLatency,
+			FailureThreshold: cfg.MCP.Health.FailureThreshold,
+		}
+		if cfg.MCP.Reconnect.Enabled {
+			health.Reconnect = &mcp.ReconnectPolicy{
+				InitialBackoff: cfg.MCP.Reconnect.InitialBackoff,
+				MaxBackoff:     cfg.MCP.Reconnect.MaxBackoff,
+			}
+		}
+		mcpManager.MonitorHealth(health)
+	}
 
-	// Create chat model
-	chatModel, err := openaiModel.NewChatModel(ctx, &openaiModel.ChatModelConfig{
-		BaseURL: cfg.Model.BaseURL,
-		APIKey:  cfg.Model.APIKey,
-		Model:   cfg.Model.Model,
-	})
+	// Create chat models, one per configured backend; the first is the primary model
+	backends := cfg.ModelBackends()
+	chatModels := make([]model.ToolCallingChatModel, 0, len(backends))
+	for _, backend := range backends {
+		chatModel, err := openaiModel.NewChatModel(ctx, &openaiModel.ChatModelConfig{
+			BaseURL: backend.BaseURL,
+			APIKey:  backend.APIKey,
+			Model:   backend.Model,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create chat model %s: %w", backend.Model, err)
+		}
+		normalized, err := agent.WithToolSchema(chatModel, backend.ToolSchema)
+		if err != nil {
+			return fmt.Errorf("invalid model %s: %w", backend.Model, err)
+		}
+		logger.Infof("Created chat model: %s", backend.Model)
+		chatModels = append(chatModels, normalized)
+	}
+	chatModel := chatModels[0]
+
+	// Register built-in local tools alongside MCP tools
+	tools := mcpManager.GetTools()
+	builtinTools, err := localtools.New(&cfg.BuiltinTools)
 	if err != nil {
-		return fmt.Errorf("failed to create chat model: %w", err)
+		return err
+	}
+	if len(builtinTools) > 0 {
+		tools = append(tools, builtinTools...)
+		logger.Infof("Enabled %d built-in tools", len(builtinTools))
+	}
+
+	// Initialize knowledge base
+	var ragService *rag.Service
+	if cfg.RAG.Enabled {
+		ragService, err = newRAGService(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize RAG: %w", err)
+		}
+		searchTool, err := ragService.SearchTool()
+		if err != nil {
+			return fmt.Errorf("failed to create knowledge search tool: %w", err)
+		}
+		tools = append(tools, searchTool)
+		logger.Infof("Initialized RAG with embedding model %s", cfg.RAG.EmbeddingModel)
 	}
-	logger.Infof("Created chat model: %s", cfg.Model.Model)
 
 	// Create agent
 	agentConfig := &agent.Config{
 		Model:        chatModel,
-		Tools:        mcpManager.GetTools(),
+		Tools:        tools,
 		SystemPrompt: cfg.Agent.SystemPrompt,
 		MaxSteps:     cfg.Agent.MaxSteps,
 		MemoryStore:  memStore,
+		Audit:        auditSink,
+		MaxSessions:  cfg.Agent.MaxSessions,
+		SessionTTL:   cfg.Agent.SessionTTL,
+		ToolTimeout:  cfg.Agent.ToolTimeout,
+		ToolTimeouts: cfg.Agent.ToolTimeouts,
+
+		MaxToolResultBytes:  cfg.Agent.MaxToolResultBytes,
+		MaxToolResultTokens: cfg.Agent.MaxToolResultTokens,
+		Retry: &agent.RetryConfig{
+			MaxAttempts:    cfg.Model.Retry.MaxAttempts,
+			InitialBackoff: cfg.Model.Retry.InitialBackoff,
+			MaxBackoff:     cfg.Model.Retry.MaxBackoff,
+		},
+
+		ApprovalTools: cfg.Agent.ApprovalTools,
+		ApprovalTTL:   cfg.Agent.ApprovalTTL,
+		ReadOnly:      cfg.Agent.ReadOnly,
+		MutatingTools: cfg.Agent.MutatingTools,
+		Limits: agent.Limits{
+			MaxTurnsPerMinute:   cfg.Agent.Limits.MaxTurnsPerMinute,
+			MaxToolCallsPerTurn: cfg.Agent.Limits.MaxToolCallsPerTurn,
+			MaxSessionTokens:    cfg.Agent.Limits.MaxSessionTokens,
+			ToolCosts:           cfg.Agent.Limits.ToolCosts,
+			MaxToolCostPerTurn:  cfg.Agent.Limits.MaxToolCostPerTurn,
+			MaxSessionToolCost:  cfg.Agent.Limits.MaxSessionToolCost,
+		},
+	}
+	if agentConfig.Guardrails, err = newGuardrails(cfg.Agent.Guardrails, chatModel, cfg.Model.APIKey); err != nil {
+		return err
+	}
+	for pattern, specs := range cfg.Agent.ToolResultTransforms {
+		for _, spec := range specs {
+			transform, err := agent.ParseToolResultTransformer(spec)
+			if err != nil {
+				return fmt.Errorf("invalid tool result transform for %s: %w", pattern, err)
+			}
+			if agentConfig.ToolResultTransformers == nil {
+				agentConfig.ToolResultTransformers = make(map[string][]agent.ToolResultTransformer)
+			}
+			agentConfig.ToolResultTransformers[pattern] = append(agentConfig.ToolResultTransformers[pattern], transform)
+		}
+	}
+	for _, sub := range cfg.Agent.SubAgents {
+		agentConfig.SubAgents = append(agentConfig.SubAgents, agent.SubAgentConfig{
+			Name:        sub.Name,
+			Description: sub.Description,
+			Instruction: sub.SystemPrompt,
+			Tools:       mcpManager.GetServerTools(sub.MCPServers...),
+			MaxSteps:    sub.MaxSteps,
+		})
 	}
 
-	aiAgent, err := agent.NewAgent(ctx, agentConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create agent: %w", err)
+	// Create one agent per model; they share tools, memory and the event bus
+	agentConfig.EventBus = agent.NewEventBus()
+	agents := agent.NewPool()
+	for i, backend := range backends {
+		backendConfig := *agentConfig
+		backendConfig.Model = chatModels[i]
+		backendConfig.Tools = slices.Clone(agentConfig.Tools)
+		backendConfig.Retry = &agent.RetryConfig{
+			MaxAttempts:    backend.Retry.MaxAttempts,
+			InitialBackoff: backend.Retry.InitialBackoff,
+			MaxBackoff:     backend.Retry.MaxBackoff,
+		}
+		modelAgent, err := agent.NewAgent(ctx, &backendConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create agent for %s: %w", backend.Model, err)
+		}
+		logger.Infof("Created ReAct agent for %s", backend.Model)
+		if err := modelAgent.Validate(ctx); err != nil {
+			logger.Warnf("Agent preflight for %s found problems:\n%v", backend.Model, err)
+		}
+		agents.Add(backend.Model, modelAgent)
+	}
+	if cfg.DefaultModel != "" {
+		if err := agents.SetDefault(cfg.DefaultModel); err != nil {
+			return fmt.Errorf("invalid default model: %w", err)
+		}
 	}
-	logger.Info("Created ReAct agent")
+	aiAgent, _ := agents.Get("")
+
+	// Keep the agents' and sub-agents' tools in sync when MCP servers are reconnected, enabled or disabled at runtime
+	subAgents := slices.Clone(cfg.Agent.SubAgents)
+	mcpManager.OnToolsChanged(func(ctx context.Context, removed []string, added []tool.BaseTool) {
+		subAgentTools := make(map[string][]tool.BaseTool, len(subAgents))
+		for _, sub := range subAgents {
+			subAgentTools[sub.Name] = mcpManager.GetServerTools(sub.MCPServers...)
+		}
+		for _, name := range agents.Models() {
+			a, _ := agents.Get(name)
+			if err := a.ReplaceTools(ctx, removed, added, subAgentTools); err != nil {
+				logger.Ctx(ctx).Warnf("Failed to update MCP tools of %s: %v", name, err)
+			}
+		}
+	})
 
 	// Create and start API server
-	apiServer := api.NewServer(aiAgent, cfg.Model.Model, cfg.GetAddress())
+	var serverOpts []api.Option
+	if cfg.Metrics.Enabled {
+		serverOpts = append(serverOpts, api.WithMetrics(cfg.Metrics.Path))
+	}
+	serverOpts = append(serverOpts, api.WithStreamTrailers(cfg.Metrics.StreamTrailers))
+	serverOpts = append(serverOpts, api.WithRequestTimeout(cfg.Server.RequestTimeout))
+	if cfg.Server.SlowRequest > 0 {
+		serverOpts = append(serverOpts, api.WithSlowRequestLog(cfg.Server.SlowRequest))
+	}
+	if cfg.Server.UserSessions.Enabled {
+		serverOpts = append(serverOpts, api.WithUserSessions(cfg.Server.UserSessions.PerConversation))
+	}
+	if cfg.Server.Idempotency.Enabled {
+		serverOpts = append(serverOpts, api.WithIdempotency(cfg.Server.Idempotency.TTL, cfg.Server.Idempotency.MaxEntries))
+	}
+	serverOpts = append(serverOpts, api.WithMCP(mcpManager))
+	for _, backend := range backends {
+		baseURL := cmp.Or(backend.BaseURL, "https://api.openai.com/v1")
+		serverOpts = append(serverOpts, api.WithHealthChecks(api.ModelHealthCheck(backend.Model, baseURL, backend.APIKey)))
+	}
+	if pinger, ok := memStore.(memory.Pinger); ok {
+		serverOpts = append(serverOpts, api.WithHealthChecks(api.HealthCheck{Name: "memory", Critical: true, Check: pinger.Ping}))
+	}
+	if cfg.Embeddings.Enabled {
+		embedder, model, err := newEmbedder(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize embeddings endpoint: %w", err)
+		}
+		serverOpts = append(serverOpts, api.WithEmbeddings(embedder, model))
+		logger.Infof("Serving embeddings with default model %s", model)
+	}
+	if cfg.Files.Enabled {
+		store := files.Store(files.NewMemoryStore())
+		if cfg.Files.Dir != "" {
+			if store, err = files.NewDirStore(cfg.Files.Dir); err != nil {
+				return fmt.Errorf("failed to initialize file store: %w", err)
+			}
+		}
+		serverOpts = append(serverOpts, api.WithFiles(files.NewService(store, cfg.Files.MaxBytes)))
+	}
+	if cfg.Audio.Enabled {
+		baseURL := cmp.Or(cfg.Audio.BaseURL, cfg.Model.BaseURL)
+		serverOpts = append(serverOpts, api.WithTranscription(baseURL, cmp.Or(cfg.Audio.APIKey, cfg.Model.APIKey)))
+		logger.Infof("Forwarding audio transcriptions to %s", baseURL)
+	}
+	if cfg.Server.Debug.Enabled {
+		serverOpts = append(serverOpts, api.WithDebug(cfg.Server.Debug.Token))
+	}
+	if cfg.Server.WebUI {
+		serverOpts = append(serverOpts, api.WithWebUI())
+	}
+	if cfg.Server.Tenants.Enabled {
+		serverOpts = append(serverOpts, api.WithTenants(cfg.Server.Tenants.Keys, cfg.Server.Tenants.Required, cfg.Server.Tenants.KeysOnly))
+	}
+	if cfg.Server.Usage.Enabled {
+		if usageStore, ok := memStore.(memory.UsageStore); ok {
+			serverOpts = append(serverOpts, api.WithUsageAccounting(usageStore))
+		} else {
+			logger.Warnf("Memory store does not support usage accounting, /v1/usage disabled")
+		}
+	}
+	if q := cfg.Server.Quotas; q.Enabled {
+		if quotaStore, ok := memStore.(memory.QuotaStore); ok {
+			serverOpts = append(serverOpts, api.WithSpendQuotas(quotaStore, api.SpendQuotas{
+				Session:         api.Budget{Tokens: q.Session.Tokens, Cost: q.Session.Cost},
+				Tenant:          api.Budget{Tokens: q.Tenant.Tokens, Cost: q.Tenant.Cost},
+				PromptPrice:     q.PromptPrice,
+				CompletionPrice: q.CompletionPrice,
+			}))
+		} else {
+			logger.Warnf("Memory store does not support spend quotas, quotas disabled")
+		}
+	}
+	// The configuration file is reloaded on SIGHUP and POST /admin/reload
+	var apiServer *api.Server
+	var reloadMu sync.Mutex
+	reload := func(ctx context.Context) error {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		return reloadConfig(ctx, mcpManager, agents, apiServer)
+	}
+	if cfg.Server.Admin.Enabled {
+		serverOpts = append(serverOpts, api.WithAdmin(cfg.Server.Admin.Token), api.WithReload(reload))
+	}
+	scheme := "http"
+	if cfg.Server.TLS.CertFile != "" {
+		tlsConfig, err := api.LoadTLSConfig(api.TLSConfig{
+			CertFile:          cfg.Server.TLS.CertFile,
+			KeyFile:           cfg.Server.TLS.KeyFile,
+			ClientCAFile:      cfg.Server.TLS.ClientCAFile,
+			RequireClientCert: cfg.Server.TLS.RequireClientCert,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		serverOpts = append(serverOpts, api.WithTLS(tlsConfig))
+		scheme = "https"
+	}
+	serverOpts = append(serverOpts, api.WithRateLimit(cfg.Server.RateLimit.RPS, cfg.Server.RateLimit.Burst))
+	serverOpts = append(serverOpts, api.WithConcurrencyLimit(cfg.Server.Concurrency.MaxRuns, cfg.Server.Concurrency.MaxQueue, cfg.Server.Concurrency.QueueTimeout))
+	serverOpts = append(serverOpts, api.WithCORS(api.CORSConfig{
+		AllowedOrigins:   cfg.Server.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.Server.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.Server.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.Server.CORS.ExposedHeaders,
+		AllowCredentials: cfg.Server.CORS.AllowCredentials,
+		MaxAge:           cfg.Server.CORS.MaxAge,
+	}))
+	if ragService != nil {
+		serverOpts = append(serverOpts, api.WithRAG(ragService))
+	}
+
+	// Start scheduled tasks
+	if cfg.Tasks.Enabled {
+		scheduler := tasks.NewScheduler(func(name string) (tasks.ChatRunner, error) {
+			a, ok := agents.Get(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown agent: %s", name)
+			}
+			return a, nil
+		}, tasks.WithOutputDir(cfg.Tasks.OutputDir))
+		for _, t := range cfg.Tasks.Tasks {
+			if err := scheduler.Add(t); err != nil {
+				return fmt.Errorf("failed to register task: %w", err)
+			}
+		}
+		scheduler.Start(ctx)
+		defer scheduler.Stop()
+		serverOpts = append(serverOpts, api.WithTasks(scheduler))
+	}
+	serverOpts = append(serverOpts, api.WithAgents(agents))
+	apiServer = api.NewServer(aiAgent, agents.DefaultModel(), cfg.GetAddress(), serverOpts...)
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -196,8 +515,22 @@ func runServer(cmd *cobra.Command, args []string) error {
 		apiServer.Stop(ctx)
 	}()
 
-	logger.Infof("Starting server on http://%s", cfg.GetAddress())
-	logger.Infof("API endpoint: http://%s/v1/chat/completions", cfg.GetAddress())
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			logger.Info("Received SIGHUP, reloading configuration...")
+			if err := reload(ctx); err != nil {
+				logger.Errorf("Failed to reload configuration: %v", err)
+			}
+		}
+	}()
+
+	logger.Infof("Starting server on %s://%s", scheme, cfg.GetAddress())
+	logger.Infof("API endpoint: %s://%s/v1/chat/completions", scheme, cfg.GetAddress())
+	if cfg.Server.WebUI {
+		logger.Infof("Chat UI: %s://%s/", scheme, cfg.GetAddress())
+	}
 
 	if err := apiServer.Start(); err != nil {
 		return fmt.Errorf("server error: %w", err)
@@ -205,3 +538,172 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// reloadConfig re-reads the config file and applies the log level, MCP server set, system prompt and rate limit.
+// Sessions and runs in progress are kept; other settings take effect on restart.
+func reloadConfig(ctx context.Context, mcpManager *mcp.Manager, agents *agent.Pool, apiServer *api.Server) error {
+	if configFile == "" {
+		return fmt.Errorf("server was started without a config file")
+	}
+	cfg, err := config.LoadFromFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if debugMode {
+		cfg.Log.Level = "debug"
+	}
+
+	if err := logger.SetLevel(cfg.Log.Level); err != nil {
+		return fmt.Errorf("failed to set log level: %w", err)
+	}
+	for _, name := range agents.Models() {
+		a, _ := agents.Get(name)
+		if err := a.SetSystemPrompt(ctx, cfg.Agent.SystemPrompt); err != nil {
+			return fmt.Errorf("failed to update system prompt of %s: %w", name, err)
+		}
+	}
+	if err := apiServer.SetRateLimit(cfg.Server.RateLimit.RPS, cfg.Server.RateLimit.Burst); err != nil {
+		return err
+	}
+	// Changed servers reconnect and update the agents' tools through OnToolsChanged
+	if err := mcpManager.Sync(ctx, slices.Clone(cfg.MCP.Servers)); err != nil {
+		return fmt.Errorf("failed to connect MCP servers: %w", err)
+	}
+
+	logger.Infof("Reloaded configuration from %s", configFile)
+	return nil
+}
+
+// newRAGService creates the knowledge base service, falling back to the chat model endpoint for embeddings
+func newRAGService(cfg *config.Config) (*rag.Service, error) {
+	baseURL := cfg.RAG.BaseURL
+	if baseURL == "" {
+		baseURL = cfg.Model.BaseURL
+	}
+	apiKey := cfg.RAG.APIKey
+	if apiKey == "" {
+		apiKey = cfg.Model.APIKey
+	}
+
+	embedder, err := rag.NewOpenAIEmbedder(&rag.OpenAIEmbedderConfig{
+		BaseURL: baseURL,
+		APIKey:  apiKey,
+		Model:   cfg.RAG.EmbeddingModel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rag.NewService(embedder, &rag.Config{
+		ChunkSize:    cfg.RAG.ChunkSize,
+		ChunkOverlap: cfg.RAG.ChunkOverlap,
+		TopK:         cfg.RAG.TopK,
+		MinScore:     cfg.RAG.MinScore,
+	}), nil
+}
+
+// newAuditSink opens the audit log sink of the configuration
+func newAuditSink(ctx context.Context, cfg *config.Config) (audit.Sink, error) {
+	switch cfg.Audit.Type {
+	case "", "file":
+		if cfg.Audit.Path == "" {
+			return nil, fmt.Errorf("audit path is required when audit type is 'file'")
+		}
+		sink, err := audit.NewFileSink(cfg.Audit.Path)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case "redis":
+		address := cmp.Or(cfg.Audit.Address, cfg.Memory.Address)
+		if address == "" {
+			return nil, fmt.Errorf("redis address is required when audit type is 'redis'")
+		}
+		sink, err := audit.NewRedisSinkFromAddress(ctx, address, cfg.Audit.Stream, cfg.Audit.MaxLen)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	default:
+		return nil, fmt.Errorf("unsupported audit type: %s", cfg.Audit.Type)
+	}
+}
+
+// newEmbedder creates the embedder of the embeddings endpoint, falling back to the RAG settings and then the chat model endpoint
+func newEmbedder(cfg *config.Config) (*rag.OpenAIEmbedder, string, error) {
+	e := cfg.Embeddings
+	if e.BaseURL == "" {
+		e.BaseURL = cmp.Or(cfg.RAG.BaseURL, cfg.Model.BaseURL)
+	}
+	if e.APIKey == "" {
+		e.APIKey = cmp.Or(cfg.RAG.APIKey, cfg.Model.APIKey)
+	}
+	if e.Model == "" {
+		e.Model = cfg.RAG.EmbeddingModel
+	}
+
+	embedder, err := rag.NewOpenAIEmbedder(&rag.OpenAIEmbedderConfig{
+		BaseURL: e.BaseURL,
+		APIKey:  e.APIKey,
+		Model:   e.Model,
+	})
+	return embedder, e.Model, err
+}
+
+// newGuardrails converts the configured guardrails; moderation checks use their provider, or else the chat model
+func newGuardrails(configs []config.GuardrailConfig, moderationModel model.BaseChatModel, apiKey string) ([]agent.Guardrail, error) {
+	var guardrails []agent.Guardrail
+	for i, gc := range configs {
+		var check agent.GuardrailCheck
+		var err error
+		switch gc.Type {
+		case "keywords":
+			check, err = agent.NewKeywordCheck("keywords", gc.Keywords...)
+		case "patterns":
+			check, err = agent.NewPatternCheck("patterns", gc.Patterns...)
+		case "max_length":
+			check = &agent.MaxLengthCheck{MaxRunes: gc.MaxLength}
+		case "prompt_injection":
+			check = agent.NewPromptInjectionCheck()
+		case "moderation":
+			if gc.BaseURL == "" {
+				check = &agent.ModerationCheck{Model: moderationModel}
+				break
+			}
+			key := gc.APIKey
+			if key == "" {
+				key = apiKey
+			}
+			check, err = agent.NewModerationAPICheck(&agent.ModerationAPIConfig{
+				BaseURL:    gc.BaseURL,
+				APIKey:     key,
+				Model:      gc.Model,
+				Categories: gc.Categories,
+			})
+		default:
+			err = fmt.Errorf("unknown type %q", gc.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid guardrail %d: %w", i, err)
+		}
+
+		g := agent.Guardrail{Check: check, Action: agent.GuardrailAction(gc.Action)}
+		switch gc.Action {
+		case "", "block", "redact", "flag":
+		default:
+			return nil, fmt.Errorf("invalid guardrail %d: unknown action %q", i, gc.Action)
+		}
+		switch gc.Apply {
+		case "", "input":
+			g.Input = true
+		case "output":
+			g.Output = true
+		case "both":
+			g.Input, g.Output = true, true
+		default:
+			return nil, fmt.Errorf("invalid guardrail %d: apply must be input, output or both", i)
+		}
+		guardrails = append(guardrails, g)
+	}
+	return guardrails, nil
+}
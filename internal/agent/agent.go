@@ -2,18 +2,24 @@
 package agent
 
 import (
+	"container/list"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/eino/adk"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 
+	"github.com/fourhu/eino-ai-agent/internal/audit"
 	"github.com/fourhu/eino-ai-agent/internal/logger"
 	"github.com/fourhu/eino-ai-agent/internal/memory"
 )
@@ -21,27 +27,82 @@ import (
 // Config is the agent configuration
 type Config struct {
 	Model        model.ToolCallingChatModel
-	Tools        []tool.BaseTool
+	Tools        []tool.BaseTool // MCP, built-in or application-supplied tools; more can be added with AddTool
 	SystemPrompt string
 	MaxSteps     int
 	MaxHistory   int // Max conversation rounds to keep (0 = unlimited)
 	MemoryStore  memory.Store
+
+	MaxSessions int           // Max sessions kept in memory, least recently used are evicted (0 = unlimited)
+	SessionTTL  time.Duration // Idle time after which a session is evicted from memory (0 = never)
+
+	// Retry is the retry policy for failed model calls (nil = no retries)
+	Retry *RetryConfig
+
+	// ApprovalTools lists tool name patterns (path.Match globs) that pause the run until approved
+	ApprovalTools []string
+	// ApprovalTTL is how long a paused run waits for decisions before it is dropped (default 24h).
+	// Paused runs are kept in memory and do not survive a restart.
+	ApprovalTTL time.Duration
+
+	// ReadOnly blocks mutating tools in every session; sessions can also be made read-only one by one
+	// (SetSessionReadOnly) or per turn (WithReadOnly). Mutating tools are those implementing MutatingTool
+	// and those matching MutatingTools (path.Match globs).
+	ReadOnly      bool
+	MutatingTools []string
+
+	ToolTimeout  time.Duration            // Default timeout per tool call (0 = none)
+	ToolTimeouts map[string]time.Duration // Per-tool overrides keyed by tool name or glob pattern
+
+	MaxToolResultBytes  int // Tool results above this size are truncated, keeping head and tail (0 = unlimited)
+	MaxToolResultTokens int // Same limit expressed in approximate tokens; the smaller limit wins
+
+	// ToolResultTransformers post-process results of tools matching the key (tool name or glob), before truncation
+	ToolResultTransformers map[string][]ToolResultTransformer
+
+	// Hooks receives lifecycle notifications (nil = none)
+	Hooks Hooks
+
+	// Audit receives a record of every tool call (nil = no audit log)
+	Audit audit.Sink
+
+	// Limits caps turns, tool calls and tokens per session
+	Limits Limits
+
+	// EventBus receives turn, tool and error events; share one bus across agents to observe them all (nil = private bus)
+	EventBus *EventBus
+
+	// SubAgents are specialist agents the main agent can delegate to (supervisor pattern)
+	SubAgents []SubAgentConfig
+
+	// Guardrails check user input and assistant output, in order
+	Guardrails []Guardrail
+
+	// Middlewares are custom ADK middlewares run after the built-in ones
+	Middlewares []adk.AgentMiddleware
 }
 
 // Session represents a conversation session
 type Session struct {
 	ID       string
 	Messages []*schema.Message
+	Meta     memory.SessionMeta
 	mu       sync.RWMutex
+
+	lastAccess time.Time
+	elem       *list.Element // Position in the agent's LRU list
 }
 
 // Agent is a multi-turn conversation ChatModel agent using ADK
 type Agent struct {
 	config      *Config
 	runner      *adk.Runner
-	sessions    map[string]*Session
-	sessionMu   sync.RWMutex
+	runnerMu    sync.RWMutex // Guards runner, config.Tools and config.SystemPrompt, which AddTool and SetSystemPrompt replace
+	checkpoints *checkpointStore
+	*sessionCache
 	memoryStore memory.Store
+	approvals   *approvalRegistry
+	limiter     *turnLimiter
 }
 
 // NewAgent creates a new ADK ChatModel agent with Runner
@@ -49,7 +110,40 @@ func NewAgent(ctx context.Context, config *Config) (*Agent, error) {
 	if config.MaxSteps == 0 {
 		config.MaxSteps = 20 // Default max iterations
 	}
+	if config.EventBus == nil {
+		config.EventBus = NewEventBus()
+	}
 
+	if config.ApprovalTTL <= 0 {
+		config.ApprovalTTL = defaultApprovalTTL
+	}
+
+	checkpoints := newCheckpointStore(config.ApprovalTTL)
+	runner, err := newRunner(ctx, config, checkpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use in-memory store if no memory store provided
+	store := config.MemoryStore
+	if store == nil {
+		store = memory.NewInMemoryStore()
+		logger.Ctx(ctx).Debug("Using in-memory session store")
+	}
+
+	return &Agent{
+		config:       config,
+		runner:       runner,
+		checkpoints:  checkpoints,
+		sessionCache: newSessionCache(),
+		memoryStore:  store,
+		approvals:    newApprovalRegistry(config.ApprovalTTL),
+		limiter:      newTurnLimiter(config.Limits),
+	}, nil
+}
+
+// newRunner builds the middlewares, the ADK agent tree and the runner from the configuration
+func newRunner(ctx context.Context, config *Config, checkpoints adk.CheckPointStore) (*adk.Runner, error) {
 	// Create middleware for history truncation and tool result formatting
 	middlewares := []adk.AgentMiddleware{}
 	if config.MaxHistory > 0 {
@@ -58,7 +152,7 @@ func NewAgent(ctx context.Context, config *Config) (*Agent, error) {
 				if len(state.Messages) > config.MaxHistory*2 {
 					// Keep only the last MaxHistory rounds (user + assistant = 2 messages per round)
 					state.Messages = state.Messages[len(state.Messages)-config.MaxHistory*2:]
-					logger.Debugf("Applied history limit: keeping last %d messages (max %d rounds)",
+					logger.Ctx(ctx).Debugf("Applied history limit: keeping last %d messages (max %d rounds)",
 						len(state.Messages), config.MaxHistory)
 				}
 				return nil
@@ -79,61 +173,135 @@ func NewAgent(ctx context.Context, config *Config) (*Agent, error) {
 							toolCallID = msg.ToolCalls[0].ID
 						}
 						state.Messages[i] = schema.ToolMessage(formatted, toolCallID)
-						logger.Debugf("Formatted tool result")
+						logger.Ctx(ctx).Debugf("Formatted tool result")
 					}
 				}
 			}
 			return nil
 		},
 	})
+	if config.Limits.MaxToolCallsPerTurn > 0 {
+		middlewares = append(middlewares, toolCallLimitMiddleware(config.Limits.MaxToolCallsPerTurn))
+	}
+	if len(config.Limits.ToolCosts) > 0 {
+		middlewares = append(middlewares, toolCostMiddleware(config.Limits))
+	}
+	middlewares = append(middlewares, hooksMiddleware(config.hooks()))
+	middlewares = append(middlewares, traceMiddleware())
+	if limit := toolResultLimit(config.MaxToolResultBytes, config.MaxToolResultTokens); limit > 0 {
+		middlewares = append(middlewares, truncationMiddleware(limit))
+	}
+	if len(config.ToolResultTransformers) > 0 {
+		middlewares = append(middlewares, transformMiddleware(config.ToolResultTransformers))
+	}
+	if config.ToolTimeout > 0 || len(config.ToolTimeouts) > 0 {
+		middlewares = append(middlewares, timeoutMiddleware(config.ToolTimeout, config.ToolTimeouts))
+	}
+	middlewares = append(middlewares, readOnlyMiddleware(config.ReadOnly, mutatingTools(ctx, config)))
+	if config.ReadOnly {
+		logger.Ctx(ctx).Infof("Read-only mode: mutating tools are blocked")
+	}
+	if len(config.ApprovalTools) > 0 {
+		middlewares = append(middlewares, approvalMiddleware(config.ApprovalTools))
+		logger.Ctx(ctx).Infof("Tool approval required for: %s", strings.Join(config.ApprovalTools, ", "))
+	}
+	middlewares = append(middlewares, config.Middlewares...)
 
 	// Create ADK ChatModel agent
-	chatModelAgent, err := adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
+	rootAgent, err := newChatModelAgent(ctx, &SubAgentConfig{
 		Name:        "eino-ai-agent",
 		Description: "A helpful AI assistant with access to various tools through MCP servers",
 		Instruction: config.SystemPrompt,
 		Model:       config.Model,
-		ToolsConfig: adk.ToolsConfig{
-			ToolsNodeConfig: compose.ToolsNodeConfig{
-				Tools: config.Tools,
-			},
-		},
-		MaxIterations: config.MaxSteps,
-		Middlewares:   middlewares,
-	})
+		Tools:       config.Tools,
+		MaxSteps:    config.MaxSteps,
+	}, config.Retry, middlewares)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat model agent: %w", err)
 	}
 
+	// Delegate to specialist sub-agents through a supervisor when configured
+	var runAgent adk.Agent = rootAgent
+	if len(config.SubAgents) > 0 {
+		runAgent, err = newSupervisor(ctx, config, rootAgent, middlewares)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create ADK Runner with streaming enabled
-	runner := adk.NewRunner(ctx, adk.RunnerConfig{
+	return adk.NewRunner(ctx, adk.RunnerConfig{
 		EnableStreaming: true,
-		Agent:           chatModelAgent,
-		CheckPointStore: &checkpointStore{memoryStore: config.MemoryStore},
-	})
+		Agent:           runAgent,
+		CheckPointStore: checkpoints,
+	}), nil
 
-	// Use in-memory store if no memory store provided
-	store := config.MemoryStore
-	if store == nil {
-		store = memory.NewInMemoryStore()
-		logger.Debug("Using in-memory session store")
+}
+
+// SetSystemPrompt replaces the system prompt, rebuilding the agent; runs already in progress keep the previous one
+func (a *Agent) SetSystemPrompt(ctx context.Context, prompt string) error {
+	a.runnerMu.Lock()
+	defer a.runnerMu.Unlock()
+
+	if prompt == a.config.SystemPrompt {
+		return nil
+	}
+	config := *a.config
+	config.SystemPrompt = prompt
+	runner, err := newRunner(ctx, &config, a.checkpoints)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild agent with new system prompt: %w", err)
 	}
+	a.config.SystemPrompt = prompt
+	a.runner = runner
 
-	return &Agent{
-		config:      config,
-		runner:      runner,
-		sessions:    make(map[string]*Session),
-		memoryStore: store,
-	}, nil
+	logger.Ctx(ctx).Infof("Replaced system prompt (%d characters)", len(prompt))
+	return nil
 }
 
-// GetOrCreateSession gets or creates a session
+// Fingerprint identifies what answers are generated with, the system prompt, tools and step limit,
+// so clients can tell when the same request may answer differently, like OpenAI's system_fingerprint
+func (a *Agent) Fingerprint(ctx context.Context) string {
+	a.runnerMu.RLock()
+	defer a.runnerMu.RUnlock()
+
+	names := make([]string, 0, len(a.config.Tools))
+	for _, t := range a.config.Tools {
+		if info, err := t.Info(ctx); err == nil {
+			names = append(names, info.Name)
+		}
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%s", a.config.SystemPrompt, a.config.MaxSteps, strings.Join(names, ","))
+	return "fp_" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// GetOrCreateSession gets or creates a session, loading it from the memory store if it is not resident
 func (a *Agent) GetOrCreateSession(ctx context.Context, sessionID string) *Session {
 	a.sessionMu.Lock()
 	defer a.sessionMu.Unlock()
 
+	session, _ := a.sessionLocked(ctx, sessionID, true)
+	return session
+}
+
+// existingSession gets a session that is resident or still in the memory store, without creating one;
+// it reports false for a session that was deleted
+func (a *Agent) existingSession(ctx context.Context, sessionID string) (*Session, bool) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	return a.sessionLocked(ctx, sessionID, false)
+}
+
+// sessionLocked returns the resident session or loads it from the memory store; a session that is in neither
+// is only created if create is set. a.sessionMu must be held.
+func (a *Agent) sessionLocked(ctx context.Context, sessionID string, create bool) (*Session, bool) {
 	if session, exists := a.sessions[sessionID]; exists {
-		return session
+		a.touchLocked(session)
+		a.evictLocked(session)
+		return session, true
 	}
 
 	// Try to load from persistent storage
@@ -142,109 +310,230 @@ func (a *Agent) GetOrCreateSession(ctx context.Context, sessionID string) *Sessi
 		var err error
 		msgs, err = a.memoryStore.Read(ctx, sessionID)
 		if err != nil {
-			logger.Warnf("Failed to read session %s from memory store: %v", sessionID, err)
+			logger.Ctx(ctx).Warnf("Failed to read session %s from memory store: %v", sessionID, err)
 		}
 		if msgs != nil {
-			logger.Debugf("Loaded session %s from memory store (%d messages)", sessionID, len(msgs))
+			logger.Ctx(ctx).Debugf("Loaded session %s from memory store (%d messages)", sessionID, len(msgs))
 		}
 	}
 
 	if msgs == nil {
+		if !create {
+			return nil, false
+		}
 		msgs = make([]*schema.Message, 0)
 	}
 
 	session := &Session{
 		ID:       sessionID,
 		Messages: msgs,
+		Meta:     a.loadSessionMeta(ctx, sessionID, msgs),
 	}
 	a.sessions[sessionID] = session
-	return session
+	a.touchLocked(session)
+	a.evictLocked(session)
+	return session, true
 }
 
-// persistSession saves session messages to memory store
-func (a *Agent) persistSession(ctx context.Context, sessionID string, msgs []*schema.Message) {
+// persistSession saves session messages and metadata to memory store; the session lock must be held
+func (a *Agent) persistSession(ctx context.Context, session *Session) {
 	if a.memoryStore == nil {
 		return
 	}
 
-	if err := a.memoryStore.Write(ctx, sessionID, msgs); err != nil {
-		logger.Warnf("Failed to persist session %s: %v", sessionID, err)
+	if err := a.memoryStore.Write(ctx, session.ID, session.Messages); err != nil {
+		logger.Ctx(ctx).Warnf("Failed to persist session %s: %v", session.ID, err)
 	} else {
-		logger.Debugf("Persisted session %s (%d messages)", sessionID, len(msgs))
+		logger.Ctx(ctx).Debugf("Persisted session %s (%d messages)", session.ID, len(session.Messages))
+	}
+	if metaStore, ok := a.memoryStore.(memory.MetaStore); ok {
+		if err := metaStore.WriteMeta(ctx, session.ID, &session.Meta); err != nil {
+			logger.Ctx(ctx).Warnf("Failed to persist metadata of session %s: %v", session.ID, err)
+		}
 	}
 }
 
-// Chat performs multi-turn conversation
-func (a *Agent) Chat(ctx context.Context, sessionID string, userMessage string) (*schema.Message, error) {
+// Chat performs multi-turn conversation.
+// The returned message's ResponseMeta.Usage holds the token usage summed over every model call of the turn.
+func (a *Agent) Chat(ctx context.Context, sessionID string, userMessage string, opts ...ChatOption) (*schema.Message, error) {
+	return a.chat(ctx, sessionID, nil, schema.UserMessage(userMessage), opts)
+}
+
+// ChatMultiModal performs a conversation turn whose user message consists of content parts
+// (text, image URLs or base64 images) for vision-capable models
+func (a *Agent) ChatMultiModal(ctx context.Context, sessionID string, parts []schema.MessageInputPart, opts ...ChatOption) (*schema.Message, error) {
+	return a.chat(ctx, sessionID, nil, multiModalMessage(parts), opts)
+}
+
+// chat runs a single turn for the given user message.
+// A non-nil history replaces the session history and is sent to the model ahead of the user message.
+func (a *Agent) chat(ctx context.Context, sessionID string, history []*schema.Message, userMsg *schema.Message, opts []ChatOption) (response *schema.Message, err error) {
+	options := applyChatOptions(opts)
 	session := a.GetOrCreateSession(ctx, sessionID)
 
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
+	if err := a.checkLimits(session); err != nil {
+		return nil, err
+	}
+
 	// Add user message to history
-	session.Messages = append(session.Messages, schema.UserMessage(userMessage))
+	userMsg, err = a.acceptInput(ctx, sessionID, userMsg)
+	if err != nil {
+		return nil, err
+	}
+	input := options.withInstructions(session.beginTurn(history, userMsg))
+	started := a.publishTurnStarted(ctx, sessionID)
+	defer func() {
+		a.publishTurnFinished(ctx, sessionID, started, usageOf(response), err)
+	}()
 
-	logger.Debugf("[Session: %s] User message: %s", sessionID, userMessage)
-	logger.Debugf("[Session: %s] Conversation history length: %d", sessionID, len(session.Messages))
+	logger.Ctx(ctx).Debugf("[Session: %s] User message: %s", sessionID, messageText(userMsg))
+	logger.Ctx(ctx).Debugf("[Session: %s] Conversation history length: %d", sessionID, len(session.Messages))
 
 	// Use Runner to query with checkpoint
-	events := a.runner.Query(ctx, userMessage, adk.WithCheckPointID(sessionID))
+	options.ReadOnly = options.ReadOnly || session.Meta.ReadOnly
+	runCtx, run := a.runs.start(withToolCost(options.runContext(ctx), session.Meta.ToolCost), sessionID)
+	defer a.runs.finish(sessionID, run)
+	events := a.currentRunner().Run(runCtx, input, options.runOptions(sessionID)...)
 
 	// Collect response from events
-	var response *schema.Message
+	var pending []*ApprovalRequest
+	var usage usageCounter
+	var limitErr *LimitError
+	var runErr error
+	clientCall := false
 	for {
 		event, ok := events.Next()
 		if !ok {
 			break
 		}
+		if errors.Is(event.Err, errClientToolCall) {
+			clientCall = true
+			continue
+		}
 		if event.Err != nil {
-			logger.Errorf("[Session: %s] Event error: %v", sessionID, event.Err)
+			logger.Ctx(ctx).Errorf("[Session: %s] Event error: %v", sessionID, event.Err)
+			a.hooks().OnError(runCtx, sessionID, event.Err)
+			errors.As(event.Err, &limitErr)
+			runErr = event.Err
 			continue
 		}
 		if event.Output != nil && event.Output.MessageOutput != nil {
 			msg, err := event.Output.MessageOutput.GetMessage()
 			if err == nil && msg != nil {
-				response = msg
+				response = splitReasoning(msg)
+				usage.add(msg)
 			}
 		}
+		if event.Action != nil && event.Action.Interrupted != nil {
+			pending = a.approvals.recordInterrupt(sessionID, event.Action.Interrupted)
+		}
 	}
 
+	session.addTokens(usage.total)
+	session.addToolCost(runCtx)
+
+	if run.cancelled.Load() {
+		logger.Ctx(ctx).Infof("[Session: %s] Run cancelled", sessionID)
+		// Persist the tokens and tool cost spent so far so session quotas count them
+		a.persistSession(context.WithoutCancel(ctx), session)
+		return nil, ErrRunCancelled
+	}
+	if err := ctx.Err(); err != nil {
+		// The caller went away or timed out; keep the user message
+		logger.Ctx(ctx).Infof("[Session: %s] Run aborted: %v", sessionID, err)
+		a.persistSession(context.WithoutCancel(ctx), session)
+		return nil, fmt.Errorf("run aborted: %w", err)
+	}
+	if limitErr != nil {
+		a.persistSession(ctx, session)
+		return nil, limitErr
+	}
+	if len(pending) > 0 {
+		a.persistSession(ctx, session)
+		return nil, &ApprovalRequiredError{SessionID: sessionID, Requests: pending}
+	}
 	if response == nil {
+		if runErr != nil {
+			return nil, runErr
+		}
 		return nil, fmt.Errorf("no assistant response received")
 	}
+	if clientCall {
+		// The caller runs its tools and continues with the results
+		response = clientToolCalls(runCtx, response)
+		logger.Ctx(ctx).Debugf("[Session: %s] Returning %d client tool calls", sessionID, len(response.ToolCalls))
+	}
 
-	logger.Debugf("[Session: %s] Agent response - Role: %s, Content: %s", sessionID, response.Role, response.Content)
+	response, err = a.guardOutput(ctx, sessionID, usage.attach(response))
+	if err != nil {
+		a.persistSession(ctx, session)
+		return nil, err
+	}
+	logger.Ctx(ctx).Debugf("[Session: %s] Agent response - Role: %s, Content: %s", sessionID, response.Role, response.Content)
 
 	// Add assistant response to history
 	session.Messages = append(session.Messages, response)
 
 	// Persist to memory store
-	a.persistSession(ctx, sessionID, session.Messages)
+	a.persistSession(ctx, session)
 
 	return response, nil
 }
 
-// ChatStream performs streaming multi-turn conversation
-func (a *Agent) ChatStream(ctx context.Context, sessionID string, userMessage string) (*schema.StreamReader[*schema.Message], error) {
+// ChatStream performs streaming multi-turn conversation.
+// The returned stream carries assistant content deltas as well as tool call and tool result events;
+// errors of the run are returned by Recv.
+func (a *Agent) ChatStream(ctx context.Context, sessionID string, userMessage string, opts ...ChatOption) (*schema.StreamReader[*StreamEvent], error) {
+	return a.chatStream(ctx, sessionID, nil, schema.UserMessage(userMessage), opts)
+}
+
+// ChatStreamMultiModal is the streaming variant of ChatMultiModal
+func (a *Agent) ChatStreamMultiModal(ctx context.Context, sessionID string, parts []schema.MessageInputPart, opts ...ChatOption) (*schema.StreamReader[*StreamEvent], error) {
+	return a.chatStream(ctx, sessionID, nil, multiModalMessage(parts), opts)
+}
+
+// chatStream runs a single streaming turn for the given user message; history is handled as in chat
+func (a *Agent) chatStream(ctx context.Context, sessionID string, history []*schema.Message, userMsg *schema.Message, opts []ChatOption) (*schema.StreamReader[*StreamEvent], error) {
+	options := applyChatOptions(opts)
 	session := a.GetOrCreateSession(ctx, sessionID)
 
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
+	if err := a.checkLimits(session); err != nil {
+		return nil, err
+	}
+
 	// Add user message to history
-	session.Messages = append(session.Messages, schema.UserMessage(userMessage))
+	userMsg, err := a.acceptInput(ctx, sessionID, userMsg)
+	if err != nil {
+		return nil, err
+	}
+	input := options.withInstructions(session.beginTurn(history, userMsg))
+	started := a.publishTurnStarted(ctx, sessionID)
 
-	logger.Debugf("[Session: %s] User message (streaming): %s", sessionID, userMessage)
-	logger.Debugf("[Session: %s] Conversation history length: %d", sessionID, len(session.Messages))
+	logger.Ctx(ctx).Debugf("[Session: %s] User message (streaming): %s", sessionID, messageText(userMsg))
+	logger.Ctx(ctx).Debugf("[Session: %s] Conversation history length: %d", sessionID, len(session.Messages))
 
 	// Persist user message immediately for streaming
-	a.persistSession(ctx, sessionID, session.Messages)
+	a.persistSession(ctx, session)
 
 	// Use Runner to query with streaming
-	events := a.runner.Query(ctx, userMessage, adk.WithCheckPointID(sessionID))
+	options.ReadOnly = options.ReadOnly || session.Meta.ReadOnly
+	runCtx, run := a.runs.start(withToolCost(options.runContext(ctx), session.Meta.ToolCost), sessionID)
+	runCtx = run.streamProgress(runCtx)
+	events := a.currentRunner().Run(runCtx, input, options.runOptions(sessionID)...)
 
+	return a.streamEvents(runCtx, sessionID, run, started, events), nil
+}
+
+// streamEvents converts runner events into a stream of typed events; the run is finished when the events end
+func (a *Agent) streamEvents(ctx context.Context, sessionID string, run *activeRun, started time.Time, events *adk.AsyncIterator[*adk.AgentEvent]) *schema.StreamReader[*StreamEvent] {
 	// Create stream reader with larger buffer
-	streamReader, streamWriter := schema.Pipe[*schema.Message](100)
+	streamReader, streamWriter := schema.Pipe[*StreamEvent](100)
 
 	// Use WaitGroup to ensure goroutine starts before returning
 	var wg sync.WaitGroup
@@ -253,54 +542,230 @@ func (a *Agent) ChatStream(ctx context.Context, sessionID string, userMessage st
 	go func() {
 		wg.Done()
 		defer streamWriter.Close()
+		defer a.runs.finish(sessionID, run)
+		if run.progress != nil {
+			run.progress.attach(streamWriter)
+			defer run.progress.detach()
+		}
+
+		var usage usageCounter
+		var turnErr error
+		interrupted := false
+		guard := &streamGuard{a: a, ctx: ctx, sessionID: sessionID}
 		for {
 			event, ok := events.Next()
 			if !ok {
-				logger.Debugf("[Session: %s] Event stream completed", sessionID)
+				logger.Ctx(ctx).Debugf("[Session: %s] Event stream completed", sessionID)
 				break
 			}
+			if guard.blocked != nil {
+				// Drain the aborted run
+				continue
+			}
+			if errors.Is(event.Err, errClientToolCall) {
+				continue
+			}
 			if event.Err != nil {
-				logger.Errorf("[Session: %s] Event error: %v", sessionID, event.Err)
+				logger.Ctx(ctx).Errorf("[Session: %s] Event error: %v", sessionID, event.Err)
+				a.hooks().OnError(ctx, sessionID, event.Err)
+				turnErr = event.Err
+				var limitErr *LimitError
+				switch {
+				case errors.As(event.Err, &limitErr):
+					streamWriter.Send(&StreamEvent{Type: EventLimitExceeded, Limit: limitErr}, nil)
+				case ctx.Err() == nil:
+					// Cancellation is reported with EventCancelled below
+					streamWriter.Send(nil, event.Err)
+				}
 				continue
 			}
 
 			if event.Output != nil && event.Output.MessageOutput != nil {
-				if event.Output.MessageOutput.IsStreaming && event.Output.MessageOutput.MessageStream != nil {
-					// Handle streaming message
-					for {
-						chunk, err := event.Output.MessageOutput.MessageStream.Recv()
-						if err != nil {
-							break
-						}
-						if chunk == nil {
-							continue
-						}
-						// Send chunk to stream - even if Send returns false, continue reading from MessageStream
-						// to ensure the MessageStream is fully consumed
-						streamWriter.Send(chunk, nil)
-					}
-				} else if event.Output.MessageOutput.Message != nil {
-					// Handle non-streaming message
-					streamWriter.Send(event.Output.MessageOutput.Message, nil)
+				usage.add(a.forwardMessageOutput(ctx, sessionID, event.Output.MessageOutput, streamWriter, guard))
+				if guard.blocked != nil {
+					streamWriter.Send(&StreamEvent{Type: EventGuardrailBlocked, Guardrail: guard.blocked}, nil)
+					run.cancel()
+					continue
 				}
 			}
+			if event.Action != nil && event.Action.Interrupted != nil {
+				interrupted = true
+				for _, req := range a.approvals.recordInterrupt(sessionID, event.Action.Interrupted) {
+					logger.Ctx(ctx).Infof("[Session: %s] Waiting for approval of %s (%s)", sessionID, req.ToolName, req.CallID)
+					streamWriter.Send(&StreamEvent{Type: EventApprovalRequired, Approval: req}, nil)
+				}
+			}
+		}
+		switch {
+		case run.cancelled.Load():
+			logger.Ctx(ctx).Infof("[Session: %s] Run cancelled", sessionID)
+			streamWriter.Send(&StreamEvent{Type: EventCancelled}, nil)
+			turnErr = ErrRunCancelled
+		case guard.blocked == nil && ctx.Err() != nil:
+			// The caller disconnected or timed out
+			logger.Ctx(ctx).Infof("[Session: %s] Run aborted: %v", sessionID, ctx.Err())
+			streamWriter.Send(&StreamEvent{Type: EventCancelled}, nil)
+			turnErr = ctx.Err()
 		}
+		if guard.blocked != nil {
+			turnErr = guard.blocked
+		}
+		if run.resume && !interrupted {
+			// The paused run is over; a new pause writes a new checkpoint
+			a.checkpoints.delete(sessionID)
+		}
+		if usage.total != nil {
+			streamWriter.Send(&StreamEvent{Type: EventUsage, Usage: usage.total}, nil)
+		}
+		// Account the turn to the resident session; a session deleted during the run is not recreated
+		a.sessionMu.RLock()
+		session, exists := a.sessions[sessionID]
+		a.sessionMu.RUnlock()
+		if exists {
+			session.mu.Lock()
+			session.addTokens(usage.total)
+			session.addToolCost(ctx)
+			session.mu.Unlock()
+		} else {
+			logger.Ctx(ctx).Debugf("[Session: %s] Session is gone, skipping usage accounting", sessionID)
+		}
+		a.publishTurnFinished(ctx, sessionID, started, usage.total, turnErr)
 	}()
 
 	// Wait for goroutine to start
 	wg.Wait()
 
-	return streamReader, nil
+	return streamReader
+}
+
+// forwardMessageOutput converts a single runner message output into stream events and returns the full message.
+// Even if Send returns false (reader closed), the message stream is fully consumed.
+func (a *Agent) forwardMessageOutput(ctx context.Context, sessionID string, output *adk.MessageVariant, w *schema.StreamWriter[*StreamEvent], guard *streamGuard) *schema.Message {
+	if output.Role == schema.Tool {
+		msg, err := output.GetMessage()
+		if err != nil || msg == nil {
+			logger.Ctx(ctx).Warnf("[Session: %s] Failed to read tool result: %v", sessionID, err)
+			return nil
+		}
+		w.Send(&StreamEvent{
+			Type:       EventToolResult,
+			ToolName:   output.ToolName,
+			ToolCallID: msg.ToolCallID,
+			Result:     formatToolResult(msg.Content),
+		}, nil)
+		return msg
+	}
+
+	var msg *schema.Message
+	if output.IsStreaming && output.MessageStream != nil {
+		var splitter reasoningSplitter
+		calls := clientCallStreamer{}
+		var err error
+		msg, err = collectStream(output.MessageStream, func(chunk *schema.Message) {
+			content, reasoning := splitter.split(chunk.Content)
+			sendDeltas(w, guard, chunk, content, chunk.ReasoningContent+reasoning)
+			calls.send(ctx, w, chunk)
+		})
+		if content, reasoning := splitter.flush(); msg != nil {
+			sendDeltas(w, guard, msg, content, reasoning)
+		}
+		var retryErr *adk.WillRetryError
+		if errors.As(err, &retryErr) {
+			// The partial answer already streamed is followed by the retried answer
+			logger.Ctx(ctx).Warnf("[Session: %s] Model stream failed, retrying (attempt %d): %v", sessionID, retryErr.RetryAttempt, err)
+			guard.reset()
+			return nil
+		}
+		if err != nil {
+			logger.Ctx(ctx).Warnf("[Session: %s] Message stream error: %v", sessionID, err)
+		}
+	} else if output.Message != nil {
+		msg = splitReasoning(output.Message)
+		sendDeltas(w, guard, msg, msg.Content, msg.ReasoningContent)
+	}
+
+	if msg == nil {
+		return nil
+	}
+	msg = splitReasoning(msg)
+	if rest := guard.finish(msg.Content); rest != "" {
+		w.Send(&StreamEvent{Type: EventAssistantDelta, Message: &schema.Message{Role: msg.Role, Content: rest}}, nil)
+	}
+	if guard.blocked != nil {
+		return msg
+	}
+	for i := range msg.ToolCalls {
+		tc := msg.ToolCalls[i]
+		if isClientTool(ctx, tc.Function.Name) {
+			w.Send(&StreamEvent{Type: EventClientToolCall, ToolCall: &tc}, nil)
+			continue
+		}
+		logger.Ctx(ctx).Debugf("[Session: %s] Tool call started: %s (%s)", sessionID, tc.Function.Name, tc.ID)
+		w.Send(&StreamEvent{Type: EventToolCallStarted, ToolCall: &tc}, nil)
+	}
+	return msg
+}
+
+// sendDeltas emits the reasoning and answer parts of a message chunk as separate events,
+// passing the answer through the output guardrails
+func sendDeltas(w *schema.StreamWriter[*StreamEvent], guard *streamGuard, chunk *schema.Message, content, reasoning string) {
+	if content != "" {
+		var ok bool
+		if content, ok = guard.filter(content); !ok {
+			return
+		}
+	}
+	if reasoning != "" {
+		w.Send(&StreamEvent{Type: EventReasoningDelta, Message: &schema.Message{Role: chunk.Role, ReasoningContent: reasoning}}, nil)
+	}
+	if content != "" {
+		delta := *chunk
+		delta.Content = content
+		delta.ReasoningContent = ""
+		w.Send(&StreamEvent{Type: EventAssistantDelta, Message: &delta}, nil)
+	}
+}
+
+// clientCallStreamer forwards the fragments of client tool calls as the model streams them.
+// It maps each call index to whether the call is a client call, known once its name arrives.
+type clientCallStreamer map[int]bool
+
+// send emits the client tool call fragments of a chunk
+func (s clientCallStreamer) send(ctx context.Context, w *schema.StreamWriter[*StreamEvent], chunk *schema.Message) {
+	for i := range chunk.ToolCalls {
+		tc := chunk.ToolCalls[i]
+		if tc.Index == nil {
+			continue
+		}
+		client, known := s[*tc.Index]
+		if !known {
+			if tc.Function.Name == "" {
+				continue
+			}
+			client = isClientTool(ctx, tc.Function.Name)
+			s[*tc.Index] = client
+		}
+		if client {
+			w.Send(&StreamEvent{Type: EventToolCallDelta, ToolCall: &tc}, nil)
+		}
+	}
 }
 
-// GetSessionHistory gets session message history
+// GetSessionHistory gets session message history, falling back to the memory store for evicted sessions
 func (a *Agent) GetSessionHistory(sessionID string) ([]*schema.Message, bool) {
 	a.sessionMu.RLock()
-	defer a.sessionMu.RUnlock()
-
 	session, exists := a.sessions[sessionID]
+	a.sessionMu.RUnlock()
+
 	if !exists {
-		return nil, false
+		if a.memoryStore == nil {
+			return nil, false
+		}
+		msgs, err := a.memoryStore.Read(context.Background(), sessionID)
+		if err != nil {
+			logger.Warnf("Failed to read session %s from memory store: %v", sessionID, err)
+		}
+		return msgs, msgs != nil
 	}
 
 	session.mu.RLock()
@@ -311,15 +776,37 @@ func (a *Agent) GetSessionHistory(sessionID string) ([]*schema.Message, bool) {
 	return result, true
 }
 
-// ClearSession clears session history
+// ClearSession clears session history in memory and in the memory store
 func (a *Agent) ClearSession(sessionID string) {
+	if err := a.DeleteSession(context.Background(), sessionID); err != nil {
+		logger.Warnf("Failed to clear session %s: %v", sessionID, err)
+	}
+}
+
+// DeleteSession removes a session, its pending approvals and its persisted history and metadata
+func (a *Agent) DeleteSession(ctx context.Context, sessionID string) error {
 	a.sessionMu.Lock()
-	defer a.sessionMu.Unlock()
+	if session, exists := a.sessions[sessionID]; exists {
+		a.removeLocked(session)
+	}
+	a.sessionMu.Unlock()
+
+	a.approvals.clear(sessionID)
+	a.checkpoints.delete(sessionID)
+	a.runs.forget(sessionID)
+	a.limiter.forget(sessionID)
 
-	delete(a.sessions, sessionID)
+	if a.memoryStore == nil {
+		return nil
+	}
+	if err := a.memoryStore.Delete(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session %s from memory store: %w", sessionID, err)
+	}
+	logger.Ctx(ctx).Debugf("[Session: %s] Deleted from memory store", sessionID)
+	return nil
 }
 
-// ListSessions lists all session IDs
+// ListSessions lists the IDs of sessions resident in memory
 func (a *Agent) ListSessions() []string {
 	a.sessionMu.RLock()
 	defer a.sessionMu.RUnlock()
@@ -331,13 +818,13 @@ func (a *Agent) ListSessions() []string {
 	return sessionIDs
 }
 
-// AppendAssistantMessage appends assistant message to session (used after streaming response)
+// AppendAssistantMessage appends assistant message to session and persists it (used after streaming response).
+// A session deleted while the response streamed is left deleted.
 func (a *Agent) AppendAssistantMessage(sessionID string, message *schema.Message) {
-	a.sessionMu.RLock()
-	session, exists := a.sessions[sessionID]
-	a.sessionMu.RUnlock()
-
+	ctx := context.Background()
+	session, exists := a.existingSession(ctx, sessionID)
 	if !exists {
+		logger.Debugf("[Session: %s] Session is gone, dropping the streamed assistant message", sessionID)
 		return
 	}
 
@@ -345,29 +832,79 @@ func (a *Agent) AppendAssistantMessage(sessionID string, message *schema.Message
 	defer session.mu.Unlock()
 
 	session.Messages = append(session.Messages, message)
+	a.persistSession(ctx, session)
+}
+
+// hooks returns the configured hooks followed by the audit log writer and the event bus publisher
+func (a *Agent) hooks() Hooks {
+	return a.config.hooks()
 }
 
-// checkpointStore implements adk.CheckPointStore interface
+func (c *Config) hooks() Hooks {
+	var hooks hookChain
+	if c.Hooks != nil {
+		hooks = append(hooks, c.Hooks)
+	}
+	if c.Audit != nil {
+		hooks = append(hooks, &auditHooks{sink: c.Audit})
+	}
+	return append(hooks, &busHooks{bus: c.EventBus})
+}
+
+// checkpointStore implements adk.CheckPointStore interface.
+// Checkpoints are only written when a run is interrupted, so they are kept in memory until the run resumes,
+// its session is deleted or they expire.
 type checkpointStore struct {
-	memoryStore memory.Store
+	data map[string]checkpoint
+	ttl  time.Duration
+	mu   sync.Mutex
+}
+
+// checkpoint is a stored checkpoint and when it expires
+type checkpoint struct {
+	data    []byte
+	expires time.Time
+}
+
+func newCheckpointStore(ttl time.Duration) *checkpointStore {
+	return &checkpointStore{data: make(map[string]checkpoint), ttl: ttl}
 }
 
 func (c *checkpointStore) Get(ctx context.Context, checkPointID string) ([]byte, bool, error) {
-	if c.memoryStore == nil {
-		return nil, false, fmt.Errorf("memory store not available")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cp, ok := c.data[checkPointID]
+	if ok && time.Now().After(cp.expires) {
+		delete(c.data, checkPointID)
+		return nil, false, nil
 	}
-	// TODO: Implement checkpoint serialization
-	return nil, false, nil
+	return cp.data, ok, nil
 }
 
+// Set stores a checkpoint, dropping expired ones
 func (c *checkpointStore) Set(ctx context.Context, checkPointID string, checkPoint []byte) error {
-	if c.memoryStore == nil {
-		return fmt.Errorf("memory store not available")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, cp := range c.data {
+		if now.After(cp.expires) {
+			delete(c.data, id)
+		}
 	}
-	// TODO: Implement checkpoint serialization
+	c.data[checkPointID] = checkpoint{data: checkPoint, expires: now.Add(c.ttl)}
 	return nil
 }
 
+// delete drops a checkpoint
+func (c *checkpointStore) delete(checkPointID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.data, checkPointID)
+}
+
 // formatToolResult formats MCP tool result JSON into human-readable format
 func formatToolResult(content string) string {
 	// Check if it's MCP tool result format
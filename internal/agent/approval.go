@@ -0,0 +1,341 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ApprovalRequest describes a tool call that is waiting for human approval
+type ApprovalRequest struct {
+	CallID    string `json:"call_id"`
+	ToolName  string `json:"tool_name"`
+	Arguments string `json:"arguments"`
+}
+
+// ApprovalDecision is the resume data delivered to a paused tool call
+type ApprovalDecision struct {
+	Approved bool
+	Reason   string
+}
+
+// defaultApprovalTTL is how long a paused run waits for decisions unless configured
+const defaultApprovalTTL = 24 * time.Hour
+
+var (
+	// ErrApprovalNotFound is returned for a tool call that is not waiting for approval. Paused runs are kept
+	// in memory: they are lost on restart and dropped after the approval TTL.
+	ErrApprovalNotFound = errors.New("approval not found")
+	// ErrApprovalDecided is returned for a tool call whose decision was already given
+	ErrApprovalDecided = errors.New("approval already decided")
+	// ErrRunInProgress is returned when a paused run cannot resume because the session is running another turn
+	ErrRunInProgress = errors.New("a run is in progress")
+)
+
+// ApprovalRequiredError is returned by Chat when the run paused for tool approval
+type ApprovalRequiredError struct {
+	SessionID string
+	Requests  []*ApprovalRequest
+}
+
+func (e *ApprovalRequiredError) Error() string {
+	names := make([]string, len(e.Requests))
+	for i, r := range e.Requests {
+		names[i] = r.ToolName
+	}
+	return fmt.Sprintf("session %s is waiting for approval of tool calls: %s", e.SessionID, strings.Join(names, ", "))
+}
+
+// pendingApproval tracks a paused run waiting for decisions
+type pendingApproval struct {
+	request     *ApprovalRequest
+	interruptID string
+	decision    *ApprovalDecision
+}
+
+// sessionApprovals are the approvals of the paused run of a session
+type sessionApprovals struct {
+	calls   map[string]*pendingApproval // By call ID
+	expires time.Time
+	resumed bool // Every call was decided and the run resumed; kept to tell repeated decisions apart
+}
+
+// approvalRegistry holds pending approvals per session until they expire
+type approvalRegistry struct {
+	pending map[string]*sessionApprovals // By session ID
+	ttl     time.Duration
+	mu      sync.Mutex
+}
+
+func init() {
+	// Interrupt info is persisted in checkpoints and must be registered for gob
+	schema.RegisterName[*ApprovalRequest]("eino_ai_agent_approval_request")
+}
+
+func newApprovalRegistry(ttl time.Duration) *approvalRegistry {
+	return &approvalRegistry{pending: make(map[string]*sessionApprovals), ttl: ttl}
+}
+
+// needsApproval reports whether the tool name matches any configured approval pattern
+func needsApproval(patterns []string, toolName string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, toolName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// approvalMiddleware pauses configured tools until an approve/deny decision is provided on resume
+func approvalMiddleware(patterns []string) adk.AgentMiddleware {
+	gate := func(ctx context.Context, input *compose.ToolInput) (denied string, err error) {
+		if !needsApproval(patterns, input.Name) {
+			return "", nil
+		}
+		request := &ApprovalRequest{CallID: input.CallID, ToolName: input.Name, Arguments: input.Arguments}
+
+		wasInterrupted, _, _ := compose.GetInterruptState[string](ctx)
+		if !wasInterrupted {
+			logger.Ctx(ctx).Infof("Tool %s (%s) requires approval, pausing run", input.Name, input.CallID)
+			return "", compose.StatefulInterrupt(ctx, request, input.Arguments)
+		}
+
+		isTarget, hasData, decision := compose.GetResumeContext[*ApprovalDecision](ctx)
+		if !isTarget {
+			// Another paused call is being resumed; stay paused
+			return "", compose.StatefulInterrupt(ctx, request, input.Arguments)
+		}
+		if hasData && decision != nil && !decision.Approved {
+			reason := decision.Reason
+			if reason == "" {
+				reason = "no reason given"
+			}
+			logger.Ctx(ctx).Infof("Tool %s (%s) denied: %s", input.Name, input.CallID, reason)
+			return fmt.Sprintf("The user denied this %s call: %s. Do not retry it; explain what you would have done instead.", input.Name, reason), nil
+		}
+		logger.Ctx(ctx).Infof("Tool %s (%s) approved", input.Name, input.CallID)
+		return "", nil
+	}
+
+	return adk.AgentMiddleware{
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					denied, err := gate(ctx, input)
+					if err != nil {
+						return nil, err
+					}
+					if denied != "" {
+						return &compose.ToolOutput{Result: denied}, nil
+					}
+					return next(ctx, input)
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					denied, err := gate(ctx, input)
+					if err != nil {
+						return nil, err
+					}
+					if denied != "" {
+						return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{denied})}, nil
+					}
+					return next(ctx, input)
+				}
+			},
+		},
+	}
+}
+
+// recordInterrupt registers pending approvals from an interrupt action and returns the new requests.
+// Expired approvals of other sessions are dropped.
+func (r *approvalRegistry) recordInterrupt(sessionID string, info *adk.InterruptInfo) []*ApprovalRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range r.pending {
+		if now.After(session.expires) {
+			delete(r.pending, id)
+		}
+	}
+
+	session := &sessionApprovals{calls: make(map[string]*pendingApproval), expires: now.Add(r.ttl)}
+	var requests []*ApprovalRequest
+	for _, ic := range info.InterruptContexts {
+		req, ok := ic.Info.(*ApprovalRequest)
+		if !ok || !ic.IsRootCause {
+			continue
+		}
+		session.calls[req.CallID] = &pendingApproval{request: req, interruptID: ic.ID}
+		requests = append(requests, req)
+	}
+	if len(session.calls) > 0 {
+		r.pending[sessionID] = session
+	}
+	return requests
+}
+
+// sessionLocked returns the unexpired approvals of a session; r.mu must be held
+func (r *approvalRegistry) sessionLocked(sessionID string) (*sessionApprovals, bool) {
+	session, ok := r.pending[sessionID]
+	if ok && time.Now().After(session.expires) {
+		delete(r.pending, sessionID)
+		return nil, false
+	}
+	return session, ok
+}
+
+// has reports whether a session has unexpired approvals, decided or not
+func (r *approvalRegistry) has(sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.sessionLocked(sessionID)
+	return ok
+}
+
+// list returns the pending approval requests for a session
+func (r *approvalRegistry) list(sessionID string) []*ApprovalRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessionLocked(sessionID)
+	if !ok || session.resumed {
+		return nil
+	}
+	var requests []*ApprovalRequest
+	for _, p := range session.calls {
+		if p.decision == nil {
+			requests = append(requests, p.request)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].CallID < requests[j].CallID })
+	return requests
+}
+
+// decide records a decision; when every pending call has a decision it returns the resume targets.
+// The caller marks the approvals resumed once the run resumed, or reopens them if it could not.
+func (r *approvalRegistry) decide(sessionID, callID string, decision *ApprovalDecision) (map[string]any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessionLocked(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("%w: session %s has no pending tool calls", ErrApprovalNotFound, sessionID)
+	}
+	p, ok := session.calls[callID]
+	if !ok {
+		return nil, fmt.Errorf("%w: tool call %s is not pending in session %s", ErrApprovalNotFound, callID, sessionID)
+	}
+	if session.resumed || p.decision != nil {
+		return nil, fmt.Errorf("%w: tool call %s in session %s", ErrApprovalDecided, callID, sessionID)
+	}
+	p.decision = decision
+
+	targets := make(map[string]any, len(session.calls))
+	for _, p := range session.calls {
+		if p.decision == nil {
+			return nil, nil
+		}
+		targets[p.interruptID] = p.decision
+	}
+	return targets, nil
+}
+
+// markResumed marks the approvals of a session resumed, so repeated decisions are told apart from unknown calls
+func (r *approvalRegistry) markResumed(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessionLocked(sessionID); ok {
+		session.resumed = true
+	}
+}
+
+// reopen drops the decisions of a session whose run failed to resume, so that its calls can be decided again
+func (r *approvalRegistry) reopen(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessionLocked(sessionID); ok {
+		for _, p := range session.calls {
+			p.decision = nil
+		}
+	}
+}
+
+// clear drops the pending approvals of a session
+func (r *approvalRegistry) clear(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pending, sessionID)
+}
+
+// PendingToolCalls returns the tool calls of a session that are waiting for approval
+func (a *Agent) PendingToolCalls(sessionID string) []*ApprovalRequest {
+	return a.approvals.list(sessionID)
+}
+
+// ApprovePendingToolCall approves a paused tool call.
+// Once every pending call of the session has a decision, the run resumes and its events are returned;
+// otherwise the returned stream is nil.
+func (a *Agent) ApprovePendingToolCall(ctx context.Context, sessionID, callID string) (*schema.StreamReader[*StreamEvent], error) {
+	return a.resolvePendingToolCall(ctx, sessionID, callID, &ApprovalDecision{Approved: true})
+}
+
+// DenyPendingToolCall denies a paused tool call; the model receives the reason as the tool result
+func (a *Agent) DenyPendingToolCall(ctx context.Context, sessionID, callID, reason string) (*schema.StreamReader[*StreamEvent], error) {
+	return a.resolvePendingToolCall(ctx, sessionID, callID, &ApprovalDecision{Approved: false, Reason: reason})
+}
+
+// resolvePendingToolCall records a decision and resumes the run when all decisions are in.
+// Like a chat turn, it holds the session lock and refuses to resume while another run of the session is in progress.
+func (a *Agent) resolvePendingToolCall(ctx context.Context, sessionID, callID string, decision *ApprovalDecision) (*schema.StreamReader[*StreamEvent], error) {
+	if !a.approvals.has(sessionID) {
+		return nil, fmt.Errorf("%w: session %s has no pending tool calls", ErrApprovalNotFound, sessionID)
+	}
+	session := a.GetOrCreateSession(ctx, sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if a.runs.active(sessionID) {
+		return nil, fmt.Errorf("%w in session %s", ErrRunInProgress, sessionID)
+	}
+	targets, err := a.approvals.decide(sessionID, callID, decision)
+	if err != nil {
+		return nil, err
+	}
+	if targets == nil {
+		logger.Ctx(ctx).Debugf("[Session: %s] Recorded decision for %s, waiting for remaining approvals", sessionID, callID)
+		return nil, nil
+	}
+
+	logger.Ctx(ctx).Infof("[Session: %s] Resuming run after approval decisions", sessionID)
+	options := &ChatOptions{ReadOnly: session.Meta.ReadOnly}
+	runCtx, run := a.runs.start(withToolCost(options.withReadOnly(ctx), session.Meta.ToolCost), sessionID)
+	run.resume = true
+	runCtx = run.streamProgress(runCtx)
+	events, err := a.currentRunner().ResumeWithParams(runCtx, sessionID, &adk.ResumeParams{Targets: targets})
+	if err != nil {
+		a.runs.finish(sessionID, run)
+		a.approvals.reopen(sessionID)
+		return nil, fmt.Errorf("failed to resume session %s: %w", sessionID, err)
+	}
+	a.approvals.markResumed(sessionID)
+	return a.streamEvents(runCtx, sessionID, run, a.publishTurnStarted(ctx, sessionID), events), nil
+}
@@ -0,0 +1,53 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/audit"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// auditResultBytes is how much of a tool result the audit record keeps
+const auditResultBytes = 1024
+
+// auditHooks writes a record of every tool call to the audit sink
+type auditHooks struct {
+	NoopHooks
+	sink  audit.Sink
+	calls sync.Map // Session and call ID -> start time
+}
+
+func (h *auditHooks) OnToolCall(ctx context.Context, sessionID string, call *schema.ToolCall) error {
+	h.calls.Store(sessionID+"/"+call.ID, time.Now())
+	return nil
+}
+
+func (h *auditHooks) OnToolResult(ctx context.Context, sessionID string, call *schema.ToolCall, result string, err error) {
+	record := &audit.Record{
+		Time:        time.Now(),
+		RequestID:   logger.RequestID(ctx),
+		SessionID:   sessionID,
+		Caller:      audit.CallerFromContext(ctx),
+		Tool:        call.Function.Name,
+		CallID:      call.ID,
+		Arguments:   call.Function.Arguments,
+		Result:      audit.Summarize(result, auditResultBytes),
+		ResultBytes: len(result),
+	}
+	if started, ok := h.calls.LoadAndDelete(sessionID + "/" + call.ID); ok {
+		record.Time = started.(time.Time)
+		record.LatencyMs = time.Since(record.Time).Milliseconds()
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	// Calls of cancelled requests are recorded too
+	if err := h.sink.Write(context.WithoutCancel(ctx), record); err != nil {
+		logger.Ctx(ctx).Errorf("Failed to write audit record for tool %s in session %s: %v", record.Tool, sessionID, err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// BusEventType identifies the kind of agent activity published on the event bus
+type BusEventType string
+
+const (
+	BusTurnStarted  BusEventType = "turn_started"  // A run starts for a user message or resumes after approval
+	BusToolCalled   BusEventType = "tool_called"   // A tool is about to run
+	BusToolFinished BusEventType = "tool_finished" // A tool returned, successfully or not
+	BusTurnFinished BusEventType = "turn_finished" // A run ended; Error is set if it failed
+	BusError        BusEventType = "error"         // A run surfaced an error
+)
+
+// BusEvent is a single agent activity notification
+type BusEvent struct {
+	Type      BusEventType       `json:"type"`
+	SessionID string             `json:"session_id"`
+	RequestID string             `json:"request_id,omitempty"` // ID of the API request that started the run
+	Time      time.Time          `json:"time"`
+	ToolName  string             `json:"tool_name,omitempty"`
+	CallID    string             `json:"call_id,omitempty"`
+	Arguments string             `json:"arguments,omitempty"`
+	Result    string             `json:"result,omitempty"`
+	LatencyMs int64              `json:"latency_ms,omitempty"` // Duration of the tool call or turn
+	Usage     *schema.TokenUsage `json:"usage,omitempty"`      // Token usage of a finished turn
+	Error     string             `json:"error,omitempty"`
+}
+
+// EventBus fans agent activity out to subscribers.
+// Publishing never blocks: events are dropped for subscribers whose buffer is full.
+type EventBus struct {
+	subs map[*Subscription]struct{}
+	mu   sync.RWMutex
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives bus events on C until it is closed
+type Subscription struct {
+	C <-chan *BusEvent
+
+	ch      chan *BusEvent
+	types   map[BusEventType]bool // nil = all types
+	bus     *EventBus
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Subscribe registers a subscriber with the given channel buffer, receiving only the listed types (none = all)
+func (b *EventBus) Subscribe(buffer int, types ...BusEventType) *Subscription {
+	ch := make(chan *BusEvent, buffer)
+	sub := &Subscription{C: ch, ch: ch, bus: b}
+	if len(types) > 0 {
+		sub.types = make(map[BusEventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close unsubscribes and closes C
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+// Dropped returns the number of events lost because the subscriber fell behind
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Publish delivers an event to every interested subscriber
+func (b *EventBus) Publish(event *BusEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			if sub.dropped.Add(1) == 1 {
+				logger.Warnf("Event bus subscriber is falling behind, dropping %s events", event.Type)
+			}
+		}
+	}
+}
+
+// busHooks publishes tool activity and run errors reported through the hooks middleware
+type busHooks struct {
+	NoopHooks
+	bus   *EventBus
+	calls sync.Map // Session and call ID -> start time
+}
+
+func (h *busHooks) OnToolCall(ctx context.Context, sessionID string, call *schema.ToolCall) error {
+	h.calls.Store(sessionID+"/"+call.ID, time.Now())
+	h.bus.Publish(&BusEvent{
+		Type:      BusToolCalled,
+		SessionID: sessionID,
+		RequestID: logger.RequestID(ctx),
+		ToolName:  call.Function.Name,
+		CallID:    call.ID,
+		Arguments: call.Function.Arguments,
+	})
+	return nil
+}
+
+func (h *busHooks) OnToolResult(ctx context.Context, sessionID string, call *schema.ToolCall, result string, err error) {
+	event := &BusEvent{
+		Type:      BusToolFinished,
+		SessionID: sessionID,
+		RequestID: logger.RequestID(ctx),
+		ToolName:  call.Function.Name,
+		CallID:    call.ID,
+		Result:    result,
+	}
+	if started, ok := h.calls.LoadAndDelete(sessionID + "/" + call.ID); ok {
+		event.LatencyMs = time.Since(started.(time.Time)).Milliseconds()
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	h.bus.Publish(event)
+}
+
+func (h *busHooks) OnError(ctx context.Context, sessionID string, err error) {
+	h.bus.Publish(&BusEvent{Type: BusError, SessionID: sessionID, RequestID: logger.RequestID(ctx), Error: err.Error()})
+}
+
+// Events returns the bus the agent publishes its activity on
+func (a *Agent) Events() *EventBus {
+	return a.config.EventBus
+}
+
+// publishTurnStarted announces an accepted user message and returns the turn start time
+func (a *Agent) publishTurnStarted(ctx context.Context, sessionID string) time.Time {
+	event := &BusEvent{Type: BusTurnStarted, SessionID: sessionID, RequestID: logger.RequestID(ctx)}
+	a.config.EventBus.Publish(event)
+	return event.Time
+}
+
+// publishTurnFinished announces the end of a turn with its usage and error
+func (a *Agent) publishTurnFinished(ctx context.Context, sessionID string, started time.Time, usage *schema.TokenUsage, err error) {
+	event := &BusEvent{
+		Type:      BusTurnFinished,
+		SessionID: sessionID,
+		RequestID: logger.RequestID(ctx),
+		LatencyMs: time.Since(started).Milliseconds(),
+		Usage:     usage,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	a.config.EventBus.Publish(event)
+}
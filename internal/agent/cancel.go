@@ -0,0 +1,98 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ErrRunCancelled is returned by Chat when the run was aborted with CancelSession
+var ErrRunCancelled = errors.New("run cancelled")
+
+// activeRun is an in-flight run that can be cancelled
+type activeRun struct {
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+	trace     *Trace
+	progress  *progressRelay // Set for streaming runs
+	resume    bool           // Resumes a run paused for approval
+}
+
+// runRegistry tracks in-flight runs and the latest trace per session
+type runRegistry struct {
+	runs   map[string]map[*activeRun]struct{}
+	traces map[string]*Trace
+	mu     sync.Mutex
+}
+
+func newRunRegistry() *runRegistry {
+	return &runRegistry{
+		runs:   make(map[string]map[*activeRun]struct{}),
+		traces: make(map[string]*Trace),
+	}
+}
+
+// start registers a run with a fresh trace and returns its cancellable context
+func (r *runRegistry) start(ctx context.Context, sessionID string) (context.Context, *activeRun) {
+	trace := newTrace(sessionID)
+	ctx, cancel := context.WithCancel(context.WithValue(withToolCallCounter(withSessionID(ctx, sessionID)), traceKey{}, trace))
+	run := &activeRun{cancel: cancel, trace: trace}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.runs[sessionID] == nil {
+		r.runs[sessionID] = make(map[*activeRun]struct{})
+	}
+	r.runs[sessionID][run] = struct{}{}
+	r.traces[sessionID] = trace
+	return ctx, run
+}
+
+// finish unregisters a run and releases its context
+func (r *runRegistry) finish(sessionID string, run *activeRun) {
+	run.cancel()
+	run.trace.finish()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.runs[sessionID], run)
+	if len(r.runs[sessionID]) == 0 {
+		delete(r.runs, sessionID)
+	}
+}
+
+// active reports whether a run of the session is in progress
+func (r *runRegistry) active(sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.runs[sessionID]) > 0
+}
+
+// forget drops the latest trace of a session
+func (r *runRegistry) forget(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.traces, sessionID)
+}
+
+// CancelSession aborts every in-flight run of the session and reports whether any was running
+func (a *Agent) CancelSession(sessionID string) bool {
+	a.runs.mu.Lock()
+	defer a.runs.mu.Unlock()
+
+	runs := a.runs.runs[sessionID]
+	for run := range runs {
+		run.cancelled.Store(true)
+		run.cancel()
+	}
+	if len(runs) > 0 {
+		logger.Infof("[Session: %s] Cancelled %d in-flight run(s)", sessionID, len(runs))
+	}
+	return len(runs) > 0
+}
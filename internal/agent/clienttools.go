@@ -0,0 +1,94 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// errClientToolCall ends a run when the model calls a client tool
+var errClientToolCall = errors.New("client tool called")
+
+// WithClientTools declares functions the caller executes itself, like the "tools" of an OpenAI request.
+// The model sees them next to the agent's tools; when it calls one, the turn ends and the returned message
+// carries the client tool calls. The caller answers them with tool messages in its next ChatWithMessages.
+func WithClientTools(tools ...*schema.ToolInfo) ChatOption {
+	return func(o *ChatOptions) {
+		o.ClientTools = tools
+	}
+}
+
+// clientToolsKey is the context key carrying the client tools of a run
+type clientToolsKey struct{}
+
+// clientTools are the caller-executed tools of a run
+type clientTools struct {
+	infos []*schema.ToolInfo
+	names map[string]bool
+}
+
+// withClientTools stores the turn's client tools in the run context
+func (o *ChatOptions) withClientTools(ctx context.Context) context.Context {
+	if len(o.ClientTools) == 0 {
+		return ctx
+	}
+	tools := &clientTools{infos: o.ClientTools, names: make(map[string]bool, len(o.ClientTools))}
+	for _, info := range o.ClientTools {
+		tools.names[info.Name] = true
+	}
+	return context.WithValue(ctx, clientToolsKey{}, tools)
+}
+
+// isClientTool reports whether the run declared a client tool of that name
+func isClientTool(ctx context.Context, name string) bool {
+	tools, _ := ctx.Value(clientToolsKey{}).(*clientTools)
+	return tools != nil && tools.names[name]
+}
+
+// clientToolOptions offers the run's client tools to the model along with the bound agent tools.
+// Client tools named like an agent tool are dropped; the agent tool wins.
+func clientToolOptions(ctx context.Context, bound []*schema.ToolInfo, opts []model.Option) []model.Option {
+	tools, _ := ctx.Value(clientToolsKey{}).(*clientTools)
+	if tools == nil {
+		return opts
+	}
+	boundNames := make(map[string]bool, len(bound))
+	for _, info := range bound {
+		boundNames[info.Name] = true
+	}
+	all := append(make([]*schema.ToolInfo, 0, len(bound)+len(tools.infos)), bound...)
+	for _, info := range tools.infos {
+		if boundNames[info.Name] {
+			logger.Ctx(ctx).Warnf("[Session: %s] Client tool %s shadows an agent tool, ignoring it", SessionIDFromContext(ctx), info.Name)
+			continue
+		}
+		all = append(all, info)
+	}
+	return append(opts, model.WithTools(all))
+}
+
+// handleUnknownTool ends the run for client tool calls; any other unknown tool fails the call
+func handleUnknownTool(ctx context.Context, name, input string) (string, error) {
+	if isClientTool(ctx, name) {
+		return "", errClientToolCall
+	}
+	return "", fmt.Errorf("tool %s not found", name)
+}
+
+// clientToolCalls keeps only the client tool calls of the message that ended a turn
+func clientToolCalls(ctx context.Context, msg *schema.Message) *schema.Message {
+	out := *msg
+	out.ToolCalls = nil
+	for _, tc := range msg.ToolCalls {
+		if isClientTool(ctx, tc.Function.Name) {
+			out.ToolCalls = append(out.ToolCalls, tc)
+		}
+	}
+	return &out
+}
@@ -0,0 +1,100 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"io"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/progress"
+)
+
+// EventType identifies the kind of a StreamEvent
+type EventType string
+
+const (
+	// EventAssistantDelta carries an incremental chunk of the assistant's answer
+	EventAssistantDelta EventType = "assistant_delta"
+	// EventReasoningDelta carries an incremental chunk of the model's reasoning in Message.ReasoningContent
+	EventReasoningDelta EventType = "reasoning_delta"
+	// EventToolCallStarted is emitted once the model has fully emitted a tool call and it is about to run
+	EventToolCallStarted EventType = "tool_call_started"
+	// EventToolCallDelta carries a fragment of a client tool call while the model streams it: the first fragment
+	// of a call has its ID and name, later ones append to its arguments. ToolCall.Index identifies the call.
+	EventToolCallDelta EventType = "tool_call_delta"
+	// EventClientToolCall carries a call of a client tool (see WithClientTools); the run ends after the message
+	EventClientToolCall EventType = "client_tool_call"
+	// EventToolProgress carries a progress report of a running tool call, for tools that send them
+	EventToolProgress EventType = "tool_progress"
+	// EventToolResult carries the result of a finished tool call
+	EventToolResult EventType = "tool_result"
+	// EventApprovalRequired is emitted when the run paused because a tool call needs approval
+	EventApprovalRequired EventType = "approval_required"
+	// EventCancelled is emitted when the run was aborted with CancelSession or by the end of the caller's context
+	EventCancelled EventType = "cancelled"
+	// EventGuardrailBlocked is emitted when an output guardrail stopped the answer; the run is aborted
+	EventGuardrailBlocked EventType = "guardrail_blocked"
+	// EventLimitExceeded is emitted when the run was aborted because the session hit a limit
+	EventLimitExceeded EventType = "limit_exceeded"
+	// EventUsage is the last event of a run and carries the token usage summed over all model calls
+	EventUsage EventType = "usage"
+)
+
+// StreamEvent is a typed event emitted by ChatStream
+type StreamEvent struct {
+	Type EventType
+
+	// Message is the assistant chunk (EventAssistantDelta and EventReasoningDelta only)
+	Message *schema.Message
+
+	// ToolCall is the complete tool call (EventToolCallStarted and EventClientToolCall) or a fragment (EventToolCallDelta)
+	ToolCall *schema.ToolCall
+
+	// ToolName, ToolCallID and Result describe a finished tool call (EventToolResult);
+	// ToolName and ToolCallID also identify the call of EventToolProgress
+	ToolName   string
+	ToolCallID string
+	Result     string
+
+	// Progress is the latest progress of a running tool call (EventToolProgress only)
+	Progress *progress.Update
+
+	// Approval describes the paused tool call (EventApprovalRequired only)
+	Approval *ApprovalRequest
+
+	// Guardrail describes the failed check (EventGuardrailBlocked only)
+	Guardrail *GuardrailError
+
+	// Limit describes the exceeded limit (EventLimitExceeded only)
+	Limit *LimitError
+
+	// Usage is the accumulated token usage (EventUsage only)
+	Usage *schema.TokenUsage
+}
+
+// collectStream drains a message stream, forwarding each chunk, and returns the concatenated message
+func collectStream(stream *schema.StreamReader[*schema.Message], forward func(chunk *schema.Message)) (*schema.Message, error) {
+	defer stream.Close()
+
+	var chunks []*schema.Message
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if chunk == nil {
+			continue
+		}
+		chunks = append(chunks, chunk)
+		if forward != nil {
+			forward(chunk)
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	return schema.ConcatMessages(chunks)
+}
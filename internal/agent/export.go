@@ -0,0 +1,86 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+)
+
+// SessionExportVersion is the version of the session export format written by ExportSession
+const SessionExportVersion = 1
+
+// SessionExport is the portable JSON representation of a session
+type SessionExport struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Meta       memory.SessionMeta `json:"meta"`
+	Messages   []*schema.Message  `json:"messages"`
+}
+
+// ExportSession returns the session's history and metadata as a versioned JSON document
+func (a *Agent) ExportSession(sessionID string) ([]byte, error) {
+	msgs, ok := a.GetSessionHistory(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("session %s not found", sessionID)
+	}
+
+	doc := SessionExport{
+		Version:    SessionExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Meta:       memory.SessionMeta{ID: sessionID},
+		Messages:   msgs,
+	}
+	if meta, ok := a.GetSessionMeta(sessionID); ok {
+		doc.Meta = *meta
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session %s: %w", sessionID, err)
+	}
+	return data, nil
+}
+
+// ImportSession loads a document produced by ExportSession, replacing any existing session with the same ID,
+// and returns the session ID
+func (a *Agent) ImportSession(doc []byte) (string, error) {
+	var export SessionExport
+	if err := json.Unmarshal(doc, &export); err != nil {
+		return "", fmt.Errorf("invalid session document: %w", err)
+	}
+	if export.Version < 1 || export.Version > SessionExportVersion {
+		return "", fmt.Errorf("unsupported session document version %d", export.Version)
+	}
+	sessionID := export.Meta.ID
+	if sessionID == "" {
+		return "", fmt.Errorf("session document has no session ID")
+	}
+	for i, msg := range export.Messages {
+		if msg == nil || msg.Role == "" {
+			return "", fmt.Errorf("message %d has no role", i)
+		}
+	}
+
+	ctx := context.Background()
+	session := a.GetOrCreateSession(ctx, sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.Messages = append(make([]*schema.Message, 0, len(export.Messages)), export.Messages...)
+	session.Meta = export.Meta
+	if session.Meta.CreatedAt.IsZero() {
+		session.Meta.CreatedAt = time.Now()
+	}
+	a.persistSession(ctx, session)
+
+	logger.Ctx(ctx).Infof("[Session: %s] Imported %d messages", sessionID, len(export.Messages))
+	return sessionID, nil
+}
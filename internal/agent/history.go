@@ -0,0 +1,118 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ErrSessionNotFound is returned when editing the history of a session that is neither resident nor stored
+var ErrSessionNotFound = errors.New("session not found")
+
+// UpdateMessage replaces the content of the message at index and persists the session
+func (a *Agent) UpdateMessage(ctx context.Context, sessionID string, index int, newContent string) error {
+	session, ok := a.existingSession(ctx, sessionID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if index < 0 || index >= len(session.Messages) {
+		return fmt.Errorf("message index %d out of range (session %s has %d messages)", index, sessionID, len(session.Messages))
+	}
+
+	// Copy so that messages shared with stores or callers are not mutated
+	updated := *session.Messages[index]
+	updated.Content = newContent
+	updated.MultiContent = nil
+	updated.UserInputMultiContent = nil
+	updated.AssistantGenMultiContent = nil
+	session.Messages[index] = &updated
+
+	logger.Ctx(ctx).Debugf("[Session: %s] Updated message %d", sessionID, index)
+	a.persistSession(ctx, session)
+	return nil
+}
+
+// DeleteMessage removes the message at index and persists the session.
+// Tool call/result pairs are kept consistent: deleting an assistant message also removes the results of
+// its tool calls, and deleting a tool result removes the matching call from the assistant message
+// (dropping that message if nothing else is left in it).
+func (a *Agent) DeleteMessage(ctx context.Context, sessionID string, index int) error {
+	session, ok := a.existingSession(ctx, sessionID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if index < 0 || index >= len(session.Messages) {
+		return fmt.Errorf("message index %d out of range (session %s has %d messages)", index, sessionID, len(session.Messages))
+	}
+
+	target := session.Messages[index]
+	remove := map[int]bool{index: true}
+
+	switch {
+	case target.Role == schema.Assistant && len(target.ToolCalls) > 0:
+		callIDs := make(map[string]bool, len(target.ToolCalls))
+		for _, tc := range target.ToolCalls {
+			callIDs[tc.ID] = true
+		}
+		for i, msg := range session.Messages {
+			if msg.Role == schema.Tool && callIDs[msg.ToolCallID] {
+				remove[i] = true
+			}
+		}
+	case target.Role == schema.Tool && target.ToolCallID != "":
+		for i := index - 1; i >= 0; i-- {
+			msg := session.Messages[i]
+			if msg.Role != schema.Assistant || !hasToolCall(msg, target.ToolCallID) {
+				continue
+			}
+			trimmed := *msg
+			trimmed.ToolCalls = nil
+			for _, tc := range msg.ToolCalls {
+				if tc.ID != target.ToolCallID {
+					trimmed.ToolCalls = append(trimmed.ToolCalls, tc)
+				}
+			}
+			if len(trimmed.ToolCalls) == 0 && trimmed.Content == "" {
+				remove[i] = true
+			} else {
+				session.Messages[i] = &trimmed
+			}
+			break
+		}
+	}
+
+	kept := make([]*schema.Message, 0, len(session.Messages)-len(remove))
+	for i, msg := range session.Messages {
+		if !remove[i] {
+			kept = append(kept, msg)
+		}
+	}
+	session.Messages = kept
+
+	logger.Ctx(ctx).Debugf("[Session: %s] Deleted message %d (%d messages removed)", sessionID, index, len(remove))
+	a.persistSession(ctx, session)
+	return nil
+}
+
+// hasToolCall reports whether msg contains a tool call with the given ID
+func hasToolCall(msg *schema.Message, callID string) bool {
+	for _, tc := range msg.ToolCalls {
+		if tc.ID == callID {
+			return true
+		}
+	}
+	return false
+}
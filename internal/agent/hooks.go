@@ -0,0 +1,163 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Hooks receives agent lifecycle notifications.
+// Embed NoopHooks to implement only the methods you need.
+type Hooks interface {
+	// OnUserMessage is called before a user message is added to the session; an error rejects the message
+	OnUserMessage(ctx context.Context, sessionID string, msg *schema.Message) error
+	// OnToolCall is called before a tool runs; an error blocks the call and is reported to the model
+	OnToolCall(ctx context.Context, sessionID string, call *schema.ToolCall) error
+	// OnToolResult is called after a tool finished
+	OnToolResult(ctx context.Context, sessionID string, call *schema.ToolCall, result string, err error)
+	// OnAssistantMessage is called for every message generated by the model
+	OnAssistantMessage(ctx context.Context, sessionID string, msg *schema.Message)
+	// OnError is called for errors surfaced by a run
+	OnError(ctx context.Context, sessionID string, err error)
+}
+
+// NoopHooks implements Hooks with no-ops
+type NoopHooks struct{}
+
+func (NoopHooks) OnUserMessage(context.Context, string, *schema.Message) error          { return nil }
+func (NoopHooks) OnToolCall(context.Context, string, *schema.ToolCall) error            { return nil }
+func (NoopHooks) OnToolResult(context.Context, string, *schema.ToolCall, string, error) {}
+func (NoopHooks) OnAssistantMessage(context.Context, string, *schema.Message)           {}
+func (NoopHooks) OnError(context.Context, string, error)                                {}
+
+// ChainHooks combines several hooks; they run in order and the first error wins
+func ChainHooks(hooks ...Hooks) Hooks {
+	return hookChain(hooks)
+}
+
+type hookChain []Hooks
+
+func (c hookChain) OnUserMessage(ctx context.Context, sessionID string, msg *schema.Message) error {
+	for _, h := range c {
+		if err := h.OnUserMessage(ctx, sessionID, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c hookChain) OnToolCall(ctx context.Context, sessionID string, call *schema.ToolCall) error {
+	for _, h := range c {
+		if err := h.OnToolCall(ctx, sessionID, call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c hookChain) OnToolResult(ctx context.Context, sessionID string, call *schema.ToolCall, result string, err error) {
+	for _, h := range c {
+		h.OnToolResult(ctx, sessionID, call, result, err)
+	}
+}
+
+func (c hookChain) OnAssistantMessage(ctx context.Context, sessionID string, msg *schema.Message) {
+	for _, h := range c {
+		h.OnAssistantMessage(ctx, sessionID, msg)
+	}
+}
+
+func (c hookChain) OnError(ctx context.Context, sessionID string, err error) {
+	for _, h := range c {
+		h.OnError(ctx, sessionID, err)
+	}
+}
+
+// sessionKey is the context key carrying the session ID of a run
+type sessionKey struct{}
+
+// withSessionID stores the session ID in the run context
+func withSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionID)
+}
+
+// SessionIDFromContext returns the session ID of the run the context belongs to
+func SessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionKey{}).(string)
+	return sessionID
+}
+
+// hooksMiddleware reports model outputs and tool calls to the hooks
+func hooksMiddleware(hooks Hooks) adk.AgentMiddleware {
+	toolCall := func(input *compose.ToolInput) *schema.ToolCall {
+		return &schema.ToolCall{ID: input.CallID, Function: schema.FunctionCall{Name: input.Name, Arguments: input.Arguments}}
+	}
+
+	return adk.AgentMiddleware{
+		AfterChatModel: func(ctx context.Context, state *adk.ChatModelAgentState) error {
+			if n := len(state.Messages); n > 0 && state.Messages[n-1].Role == schema.Assistant {
+				hooks.OnAssistantMessage(ctx, SessionIDFromContext(ctx), state.Messages[n-1])
+			}
+			return nil
+		},
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					sessionID, call := SessionIDFromContext(ctx), toolCall(input)
+					if err := hooks.OnToolCall(ctx, sessionID, call); err != nil {
+						return &compose.ToolOutput{Result: "Tool call blocked: " + err.Error()}, nil
+					}
+					output, err := next(ctx, input)
+					result := ""
+					if output != nil {
+						result = output.Result
+					}
+					hooks.OnToolResult(ctx, sessionID, call, result, err)
+					return output, err
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					sessionID, call := SessionIDFromContext(ctx), toolCall(input)
+					if err := hooks.OnToolCall(ctx, sessionID, call); err != nil {
+						return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{"Tool call blocked: " + err.Error()})}, nil
+					}
+					output, err := next(ctx, input)
+					if err != nil || output == nil || output.Result == nil {
+						hooks.OnToolResult(ctx, sessionID, call, "", err)
+						return output, err
+					}
+					// Drain the stream so the hook sees the full result, then replay it
+					result, err := concatStrings(output.Result)
+					hooks.OnToolResult(ctx, sessionID, call, result, err)
+					if err != nil {
+						return nil, err
+					}
+					return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{result})}, nil
+				}
+			},
+		},
+	}
+}
+
+// concatStrings drains a string stream
+func concatStrings(stream *schema.StreamReader[string]) (string, error) {
+	defer stream.Close()
+
+	var sb strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		sb.WriteString(chunk)
+	}
+}
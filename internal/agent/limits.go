@@ -0,0 +1,281 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+)
+
+// Limits caps what a single session may consume; zero values mean unlimited
+type Limits struct {
+	MaxTurnsPerMinute   int // Chat turns a session may start within any minute
+	MaxToolCallsPerTurn int // Tool calls a single run may make before it is aborted
+	MaxSessionTokens    int // Total tokens a session may use over its lifetime
+
+	ToolCosts          map[string]float64 // Cost weight of each tool by exact name or glob pattern; unlisted tools are free
+	MaxToolCostPerTurn float64            // Tool cost a single run may spend before it is aborted
+	MaxSessionToolCost float64            // Total tool cost a session may spend over its lifetime
+}
+
+// LimitKind identifies which limit was hit
+type LimitKind string
+
+const (
+	LimitTurnsPerMinute   LimitKind = "turns_per_minute"
+	LimitToolCallsPerTurn LimitKind = "tool_calls_per_turn"
+	LimitSessionTokens    LimitKind = "session_tokens"
+	LimitToolCostPerTurn  LimitKind = "tool_cost_per_turn"
+	LimitSessionToolCost  LimitKind = "session_tool_cost"
+)
+
+// LimitError is returned when a session exceeds one of its limits
+type LimitError struct {
+	SessionID  string        `json:"session_id"`
+	Limit      LimitKind     `json:"limit"`
+	Max        float64       `json:"max"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"` // Wait before the turn limit admits a new turn
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case LimitTurnsPerMinute:
+		return fmt.Sprintf("session %s exceeded %g turns per minute, retry in %s", e.SessionID, e.Max, e.RetryAfter.Round(time.Second))
+	case LimitToolCallsPerTurn:
+		return fmt.Sprintf("session %s exceeded %g tool calls in one turn", e.SessionID, e.Max)
+	case LimitToolCostPerTurn:
+		return fmt.Sprintf("session %s exceeded its tool budget of %g per turn", e.SessionID, e.Max)
+	case LimitSessionToolCost:
+		return fmt.Sprintf("session %s exceeded its total tool budget of %g", e.SessionID, e.Max)
+	default:
+		return fmt.Sprintf("session %s exceeded its budget of %g tokens", e.SessionID, e.Max)
+	}
+}
+
+// turnLimiter tracks the recent turn start times of each session
+type turnLimiter struct {
+	limits Limits
+	turns  map[string][]time.Time
+	mu     sync.Mutex
+}
+
+func newTurnLimiter(limits Limits) *turnLimiter {
+	return &turnLimiter{limits: limits, turns: make(map[string][]time.Time)}
+}
+
+// admit records a new turn, or returns a LimitError if the session is over its turn rate
+func (l *turnLimiter) admit(sessionID string) error {
+	if l.limits.MaxTurnsPerMinute <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	recent := l.turns[sessionID]
+	for len(recent) > 0 && now.Sub(recent[0]) >= time.Minute {
+		recent = recent[1:]
+	}
+	if len(recent) >= l.limits.MaxTurnsPerMinute {
+		l.turns[sessionID] = recent
+		return &LimitError{
+			SessionID:  sessionID,
+			Limit:      LimitTurnsPerMinute,
+			Max:        float64(l.limits.MaxTurnsPerMinute),
+			RetryAfter: time.Minute - now.Sub(recent[0]),
+		}
+	}
+	l.turns[sessionID] = append(recent, now)
+	return nil
+}
+
+// forget drops the turn history of a session
+func (l *turnLimiter) forget(sessionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.turns, sessionID)
+}
+
+// checkLimits admits a new turn for the session; the session lock must be held
+func (a *Agent) checkLimits(session *Session) error {
+	if max := a.config.Limits.MaxSessionTokens; max > 0 && session.Meta.TotalTokens >= max {
+		return &LimitError{SessionID: session.ID, Limit: LimitSessionTokens, Max: float64(max)}
+	}
+	if max := a.config.Limits.MaxSessionToolCost; max > 0 && session.Meta.ToolCost >= max {
+		return &LimitError{SessionID: session.ID, Limit: LimitSessionToolCost, Max: max}
+	}
+	return a.limiter.admit(session.ID)
+}
+
+// ChargeSession adds the tokens and tool cost spent in another session on a session's behalf, such as a scratch
+// session generating an alternative answer, to the session's totals so they count toward its limits.
+// A session that was deleted is not recreated.
+func (a *Agent) ChargeSession(ctx context.Context, sessionID, spentIn string) {
+	spent, ok := a.GetSessionMeta(spentIn)
+	if !ok || (spent.TotalTokens == 0 && spent.ToolCost == 0) {
+		return
+	}
+	session, exists := a.existingSession(ctx, sessionID)
+	if !exists {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.Meta.TotalTokens += spent.TotalTokens
+	session.Meta.ToolCost += spent.ToolCost
+	if metaStore, ok := a.memoryStore.(memory.MetaStore); ok {
+		if err := metaStore.WriteMeta(ctx, sessionID, &session.Meta); err != nil {
+			logger.Ctx(ctx).Warnf("Failed to persist metadata of session %s: %v", sessionID, err)
+		}
+	}
+}
+
+// addTokens charges the usage of a turn to the session; the session lock must be held
+func (s *Session) addTokens(usage *schema.TokenUsage) {
+	if usage != nil {
+		s.Meta.TotalTokens += usage.TotalTokens
+	}
+}
+
+// toolCallCounterKey is the context key carrying the tool call count of a run
+type toolCallCounterKey struct{}
+
+// withToolCallCounter starts a fresh tool call count for a run
+func withToolCallCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, toolCallCounterKey{}, new(atomic.Int64))
+}
+
+// toolCallLimitMiddleware aborts a run with a LimitError once it makes more than max tool calls
+func toolCallLimitMiddleware(max int) adk.AgentMiddleware {
+	check := func(ctx context.Context, input *compose.ToolInput) error {
+		counter, ok := ctx.Value(toolCallCounterKey{}).(*atomic.Int64)
+		if !ok || counter.Add(1) <= int64(max) {
+			return nil
+		}
+		sessionID := SessionIDFromContext(ctx)
+		logger.Ctx(ctx).Warnf("[Session: %s] Tool call limit of %d reached, refusing %s", sessionID, max, input.Name)
+		return &LimitError{SessionID: sessionID, Limit: LimitToolCallsPerTurn, Max: float64(max)}
+	}
+
+	return adk.AgentMiddleware{
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					if err := check(ctx, input); err != nil {
+						return nil, err
+					}
+					return next(ctx, input)
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					if err := check(ctx, input); err != nil {
+						return nil, err
+					}
+					return next(ctx, input)
+				}
+			},
+		},
+	}
+}
+
+// toolCost returns the cost weight of a tool: an exact entry, then a glob entry, then free
+func toolCost(costs map[string]float64, toolName string) float64 {
+	if cost, ok := costs[toolName]; ok {
+		return cost
+	}
+	for pattern, cost := range costs {
+		if ok, _ := path.Match(pattern, toolName); ok {
+			return cost
+		}
+	}
+	return 0
+}
+
+// runToolCost is the tool cost spent by a run, next to what its session spent before
+type runToolCost struct {
+	mu      sync.Mutex
+	session float64
+	turn    float64
+}
+
+// toolCostKey is the context key carrying the tool cost of a run
+type toolCostKey struct{}
+
+// withToolCost starts a fresh tool cost for a run of a session that already spent sessionCost
+func withToolCost(ctx context.Context, sessionCost float64) context.Context {
+	return context.WithValue(ctx, toolCostKey{}, &runToolCost{session: sessionCost})
+}
+
+// addToolCost charges the tool cost of the run of ctx to the session; the session lock must be held
+func (s *Session) addToolCost(ctx context.Context) {
+	if spent, ok := ctx.Value(toolCostKey{}).(*runToolCost); ok {
+		spent.mu.Lock()
+		defer spent.mu.Unlock()
+		s.Meta.ToolCost += spent.turn
+	}
+}
+
+// toolCostMiddleware aborts a run with a LimitError before a tool call that would take it
+// over its per-turn or per-session tool budget
+func toolCostMiddleware(limits Limits) adk.AgentMiddleware {
+	charge := func(ctx context.Context, input *compose.ToolInput) error {
+		cost := toolCost(limits.ToolCosts, input.Name)
+		spent, ok := ctx.Value(toolCostKey{}).(*runToolCost)
+		if cost <= 0 || !ok {
+			return nil
+		}
+
+		spent.mu.Lock()
+		defer spent.mu.Unlock()
+
+		var limitErr *LimitError
+		sessionID := SessionIDFromContext(ctx)
+		if max := limits.MaxToolCostPerTurn; max > 0 && spent.turn+cost > max {
+			limitErr = &LimitError{SessionID: sessionID, Limit: LimitToolCostPerTurn, Max: max}
+		} else if max := limits.MaxSessionToolCost; max > 0 && spent.session+spent.turn+cost > max {
+			limitErr = &LimitError{SessionID: sessionID, Limit: LimitSessionToolCost, Max: max}
+		}
+		if limitErr != nil {
+			logger.Ctx(ctx).Warnf("[Session: %s] Tool budget of %g reached, refusing %s (cost %g)", sessionID, limitErr.Max, input.Name, cost)
+			return limitErr
+		}
+		spent.turn += cost
+		return nil
+	}
+
+	return adk.AgentMiddleware{
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					if err := charge(ctx, input); err != nil {
+						return nil, err
+					}
+					return next(ctx, input)
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					if err := charge(ctx, input); err != nil {
+						return nil, err
+					}
+					return next(ctx, input)
+				}
+			},
+		},
+	}
+}
@@ -0,0 +1,80 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ChatWithMessages performs a conversation turn for clients that send their own history, like standard OpenAI clients.
+// The last message must be the user message, or the result of a client tool call (see WithClientTools);
+// the ones before it replace the stored session history.
+func (a *Agent) ChatWithMessages(ctx context.Context, sessionID string, msgs []*schema.Message, opts ...ChatOption) (*schema.Message, error) {
+	history, userMsg, err := splitMessages(msgs)
+	if err != nil {
+		return nil, err
+	}
+	return a.chat(ctx, sessionID, history, userMsg, opts)
+}
+
+// ChatStreamWithMessages is the streaming variant of ChatWithMessages
+func (a *Agent) ChatStreamWithMessages(ctx context.Context, sessionID string, msgs []*schema.Message, opts ...ChatOption) (*schema.StreamReader[*StreamEvent], error) {
+	history, userMsg, err := splitMessages(msgs)
+	if err != nil {
+		return nil, err
+	}
+	return a.chatStream(ctx, sessionID, history, userMsg, opts)
+}
+
+// splitMessages separates a client-supplied conversation into prior history and the final user or tool message
+func splitMessages(msgs []*schema.Message) ([]*schema.Message, *schema.Message, error) {
+	if len(msgs) == 0 {
+		return nil, nil, fmt.Errorf("no messages given")
+	}
+	last := msgs[len(msgs)-1]
+	if last == nil || (last.Role != schema.User && last.Role != schema.Tool) {
+		return nil, nil, fmt.Errorf("last message must be a user or tool message")
+	}
+	history := make([]*schema.Message, 0, len(msgs)-1)
+	for i, msg := range msgs[:len(msgs)-1] {
+		if msg == nil {
+			return nil, nil, fmt.Errorf("message %d is nil", i)
+		}
+		history = append(history, msg)
+	}
+	return history, last, nil
+}
+
+// beginTurn adds the user message to the session and returns the model input for the turn.
+// A non-nil history replaces the stored messages and is sent along with the user message;
+// otherwise only the user message is sent. The session lock must be held.
+func (s *Session) beginTurn(history []*schema.Message, userMsg *schema.Message) []adk.Message {
+	input := []adk.Message{userMsg}
+	if history != nil {
+		s.Messages = append(make([]*schema.Message, 0, len(history)+1), history...)
+		input = append(append(make([]adk.Message, 0, len(history)+1), history...), userMsg)
+	}
+	s.Messages = append(s.Messages, userMsg)
+	if userMsg.Role == schema.User {
+		s.recordUserMessage(messageText(userMsg))
+	}
+	return input
+}
+
+// acceptInput runs the input guardrails and hooks on a user message; tool results returned by the caller pass as is
+func (a *Agent) acceptInput(ctx context.Context, sessionID string, msg *schema.Message) (*schema.Message, error) {
+	if msg.Role != schema.User {
+		return msg, nil
+	}
+	msg, err := a.guardInput(ctx, sessionID, msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.hooks().OnUserMessage(ctx, sessionID, msg); err != nil {
+		return nil, fmt.Errorf("user message rejected: %w", err)
+	}
+	return msg, nil
+}
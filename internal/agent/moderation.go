@@ -0,0 +1,136 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ModerationAPIConfig configures an OpenAI-compatible moderation provider
+type ModerationAPIConfig struct {
+	BaseURL    string
+	APIKey     string
+	Model      string        // Empty uses the provider's default model
+	Categories []string      // Only these categories fail the check (empty = any flagged category)
+	Timeout    time.Duration // Defaults to 10s
+}
+
+// ModerationAPICheck classifies text with an OpenAI-compatible /moderations endpoint
+type ModerationAPICheck struct {
+	config *ModerationAPIConfig
+	client *http.Client
+}
+
+// NewModerationAPICheck creates a check against a moderation provider
+func NewModerationAPICheck(config *ModerationAPIConfig) (*ModerationAPICheck, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("moderation base URL is required")
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &ModerationAPICheck{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+type moderationRequest struct {
+	Model string `json:"model,omitempty"`
+	Input string `json:"input"`
+}
+
+type moderationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (c *ModerationAPICheck) Name() string { return "moderation" }
+
+// WholeTextOnly keeps the provider from being called for every streamed delta
+func (c *ModerationAPICheck) WholeTextOnly() bool { return true }
+
+func (c *ModerationAPICheck) Check(ctx context.Context, text string) (*GuardrailViolation, error) {
+	body, err := json.Marshal(moderationRequest{Model: c.config.Model, Input: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	url := strings.TrimSuffix(c.config.BaseURL, "/") + "/moderations"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation response: %w", err)
+	}
+	var result moderationResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil {
+			return nil, fmt.Errorf("moderation request returned status %d: %s", resp.StatusCode, result.Error.Message)
+		}
+		return nil, fmt.Errorf("moderation request returned status %d", resp.StatusCode)
+	}
+
+	var flagged []string
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		for category, hit := range r.Categories {
+			if hit && c.blocks(category) {
+				flagged = append(flagged, category)
+			}
+		}
+		// Providers that flag without categories
+		if len(r.Categories) == 0 && len(c.config.Categories) == 0 {
+			flagged = append(flagged, "unspecified")
+		}
+	}
+	if len(flagged) == 0 {
+		return nil, nil
+	}
+	sort.Strings(flagged)
+	return &GuardrailViolation{Reason: "flagged for " + strings.Join(flagged, ", ")}, nil
+}
+
+// blocks reports whether a flagged category fails the check
+func (c *ModerationAPICheck) blocks(category string) bool {
+	if len(c.config.Categories) == 0 {
+		return true
+	}
+	for _, name := range c.config.Categories {
+		if name == category {
+			return true
+		}
+	}
+	return false
+}
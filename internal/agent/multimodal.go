@@ -0,0 +1,30 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// multiModalMessage builds a user message from content parts
+func multiModalMessage(parts []schema.MessageInputPart) *schema.Message {
+	return &schema.Message{
+		Role:                  schema.User,
+		UserInputMultiContent: parts,
+	}
+}
+
+// messageText returns the textual content of a message, joining the text parts of multi-modal input
+func messageText(msg *schema.Message) string {
+	if msg.Content != "" || len(msg.UserInputMultiContent) == 0 {
+		return msg.Content
+	}
+	var texts []string
+	for _, part := range msg.UserInputMultiContent {
+		if part.Type == schema.ChatMessagePartTypeText && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,194 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ChatOptions holds per-call generation options; nil fields fall back to the model defaults
+type ChatOptions struct {
+	Temperature *float32
+	TopP        *float32
+	MaxTokens   *int
+	Stop        []string
+	Seed        *int
+	LogitBias   map[string]int // Token ID -> bias in [-100, 100]
+
+	// Instructions are sent as a system message after the agent's system prompt, for this call only
+	Instructions string
+
+	// ToolChoice and ToolNames control tool calling (see WithToolChoice)
+	ToolChoice *schema.ToolChoice
+	ToolNames  []string
+
+	// ClientTools are executed by the caller (see WithClientTools)
+	ClientTools []*schema.ToolInfo
+
+	// ReadOnly blocks mutating tools for this call (see WithReadOnly)
+	ReadOnly bool
+
+	// OutputSchema only applies to ChatStructured; StructuredRetries also to EnsureStructured
+	OutputSchema      json.RawMessage
+	StructuredRetries *int
+}
+
+// ChatOption configures a single Chat or ChatStream call
+type ChatOption func(*ChatOptions)
+
+// WithTemperature sets the sampling temperature
+func WithTemperature(temperature float32) ChatOption {
+	return func(o *ChatOptions) {
+		o.Temperature = &temperature
+	}
+}
+
+// WithTopP sets nucleus sampling probability mass
+func WithTopP(topP float32) ChatOption {
+	return func(o *ChatOptions) {
+		o.TopP = &topP
+	}
+}
+
+// WithMaxTokens limits the number of tokens generated per model call
+func WithMaxTokens(maxTokens int) ChatOption {
+	return func(o *ChatOptions) {
+		o.MaxTokens = &maxTokens
+	}
+}
+
+// WithStop sets the stop sequences
+func WithStop(stop ...string) ChatOption {
+	return func(o *ChatOptions) {
+		o.Stop = stop
+	}
+}
+
+// WithSeed asks backends that support it for deterministic sampling
+func WithSeed(seed int) ChatOption {
+	return func(o *ChatOptions) {
+		o.Seed = &seed
+	}
+}
+
+// WithLogitBias adjusts the likelihood of tokens on backends that support it
+func WithLogitBias(bias map[string]int) ChatOption {
+	return func(o *ChatOptions) {
+		o.LogitBias = bias
+	}
+}
+
+// WithInstructions adds per-call instructions, e.g. the system messages of an OpenAI request
+func WithInstructions(instructions string) ChatOption {
+	return func(o *ChatOptions) {
+		o.Instructions = instructions
+	}
+}
+
+// WithChatOptions applies every non-nil field of the given options
+func WithChatOptions(opts ChatOptions) ChatOption {
+	return func(o *ChatOptions) {
+		if opts.Temperature != nil {
+			o.Temperature = opts.Temperature
+		}
+		if opts.TopP != nil {
+			o.TopP = opts.TopP
+		}
+		if opts.MaxTokens != nil {
+			o.MaxTokens = opts.MaxTokens
+		}
+		if opts.Stop != nil {
+			o.Stop = opts.Stop
+		}
+		if opts.Seed != nil {
+			o.Seed = opts.Seed
+		}
+		if opts.LogitBias != nil {
+			o.LogitBias = opts.LogitBias
+		}
+		if opts.Instructions != "" {
+			o.Instructions = opts.Instructions
+		}
+		if opts.ToolChoice != nil {
+			o.ToolChoice = opts.ToolChoice
+			o.ToolNames = opts.ToolNames
+		}
+		if opts.ClientTools != nil {
+			o.ClientTools = opts.ClientTools
+		}
+		if opts.OutputSchema != nil {
+			o.OutputSchema = opts.OutputSchema
+		}
+		if opts.StructuredRetries != nil {
+			o.StructuredRetries = opts.StructuredRetries
+		}
+		if opts.ReadOnly {
+			o.ReadOnly = true
+		}
+	}
+}
+
+// applyChatOptions folds option funcs into a ChatOptions value
+func applyChatOptions(opts []ChatOption) *ChatOptions {
+	o := &ChatOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// modelOptions converts the chat options to model call options
+func (o *ChatOptions) modelOptions() []model.Option {
+	var opts []model.Option
+	if o.Temperature != nil {
+		opts = append(opts, model.WithTemperature(*o.Temperature))
+	}
+	if o.TopP != nil {
+		opts = append(opts, model.WithTopP(*o.TopP))
+	}
+	if o.MaxTokens != nil {
+		opts = append(opts, model.WithMaxTokens(*o.MaxTokens))
+	}
+	if len(o.Stop) > 0 {
+		opts = append(opts, model.WithStop(o.Stop))
+	}
+	// OpenAI-compatible backends send these as request fields; other backends ignore the option
+	extra := make(map[string]any)
+	if o.Seed != nil {
+		extra["seed"] = *o.Seed
+	}
+	if len(o.LogitBias) > 0 {
+		extra["logit_bias"] = o.LogitBias
+	}
+	if len(extra) > 0 {
+		opts = append(opts, openai.WithExtraFields(extra))
+	}
+	return opts
+}
+
+// withInstructions prepends the per-call instructions to the model input; they are not stored in the session
+func (o *ChatOptions) withInstructions(input []adk.Message) []adk.Message {
+	if o.Instructions == "" {
+		return input
+	}
+	return append([]adk.Message{schema.SystemMessage(o.Instructions)}, input...)
+}
+
+// runContext stores the per-run options read by the model wrapper and the tool node
+func (o *ChatOptions) runContext(ctx context.Context) context.Context {
+	return o.withReadOnly(o.withClientTools(o.withToolChoice(ctx)))
+}
+
+// runOptions builds the ADK run options for a session
+func (o *ChatOptions) runOptions(sessionID string) []adk.AgentRunOption {
+	runOpts := []adk.AgentRunOption{adk.WithCheckPointID(sessionID)}
+	if modelOpts := o.modelOptions(); len(modelOpts) > 0 {
+		runOpts = append(runOpts, adk.WithChatModelOptions(modelOpts))
+	}
+	return runOpts
+}
@@ -0,0 +1,109 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Pool holds one agent per model name and routes calls by model identifier.
+// Its agents share their resident sessions, so every model continues a conversation where the last one left it.
+type Pool struct {
+	agents      map[string]*Agent
+	names       []string // Registration order
+	defaultName string
+	sessions    *sessionCache
+	mu          sync.RWMutex
+}
+
+// NewPool creates an empty agent pool
+func NewPool() *Pool {
+	return &Pool{agents: make(map[string]*Agent), sessions: newSessionCache()}
+}
+
+// Add registers an agent under a model name; the first registered agent becomes the default.
+// The agent takes over the pool's sessions, so it must be added before it serves any.
+func (p *Pool) Add(model string, a *Agent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	a.sessionCache = p.sessions
+
+	if _, exists := p.agents[model]; !exists {
+		p.names = append(p.names, model)
+	}
+	p.agents[model] = a
+	if p.defaultName == "" {
+		p.defaultName = model
+	}
+}
+
+// SetDefault selects the agent used when no model is requested
+func (p *Pool) SetDefault(model string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.agents[model]; !exists {
+		return fmt.Errorf("model %s is not registered", model)
+	}
+	p.defaultName = model
+	return nil
+}
+
+// DefaultModel returns the name of the default model
+func (p *Pool) DefaultModel() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.defaultName
+}
+
+// Get returns the agent for a model; an empty name selects the default
+func (p *Pool) Get(model string) (*Agent, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if model == "" {
+		model = p.defaultName
+	}
+	a, ok := p.agents[model]
+	return a, ok
+}
+
+// Models returns the registered model names in registration order
+func (p *Pool) Models() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]string(nil), p.names...)
+}
+
+// resolve returns the agent for a model or an error naming the unknown model
+func (p *Pool) resolve(model string) (*Agent, error) {
+	a, ok := p.Get(model)
+	if !ok {
+		return nil, fmt.Errorf("model %s does not exist", model)
+	}
+	return a, nil
+}
+
+// Chat routes a conversation turn to the agent of the given model
+func (p *Pool) Chat(ctx context.Context, model, sessionID, userMessage string, opts ...ChatOption) (*schema.Message, error) {
+	a, err := p.resolve(model)
+	if err != nil {
+		return nil, err
+	}
+	return a.Chat(ctx, sessionID, userMessage, opts...)
+}
+
+// ChatStream routes a streaming conversation turn to the agent of the given model
+func (p *Pool) ChatStream(ctx context.Context, model, sessionID, userMessage string, opts ...ChatOption) (*schema.StreamReader[*StreamEvent], error) {
+	a, err := p.resolve(model)
+	if err != nil {
+		return nil, err
+	}
+	return a.ChatStream(ctx, sessionID, userMessage, opts...)
+}
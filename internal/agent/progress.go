@@ -0,0 +1,52 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/progress"
+)
+
+// progressRelay forwards the progress tools report during a streaming run to its event stream
+type progressRelay struct {
+	mu sync.Mutex
+	w  *schema.StreamWriter[*StreamEvent] // nil before the stream starts and once it ends
+}
+
+// streamProgress makes the tool calls of ctx report their progress to the run's event stream
+func (r *activeRun) streamProgress(ctx context.Context) context.Context {
+	r.progress = &progressRelay{}
+	return progress.WithReporter(ctx, r.progress.report)
+}
+
+// attach starts forwarding to w; detach stops it before w is closed
+func (p *progressRelay) attach(w *schema.StreamWriter[*StreamEvent]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.w = w
+}
+
+func (p *progressRelay) detach() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.w = nil
+}
+
+// report emits an EventToolProgress for the tool call running with ctx
+func (p *progressRelay) report(ctx context.Context, tool string, update progress.Update) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.w == nil {
+		return
+	}
+	p.w.Send(&StreamEvent{
+		Type:       EventToolProgress,
+		ToolName:   tool,
+		ToolCallID: compose.GetToolCallID(ctx),
+		Progress:   &update,
+	}, nil)
+}
@@ -0,0 +1,118 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// MutatingTool is implemented by tools that declare whether they change their environment,
+// such as MCP tools from their readOnlyHint and destructiveHint annotations
+type MutatingTool interface {
+	Mutating() bool
+}
+
+// WithReadOnly blocks the calls of mutating tools for a single turn, in addition to sessions
+// marked read-only with SetSessionReadOnly
+func WithReadOnly() ChatOption {
+	return func(o *ChatOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// readOnlyKey is the context key marking a read-only run
+type readOnlyKey struct{}
+
+// withReadOnly marks the run context read-only if the turn is
+func (o *ChatOptions) withReadOnly(ctx context.Context) context.Context {
+	if !o.ReadOnly {
+		return ctx
+	}
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// isReadOnly reports whether the run of ctx must not call mutating tools
+func isReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// SetSessionReadOnly marks a session read-only, blocking mutating tools in its later turns, and persists it
+func (a *Agent) SetSessionReadOnly(ctx context.Context, sessionID string, readOnly bool) {
+	session := a.GetOrCreateSession(ctx, sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.Meta.ReadOnly = readOnly
+	a.persistSession(ctx, session)
+}
+
+// mutatingTools returns the names of the tools blocked in read-only runs: tools declaring themselves
+// mutating and tools matching a configured pattern
+func mutatingTools(ctx context.Context, config *Config) map[string]bool {
+	tools := append([]tool.BaseTool(nil), config.Tools...)
+	for _, sub := range config.SubAgents {
+		tools = append(tools, sub.Tools...)
+	}
+	mutating := make(map[string]bool)
+	for _, t := range tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			continue
+		}
+		m, ok := t.(MutatingTool)
+		mutating[info.Name] = ok && m.Mutating() || slices.ContainsFunc(config.MutatingTools, func(pattern string) bool {
+			matched, _ := path.Match(pattern, info.Name)
+			return matched
+		})
+	}
+	return mutating
+}
+
+// readOnlyMessage is the tool result the model sees when a mutating tool was blocked
+func readOnlyMessage(toolName string) string {
+	return fmt.Sprintf("Tool %s was blocked: read-only mode is on and the tool may change real systems. "+
+		"Do not retry it; use read-only tools or describe the change you would make instead.", toolName)
+}
+
+// readOnlyMiddleware blocks calls of mutating tools in read-only runs, or in every run if global is set
+func readOnlyMiddleware(global bool, mutating map[string]bool) adk.AgentMiddleware {
+	blocked := func(ctx context.Context, input *compose.ToolInput) string {
+		if !mutating[input.Name] || !global && !isReadOnly(ctx) {
+			return ""
+		}
+		logger.Ctx(ctx).Infof("Tool %s (%s) blocked in read-only mode", input.Name, input.CallID)
+		return readOnlyMessage(input.Name)
+	}
+
+	return adk.AgentMiddleware{
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					if message := blocked(ctx, input); message != "" {
+						return &compose.ToolOutput{Result: message}, nil
+					}
+					return next(ctx, input)
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					if message := blocked(ctx, input); message != "" {
+						return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{message})}, nil
+					}
+					return next(ctx, input)
+				}
+			},
+		},
+	}
+}
@@ -0,0 +1,95 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Tags some reasoning models (e.g. DeepSeek-R1 behind OpenAI-compatible servers) use to inline their thinking
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// reasoningSplitter separates inline <think> blocks from streamed content.
+// Text that could be the start of a tag is held back until the next chunk decides it.
+type reasoningSplitter struct {
+	inThink   bool
+	trimStart bool   // Drop whitespace between a closing tag and the answer
+	pending   string // Held-back partial tag
+}
+
+// split returns the answer and reasoning parts of a content chunk
+func (r *reasoningSplitter) split(chunk string) (content, reasoning string) {
+	var contentBuf, reasoningBuf strings.Builder
+	emit := func(text string) {
+		if r.inThink {
+			reasoningBuf.WriteString(text)
+			return
+		}
+		if r.trimStart {
+			text = strings.TrimLeft(text, " \t\r\n")
+			r.trimStart = text == ""
+		}
+		contentBuf.WriteString(text)
+	}
+
+	buf := r.pending + chunk
+	r.pending = ""
+	for buf != "" {
+		tag := thinkOpenTag
+		if r.inThink {
+			tag = thinkCloseTag
+		}
+		if i := strings.Index(buf, tag); i >= 0 {
+			emit(buf[:i])
+			buf = buf[i+len(tag):]
+			r.inThink = !r.inThink
+			r.trimStart = !r.inThink
+			continue
+		}
+		keep := partialTagSuffix(buf, tag)
+		emit(buf[:len(buf)-keep])
+		r.pending = buf[len(buf)-keep:]
+		break
+	}
+	return contentBuf.String(), reasoningBuf.String()
+}
+
+// flush returns text held back at the end of the stream
+func (r *reasoningSplitter) flush() (content, reasoning string) {
+	pending := r.pending
+	r.pending = ""
+	if r.inThink {
+		return "", pending
+	}
+	return pending, ""
+}
+
+// partialTagSuffix returns the length of the longest suffix of s that is a proper prefix of tag
+func partialTagSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// splitReasoning moves inline <think> blocks of a complete message into ReasoningContent
+func splitReasoning(msg *schema.Message) *schema.Message {
+	if msg == nil || !strings.Contains(msg.Content, thinkOpenTag) {
+		return msg
+	}
+
+	var r reasoningSplitter
+	content, reasoning := r.split(msg.Content)
+	restContent, restReasoning := r.flush()
+
+	result := *msg
+	result.Content = content + restContent
+	result.ReasoningContent = msg.ReasoningContent + reasoning + restReasoning
+	return &result
+}
@@ -0,0 +1,103 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// RetryConfig controls retries of failed model calls
+type RetryConfig struct {
+	MaxAttempts    int           // Total attempts including the first (<= 1 disables retries)
+	InitialBackoff time.Duration // Delay before the first retry (default 500ms)
+	MaxBackoff     time.Duration // Upper bound for the delay (default 10s)
+	Multiplier     float64       // Backoff growth factor (default 2)
+
+	// IsRetryable classifies errors; defaults to IsRetryableModelError
+	IsRetryable func(err error) bool
+}
+
+// statusCodePattern matches the HTTP status code in OpenAI client errors ("status code: 429")
+var statusCodePattern = regexp.MustCompile(`status code: (\d{3})`)
+
+// IsRetryableModelError reports whether a model call error is transient:
+// rate limits (429), server errors (5xx), timeouts and dropped connections
+func IsRetryableModelError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := err.Error()
+	if m := statusCodePattern.FindStringSubmatch(msg); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return code == 429 || code == 408 || code >= 500
+	}
+	msg = strings.ToLower(msg)
+	for _, marker := range []string{"rate limit", "timeout", "connection reset", "connection refused", "overloaded", "temporarily unavailable"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelRetryConfig converts the policy to the ADK model retry configuration
+func (c *RetryConfig) modelRetryConfig() *adk.ModelRetryConfig {
+	if c == nil || c.MaxAttempts <= 1 {
+		return nil
+	}
+	initial, maxBackoff, multiplier := c.InitialBackoff, c.MaxBackoff, c.Multiplier
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	isRetryable := c.IsRetryable
+	if isRetryable == nil {
+		isRetryable = IsRetryableModelError
+	}
+
+	return &adk.ModelRetryConfig{
+		MaxRetries: c.MaxAttempts - 1,
+		IsRetryAble: func(ctx context.Context, err error) bool {
+			if ctx.Err() != nil || !isRetryable(err) {
+				return false
+			}
+			logger.Ctx(ctx).Warnf("Model call failed, retrying: %v", err)
+			return true
+		},
+		BackoffFunc: func(ctx context.Context, attempt int) time.Duration {
+			delay := float64(initial)
+			for i := 1; i < attempt; i++ {
+				delay *= multiplier
+			}
+			if delay > float64(maxBackoff) {
+				delay = float64(maxBackoff)
+			}
+			// Up to 20% jitter to avoid synchronized retries
+			return time.Duration(delay * (1 + 0.2*rand.Float64()))
+		},
+	}
+}
@@ -0,0 +1,225 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+)
+
+// sessionCache holds the resident sessions and their in-flight runs. The agents of a pool share one, so that
+// a conversation moving between models continues on one session instead of a stale copy per model.
+type sessionCache struct {
+	sessions  map[string]*Session
+	lru       *list.List // Resident sessions, most recently used first
+	sessionMu sync.RWMutex
+	runs      *runRegistry
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{
+		sessions: make(map[string]*Session),
+		lru:      list.New(),
+		runs:     newRunRegistry(),
+	}
+}
+
+// touchLocked marks a resident session as most recently used; a.sessionMu must be held
+func (a *Agent) touchLocked(session *Session) {
+	session.lastAccess = time.Now()
+	if session.elem == nil {
+		session.elem = a.lru.PushFront(session)
+		return
+	}
+	a.lru.MoveToFront(session.elem)
+}
+
+// removeLocked drops a session from the resident set; a.sessionMu must be held
+func (a *Agent) removeLocked(session *Session) {
+	if session.elem != nil {
+		a.lru.Remove(session.elem)
+		session.elem = nil
+	}
+	delete(a.sessions, session.ID)
+}
+
+// evictLocked drops idle sessions past the TTL and least recently used sessions above MaxSessions.
+// The keep session and sessions with a run in progress are never evicted. Evicted sessions are reloaded from the memory store on next access.
+// a.sessionMu must be held.
+func (a *Agent) evictLocked(keep *Session) {
+	if a.config.SessionTTL <= 0 && a.config.MaxSessions <= 0 {
+		return
+	}
+
+	now := time.Now()
+	var next *list.Element
+	for e := a.lru.Back(); e != nil; e = next {
+		next = e.Prev()
+		session := e.Value.(*Session)
+
+		expired := a.config.SessionTTL > 0 && now.Sub(session.lastAccess) > a.config.SessionTTL
+		overflow := a.config.MaxSessions > 0 && len(a.sessions) > a.config.MaxSessions
+		if !expired && !overflow {
+			// Entries further to the front are more recent, so nothing else qualifies
+			return
+		}
+		// Streaming runs do not hold the session lock, so in-flight runs are checked separately; their
+		// usage is accounted to the resident session when they finish
+		if session == keep || a.runs.active(session.ID) || !session.mu.TryLock() {
+			continue
+		}
+		a.removeLocked(session)
+		session.mu.Unlock()
+
+		if expired {
+			logger.Debugf("[Session: %s] Evicted from memory after %s idle", session.ID, now.Sub(session.lastAccess).Round(time.Second))
+		} else {
+			logger.Debugf("[Session: %s] Evicted from memory (max %d resident sessions)", session.ID, a.config.MaxSessions)
+		}
+	}
+}
+
+// maxTitleLength is the maximum length (in runes) of an auto-generated session title
+const maxTitleLength = 60
+
+// loadSessionMeta reads session metadata from the memory store or initializes it
+func (a *Agent) loadSessionMeta(ctx context.Context, sessionID string, msgs []*schema.Message) memory.SessionMeta {
+	if metaStore, ok := a.memoryStore.(memory.MetaStore); ok {
+		meta, err := metaStore.ReadMeta(ctx, sessionID)
+		if err != nil {
+			logger.Ctx(ctx).Warnf("Failed to read metadata of session %s: %v", sessionID, err)
+		}
+		if meta != nil {
+			return *meta
+		}
+	}
+
+	now := time.Now()
+	meta := memory.SessionMeta{ID: sessionID, CreatedAt: now, LastActiveAt: now}
+	for _, msg := range msgs {
+		if msg.Role == schema.User {
+			meta.Title = generateTitle(msg.Content)
+			break
+		}
+	}
+	return meta
+}
+
+// recordUserMessage updates the metadata for a new user message; the session lock must be held
+func (s *Session) recordUserMessage(content string) {
+	s.Meta.LastActiveAt = time.Now()
+	if s.Meta.Title == "" {
+		s.Meta.Title = generateTitle(content)
+	}
+}
+
+// generateTitle derives a session title from the first line of the first user message
+func generateTitle(content string) string {
+	title := strings.TrimSpace(content)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	runes := []rune(title)
+	if len(runes) <= maxTitleLength {
+		return title
+	}
+	cut := string(runes[:maxTitleLength])
+	if i := strings.LastIndexByte(cut, ' '); i > maxTitleLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
+// GetSessionMeta returns a copy of the session's metadata, falling back to the memory store for evicted sessions
+func (a *Agent) GetSessionMeta(sessionID string) (*memory.SessionMeta, bool) {
+	a.sessionMu.RLock()
+	session, exists := a.sessions[sessionID]
+	a.sessionMu.RUnlock()
+
+	if exists {
+		session.mu.RLock()
+		defer session.mu.RUnlock()
+		meta := session.Meta
+		meta.Tags = append([]string(nil), session.Meta.Tags...)
+		return &meta, true
+	}
+
+	metaStore, ok := a.memoryStore.(memory.MetaStore)
+	if !ok {
+		return nil, false
+	}
+	meta, err := metaStore.ReadMeta(context.Background(), sessionID)
+	if err != nil {
+		logger.Warnf("Failed to read metadata of session %s: %v", sessionID, err)
+	}
+	return meta, meta != nil
+}
+
+// ListStoredSessions returns a page of the IDs of the sessions in the memory store starting with prefix,
+// in ascending order; see memory.Store.List
+func (a *Agent) ListStoredSessions(ctx context.Context, prefix, cursor string, limit int) ([]string, error) {
+	if a.memoryStore == nil {
+		return nil, nil
+	}
+	return a.memoryStore.List(ctx, prefix, cursor, limit)
+}
+
+// ListSessionsWithMeta returns metadata of the resident and stored sessions starting with prefix, most recently active first
+func (a *Agent) ListSessionsWithMeta(ctx context.Context, prefix string) []*memory.SessionMeta {
+	byID := make(map[string]*memory.SessionMeta)
+	if metaStore, ok := a.memoryStore.(memory.MetaStore); ok {
+		stored, err := metaStore.ListMeta(ctx, prefix)
+		if err != nil {
+			logger.Warnf("Failed to list session metadata: %v", err)
+		}
+		for _, meta := range stored {
+			byID[meta.ID] = meta
+		}
+	}
+
+	for _, id := range a.ListSessions() {
+		if !strings.HasPrefix(id, prefix) {
+			continue
+		}
+		if meta, ok := a.GetSessionMeta(id); ok {
+			byID[id] = meta
+		}
+	}
+
+	result := make([]*memory.SessionMeta, 0, len(byID))
+	for _, meta := range byID {
+		result = append(result, meta)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastActiveAt.After(result[j].LastActiveAt) })
+	return result
+}
+
+// SetSessionTitle overrides the session title and persists it
+func (a *Agent) SetSessionTitle(ctx context.Context, sessionID, title string) {
+	session := a.GetOrCreateSession(ctx, sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.Meta.Title = title
+	a.persistSession(ctx, session)
+}
+
+// SetSessionTags replaces the session tags and persists them
+func (a *Agent) SetSessionTags(ctx context.Context, sessionID string, tags ...string) {
+	session := a.GetOrCreateSession(ctx, sessionID)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.Meta.Tags = append([]string(nil), tags...)
+	a.persistSession(ctx, session)
+}
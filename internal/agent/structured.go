@@ -0,0 +1,297 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// defaultStructuredRetries is the number of correction rounds after an invalid answer
+const defaultStructuredRetries = 2
+
+// WithOutputSchema sets the JSON schema ChatStructured enforces instead of deriving one from the target type
+func WithOutputSchema(schema json.RawMessage) ChatOption {
+	return func(o *ChatOptions) {
+		o.OutputSchema = schema
+	}
+}
+
+// WithStructuredRetries sets how many times ChatStructured asks the model to fix an invalid answer
+func WithStructuredRetries(retries int) ChatOption {
+	return func(o *ChatOptions) {
+		o.StructuredRetries = &retries
+	}
+}
+
+// StructuredOutputError is returned when the model never produced output matching the schema
+type StructuredOutputError struct {
+	Output string // Last answer received
+	Err    error  // Last validation error
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("model output does not match schema: %v", e.Err)
+}
+
+func (e *StructuredOutputError) Unwrap() error {
+	return e.Err
+}
+
+// ChatStructured performs a conversation turn whose answer must be JSON matching a schema,
+// and decodes it into out (a non-nil pointer).
+// The schema is derived from out's type unless WithOutputSchema is given; pass a *json.RawMessage
+// or *map[string]any together with WithOutputSchema to work with a schema only.
+// Invalid answers are sent back to the model with the validation error until the retries are exhausted.
+func (a *Agent) ChatStructured(ctx context.Context, sessionID string, userMessage string, out any, opts ...ChatOption) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("structured output target must be a non-nil pointer, got %T", out)
+	}
+
+	options := applyChatOptions(opts)
+	schemaJSON := options.OutputSchema
+	if len(schemaJSON) == 0 {
+		reflector := &jsonschema.Reflector{DoNotReference: true, Anonymous: true}
+		derived := reflector.ReflectFromType(rv.Elem().Type())
+		derived.Version = ""
+		var err error
+		if schemaJSON, err = json.Marshal(derived); err != nil {
+			return fmt.Errorf("failed to derive schema from %T: %w", out, err)
+		}
+	}
+	var schemaDoc map[string]any
+	if err := json.Unmarshal(schemaJSON, &schemaDoc); err != nil {
+		return fmt.Errorf("invalid output schema: %w", err)
+	}
+
+	prompt := fmt.Sprintf("%s\n\n%s", userMessage, StructuredInstructions(schemaJSON))
+	response, err := a.Chat(ctx, sessionID, prompt, opts...)
+	if err != nil {
+		return err
+	}
+	if response, err = a.ensureStructured(ctx, sessionID, response, schemaDoc, opts); err != nil {
+		return err
+	}
+	return decodeStructured(response.Content, schemaDoc, out)
+}
+
+// StructuredInstructions asks the model to answer with JSON conforming to schemaJSON
+func StructuredInstructions(schemaJSON json.RawMessage) string {
+	return fmt.Sprintf("Respond ONLY with a JSON value that conforms to this JSON schema, without any other text:\n%s", schemaJSON)
+}
+
+// EnsureStructured checks that an answer is JSON matching schemaJSON and asks the model to correct invalid
+// answers in the same session (see WithStructuredRetries). The returned answer holds only the JSON value
+// and the usage of every attempt. Answers with tool calls are returned unchanged.
+func (a *Agent) EnsureStructured(ctx context.Context, sessionID string, response *schema.Message, schemaJSON json.RawMessage, opts ...ChatOption) (*schema.Message, error) {
+	var schemaDoc map[string]any
+	if err := json.Unmarshal(schemaJSON, &schemaDoc); err != nil {
+		return nil, fmt.Errorf("invalid output schema: %w", err)
+	}
+	return a.ensureStructured(ctx, sessionID, response, schemaDoc, opts)
+}
+
+// ensureStructured implements EnsureStructured for a decoded schema
+func (a *Agent) ensureStructured(ctx context.Context, sessionID string, response *schema.Message, schemaDoc map[string]any, opts []ChatOption) (*schema.Message, error) {
+	if len(response.ToolCalls) > 0 {
+		return response, nil
+	}
+	retries := defaultStructuredRetries
+	if r := applyChatOptions(opts).StructuredRetries; r != nil {
+		retries = *r
+	}
+
+	var usage usageCounter
+	for attempt := 0; ; attempt++ {
+		usage.add(response)
+		var raw json.RawMessage
+		err := decodeStructured(response.Content, schemaDoc, &raw)
+		if err == nil {
+			out := *response
+			out.Content = string(raw)
+			return usage.attach(&out), nil
+		}
+		if attempt == retries {
+			return nil, &StructuredOutputError{Output: response.Content, Err: err}
+		}
+
+		logger.Ctx(ctx).Debugf("[Session: %s] Structured output attempt %d invalid: %v", sessionID, attempt+1, err)
+		prompt := fmt.Sprintf("Your previous reply was invalid: %v\nReply again with ONLY the corrected JSON value.", err)
+		if response, err = a.Chat(ctx, sessionID, prompt, opts...); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decodeStructured extracts the JSON value from an answer, validates it and decodes it into out
+func decodeStructured(content string, schemaDoc map[string]any, out any) error {
+	raw := extractJSON(content)
+
+	var value any
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("answer is not valid JSON: %w", err)
+	}
+	if err := validateSchema(schemaDoc, value, "$"); err != nil {
+		return err
+	}
+
+	if rawOut, ok := out.(*json.RawMessage); ok {
+		*rawOut = json.RawMessage(raw)
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return fmt.Errorf("failed to decode answer: %w", err)
+	}
+	return nil
+}
+
+// extractJSON strips markdown code fences and surrounding prose from a model answer
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		if i := strings.LastIndex(content, "```"); i >= 0 {
+			content = content[:i]
+		}
+		return strings.TrimSpace(content)
+	}
+	start := strings.IndexAny(content, "{[")
+	end := strings.LastIndexAny(content, "}]")
+	if start > 0 && end > start {
+		return content[start : end+1]
+	}
+	return content
+}
+
+// validateSchema checks a decoded JSON value against the commonly used subset of JSON schema:
+// type, enum, const, properties, required, additionalProperties, items, minItems/maxItems
+func validateSchema(schema map[string]any, value any, path string) error {
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		return fmt.Errorf("%s: expected type %v, got %s", path, t, jsonTypeOf(value))
+	}
+	if enum, ok := schema["enum"].([]any); ok && !containsJSON(enum, value) {
+		return fmt.Errorf("%s: value must be one of %v", path, enum)
+	}
+	if c, ok := schema["const"]; ok && !equalJSON(c, value) {
+		return fmt.Errorf("%s: value must be %v", path, c)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, exists := v[name]; !exists {
+					return fmt.Errorf("%s: missing required property %q", path, name)
+				}
+			}
+		}
+		for name, field := range v {
+			if propSchema, ok := props[name].(map[string]any); ok {
+				if err := validateSchema(propSchema, field, path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+			case map[string]any:
+				if err := validateSchema(extra, field, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if n, ok := schema["minItems"].(float64); ok && float64(len(v)) < n {
+			return fmt.Errorf("%s: expected at least %v items", path, n)
+		}
+		if n, ok := schema["maxItems"].(float64); ok && float64(len(v)) > n {
+			return fmt.Errorf("%s: expected at most %v items", path, n)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value matches a schema "type" (string or list of strings)
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		actual := jsonTypeOf(value)
+		return actual == t || (t == "number" && actual == "integer")
+	case []any:
+		for _, candidate := range t {
+			if matchesType(candidate, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// jsonTypeOf returns the JSON schema type name of a decoded value
+func jsonTypeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// containsJSON reports whether value equals one of the candidates
+func containsJSON(candidates []any, value any) bool {
+	for _, c := range candidates {
+		if equalJSON(c, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalJSON compares two decoded JSON values by their encoding
+func equalJSON(a, b any) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ab, bb)
+}
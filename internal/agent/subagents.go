@@ -0,0 +1,83 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/adk/prebuilt/supervisor"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// SubAgentConfig defines a specialist agent the supervisor can delegate to
+type SubAgentConfig struct {
+	Name        string
+	Description string // Used by the supervisor to decide when to delegate
+	Instruction string
+	Model       model.ToolCallingChatModel // Defaults to the main agent's model
+	Tools       []tool.BaseTool
+	MaxSteps    int // Defaults to the main agent's MaxSteps
+}
+
+// newChatModelAgent creates an ADK ChatModel agent from a sub-agent definition
+func newChatModelAgent(ctx context.Context, cfg *SubAgentConfig, retry *RetryConfig, middlewares []adk.AgentMiddleware) (*adk.ChatModelAgent, error) {
+	return adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Instruction: cfg.Instruction,
+		Model:       withToolChoiceModel(cfg.Model),
+		ToolsConfig: adk.ToolsConfig{
+			ToolsNodeConfig: compose.ToolsNodeConfig{
+				Tools:               cfg.Tools,
+				UnknownToolsHandler: handleUnknownTool,
+			},
+		},
+		MaxIterations:    cfg.MaxSteps,
+		Middlewares:      middlewares,
+		ModelRetryConfig: retry.modelRetryConfig(),
+	})
+}
+
+// newSupervisor wires the configured sub-agents under the main agent.
+// The main agent routes each request to a specialist, which hands control back when done.
+func newSupervisor(ctx context.Context, config *Config, root adk.Agent, middlewares []adk.AgentMiddleware) (adk.Agent, error) {
+	seen := map[string]bool{root.Name(ctx): true}
+	subAgents := make([]adk.Agent, 0, len(config.SubAgents))
+	for i := range config.SubAgents {
+		cfg := config.SubAgents[i]
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("sub-agent %d: name is required", i)
+		}
+		if seen[cfg.Name] {
+			return nil, fmt.Errorf("duplicate agent name: %s", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		if cfg.Model == nil {
+			cfg.Model = config.Model
+		}
+		if cfg.MaxSteps == 0 {
+			cfg.MaxSteps = config.MaxSteps
+		}
+		sub, err := newChatModelAgent(ctx, &cfg, config.Retry, middlewares)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sub-agent %s: %w", cfg.Name, err)
+		}
+		subAgents = append(subAgents, sub)
+		logger.Ctx(ctx).Infof("Registered sub-agent %s with %d tools", cfg.Name, len(cfg.Tools))
+	}
+
+	sv, err := supervisor.New(ctx, &supervisor.Config{
+		Supervisor: root,
+		SubAgents:  subAgents,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create supervisor: %w", err)
+	}
+	return sv, nil
+}
@@ -0,0 +1,115 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// toolTimeout returns the timeout for a tool: an exact override, then a glob override, then the default
+func toolTimeout(defaultTimeout time.Duration, overrides map[string]time.Duration, toolName string) time.Duration {
+	if d, ok := overrides[toolName]; ok {
+		return d
+	}
+	for pattern, d := range overrides {
+		if ok, _ := path.Match(pattern, toolName); ok {
+			return d
+		}
+	}
+	return defaultTimeout
+}
+
+// timeoutMessage is the tool result the model sees when a call timed out
+func timeoutMessage(toolName string, timeout time.Duration) string {
+	return fmt.Sprintf("Tool %s timed out after %s and was cancelled. Try a narrower request or a different approach.", toolName, timeout)
+}
+
+// timeoutMiddleware cancels tool calls that exceed their timeout and reports the timeout to the model
+func timeoutMiddleware(defaultTimeout time.Duration, overrides map[string]time.Duration) adk.AgentMiddleware {
+	return adk.AgentMiddleware{
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					timeout := toolTimeout(defaultTimeout, overrides, input.Name)
+					if timeout <= 0 {
+						return next(ctx, input)
+					}
+					result, err := runWithTimeout(ctx, timeout, func(ctx context.Context) (string, error) {
+						output, err := next(ctx, input)
+						if err != nil || output == nil {
+							return "", err
+						}
+						return output.Result, nil
+					})
+					if err == context.DeadlineExceeded {
+						logger.Ctx(ctx).Warnf("Tool %s (%s) timed out after %s", input.Name, input.CallID, timeout)
+						return &compose.ToolOutput{Result: timeoutMessage(input.Name, timeout)}, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return &compose.ToolOutput{Result: result}, nil
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					timeout := toolTimeout(defaultTimeout, overrides, input.Name)
+					if timeout <= 0 {
+						return next(ctx, input)
+					}
+					// The whole stream must finish within the timeout, so it is collected before returning
+					result, err := runWithTimeout(ctx, timeout, func(ctx context.Context) (string, error) {
+						output, err := next(ctx, input)
+						if err != nil || output == nil || output.Result == nil {
+							return "", err
+						}
+						return concatStrings(output.Result)
+					})
+					if err == context.DeadlineExceeded {
+						logger.Ctx(ctx).Warnf("Tool %s (%s) timed out after %s", input.Name, input.CallID, timeout)
+						result, err = timeoutMessage(input.Name, timeout), nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{result})}, nil
+				}
+			},
+		},
+	}
+}
+
+// runWithTimeout runs fn with a deadline and returns context.DeadlineExceeded once it passes,
+// even if fn ignores cancellation
+func runWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (string, error)) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := fn(ctx)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && ctx.Err() == context.DeadlineExceeded {
+			return "", context.DeadlineExceeded
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
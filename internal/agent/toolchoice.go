@@ -0,0 +1,97 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// WithToolChoice controls tool calling for a single turn.
+// schema.ToolChoiceForbidden disables tool calls for every model call of the turn.
+// schema.ToolChoiceForced makes the first model call of the turn call one of toolNames (any tool if none are given);
+// later calls are unrestricted so the model can answer with the tool results.
+func WithToolChoice(choice schema.ToolChoice, toolNames ...string) ChatOption {
+	return func(o *ChatOptions) {
+		o.ToolChoice = &choice
+		o.ToolNames = toolNames
+	}
+}
+
+// toolChoiceKey is the context key carrying the tool choice of a run
+type toolChoiceKey struct{}
+
+// toolChoiceState is the tool choice of a run; forced choices are consumed by the first model call
+type toolChoiceState struct {
+	choice schema.ToolChoice
+	names  []string
+	used   atomic.Bool
+}
+
+// withToolChoice stores the turn's tool choice in the run context
+func (o *ChatOptions) withToolChoice(ctx context.Context) context.Context {
+	if o.ToolChoice == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, toolChoiceKey{}, &toolChoiceState{choice: *o.ToolChoice, names: o.ToolNames})
+}
+
+// toolChoiceOptions returns the model options for the next model call of the run
+func toolChoiceOptions(ctx context.Context, opts []model.Option) []model.Option {
+	state, _ := ctx.Value(toolChoiceKey{}).(*toolChoiceState)
+	if state == nil {
+		return opts
+	}
+	if state.choice == schema.ToolChoiceForced && state.used.Swap(true) {
+		return opts
+	}
+	return append(opts, model.WithToolChoice(state.choice, state.names...))
+}
+
+// toolChoiceModel applies the run's tool choice and client tools to the wrapped model's calls
+type toolChoiceModel struct {
+	model.ToolCallingChatModel
+	tools []*schema.ToolInfo // Tools bound with WithTools
+}
+
+// withToolChoiceModel wraps a model so per-turn tool choices reach it
+func withToolChoiceModel(m model.ToolCallingChatModel) model.ToolCallingChatModel {
+	if m == nil {
+		return nil
+	}
+	return &toolChoiceModel{ToolCallingChatModel: m}
+}
+
+func (m *toolChoiceModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.ToolCallingChatModel.Generate(ctx, input, m.options(ctx, opts)...)
+}
+
+func (m *toolChoiceModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return m.ToolCallingChatModel.Stream(ctx, input, m.options(ctx, opts)...)
+}
+
+// options adds the run's client tools and tool choice to the call options
+func (m *toolChoiceModel) options(ctx context.Context, opts []model.Option) []model.Option {
+	return toolChoiceOptions(ctx, clientToolOptions(ctx, m.tools, opts))
+}
+
+func (m *toolChoiceModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &toolChoiceModel{ToolCallingChatModel: inner, tools: tools}, nil
+}
+
+// GetType and IsCallbacksEnabled forward to the wrapped model so callbacks are not reported twice
+func (m *toolChoiceModel) GetType() string {
+	typ, _ := components.GetType(m.ToolCallingChatModel)
+	return typ
+}
+
+func (m *toolChoiceModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(m.ToolCallingChatModel)
+}
@@ -0,0 +1,127 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// currentRunner returns the runner new runs should use
+func (a *Agent) currentRunner() *adk.Runner {
+	a.runnerMu.RLock()
+	defer a.runnerMu.RUnlock()
+
+	return a.runner
+}
+
+// Tools returns the tools available to the main agent
+func (a *Agent) Tools() []tool.BaseTool {
+	a.runnerMu.RLock()
+	defer a.runnerMu.RUnlock()
+
+	return append([]tool.BaseTool(nil), a.config.Tools...)
+}
+
+// AddTool registers application-supplied tools after construction.
+// The agent is rebuilt with the new tool set; runs already in progress keep the previous tools.
+func (a *Agent) AddTool(ctx context.Context, tools ...tool.BaseTool) error {
+	a.runnerMu.Lock()
+	defer a.runnerMu.Unlock()
+
+	existing := make(map[string]bool, len(a.config.Tools))
+	for _, t := range a.config.Tools {
+		if info, err := t.Info(ctx); err == nil {
+			existing[info.Name] = true
+		}
+	}
+	infos, problems := validateTools(ctx, tools)
+	if err := errors.Join(problems...); err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if existing[info.Name] {
+			return fmt.Errorf("tool %s is already registered", info.Name)
+		}
+	}
+
+	config := *a.config
+	config.Tools = append(append([]tool.BaseTool(nil), a.config.Tools...), tools...)
+	runner, err := newRunner(ctx, &config, a.checkpoints)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild agent with new tools: %w", err)
+	}
+	a.config.Tools = config.Tools
+	a.runner = runner
+
+	logger.Ctx(ctx).Infof("Registered %d tool(s): %s", len(infos), toolNames(infos))
+	return nil
+}
+
+// ReplaceTools removes the named tools and registers others in their place, and sets the tools of the
+// sub-agents named in subAgentTools, rebuilding the agent once. Runs already in progress keep the previous tools.
+func (a *Agent) ReplaceTools(ctx context.Context, remove []string, add []tool.BaseTool, subAgentTools map[string][]tool.BaseTool) error {
+	a.runnerMu.Lock()
+	defer a.runnerMu.Unlock()
+
+	removed := make(map[string]bool, len(remove))
+	for _, name := range remove {
+		removed[name] = true
+	}
+	tools := make([]tool.BaseTool, 0, len(a.config.Tools)+len(add))
+	for _, t := range a.config.Tools {
+		if info, err := t.Info(ctx); err == nil && removed[info.Name] {
+			continue
+		}
+		tools = append(tools, t)
+	}
+	dropped := len(a.config.Tools) - len(tools)
+	tools = append(tools, add...)
+	if _, problems := validateTools(ctx, tools); len(problems) > 0 {
+		return errors.Join(problems...)
+	}
+
+	config := *a.config
+	config.Tools = tools
+	if len(subAgentTools) > 0 {
+		// The sub-agent list may be shared with other agents of the pool
+		config.SubAgents = slices.Clone(a.config.SubAgents)
+		for i := range config.SubAgents {
+			subTools, ok := subAgentTools[config.SubAgents[i].Name]
+			if !ok {
+				continue
+			}
+			if _, problems := validateTools(ctx, subTools); len(problems) > 0 {
+				return fmt.Errorf("sub-agent %s: %w", config.SubAgents[i].Name, errors.Join(problems...))
+			}
+			config.SubAgents[i].Tools = subTools
+		}
+	}
+	runner, err := newRunner(ctx, &config, a.checkpoints)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild agent with new tools: %w", err)
+	}
+	a.config.Tools = config.Tools
+	a.config.SubAgents = config.SubAgents
+	a.runner = runner
+
+	logger.Ctx(ctx).Infof("Replaced tools: %d removed, %d added", dropped, len(add))
+	return nil
+}
+
+// toolNames joins the names of the given tool infos
+func toolNames(infos []*schema.ToolInfo) string {
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,510 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ToolSchemaDialect names the subset of JSON schema a provider accepts for tool parameters
+type ToolSchemaDialect string
+
+const (
+	// ToolSchemaDefault fills in missing object types and properties, which every provider accepts
+	ToolSchemaDefault ToolSchemaDialect = ""
+	// ToolSchemaStrict targets OpenAI and Azure OpenAI strict mode: closed objects, every property required
+	// (optional ones nullable) and no validation keywords such as pattern or minimum
+	ToolSchemaStrict ToolSchemaDialect = "strict"
+	// ToolSchemaGemini targets Gemini's OpenAPI subset: no $ref, anyOf, const or additionalProperties,
+	// nullable instead of null types and a type on every schema
+	ToolSchemaGemini ToolSchemaDialect = "gemini"
+	// ToolSchemaClaude targets Anthropic: the top-level schema must be a plain object
+	ToolSchemaClaude ToolSchemaDialect = "claude"
+)
+
+// maxRefDepth bounds the inlining of nested $ref for dialects without references
+const maxRefDepth = 4
+
+// geminiFormats are the formats Gemini accepts per type
+var geminiFormats = map[string][]string{
+	"string":  {"enum", "date-time"},
+	"number":  {"float", "double"},
+	"integer": {"int32", "int64"},
+}
+
+// WithToolSchema wraps a model so the parameter schemas of the tools bound to it, and of client tools,
+// are adapted to the dialect of its provider
+func WithToolSchema(m model.ToolCallingChatModel, dialect string) (model.ToolCallingChatModel, error) {
+	switch d := ToolSchemaDialect(dialect); d {
+	case ToolSchemaDefault, ToolSchemaStrict, ToolSchemaGemini, ToolSchemaClaude:
+		return &toolSchemaModel{ToolCallingChatModel: m, dialect: d}, nil
+	default:
+		return nil, fmt.Errorf("unknown tool schema dialect %q (want strict, gemini or claude)", dialect)
+	}
+}
+
+// toolSchemaModel normalizes the tool schemas of the wrapped model's calls
+type toolSchemaModel struct {
+	model.ToolCallingChatModel
+	dialect ToolSchemaDialect
+}
+
+func (m *toolSchemaModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.ToolCallingChatModel.Generate(ctx, input, m.options(ctx, opts)...)
+}
+
+func (m *toolSchemaModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return m.ToolCallingChatModel.Stream(ctx, input, m.options(ctx, opts)...)
+}
+
+// options normalizes tools passed per call, such as client tools
+func (m *toolSchemaModel) options(ctx context.Context, opts []model.Option) []model.Option {
+	tools := model.GetCommonOptions(nil, opts...).Tools
+	if len(tools) == 0 {
+		return opts
+	}
+	return append(opts, model.WithTools(normalizeTools(ctx, tools, m.dialect)))
+}
+
+func (m *toolSchemaModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.ToolCallingChatModel.WithTools(normalizeTools(context.Background(), tools, m.dialect))
+	if err != nil {
+		return nil, err
+	}
+	return &toolSchemaModel{ToolCallingChatModel: inner, dialect: m.dialect}, nil
+}
+
+// GetType and IsCallbacksEnabled forward to the wrapped model so callbacks are not reported twice
+func (m *toolSchemaModel) GetType() string {
+	typ, _ := components.GetType(m.ToolCallingChatModel)
+	return typ
+}
+
+func (m *toolSchemaModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(m.ToolCallingChatModel)
+}
+
+// normalizeTools returns copies of the tools with their parameter schemas adapted to dialect;
+// tools whose schema cannot be converted are passed unchanged
+func normalizeTools(ctx context.Context, tools []*schema.ToolInfo, dialect ToolSchemaDialect) []*schema.ToolInfo {
+	normalized := make([]*schema.ToolInfo, 0, len(tools))
+	for _, info := range tools {
+		if info == nil || info.ParamsOneOf == nil {
+			normalized = append(normalized, info)
+			continue
+		}
+		params, err := normalizeParams(info.ParamsOneOf, dialect)
+		if err != nil {
+			logger.Ctx(ctx).Warnf("Failed to normalize the schema of tool %s: %v", info.Name, err)
+			normalized = append(normalized, info)
+			continue
+		}
+		copied := *info
+		copied.ParamsOneOf = params
+		normalized = append(normalized, &copied)
+	}
+	return normalized
+}
+
+// normalizeParams converts tool parameters to a JSON schema in dialect
+func normalizeParams(params *schema.ParamsOneOf, dialect ToolSchemaDialect) (*schema.ParamsOneOf, error) {
+	js, err := params.ToJSONSchema()
+	if err != nil {
+		return nil, err
+	}
+	if js == nil {
+		return params, nil
+	}
+	// Work on a copy; the tool's own schema may be shared
+	data, err := json.Marshal(js)
+	if err != nil {
+		return nil, err
+	}
+	root := &jsonschema.Schema{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, err
+	}
+	return schema.NewParamsOneOfByJSONSchema(normalizeSchema(root, dialect)), nil
+}
+
+// normalizeSchema adapts a parameter schema to dialect in place and returns it
+func normalizeSchema(root *jsonschema.Schema, dialect ToolSchemaDialect) *jsonschema.Schema {
+	n := &schemaNormalizer{dialect: dialect, defs: root.Definitions}
+	if dialect != ToolSchemaDefault {
+		// Providers require a plain object at the top level
+		root, _ = n.flatten(n.resolve(root, 0), 0)
+	}
+	root = n.normalize(root, 0)
+	if root.Type == "" && len(root.TypeEnhanced) == 0 {
+		root.Type = "object"
+	}
+	if root.Type == "object" && root.Properties == nil {
+		root.Properties = jsonschema.NewProperties()
+	}
+	if dialect == ToolSchemaGemini {
+		root.Definitions = nil
+	}
+	return root
+}
+
+// schemaNormalizer rewrites schemas for one dialect
+type schemaNormalizer struct {
+	dialect ToolSchemaDialect
+	defs    jsonschema.Definitions // Definitions of the root schema, inlined where $ref is unsupported
+}
+
+// normalize adapts s and its sub-schemas; refs counts the references inlined on the way to s
+func (n *schemaNormalizer) normalize(s *jsonschema.Schema, refs int) *jsonschema.Schema {
+	if s == nil {
+		return nil
+	}
+	if isBooleanSchema(s) {
+		if !n.needsTypes() {
+			return s
+		}
+		s = &jsonschema.Schema{}
+	}
+
+	if n.dialect == ToolSchemaGemini {
+		s = n.resolve(s, refs)
+		if s.Ref != "" {
+			refs++
+		}
+		s.Ref = ""
+	}
+	if n.dialect != ToolSchemaDefault && len(s.AllOf) > 0 {
+		for _, sub := range s.AllOf {
+			mergeSchema(s, n.resolve(sub, refs))
+		}
+		s.AllOf = nil
+	}
+	switch n.dialect {
+	case ToolSchemaStrict:
+		s.AnyOf, s.OneOf = append(s.AnyOf, s.OneOf...), nil
+	case ToolSchemaGemini:
+		// Nested branches are flattened too, up to the depth references may add
+		null := false
+		for i := 0; i < maxRefDepth && len(s.AnyOf)+len(s.OneOf) > 0; i++ {
+			var branchNull bool
+			s, branchNull = n.flatten(s, refs)
+			null = null || branchNull
+		}
+		n.nullable(s, null)
+	}
+
+	fillType(s, n.dialect)
+
+	if s.Properties != nil {
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			pair.Value = n.normalize(pair.Value, refs)
+		}
+	}
+	if s.Type == "array" && s.Items == nil && n.needsTypes() {
+		s.Items = &jsonschema.Schema{}
+	}
+	s.Items = n.normalize(s.Items, refs)
+	if s.AdditionalProperties != nil && !isBooleanSchema(s.AdditionalProperties) {
+		s.AdditionalProperties = n.normalize(s.AdditionalProperties, refs)
+	}
+	for i := range s.AnyOf {
+		s.AnyOf[i] = n.normalize(s.AnyOf[i], refs)
+	}
+	for i := range s.OneOf {
+		s.OneOf[i] = n.normalize(s.OneOf[i], refs)
+	}
+	for i := range s.AllOf {
+		s.AllOf[i] = n.normalize(s.AllOf[i], refs)
+	}
+	if n.dialect != ToolSchemaGemini {
+		for name, def := range s.Definitions {
+			s.Definitions[name] = n.normalize(def, refs)
+		}
+	}
+
+	if s.Type == "object" {
+		n.object(s)
+	}
+	n.trim(s)
+	return s
+}
+
+// needsTypes reports whether the dialect rejects schemas without a type
+func (n *schemaNormalizer) needsTypes() bool {
+	return n.dialect == ToolSchemaStrict || n.dialect == ToolSchemaGemini
+}
+
+// resolve returns the definition s references, keeping the description of s; unresolvable or too deep
+// references resolve to an untyped schema
+func (n *schemaNormalizer) resolve(s *jsonschema.Schema, refs int) *jsonschema.Schema {
+	if s == nil || s.Ref == "" || n.dialect != ToolSchemaGemini {
+		return s
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(s.Ref, "#/$defs/"), "#/definitions/")
+	def, ok := n.defs[name]
+	if !ok || refs >= maxRefDepth {
+		return &jsonschema.Schema{Description: s.Description}
+	}
+	// Copy so that every use of the definition is rewritten on its own
+	data, err := json.Marshal(def)
+	resolved := &jsonschema.Schema{}
+	if err != nil || json.Unmarshal(data, resolved) != nil {
+		return &jsonschema.Schema{Description: s.Description}
+	}
+	if s.Description != "" {
+		resolved.Description = s.Description
+	}
+	resolved.Ref = s.Ref // Counted by the caller as one more level
+	return resolved
+}
+
+// flatten replaces anyOf and oneOf with a single schema: the only non-null branch, the union of the
+// properties of object branches, or else the first branch. It reports whether a branch was null.
+func (n *schemaNormalizer) flatten(s *jsonschema.Schema, refs int) (*jsonschema.Schema, bool) {
+	branches := append(s.AnyOf, s.OneOf...)
+	if len(branches) == 0 {
+		return s, false
+	}
+	s.AnyOf, s.OneOf = nil, nil
+
+	var kept []*jsonschema.Schema
+	null := false
+	for _, branch := range branches {
+		branch = n.resolve(branch, refs)
+		if branch == nil || isBooleanSchema(branch) {
+			continue
+		}
+		if branch.Type == "null" {
+			null = true
+			continue
+		}
+		kept = append(kept, branch)
+	}
+
+	objects := len(kept) > 1 && !slices.ContainsFunc(kept, func(b *jsonschema.Schema) bool {
+		return b.Type != "object" && b.Properties == nil
+	})
+	switch {
+	case objects:
+		// Any branch may apply, so no property is required by all of them
+		for _, branch := range kept {
+			branch.Required = nil
+			mergeSchema(s, branch)
+		}
+	case len(kept) > 0:
+		mergeSchema(s, kept[0])
+	}
+	return s, null
+}
+
+// nullable turns null types, and null branches if null is set, into Gemini's nullable flag
+func (n *schemaNormalizer) nullable(s *jsonschema.Schema, null bool) {
+	types := slices.DeleteFunc(slices.Clone(s.TypeEnhanced), func(t string) bool { return t == "null" })
+	if null || len(types) < len(s.TypeEnhanced) {
+		s.Extras = map[string]any{"nullable": true}
+	}
+	if s.Type == "" && len(types) > 0 {
+		s.Type = types[0]
+	}
+	s.TypeEnhanced = nil
+}
+
+// object fixes the properties of an object schema
+func (n *schemaNormalizer) object(s *jsonschema.Schema) {
+	if s.Properties == nil {
+		s.Properties = jsonschema.NewProperties()
+	}
+	switch n.dialect {
+	case ToolSchemaStrict:
+		// Strict mode requires every property; optional ones accept null instead
+		var required []string
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if !slices.Contains(s.Required, pair.Key) {
+				makeNullable(pair.Value)
+			}
+			required = append(required, pair.Key)
+		}
+		s.Required = required
+		s.AdditionalProperties = jsonschema.FalseSchema
+	case ToolSchemaGemini:
+		s.Required = slices.DeleteFunc(s.Required, func(name string) bool {
+			_, ok := s.Properties.Get(name)
+			return !ok
+		})
+		s.AdditionalProperties = nil
+	}
+}
+
+// trim drops the keywords the dialect rejects
+func (n *schemaNormalizer) trim(s *jsonschema.Schema) {
+	if s.Const != nil && n.needsTypes() {
+		s.Enum, s.Const = []any{s.Const}, nil
+	}
+	switch n.dialect {
+	case ToolSchemaStrict:
+		s.Not, s.If, s.Then, s.Else, s.DependentSchemas, s.DependentRequired = nil, nil, nil, nil, nil, nil
+		s.PrefixItems, s.Contains, s.PatternProperties, s.PropertyNames = nil, nil, nil, nil
+		s.MinLength, s.MaxLength, s.Pattern, s.Format = nil, nil, "", ""
+		s.Minimum, s.Maximum, s.ExclusiveMinimum, s.ExclusiveMaximum, s.MultipleOf = "", "", "", "", ""
+		s.MinProperties, s.MaxProperties = nil, nil
+		s.MinItems, s.MaxItems, s.UniqueItems, s.MinContains, s.MaxContains = nil, nil, false, nil, nil
+		s.Default, s.Examples = nil, nil
+	case ToolSchemaGemini:
+		if s.Type != "string" {
+			s.Enum = nil
+		}
+		if !slices.Contains(geminiFormats[s.Type], s.Format) {
+			s.Format = ""
+		}
+		nullable := s.Extras["nullable"]
+		*s = jsonschema.Schema{
+			Type:        s.Type,
+			Format:      s.Format,
+			Description: s.Description,
+			Enum:        s.Enum,
+			Properties:  s.Properties,
+			Required:    s.Required,
+			Items:       s.Items,
+			MinItems:    s.MinItems,
+			MaxItems:    s.MaxItems,
+		}
+		if nullable != nil {
+			s.Extras = map[string]any{"nullable": nullable}
+		}
+	}
+}
+
+// fillType infers a missing type from the other keywords; dialects requiring types fall back to string
+func fillType(s *jsonschema.Schema, dialect ToolSchemaDialect) {
+	if s.Type != "" || len(s.TypeEnhanced) > 0 || len(s.AnyOf) > 0 || len(s.OneOf) > 0 || s.Ref != "" {
+		return
+	}
+	switch {
+	case s.Properties != nil || s.AdditionalProperties != nil:
+		s.Type = "object"
+	case s.Items != nil:
+		s.Type = "array"
+	case s.Const != nil:
+		s.Type = jsonType(s.Const)
+	case len(s.Enum) > 0:
+		s.Type = jsonType(s.Enum[0])
+	}
+	if s.Type == "" && (dialect == ToolSchemaStrict || dialect == ToolSchemaGemini) {
+		s.Type = "string"
+	}
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return ""
+}
+
+// makeNullable lets a schema also accept null
+func makeNullable(s *jsonschema.Schema) {
+	switch {
+	case s.Type != "":
+		s.TypeEnhanced, s.Type = []string{s.Type, "null"}, ""
+	case len(s.TypeEnhanced) > 0:
+		if !slices.Contains(s.TypeEnhanced, "null") {
+			s.TypeEnhanced = append(s.TypeEnhanced, "null")
+		}
+	case len(s.AnyOf) > 0:
+		if !slices.ContainsFunc(s.AnyOf, func(b *jsonschema.Schema) bool { return b.Type == "null" }) {
+			s.AnyOf = append(s.AnyOf, &jsonschema.Schema{Type: "null"})
+		}
+	case s.Ref != "":
+		s.AnyOf, s.Ref = []*jsonschema.Schema{{Ref: s.Ref}, {Type: "null"}}, ""
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, nil) {
+		s.Enum = append(s.Enum, nil)
+	}
+}
+
+// mergeSchema folds sub into s where s leaves a keyword unset, as flattening allOf and anyOf needs
+func mergeSchema(s, sub *jsonschema.Schema) {
+	if sub == nil || isBooleanSchema(sub) {
+		return
+	}
+	if s.Type == "" && len(s.TypeEnhanced) == 0 {
+		s.Type, s.TypeEnhanced = sub.Type, sub.TypeEnhanced
+	}
+	if s.Description == "" {
+		s.Description = sub.Description
+	}
+	if sub.Properties != nil {
+		if s.Properties == nil {
+			s.Properties = jsonschema.NewProperties()
+		}
+		for pair := sub.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if _, ok := s.Properties.Get(pair.Key); !ok {
+				s.Properties.Set(pair.Key, pair.Value)
+			}
+		}
+	}
+	for _, name := range sub.Required {
+		if !slices.Contains(s.Required, name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	if s.Items == nil {
+		s.Items = sub.Items
+	}
+	if s.AdditionalProperties == nil {
+		s.AdditionalProperties = sub.AdditionalProperties
+	}
+	if s.Enum == nil {
+		s.Enum = sub.Enum
+	}
+	if s.Const == nil {
+		s.Const = sub.Const
+	}
+	if s.Format == "" {
+		s.Format = sub.Format
+	}
+	if s.AnyOf == nil && s.OneOf == nil {
+		s.AnyOf, s.OneOf = sub.AnyOf, sub.OneOf
+	}
+	if s.Ref == "" {
+		s.Ref = sub.Ref
+	}
+}
+
+// isBooleanSchema reports whether s is the literal true or false schema, which cannot carry keywords
+func isBooleanSchema(s *jsonschema.Schema) bool {
+	if reflect.DeepEqual(s, &jsonschema.Schema{}) {
+		return false
+	}
+	data, err := json.Marshal(s)
+	return err == nil && (bytes.Equal(data, []byte("true")) || bytes.Equal(data, []byte("false")))
+}
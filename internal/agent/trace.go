@@ -0,0 +1,209 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// TraceStepType identifies the kind of a TraceStep
+type TraceStepType string
+
+const (
+	// TraceStepModel is a chat model call
+	TraceStepModel TraceStepType = "model"
+	// TraceStepTool is a tool call
+	TraceStepTool TraceStepType = "tool"
+)
+
+// TraceStep is a single model or tool call of a ReAct loop
+type TraceStep struct {
+	Type      TraceStepType `json:"type"`
+	StartedAt time.Time     `json:"started_at"`
+	LatencyMs int64         `json:"latency_ms"`
+
+	// Model calls: number of messages sent and the message returned (content and tool calls)
+	InputMessages int             `json:"input_messages,omitempty"`
+	Output        *schema.Message `json:"output,omitempty"`
+
+	// Tool calls
+	ToolName  string `json:"tool_name,omitempty"`
+	CallID    string `json:"call_id,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Trace records the ReAct loop of a single chat turn
+type Trace struct {
+	SessionID  string       `json:"session_id"`
+	StartedAt  time.Time    `json:"started_at"`
+	LatencyMs  int64        `json:"latency_ms"`
+	Iterations int          `json:"iterations"` // Number of model calls
+	Steps      []*TraceStep `json:"steps"`
+
+	pendingModel *TraceStep // Model call between BeforeChatModel and AfterChatModel
+	mu           sync.Mutex
+}
+
+// traceKey is the context key carrying the trace of a run
+type traceKey struct{}
+
+func newTrace(sessionID string) *Trace {
+	return &Trace{SessionID: sessionID, StartedAt: time.Now()}
+}
+
+// traceFromContext returns the trace of the run the context belongs to, or nil
+func traceFromContext(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// beginModel records the start of a model call
+func (t *Trace) beginModel(inputMessages int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.Iterations++
+	t.pendingModel = &TraceStep{Type: TraceStepModel, StartedAt: time.Now(), InputMessages: inputMessages}
+	t.Steps = append(t.Steps, t.pendingModel)
+}
+
+// endModel records the output of the current model call
+func (t *Trace) endModel(output *schema.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pendingModel == nil {
+		return
+	}
+	t.pendingModel.Output = output
+	t.pendingModel.LatencyMs = time.Since(t.pendingModel.StartedAt).Milliseconds()
+	t.pendingModel = nil
+}
+
+// addStep appends a finished step
+func (t *Trace) addStep(step *TraceStep) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.Steps = append(t.Steps, step)
+}
+
+// finish records the total latency of the turn
+func (t *Trace) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.LatencyMs = time.Since(t.StartedAt).Milliseconds()
+}
+
+// snapshot returns a copy that is safe to read while the run continues
+func (t *Trace) snapshot() *Trace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	steps := make([]*TraceStep, len(t.Steps))
+	for i, step := range t.Steps {
+		s := *step
+		steps[i] = &s
+	}
+	return &Trace{
+		SessionID:  t.SessionID,
+		StartedAt:  t.StartedAt,
+		LatencyMs:  t.LatencyMs,
+		Iterations: t.Iterations,
+		Steps:      steps,
+	}
+}
+
+// traceMiddleware records model and tool calls into the run's trace, and counts tool calls for usage accounting
+func traceMiddleware() adk.AgentMiddleware {
+	traceTool := func(ctx context.Context, input *compose.ToolInput, call func() (string, error)) {
+		countToolCall(ctx)
+		trace := traceFromContext(ctx)
+		if trace == nil {
+			call()
+			return
+		}
+		step := &TraceStep{Type: TraceStepTool, StartedAt: time.Now(), ToolName: input.Name, CallID: input.CallID, Arguments: input.Arguments}
+		result, err := call()
+		step.LatencyMs = time.Since(step.StartedAt).Milliseconds()
+		step.Result = result
+		if err != nil {
+			step.Error = err.Error()
+		}
+		trace.addStep(step)
+	}
+
+	return adk.AgentMiddleware{
+		BeforeChatModel: func(ctx context.Context, state *adk.ChatModelAgentState) error {
+			if trace := traceFromContext(ctx); trace != nil {
+				trace.beginModel(len(state.Messages))
+			}
+			return nil
+		},
+		AfterChatModel: func(ctx context.Context, state *adk.ChatModelAgentState) error {
+			if trace := traceFromContext(ctx); trace != nil {
+				var output *schema.Message
+				if n := len(state.Messages); n > 0 {
+					output = state.Messages[n-1]
+				}
+				trace.endModel(output)
+			}
+			return nil
+		},
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (output *compose.ToolOutput, err error) {
+					traceTool(ctx, input, func() (string, error) {
+						output, err = next(ctx, input)
+						if output == nil {
+							return "", err
+						}
+						return output.Result, err
+					})
+					return output, err
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (output *compose.StreamToolOutput, err error) {
+					traceTool(ctx, input, func() (string, error) {
+						output, err = next(ctx, input)
+						if err != nil || output == nil || output.Result == nil {
+							return "", err
+						}
+						// Drain the stream so the trace holds the full result, then replay it
+						var result string
+						result, err = concatStrings(output.Result)
+						if err != nil {
+							output = nil
+							return result, err
+						}
+						output = &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{result})}
+						return result, nil
+					})
+					return output, err
+				}
+			},
+		},
+	}
+}
+
+// GetLastTrace returns the trace of the most recent chat turn of a session.
+// While a turn is running, its partial trace is returned.
+func (a *Agent) GetLastTrace(sessionID string) (*Trace, bool) {
+	a.runs.mu.Lock()
+	trace, ok := a.runs.traces[sessionID]
+	a.runs.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+	return trace.snapshot(), true
+}
@@ -0,0 +1,198 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ToolResultTransformer rewrites a tool result before it is fed back to the model.
+// On error the result is passed on unchanged.
+type ToolResultTransformer func(ctx context.Context, toolName, result string) (string, error)
+
+// transformersFor returns the transformers registered for a tool: exact name first, then matching globs in pattern order
+func transformersFor(registry map[string][]ToolResultTransformer, toolName string) []ToolResultTransformer {
+	result := append([]ToolResultTransformer(nil), registry[toolName]...)
+
+	patterns := make([]string, 0, len(registry))
+	for pattern := range registry {
+		if pattern != toolName {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, toolName); ok {
+			result = append(result, registry[pattern]...)
+		}
+	}
+	return result
+}
+
+// transformToolResult extracts MCP text and runs the transformers in order
+func transformToolResult(ctx context.Context, toolName, result string, transformers []ToolResultTransformer) string {
+	result = formatToolResult(result)
+	for _, transform := range transformers {
+		transformed, err := transform(ctx, toolName, result)
+		if err != nil {
+			logger.Ctx(ctx).Warnf("Tool result transformer for %s failed: %v", toolName, err)
+			continue
+		}
+		result = transformed
+	}
+	return result
+}
+
+// transformMiddleware applies the registered result transformers to matching tools
+func transformMiddleware(registry map[string][]ToolResultTransformer) adk.AgentMiddleware {
+	return adk.AgentMiddleware{
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					output, err := next(ctx, input)
+					transformers := transformersFor(registry, input.Name)
+					if err != nil || output == nil || len(transformers) == 0 {
+						return output, err
+					}
+					output.Result = transformToolResult(ctx, input.Name, output.Result, transformers)
+					return output, nil
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					output, err := next(ctx, input)
+					transformers := transformersFor(registry, input.Name)
+					if err != nil || output == nil || output.Result == nil || len(transformers) == 0 {
+						return output, err
+					}
+					result, err := concatStrings(output.Result)
+					if err != nil {
+						return nil, err
+					}
+					return &compose.StreamToolOutput{
+						Result: schema.StreamReaderFromArray([]string{transformToolResult(ctx, input.Name, result, transformers)}),
+					}, nil
+				}
+			},
+		},
+	}
+}
+
+// base64Blob matches long runs of base64 data such as embedded images or secrets
+var base64Blob = regexp.MustCompile(`[A-Za-z0-9+/]{200,}={0,2}`)
+
+// StripBase64 replaces long base64 blobs with a short placeholder
+func StripBase64() ToolResultTransformer {
+	return func(ctx context.Context, toolName, result string) (string, error) {
+		return base64Blob.ReplaceAllStringFunc(result, func(blob string) string {
+			return fmt.Sprintf("[base64 data, %d bytes]", len(blob))
+		}), nil
+	}
+}
+
+// HeadLines keeps the first n lines and notes how many were dropped
+func HeadLines(n int) ToolResultTransformer {
+	return func(ctx context.Context, toolName, result string) (string, error) {
+		lines := strings.Split(result, "\n")
+		if len(lines) <= n {
+			return result, nil
+		}
+		return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n... [%d more lines]", len(lines)-n), nil
+	}
+}
+
+// TailLines keeps the last n lines and notes how many were dropped
+func TailLines(n int) ToolResultTransformer {
+	return func(ctx context.Context, toolName, result string) (string, error) {
+		lines := strings.Split(result, "\n")
+		if len(lines) <= n {
+			return result, nil
+		}
+		return fmt.Sprintf("[%d earlier lines] ...\n", len(lines)-n) + strings.Join(lines[len(lines)-n:], "\n"), nil
+	}
+}
+
+// DropLines removes lines matching a regular expression
+func DropLines(pattern string) (ToolResultTransformer, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return func(ctx context.Context, toolName, result string) (string, error) {
+		lines := strings.Split(result, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			if !re.MatchString(line) {
+				kept = append(kept, line)
+			}
+		}
+		return strings.Join(kept, "\n"), nil
+	}, nil
+}
+
+// CollapseBlankLines squeezes runs of blank lines into one and trims trailing spaces
+func CollapseBlankLines() ToolResultTransformer {
+	return func(ctx context.Context, toolName, result string) (string, error) {
+		var sb strings.Builder
+		blank := false
+		for _, line := range strings.Split(result, "\n") {
+			line = strings.TrimRight(line, " \t\r")
+			if line == "" {
+				if blank {
+					continue
+				}
+				blank = true
+			} else {
+				blank = false
+			}
+			sb.WriteString(line + "\n")
+		}
+		return strings.TrimRight(sb.String(), "\n"), nil
+	}
+}
+
+// ParseToolResultTransformer builds a transformer from a config spec:
+// "strip_base64", "collapse_blank_lines", "head_lines:N", "tail_lines:N" or "drop_lines:REGEX"
+func ParseToolResultTransformer(spec string) (ToolResultTransformer, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	lineCount := func() (int, error) {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%s needs a positive line count, got %q", name, arg)
+		}
+		return n, nil
+	}
+
+	switch name {
+	case "strip_base64":
+		return StripBase64(), nil
+	case "collapse_blank_lines":
+		return CollapseBlankLines(), nil
+	case "head_lines":
+		n, err := lineCount()
+		if err != nil {
+			return nil, err
+		}
+		return HeadLines(n), nil
+	case "tail_lines":
+		n, err := lineCount()
+		if err != nil {
+			return nil, err
+		}
+		return TailLines(n), nil
+	case "drop_lines":
+		return DropLines(arg)
+	}
+	return nil, fmt.Errorf("unknown tool result transformer %q", name)
+}
@@ -0,0 +1,113 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+const (
+	// bytesPerToken approximates token counts for the tool result limit
+	bytesPerToken = 4
+	// elisionReserve is the space kept for the elision marker
+	elisionReserve = 64
+)
+
+// toolResultLimit returns the effective byte limit from the byte and token settings (0 = unlimited)
+func toolResultLimit(maxBytes, maxTokens int) int {
+	limit := maxBytes
+	if maxTokens > 0 && (limit <= 0 || maxTokens*bytesPerToken < limit) {
+		limit = maxTokens * bytesPerToken
+	}
+	return limit
+}
+
+// truncateMiddle keeps the head and tail of s within limit bytes, joined by an elision marker.
+// Cuts prefer line boundaries and never split UTF-8 characters.
+func truncateMiddle(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+
+	// Reserve room for the marker so the result stays within the limit
+	budget := limit
+	if budget > 4*elisionReserve {
+		budget -= elisionReserve
+	}
+	headLen := budget * 2 / 3
+	tailLen := budget - headLen
+
+	head := s[:headLen]
+	if i := strings.LastIndexByte(head, '\n'); i > headLen/2 {
+		head = head[:i+1]
+	}
+	for len(head) > 0 && !utf8.ValidString(head) {
+		head = head[:len(head)-1]
+	}
+
+	tail := s[len(s)-tailLen:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < tailLen/2 {
+		tail = tail[i+1:]
+	}
+	for len(tail) > 0 && !utf8.ValidString(tail) {
+		tail = tail[1:]
+	}
+
+	omitted := len(s) - len(head) - len(tail)
+	return fmt.Sprintf("%s\n... [%d bytes omitted, result truncated] ...\n%s", head, omitted, tail)
+}
+
+// truncateToolResult formats MCP results and shortens them to the limit
+func truncateToolResult(toolName, result string, limit int) string {
+	if len(result) <= limit {
+		return result
+	}
+	// Truncating raw MCP JSON would break its structure, so extract the text first
+	formatted := formatToolResult(result)
+	if len(formatted) <= limit {
+		return formatted
+	}
+	logger.Debugf("Truncating %s result from %d to %d bytes", toolName, len(formatted), limit)
+	return truncateMiddle(formatted, limit)
+}
+
+// truncationMiddleware caps tool results before they are fed back to the model
+func truncationMiddleware(limit int) adk.AgentMiddleware {
+	return adk.AgentMiddleware{
+		WrapToolCall: compose.ToolMiddleware{
+			Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+					output, err := next(ctx, input)
+					if err != nil || output == nil {
+						return output, err
+					}
+					output.Result = truncateToolResult(input.Name, output.Result, limit)
+					return output, nil
+				}
+			},
+			Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+				return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+					output, err := next(ctx, input)
+					if err != nil || output == nil || output.Result == nil {
+						return output, err
+					}
+					result, err := concatStrings(output.Result)
+					if err != nil {
+						return nil, err
+					}
+					return &compose.StreamToolOutput{
+						Result: schema.StreamReaderFromArray([]string{truncateToolResult(input.Name, result, limit)}),
+					}, nil
+				}
+			},
+		},
+	}
+}
@@ -0,0 +1,71 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// toolTallyKey is the context key carrying a caller's tool call tally
+type toolTallyKey struct{}
+
+// WithToolCallTally returns a context in which every tool call of the runs started with it is counted,
+// for usage accounting across the turns and resumptions of a request
+func WithToolCallTally(ctx context.Context) (context.Context, *atomic.Int64) {
+	tally := new(atomic.Int64)
+	return context.WithValue(ctx, toolTallyKey{}, tally), tally
+}
+
+// countToolCall adds a tool call to the tally of the context, if any
+func countToolCall(ctx context.Context) {
+	if tally, ok := ctx.Value(toolTallyKey{}).(*atomic.Int64); ok {
+		tally.Add(1)
+	}
+}
+
+// usageCounter sums token usage across every model call of a run (including tool iterations)
+type usageCounter struct {
+	total *schema.TokenUsage
+}
+
+// add records the usage reported on an assistant message, if any
+func (u *usageCounter) add(msg *schema.Message) {
+	if msg == nil || msg.Role != schema.Assistant || msg.ResponseMeta == nil || msg.ResponseMeta.Usage == nil {
+		return
+	}
+	if u.total == nil {
+		u.total = &schema.TokenUsage{}
+	}
+	usage := msg.ResponseMeta.Usage
+	u.total.PromptTokens += usage.PromptTokens
+	u.total.PromptTokenDetails.CachedTokens += usage.PromptTokenDetails.CachedTokens
+	u.total.CompletionTokens += usage.CompletionTokens
+	u.total.TotalTokens += usage.TotalTokens
+	u.total.CompletionTokensDetails.ReasoningTokens += usage.CompletionTokensDetails.ReasoningTokens
+}
+
+// attach returns a copy of msg whose ResponseMeta.Usage is the accumulated total
+func (u *usageCounter) attach(msg *schema.Message) *schema.Message {
+	if u.total == nil {
+		return msg
+	}
+	out := *msg
+	meta := schema.ResponseMeta{}
+	if msg.ResponseMeta != nil {
+		meta = *msg.ResponseMeta
+	}
+	usage := *u.total
+	meta.Usage = &usage
+	out.ResponseMeta = &meta
+	return &out
+}
+
+// usageOf returns the token usage attached to a message, if any
+func usageOf(msg *schema.Message) *schema.TokenUsage {
+	if msg == nil || msg.ResponseMeta == nil {
+		return nil
+	}
+	return msg.ResponseMeta.Usage
+}
@@ -0,0 +1,91 @@
+// Package agent provides ChatModel agent implementation with memory support using Eino ADK.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// toolNamePattern is the function name format accepted by OpenAI-compatible providers
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Validate performs preflight checks: every tool schema must convert cleanly for the provider
+// and the model must answer a minimal request. All problems found are reported together.
+func (a *Agent) Validate(ctx context.Context) error {
+	var problems []error
+
+	infos, errs := validateTools(ctx, a.Tools())
+	problems = append(problems, errs...)
+	problems = append(problems, validateModel(ctx, "model", a.config.Model, infos)...)
+
+	for _, sub := range a.config.SubAgents {
+		subInfos, errs := validateTools(ctx, sub.Tools)
+		for _, err := range errs {
+			problems = append(problems, fmt.Errorf("sub-agent %s: %w", sub.Name, err))
+		}
+		if sub.Model != nil {
+			problems = append(problems, validateModel(ctx, "sub-agent "+sub.Name+" model", sub.Model, subInfos)...)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// validateTools checks tool names and parameter schemas and returns the infos of valid tools
+func validateTools(ctx context.Context, tools []tool.BaseTool) ([]*schema.ToolInfo, []error) {
+	var infos []*schema.ToolInfo
+	var problems []error
+	seen := make(map[string]bool)
+	for i, t := range tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("tool %d: failed to get info: %w", i, err))
+			continue
+		}
+		if !toolNamePattern.MatchString(info.Name) {
+			problems = append(problems, fmt.Errorf("tool %q: name must match %s", info.Name, toolNamePattern))
+			continue
+		}
+		if seen[info.Name] {
+			problems = append(problems, fmt.Errorf("tool %q: duplicate name", info.Name))
+			continue
+		}
+		seen[info.Name] = true
+
+		if info.ParamsOneOf != nil {
+			js, err := info.ParamsOneOf.ToJSONSchema()
+			if err != nil {
+				problems = append(problems, fmt.Errorf("tool %q: invalid parameter schema: %w", info.Name, err))
+				continue
+			}
+			if js != nil && js.Type != "" && js.Type != "object" {
+				problems = append(problems, fmt.Errorf("tool %q: parameter schema must be an object, got %s", info.Name, js.Type))
+				continue
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, problems
+}
+
+// validateModel binds the tools to the model and sends a one-token ping
+func validateModel(ctx context.Context, name string, m model.ToolCallingChatModel, infos []*schema.ToolInfo) []error {
+	if m == nil {
+		return []error{fmt.Errorf("%s: not configured", name)}
+	}
+	if len(infos) > 0 {
+		var err error
+		if m, err = m.WithTools(infos); err != nil {
+			return []error{fmt.Errorf("%s: failed to bind tools: %w", name, err)}
+		}
+	}
+	if _, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("ping")}, model.WithMaxTokens(1)); err != nil {
+		return []error{fmt.Errorf("%s: ping failed: %w", name, err)}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/mcp"
+)
+
+// WithAdmin serves the management endpoints under /admin.
+// Requests must carry the token as a bearer token; without a token only loopback clients are served.
+func WithAdmin(token string) Option {
+	return func(s *Server) {
+		s.admin = true
+		s.adminToken = token
+	}
+}
+
+// registerAdminRoutes registers the management endpoints
+func (s *Server) registerAdminRoutes() {
+	if s.adminToken == "" {
+		logger.Warnf("[API] Admin endpoints enabled without a token, serving loopback clients only")
+	}
+	g := s.httpServer.Group("/admin", operatorAuth(s.adminToken, "admin"))
+	if s.mcp != nil {
+		g.GET("/mcp/servers", s.handleListMCPServers)
+		g.GET("/mcp/servers/:name", s.handleGetMCPServer)
+		g.GET("/mcp/servers/:name/tools", s.handleListMCPServerTools)
+		g.POST("/mcp/servers/:name/reconnect", s.handleReconnectMCPServer)
+		g.POST("/mcp/servers/:name/enable", s.handleSetMCPServerEnabled(true))
+		g.POST("/mcp/servers/:name/disable", s.handleSetMCPServerEnabled(false))
+		g.GET("/mcp/tools", s.handleListMCPTools)
+		g.GET("/mcp/stats", s.handleMCPToolStats)
+	}
+	g.GET("/sessions", s.handleAdminListSessions)
+	g.DELETE("/sessions/*id", s.handleAdminDeleteSession)
+	if s.usage != nil {
+		g.GET("/usage", s.handleAdminUsage)
+	}
+	if s.reload != nil {
+		g.POST("/reload", s.handleReload)
+	}
+}
+
+// handleListMCPServers lists the configured MCP servers with their connection status and tools
+func (s *Server) handleListMCPServers(ctx context.Context, c *app.RequestContext) {
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   s.mcp.Status(ctx),
+	})
+}
+
+// handleGetMCPServer returns the status of one MCP server
+func (s *Server) handleGetMCPServer(ctx context.Context, c *app.RequestContext) {
+	s.writeMCPServerStatus(ctx, c, c.Param("name"))
+}
+
+// handleListMCPServerTools lists the tools a server provides, with their parameter schemas
+func (s *Server) handleListMCPServerTools(ctx context.Context, c *app.RequestContext) {
+	s.writeMCPTools(ctx, c, c.Param("name"))
+}
+
+// handleListMCPTools lists the tools of all servers with the server providing each and whether it is callable
+func (s *Server) handleListMCPTools(ctx context.Context, c *app.RequestContext) {
+	s.writeMCPTools(ctx, c)
+}
+
+// writeMCPTools answers with the tools of the named servers, or of all servers
+func (s *Server) writeMCPTools(ctx context.Context, c *app.RequestContext, servers ...string) {
+	tools, err := s.mcp.ListToolInfos(ctx, servers...)
+	if err != nil {
+		writeMCPError(c, err)
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   tools,
+	})
+}
+
+// handleReconnectMCPServer replaces a server's connection and reloads its tools
+func (s *Server) handleReconnectMCPServer(ctx context.Context, c *app.RequestContext) {
+	name := c.Param("name")
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	logger.Ctx(ctx).Infof("[API] Reconnecting MCP server %s", name)
+	if err := s.mcp.Reconnect(ctx, name); err != nil {
+		writeMCPError(c, err)
+		return
+	}
+	s.writeMCPServerStatus(ctx, c, name)
+}
+
+// handleSetMCPServerEnabled connects or disconnects a server at runtime; the change lasts until restart
+func (s *Server) handleSetMCPServerEnabled(enabled bool) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		name := c.Param("name")
+		ctx, cancel := s.requestContext(ctx)
+		defer cancel()
+
+		logger.Ctx(ctx).Infof("[API] Setting MCP server %s enabled=%t", name, enabled)
+		if err := s.mcp.SetEnabled(ctx, name, enabled); err != nil {
+			writeMCPError(c, err)
+			return
+		}
+		s.writeMCPServerStatus(ctx, c, name)
+	}
+}
+
+// handleMCPToolStats reports call counts, errors, latency percentiles and payload sizes per tool and server
+func (s *Server) handleMCPToolStats(ctx context.Context, c *app.RequestContext) {
+	c.JSON(consts.StatusOK, s.mcp.ToolStats(ctx))
+}
+
+// writeMCPServerStatus answers with the status of the named server
+func (s *Server) writeMCPServerStatus(ctx context.Context, c *app.RequestContext, name string) {
+	for _, status := range s.mcp.Status(ctx) {
+		if status.Name == name {
+			c.JSON(consts.StatusOK, status)
+			return
+		}
+	}
+	writeMCPError(c, fmt.Errorf("%w: %s", mcp.ErrUnknownServer, name))
+}
+
+// writeMCPError answers 404 for unknown servers, 409 for disabled ones and 502 for connection failures
+func writeMCPError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, mcp.ErrUnknownServer):
+		writeError(c, consts.StatusNotFound, "mcp_server_not_found", err)
+	case errors.Is(err, mcp.ErrServerDisabled):
+		writeError(c, consts.StatusConflict, "mcp_server_disabled", err)
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(c, consts.StatusGatewayTimeout, "timeout", err)
+	default:
+		writeError(c, consts.StatusBadGateway, "upstream_error", err)
+	}
+}
@@ -0,0 +1,639 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/google/uuid"
+	"github.com/hertz-contrib/sse"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// anthropicSessionHeader carries the session of an Anthropic Messages request; a new one is created if absent
+const anthropicSessionHeader = "X-Session-Id"
+
+// AnthropicRequest represents an Anthropic Messages API request
+type AnthropicRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        json.RawMessage    `json:"system,omitempty"` // A string or an array of text blocks
+	Messages      []AnthropicMessage `json:"messages"`
+	Stream        bool               `json:"stream,omitempty"`
+	Temperature   *float32           `json:"temperature,omitempty"`
+	TopP          *float32           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+
+	// Tools declares client-side tools; calls to them end the turn with stop_reason "tool_use"
+	Tools      []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice *AnthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// AnthropicMessage is a conversation message whose content is a string or an array of content blocks
+type AnthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// AnthropicContentBlock is a text, image, tool_use or tool_result block
+type AnthropicContentBlock struct {
+	Type string `json:"type"`
+
+	Text   string                `json:"text,omitempty"`
+	Source *AnthropicImageSource `json:"source,omitempty"`
+
+	// tool_use blocks
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result blocks; Content is a string or an array of text blocks
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// AnthropicImageSource is the base64 data or URL of an image block
+type AnthropicImageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// AnthropicTool declares a client-side tool with its JSON schema input
+type AnthropicTool struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	InputSchema *jsonschema.Schema `json:"input_schema,omitempty"`
+}
+
+// AnthropicToolChoice controls tool calling: "auto", "any", "tool" (with Name) or "none"
+type AnthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// AnthropicResponse represents a non-streaming Anthropic Messages API response
+type AnthropicResponse struct {
+	ID           string                  `json:"id"`
+	Type         string                  `json:"type"`
+	Role         string                  `json:"role"`
+	Model        string                  `json:"model"`
+	Content      []AnthropicContentBlock `json:"content"`
+	StopReason   *string                 `json:"stop_reason"`
+	StopSequence *string                 `json:"stop_sequence"`
+	Usage        AnthropicUsage          `json:"usage"`
+}
+
+// AnthropicUsage represents token usage in the Anthropic format
+type AnthropicUsage struct {
+	InputTokens          int `json:"input_tokens"`
+	OutputTokens         int `json:"output_tokens"`
+	CacheReadInputTokens int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// toAnthropicUsage converts agent token usage to the Anthropic format
+func toAnthropicUsage(usage *schema.TokenUsage) AnthropicUsage {
+	if usage == nil {
+		return AnthropicUsage{}
+	}
+	return AnthropicUsage{
+		InputTokens:          usage.PromptTokens,
+		OutputTokens:         usage.CompletionTokens,
+		CacheReadInputTokens: usage.PromptTokenDetails.CachedTokens,
+	}
+}
+
+// writeAnthropicError answers with an Anthropic error envelope
+func writeAnthropicError(c *app.RequestContext, status int, errType, message string) {
+	c.JSON(status, map[string]interface{}{
+		"type":  "error",
+		"error": map[string]string{"type": errType, "message": message},
+	})
+}
+
+// handleAnthropicMessages handles Anthropic Messages API requests with the same agents as chat completions
+func (s *Server) handleAnthropicMessages(ctx context.Context, c *app.RequestContext) {
+	var req AnthropicRequest
+	if err := c.BindJSON(&req); err != nil {
+		logger.Ctx(ctx).Errorf("[API] Failed to parse messages request: %v", err)
+		writeAnthropicError(c, consts.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	turn, err := newAnthropicTurn(&req)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Invalid messages request: %v", err)
+		writeAnthropicError(c, consts.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	turn.sessionID = string(c.GetHeader(anthropicSessionHeader))
+	if turn.sessionID == "" {
+		turn.sessionID = uuid.New().String()
+	}
+	c.Response.Header.Set(anthropicSessionHeader, turn.sessionID)
+	if turn.sessionID, err = s.scopeSession(c, turn.sessionID); err != nil {
+		writeAnthropicError(c, consts.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if quotaErr := s.checkSpend(ctx, c, turn.sessionID); quotaErr != nil {
+		setQuotaRetryAfter(c, quotaErr)
+		errType := "rate_limit_error"
+		if quotaErr.Limit == "cost" {
+			errType = "billing_error"
+		}
+		writeAnthropicError(c, quotaStatus(quotaErr), errType, quotaErr.Error())
+		return
+	}
+
+	var ok bool
+	if turn.agent, turn.model, ok = s.modelAgent(req.Model); !ok {
+		writeAnthropicError(c, consts.StatusNotFound, "not_found_error", fmt.Sprintf("model %s does not exist", req.Model))
+		return
+	}
+
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	logger.Ctx(ctx).Debugf("[API] Received messages request - Session: %s, Model: %s, Stream: %v, Messages: %d",
+		turn.sessionID, req.Model, req.Stream, len(req.Messages))
+	if req.Stream {
+		s.handleAnthropicStream(ctx, c, turn)
+	} else {
+		s.handleAnthropicResponse(ctx, c, turn)
+	}
+}
+
+// newAnthropicTurn maps a Messages request to a turn like newChatTurn does for chat completions
+func newAnthropicTurn(req *AnthropicRequest) (*chatTurn, error) {
+	if req.MaxTokens <= 0 {
+		return nil, fmt.Errorf("max_tokens must be positive")
+	}
+	turn := &chatTurn{opts: []agent.ChatOption{agent.WithMaxTokens(req.MaxTokens)}}
+	if req.Temperature != nil {
+		if *req.Temperature < 0 || *req.Temperature > 1 {
+			return nil, fmt.Errorf("temperature must be between 0 and 1")
+		}
+		turn.opts = append(turn.opts, agent.WithTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		turn.opts = append(turn.opts, agent.WithTopP(*req.TopP))
+	}
+	if len(req.StopSequences) > 0 {
+		turn.opts = append(turn.opts, agent.WithStop(req.StopSequences...))
+	}
+
+	system, err := textOf(req.System)
+	if err != nil {
+		return nil, fmt.Errorf("system: %w", err)
+	}
+	if system != "" {
+		turn.opts = append(turn.opts, agent.WithInstructions(system))
+	}
+
+	toolOpts, err := anthropicToolOptions(req.Tools, req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	turn.opts = append(turn.opts, toolOpts...)
+
+	var messages []*schema.Message
+	for i := range req.Messages {
+		msgs, err := req.Messages[i].toSchema()
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		messages = append(messages, msgs...)
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no user message found")
+	}
+
+	last := messages[len(messages)-1]
+	if last.Role != schema.User && last.Role != schema.Tool {
+		return nil, fmt.Errorf("last message must be a user message")
+	}
+	if last.Role == schema.User {
+		turn.userMessage = last.Content
+	}
+	if len(messages) == 1 && last.Role == schema.User {
+		// A lone user message continues the stored session
+		turn.parts = last.UserInputMultiContent
+		return turn, nil
+	}
+	turn.messages = messages
+	return turn, nil
+}
+
+// anthropicToolOptions converts the declared tools and the tool choice to chat options
+func anthropicToolOptions(tools []AnthropicTool, choice *AnthropicToolChoice) ([]agent.ChatOption, error) {
+	var opts []agent.ChatOption
+	if len(tools) > 0 {
+		infos := make([]*schema.ToolInfo, 0, len(tools))
+		for i, t := range tools {
+			if t.Name == "" {
+				return nil, fmt.Errorf("tool %d: name is required", i)
+			}
+			info := &schema.ToolInfo{Name: t.Name, Desc: t.Description}
+			if t.InputSchema != nil {
+				info.ParamsOneOf = schema.NewParamsOneOfByJSONSchema(t.InputSchema)
+			}
+			infos = append(infos, info)
+		}
+		opts = append(opts, agent.WithClientTools(infos...))
+	}
+
+	if choice == nil {
+		return opts, nil
+	}
+	switch choice.Type {
+	case "", "auto":
+	case "none":
+		opts = append(opts, agent.WithToolChoice(schema.ToolChoiceForbidden))
+	case "any":
+		opts = append(opts, agent.WithToolChoice(schema.ToolChoiceForced))
+	case "tool":
+		if choice.Name == "" {
+			return nil, fmt.Errorf("tool_choice of type tool requires a name")
+		}
+		opts = append(opts, agent.WithToolChoice(schema.ToolChoiceForced, choice.Name))
+	default:
+		return nil, fmt.Errorf("invalid tool_choice type %q", choice.Type)
+	}
+	return opts, nil
+}
+
+// blocks decodes the message content; a plain string becomes a single text block
+func (m *AnthropicMessage) blocks() ([]AnthropicContentBlock, error) {
+	var text string
+	if err := json.Unmarshal(m.Content, &text); err == nil {
+		return []AnthropicContentBlock{{Type: "text", Text: text}}, nil
+	}
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(m.Content, &blocks); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content blocks")
+	}
+	return blocks, nil
+}
+
+// toSchema converts a message to agent messages; tool results of a user message become tool messages
+// placed before its remaining content
+func (m *AnthropicMessage) toSchema() ([]*schema.Message, error) {
+	blocks, err := m.blocks()
+	if err != nil {
+		return nil, err
+	}
+
+	switch m.Role {
+	case "user":
+		var out []*schema.Message
+		var parts []schema.MessageInputPart
+		var texts []string
+		hasImage := false
+		for _, block := range blocks {
+			switch block.Type {
+			case "text":
+				texts = append(texts, block.Text)
+				parts = append(parts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeText, Text: block.Text})
+			case "image":
+				if block.Source == nil {
+					return nil, fmt.Errorf("image block without source")
+				}
+				image := &schema.MessageInputImage{}
+				if block.Source.Type == "url" {
+					url := block.Source.URL
+					image.URL = &url
+				} else {
+					data := block.Source.Data
+					image.Base64Data, image.MIMEType = &data, block.Source.MediaType
+				}
+				hasImage = true
+				parts = append(parts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeImageURL, Image: image})
+			case "tool_result":
+				result, err := textOf(block.Content)
+				if err != nil {
+					return nil, fmt.Errorf("tool_result %s: %w", block.ToolUseID, err)
+				}
+				if block.IsError {
+					result = "Error: " + result
+				}
+				out = append(out, schema.ToolMessage(result, block.ToolUseID))
+			}
+		}
+		switch {
+		case hasImage:
+			out = append(out, &schema.Message{Role: schema.User, UserInputMultiContent: parts})
+		case len(texts) > 0:
+			out = append(out, schema.UserMessage(strings.Join(texts, "\n")))
+		}
+		return out, nil
+
+	case "assistant":
+		var texts []string
+		var toolCalls []schema.ToolCall
+		for _, block := range blocks {
+			switch block.Type {
+			case "text":
+				texts = append(texts, block.Text)
+			case "tool_use":
+				args := string(block.Input)
+				if args == "" {
+					args = "{}"
+				}
+				toolCalls = append(toolCalls, schema.ToolCall{
+					ID:       block.ID,
+					Type:     "function",
+					Function: schema.FunctionCall{Name: block.Name, Arguments: args},
+				})
+			}
+		}
+		return []*schema.Message{schema.AssistantMessage(strings.Join(texts, "\n"), toolCalls)}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported role %q", m.Role)
+	}
+}
+
+// textOf returns a string, or joins the text of an array of content blocks
+func textOf(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", fmt.Errorf("content must be a string or an array of text blocks")
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// toAnthropicContent converts an answer to content blocks, text first
+func toAnthropicContent(msg *schema.Message) []AnthropicContentBlock {
+	content := make([]AnthropicContentBlock, 0, 1+len(msg.ToolCalls))
+	if msg.Content != "" {
+		content = append(content, AnthropicContentBlock{Type: "text", Text: msg.Content})
+	}
+	for _, tc := range msg.ToolCalls {
+		content = append(content, AnthropicContentBlock{
+			Type:  "tool_use",
+			ID:    tc.ID,
+			Name:  tc.Function.Name,
+			Input: toolInput(tc.Function.Arguments),
+		})
+	}
+	return content
+}
+
+// toolInput returns tool call arguments as a JSON object, falling back to {} for empty or invalid arguments
+func toolInput(arguments string) json.RawMessage {
+	if !json.Valid([]byte(arguments)) || !strings.HasPrefix(strings.TrimSpace(arguments), "{") {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// anthropicStopReason maps the end of a turn to an Anthropic stop reason
+func anthropicStopReason(msg *schema.Message) string {
+	if len(msg.ToolCalls) > 0 {
+		return "tool_use"
+	}
+	if msg.ResponseMeta != nil && msg.ResponseMeta.FinishReason == "length" {
+		return "max_tokens"
+	}
+	return "end_turn"
+}
+
+// handleAnthropicResponse answers a non-streaming Messages request
+func (s *Server) handleAnthropicResponse(ctx context.Context, c *app.RequestContext, turn *chatTurn) {
+	sessionID := turn.sessionID
+	recordSession(c, sessionID)
+	response, err := s.chat(ctx, turn)
+	if errors.Is(err, agent.ErrRunCancelled) {
+		writeAnthropicError(c, consts.StatusConflict, "api_error", "chat cancelled")
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Ctx(ctx).Warnf("[API] Chat timed out - Session: %s", sessionID)
+		writeAnthropicError(c, consts.StatusGatewayTimeout, "timeout_error", "request timed out")
+		return
+	}
+	var approvalErr *agent.ApprovalRequiredError
+	if errors.As(err, &approvalErr) {
+		logger.Ctx(ctx).Infof("[API] Run paused for approval - Session: %s", sessionID)
+		c.JSON(consts.StatusAccepted, map[string]interface{}{
+			"session":           s.publicSession(sessionID),
+			"status":            "approval_required",
+			"pending_approvals": approvalErr.Requests,
+		})
+		return
+	}
+	var limitErr *agent.LimitError
+	if errors.As(err, &limitErr) {
+		writeAnthropicError(c, consts.StatusTooManyRequests, "rate_limit_error", limitErr.Error())
+		return
+	}
+	stopReason := ""
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) {
+		if guardErr.Stage == agent.GuardrailInput {
+			writeAnthropicError(c, consts.StatusBadRequest, "invalid_request_error", guardErr.Error())
+			return
+		}
+		response, err = schema.AssistantMessage("", nil), nil
+		stopReason = "refusal"
+	}
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Chat failed - Session: %s, Error: %v", sessionID, err)
+		writeAnthropicError(c, consts.StatusInternalServerError, "api_error", fmt.Sprintf("chat failed: %v", err))
+		return
+	}
+	if stopReason == "" {
+		stopReason = anthropicStopReason(response)
+	}
+
+	recordUsage(c, usageOf(response))
+	c.JSON(consts.StatusOK, AnthropicResponse{
+		ID:         "msg_" + strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Type:       "message",
+		Role:       "assistant",
+		Model:      turn.model,
+		Content:    toAnthropicContent(response),
+		StopReason: &stopReason,
+		Usage:      toAnthropicUsage(usageOf(response)),
+	})
+}
+
+// handleAnthropicStream answers a streaming Messages request with Anthropic SSE events
+func (s *Server) handleAnthropicStream(ctx context.Context, c *app.RequestContext, turn *chatTurn) {
+	stream, err := s.chatStream(ctx, turn)
+	var limitErr *agent.LimitError
+	if errors.As(err, &limitErr) {
+		writeAnthropicError(c, consts.StatusTooManyRequests, "rate_limit_error", limitErr.Error())
+		return
+	}
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) {
+		writeAnthropicError(c, consts.StatusBadRequest, "invalid_request_error", guardErr.Error())
+		return
+	}
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Chat stream failed - Session: %s, Error: %v", turn.sessionID, err)
+		writeAnthropicError(c, consts.StatusInternalServerError, "api_error", fmt.Sprintf("chat stream failed: %v", err))
+		return
+	}
+	s.writeAnthropicStream(ctx, c, turn, stream)
+}
+
+// anthropicStream tracks the content blocks of a streamed Anthropic message
+type anthropicStream struct {
+	sse      *sse.Stream
+	index    int  // Index of the next content block
+	textOpen bool // Whether a text block is open at index-1
+}
+
+// send publishes an event named after its type
+func (w *anthropicStream) send(eventType string, payload map[string]interface{}) {
+	payload["type"] = eventType
+	data, _ := json.Marshal(payload)
+	w.sse.Publish(&sse.Event{Event: eventType, Data: data})
+}
+
+// text appends text, opening a text block if none is open
+func (w *anthropicStream) text(text string) {
+	if !w.textOpen {
+		w.send("content_block_start", map[string]interface{}{
+			"index":         w.index,
+			"content_block": map[string]string{"type": "text", "text": ""},
+		})
+		w.textOpen = true
+		w.index++
+	}
+	w.send("content_block_delta", map[string]interface{}{
+		"index": w.index - 1,
+		"delta": map[string]string{"type": "text_delta", "text": text},
+	})
+}
+
+// closeText ends the open text block, if any
+func (w *anthropicStream) closeText() {
+	if w.textOpen {
+		w.send("content_block_stop", map[string]interface{}{"index": w.index - 1})
+		w.textOpen = false
+	}
+}
+
+// toolUse sends a complete tool_use block
+func (w *anthropicStream) toolUse(tc *schema.ToolCall) {
+	w.closeText()
+	w.send("content_block_start", map[string]interface{}{
+		"index": w.index,
+		"content_block": map[string]interface{}{
+			"type": "tool_use", "id": tc.ID, "name": tc.Function.Name, "input": map[string]interface{}{},
+		},
+	})
+	w.send("content_block_delta", map[string]interface{}{
+		"index": w.index,
+		"delta": map[string]string{"type": "input_json_delta", "partial_json": string(toolInput(tc.Function.Arguments))},
+	})
+	w.send("content_block_stop", map[string]interface{}{"index": w.index})
+	w.index++
+}
+
+// writeAnthropicStream relays agent stream events as Anthropic SSE events.
+// Server-side tool activity is not forwarded; a run paused for approval ends with stop_reason "pause_turn".
+func (s *Server) writeAnthropicStream(ctx context.Context, c *app.RequestContext, turn *chatTurn, stream *schema.StreamReader[*agent.StreamEvent]) {
+	defer stream.Close()
+	s.activeStreams.Add(1)
+	defer s.activeStreams.Add(-1)
+	sessionID := turn.sessionID
+	recordSession(c, sessionID)
+
+	c.SetContentType("text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("Connection", "keep-alive")
+
+	w := &anthropicStream{sse: sse.NewStream(c)}
+	w.send("message_start", map[string]interface{}{
+		"message": AnthropicResponse{
+			ID:      "msg_" + strings.ReplaceAll(uuid.New().String(), "-", ""),
+			Type:    "message",
+			Role:    "assistant",
+			Model:   turn.model,
+			Content: []AnthropicContentBlock{},
+		},
+	})
+
+	var fullContent string
+	var clientCalls []schema.ToolCall
+	var usage *schema.TokenUsage
+	stopReason := "end_turn"
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Ctx(ctx).Errorf("[API] Stream error - Session: %s, Error: %v", sessionID, err)
+			w.send("error", map[string]interface{}{"error": map[string]string{"type": "api_error", "message": err.Error()}})
+			return
+		}
+
+		switch chunk.Type {
+		case agent.EventAssistantDelta:
+			if chunk.Message.Content == "" {
+				continue
+			}
+			fullContent += chunk.Message.Content
+			w.text(chunk.Message.Content)
+		case agent.EventClientToolCall:
+			clientCalls = append(clientCalls, *chunk.ToolCall)
+			w.toolUse(chunk.ToolCall)
+			stopReason = "tool_use"
+		case agent.EventUsage:
+			usage = chunk.Usage
+		case agent.EventApprovalRequired:
+			logger.Ctx(ctx).Infof("[API] Run paused for approval - Session: %s", sessionID)
+			stopReason = "pause_turn"
+		case agent.EventGuardrailBlocked:
+			stopReason = "refusal"
+		case agent.EventLimitExceeded:
+			logger.Ctx(ctx).Warnf("[API] Stream aborted by limit - Session: %s, Error: %v", sessionID, chunk.Limit)
+			w.send("error", map[string]interface{}{"error": map[string]string{"type": "rate_limit_error", "message": chunk.Limit.Error()}})
+			return
+		case agent.EventCancelled:
+			logger.Ctx(ctx).Infof("[API] Stream cancelled - Session: %s", sessionID)
+		}
+	}
+	w.closeText()
+	recordUsage(c, usage)
+
+	w.send("message_delta", map[string]interface{}{
+		"delta": map[string]interface{}{"stop_reason": stopReason, "stop_sequence": nil},
+		"usage": toAnthropicUsage(usage),
+	})
+	w.send("message_stop", map[string]interface{}{})
+
+	if stopReason != "pause_turn" || fullContent != "" {
+		turn.agent.AppendAssistantMessage(sessionID, schema.AssistantMessage(fullContent, clientCalls))
+	}
+}
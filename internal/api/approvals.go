@@ -0,0 +1,103 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/hertz-contrib/sse"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ApprovalDecisionRequest is the body of an approve/deny call
+type ApprovalDecisionRequest struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// registerApprovalRoutes registers the tool approval endpoints
+func (s *Server) registerApprovalRoutes() {
+	s.httpServer.GET("/v1/sessions/:id/approvals", s.handleListApprovals)
+	s.httpServer.POST("/v1/sessions/:id/approvals/:call_id", s.runHandlers(s.handleDecideApproval)...)
+}
+
+// handleListApprovals lists tool calls of a session waiting for approval
+func (s *Server) handleListApprovals(ctx context.Context, c *app.RequestContext) {
+	sessionID := s.sessionParam(c)
+	a, _ := s.approvalAgent(sessionID)
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   a.PendingToolCalls(sessionID),
+	})
+}
+
+// handleDecideApproval approves or denies a paused tool call.
+// When it was the last pending call, the resumed run is streamed back as SSE.
+func (s *Server) handleDecideApproval(ctx context.Context, c *app.RequestContext) {
+	sessionID, callID := s.sessionParam(c), c.Param("call_id")
+	recordSession(c, sessionID)
+	if quotaErr := s.checkSpend(ctx, c, sessionID); quotaErr != nil {
+		writeQuotaError(c, quotaErr)
+		return
+	}
+
+	var req ApprovalDecisionRequest
+	if err := c.BindJSON(&req); err != nil {
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	logger.Ctx(ctx).Infof("[API] Tool call %s in session %s: approved=%v", callID, sessionID, req.Approved)
+
+	a, model := s.approvalAgent(sessionID)
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+	var stream *schema.StreamReader[*agent.StreamEvent]
+	var err error
+	if req.Approved {
+		stream, err = a.ApprovePendingToolCall(ctx, sessionID, callID)
+	} else {
+		stream, err = a.DenyPendingToolCall(ctx, sessionID, callID, req.Reason)
+	}
+	switch {
+	case errors.Is(err, agent.ErrApprovalNotFound):
+		writeError(c, consts.StatusNotFound, "approval_not_found", err)
+		return
+	case errors.Is(err, agent.ErrApprovalDecided):
+		writeError(c, consts.StatusConflict, "approval_already_decided", err)
+		return
+	case errors.Is(err, agent.ErrRunInProgress):
+		writeError(c, consts.StatusConflict, "run_in_progress", err)
+		return
+	case err != nil:
+		logger.Ctx(ctx).Errorf("[API] Failed to resume session %s: %v", sessionID, err)
+		writeError(c, consts.StatusInternalServerError, "", err)
+		return
+	}
+	if stream == nil {
+		c.JSON(consts.StatusAccepted, map[string]interface{}{
+			"session":           s.publicSession(sessionID),
+			"status":            "approval_required",
+			"pending_approvals": a.PendingToolCalls(sessionID),
+		})
+		return
+	}
+
+	s.writeStream(ctx, c, a, model, sessionID, stream, false)
+}
+
+// sendApprovalEvent sends a named SSE event describing a tool call waiting for approval
+func (s *Server) sendApprovalEvent(stream *sse.Stream, req *agent.ApprovalRequest) {
+	data, _ := json.Marshal(req)
+	stream.Publish(&sse.Event{
+		Event: string(agent.EventApprovalRequired),
+		Data:  data,
+	})
+}
@@ -0,0 +1,87 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// maxAudioBytes is the upload limit of Whisper-compatible transcription APIs
+const maxAudioBytes = 25 << 20
+
+// maxTranscriptBytes limits the backend response relayed to the client
+const maxTranscriptBytes = 16 << 20
+
+// transcriptionBackend is a Whisper-compatible speech-to-text API
+type transcriptionBackend struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// WithTranscription forwards /v1/audio/transcriptions to a Whisper-compatible backend, such as
+// https://api.openai.com/v1 or a local whisper server. The multipart request is relayed unchanged.
+func WithTranscription(baseURL, apiKey string) Option {
+	return func(s *Server) {
+		s.transcription = &transcriptionBackend{
+			baseURL: strings.TrimSuffix(baseURL, "/"),
+			apiKey:  apiKey,
+			client:  &http.Client{},
+		}
+	}
+}
+
+// handleTranscription relays a transcription request and the backend's answer, including its errors
+func (s *Server) handleTranscription(ctx context.Context, c *app.RequestContext) {
+	body := c.Request.Body()
+	if len(body) > maxAudioBytes {
+		writeError(c, consts.StatusRequestEntityTooLarge, "", invalidParam("file", "audio exceeds %d bytes", maxAudioBytes))
+		return
+	}
+
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	backend := s.transcription
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, backend.baseURL+"/audio/transcriptions", bytes.NewReader(body))
+	if err != nil {
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("failed to create transcription request: %w", err))
+		return
+	}
+	req.Header.Set("Content-Type", string(c.ContentType()))
+	if backend.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+backend.apiKey)
+	}
+
+	resp, err := backend.client.Do(req)
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeError(c, consts.StatusGatewayTimeout, "timeout", errors.New("request timed out"))
+		return
+	}
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Transcription backend failed: %v", err)
+		writeError(c, consts.StatusBadGateway, "upstream_error", fmt.Errorf("transcription failed: %w", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTranscriptBytes))
+	if err != nil {
+		writeError(c, consts.StatusBadGateway, "upstream_error", fmt.Errorf("failed to read transcription: %w", err))
+		return
+	}
+	if resp.StatusCode >= 300 {
+		logger.Ctx(ctx).Warnf("[API] Transcription backend answered %d", resp.StatusCode)
+	}
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), data)
+}
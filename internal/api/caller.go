@@ -0,0 +1,29 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/fourhu/eino-ai-agent/internal/audit"
+)
+
+// callerMiddleware carries the identity of the client in the context, so that the tool calls of its runs
+// can be attributed in the audit log
+func (s *Server) callerMiddleware(ctx context.Context, c *app.RequestContext) {
+	caller := audit.Caller{Tenant: c.GetString(tenantKey), IP: c.ClientIP()}
+	if key, ok := strings.CutPrefix(clientKey(c), "key:"); ok {
+		caller.KeyID = keyFingerprint(key)
+	}
+	c.Next(audit.WithCaller(ctx, caller))
+}
+
+// keyFingerprint identifies an API key in logs without revealing it
+func keyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,162 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+const (
+	maxChoices        = 8 // Upper bound of the n parameter
+	choiceConcurrency = 4 // Extra choices generated at once
+)
+
+// choiceResult is an extra choice of a chat completion
+type choiceResult struct {
+	message      *schema.Message
+	finishReason string
+	err          error
+}
+
+// startExtraChoices generates choices 1..n-1 of a turn in the background and returns a func waiting for them.
+// Each is a full agent run (tools included) from the same input and history as choice 0, in a scratch session
+// deleted afterwards, so only choice 0 is stored in the session; the usage of the scratch sessions is charged
+// to it. Each run takes its own slot of the concurrency limit: the request gives up its slot and takes all n at
+// once, and the error of the limit is returned if they cannot be had, before any run starts.
+func (s *Server) startExtraChoices(ctx context.Context, turn *chatTurn) (func() []choiceResult, error) {
+	if turn.n <= 1 {
+		return func() []choiceResult { return nil }, nil
+	}
+
+	if s.runLimiter != nil {
+		if turn.n > cap(s.runLimiter.slots) {
+			return nil, fmt.Errorf("n=%d exceeds the %d concurrent agent runs allowed", turn.n, cap(s.runLimiter.slots))
+		}
+		want := turn.n - 1
+		held, _ := ctx.Value(heldSlotsKey{}).(*heldSlots)
+		if held != nil && held.n == 1 {
+			// Waiting while holding a slot could deadlock with another request doing the same
+			held.n = 0
+			runsInFlight.Add(-1)
+			s.runLimiter.release()
+			want = turn.n
+		}
+		if err := s.runLimiter.acquireN(ctx, want); err != nil {
+			return nil, err
+		}
+		if want == turn.n {
+			held.n = 1
+		}
+		runsInFlight.Add(float64(want))
+	}
+
+	// Choice 0 updates the session as it runs, so the history is taken before it starts
+	scratch := choiceTurn(turn)
+	results := make([]choiceResult, turn.n-1)
+	sem := make(chan struct{}, choiceConcurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.runLimiter != nil {
+				defer func() {
+					runsInFlight.Add(-1)
+					s.runLimiter.release()
+				}()
+			}
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = s.extraChoice(ctx, turn, *scratch)
+		}()
+	}
+	return func() []choiceResult {
+		wg.Wait()
+		return results
+	}, nil
+}
+
+// choiceTurn returns a copy of turn sending the conversation explicitly: a turn continuing the stored session
+// gets a copy of its history ahead of the user message
+func choiceTurn(turn *chatTurn) *chatTurn {
+	scratch := *turn
+	if turn.messages != nil {
+		return &scratch
+	}
+	history, _ := turn.agent.GetSessionHistory(turn.sessionID)
+	userMsg := schema.UserMessage(turn.userMessage)
+	if len(turn.parts) > 0 {
+		userMsg = &schema.Message{Role: schema.User, UserInputMultiContent: turn.parts}
+	}
+	scratch.messages = append(slices.Clip(history), userMsg)
+	return &scratch
+}
+
+// extraChoice runs one extra choice of turn in a scratch session
+func (s *Server) extraChoice(ctx context.Context, turn *chatTurn, scratch chatTurn) choiceResult {
+	scratch.sessionID = s.scopeLike(turn.sessionID, "choice-"+uuid.New().String())
+	defer func() {
+		turn.agent.ChargeSession(context.WithoutCancel(ctx), turn.sessionID, scratch.sessionID)
+		if err := turn.agent.DeleteSession(context.WithoutCancel(ctx), scratch.sessionID); err != nil {
+			logger.Ctx(ctx).Warnf("[API] Failed to delete choice session %s: %v", scratch.sessionID, err)
+		}
+	}()
+
+	response, err := s.chat(ctx, &scratch)
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) && guardErr.Stage != agent.GuardrailInput {
+		return choiceResult{message: schema.AssistantMessage("", nil), finishReason: "content_filter"}
+	}
+	if err == nil && turn.outputSchema != nil {
+		response, err = turn.agent.EnsureStructured(ctx, scratch.sessionID, response, turn.outputSchema, turn.opts...)
+	}
+	if err != nil {
+		return choiceResult{err: err}
+	}
+	return choiceResult{message: response, finishReason: "stop"}
+}
+
+// toChoice converts an answer to a choice of a chat completion
+func toChoice(index int, response *schema.Message, finishReason string) Choice {
+	if len(response.ToolCalls) > 0 {
+		finishReason = "tool_calls"
+	}
+	return Choice{
+		Index: index,
+		Message: &OpenAIMessage{
+			Role:             "assistant",
+			Content:          response.Content,
+			ReasoningContent: response.ReasoningContent,
+			ToolCalls:        toOpenAIToolCalls(response.ToolCalls, false),
+		},
+		FinishReason: finishReason,
+	}
+}
+
+// sumUsage adds up the usage of several answers; nil if none reported any
+func sumUsage(usages ...*schema.TokenUsage) *schema.TokenUsage {
+	var total *schema.TokenUsage
+	for _, usage := range usages {
+		if usage == nil {
+			continue
+		}
+		if total == nil {
+			total = &schema.TokenUsage{}
+		}
+		total.PromptTokens += usage.PromptTokens
+		total.PromptTokenDetails.CachedTokens += usage.PromptTokenDetails.CachedTokens
+		total.CompletionTokens += usage.CompletionTokens
+		total.TotalTokens += usage.TotalTokens
+		total.CompletionTokensDetails.ReasoningTokens += usage.CompletionTokensDetails.ReasoningTokens
+	}
+	return total
+}
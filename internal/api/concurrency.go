@@ -0,0 +1,179 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/metrics"
+)
+
+// Concurrency metrics
+var (
+	runsInFlight = metrics.NewGauge("eino_runs_in_flight",
+		"Agent runs currently executing")
+	runsQueued = metrics.NewGauge("eino_runs_queued",
+		"Requests waiting for a free agent run slot")
+	runsRejected = metrics.NewCounter("eino_runs_rejected_total",
+		"Requests rejected because the concurrency limit was reached", "reason")
+)
+
+// WithConcurrencyLimit caps the agent runs executing at once across all clients. Up to maxQueue further
+// requests wait at most queueTimeout (default 30s) for a slot; the rest are answered 429 with Retry-After.
+func WithConcurrencyLimit(maxRuns, maxQueue int, queueTimeout time.Duration) Option {
+	return func(s *Server) {
+		if maxRuns <= 0 {
+			return
+		}
+		if queueTimeout <= 0 {
+			queueTimeout = 30 * time.Second
+		}
+		s.runLimiter = &runLimiter{
+			slots:        make(chan struct{}, maxRuns),
+			multi:        make(chan struct{}, 1),
+			maxQueue:     int64(max(maxQueue, 0)),
+			queueTimeout: queueTimeout,
+		}
+	}
+}
+
+// runLimiter is a semaphore over agent runs with a bounded wait queue
+type runLimiter struct {
+	slots        chan struct{}
+	multi        chan struct{} // Held while a request takes several slots
+	queued       atomic.Int64
+	maxQueue     int64
+	queueTimeout time.Duration
+}
+
+// acquire takes a run slot, waiting in the queue if there is room; it returns the error to answer otherwise
+func (l *runLimiter) acquire(ctx context.Context) error {
+	return l.acquireN(ctx, 1)
+}
+
+// acquireN takes n run slots for a request holding none, waiting at most once in the queue for all of them.
+// Requests taking several slots take turns, so two of them never hold part of the slots each while waiting
+// for the rest.
+func (l *runLimiter) acquireN(ctx context.Context, n int) error {
+	w := &slotWait{limiter: l, ctx: ctx}
+	defer w.done()
+
+	if n > 1 {
+		if err := w.take(l.multi); err != nil {
+			return err
+		}
+		defer func() { <-l.multi }()
+	}
+	for taken := range n {
+		if err := w.take(l.slots); err != nil {
+			for range taken {
+				l.release()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// slotWait is a request waiting for run slots; it joins the queue the first time it has to wait
+type slotWait struct {
+	limiter *runLimiter
+	ctx     context.Context
+	timer   *time.Timer // Set once queued
+}
+
+// take sends on ch, waiting in the queue until the queue timeout if ch is full
+func (w *slotWait) take(ch chan struct{}) error {
+	select {
+	case ch <- struct{}{}:
+		return nil
+	default:
+	}
+
+	l := w.limiter
+	if w.timer == nil {
+		if l.queued.Add(1) > l.maxQueue {
+			l.queued.Add(-1)
+			runsRejected.Inc("queue_full")
+			return errors.New("too many concurrent requests")
+		}
+		runsQueued.Add(1)
+		w.timer = time.NewTimer(l.queueTimeout)
+	}
+	select {
+	case ch <- struct{}{}:
+		return nil
+	case <-w.timer.C:
+		runsRejected.Inc("queue_timeout")
+		return errors.New("timed out waiting for a free run slot")
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// done leaves the queue
+func (w *slotWait) done() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.limiter.queued.Add(-1)
+		runsQueued.Add(-1)
+	}
+}
+
+// release frees a run slot
+func (l *runLimiter) release() {
+	<-l.slots
+}
+
+// heldSlotsKey is the context key carrying the run slots a request holds
+type heldSlotsKey struct{}
+
+// heldSlots counts the run slots of a request; the handler may change it, the middleware releases them
+type heldSlots struct {
+	n int
+}
+
+// concurrencyMiddleware holds a run slot for the whole request, including a streamed response
+func (s *Server) concurrencyMiddleware(ctx context.Context, c *app.RequestContext) {
+	if err := s.runLimiter.acquire(ctx); err != nil {
+		if ctx.Err() != nil {
+			c.Abort()
+			return
+		}
+		logger.Ctx(ctx).Warnf("[API] Rejected %s: %v", c.Path(), err)
+		c.Response.Header.Set("Retry-After", "1")
+		abortWithError(c, consts.StatusTooManyRequests, "concurrency_limit_exceeded", err)
+		return
+	}
+	held := &heldSlots{n: 1}
+	runsInFlight.Add(1)
+	defer func() {
+		runsInFlight.Add(-float64(held.n))
+		for range held.n {
+			s.runLimiter.release()
+		}
+	}()
+	c.Next(context.WithValue(ctx, heldSlotsKey{}, held))
+}
+
+// runHandlers prepends the concurrency limit and usage accounting to the handler of an endpoint that runs
+// the agent; requests rejected by the limit are not accounted
+func (s *Server) runHandlers(handler app.HandlerFunc) []app.HandlerFunc {
+	var handlers []app.HandlerFunc
+	if s.runLimiter != nil {
+		handlers = append(handlers, s.concurrencyMiddleware)
+	}
+	if s.usage != nil {
+		handlers = append(handlers, s.usageMiddleware)
+	}
+	if s.quotas != nil {
+		handlers = append(handlers, s.spendMiddleware)
+	}
+	return append(handlers, handler)
+}
@@ -0,0 +1,111 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ContentPart is an element of an OpenAI content-part array
+type ContentPart struct {
+	Type     string    `json:"type"` // "text", "image_url" or "file"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+	File     *FileRef  `json:"file,omitempty"` // Replaced by the file's text before the turn runs
+}
+
+// ImageURL references an image by URL or data URL
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// UnmarshalJSON accepts content either as a string or as an array of content parts
+func (m *OpenAIMessage) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Role       string           `json:"role"`
+		Content    json.RawMessage  `json:"content"`
+		ToolCalls  []OpenAIToolCall `json:"tool_calls"`
+		ToolCallID string           `json:"tool_call_id"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	m.Role, m.ToolCalls, m.ToolCallID = aux.Role, aux.ToolCalls, aux.ToolCallID
+	m.Content, m.Parts = "", nil
+
+	content := strings.TrimSpace(string(aux.Content))
+	switch {
+	case content == "" || content == "null":
+		return nil
+	case strings.HasPrefix(content, "["):
+		if err := json.Unmarshal(aux.Content, &m.Parts); err != nil {
+			return fmt.Errorf("invalid content parts: %w", err)
+		}
+		m.Content = m.partsContent()
+		return nil
+	default:
+		return json.Unmarshal(aux.Content, &m.Content)
+	}
+}
+
+// partsContent joins the text parts
+func (m *OpenAIMessage) partsContent() string {
+	var texts []string
+	for _, part := range m.Parts {
+		if part.Type == "text" && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// hasImages reports whether the message carries image parts
+func (m *OpenAIMessage) hasImages() bool {
+	for _, part := range m.Parts {
+		if part.Type == "image_url" && part.ImageURL != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// inputParts converts content parts to agent input parts; data URLs become base64 data
+func (m *OpenAIMessage) inputParts() []schema.MessageInputPart {
+	parts := make([]schema.MessageInputPart, 0, len(m.Parts))
+	for _, part := range m.Parts {
+		switch part.Type {
+		case "text":
+			parts = append(parts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeText, Text: part.Text})
+		case "image_url":
+			if part.ImageURL == nil {
+				continue
+			}
+			image := &schema.MessageInputImage{Detail: schema.ImageURLDetail(part.ImageURL.Detail)}
+			if mime, data, ok := parseDataURL(part.ImageURL.URL); ok {
+				image.Base64Data, image.MIMEType = &data, mime
+			} else {
+				url := part.ImageURL.URL
+				image.URL = &url
+			}
+			parts = append(parts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeImageURL, Image: image})
+		}
+	}
+	return parts
+}
+
+// parseDataURL splits a base64 data URL ("data:image/png;base64,...") into MIME type and data
+func parseDataURL(url string) (mime, data string, ok bool) {
+	rest, found := strings.CutPrefix(url, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(rest, ",")
+	if !found || !strings.HasSuffix(meta, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(meta, ";base64"), data, true
+}
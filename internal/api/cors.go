@@ -0,0 +1,96 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// CORSConfig configures cross-origin access for browser clients
+type CORSConfig struct {
+	AllowedOrigins   []string // Origins or globs such as "https://*.example.com"; "*" allows any origin
+	AllowedMethods   []string // Default GET, POST, DELETE, OPTIONS
+	AllowedHeaders   []string // Default Authorization, Content-Type, Accept
+	ExposedHeaders   []string // Response headers readable by the browser
+	AllowCredentials bool     // Allow cookies and auth headers; the origin is echoed instead of "*"
+	MaxAge           int      // Seconds a preflight result may be cached (0 = browser default)
+}
+
+// WithCORS answers preflight requests and adds CORS headers for the allowed origins
+func WithCORS(cfg CORSConfig) Option {
+	return func(s *Server) {
+		if len(cfg.AllowedOrigins) == 0 {
+			return
+		}
+		if len(cfg.AllowedMethods) == 0 {
+			cfg.AllowedMethods = []string{"GET", "POST", "DELETE", "OPTIONS"}
+		}
+		if len(cfg.AllowedHeaders) == 0 {
+			cfg.AllowedHeaders = []string{"Authorization", "Content-Type", "Accept"}
+		}
+		s.cors = &cfg
+	}
+}
+
+// allowOrigin reports whether the origin matches an allowed origin
+func (cfg *CORSConfig) allowOrigin(origin string) bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if ok, _ := path.Match(allowed, origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware sets the CORS headers and ends preflight requests with 204
+func (s *Server) corsMiddleware(ctx context.Context, c *app.RequestContext) {
+	origin := string(c.GetHeader("Origin"))
+	if origin == "" || !s.cors.allowOrigin(origin) {
+		c.Next(ctx)
+		return
+	}
+
+	h := &c.Response.Header
+	if s.cors.AllowCredentials || !s.cors.allowsAny() {
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+	} else {
+		h.Set("Access-Control-Allow-Origin", "*")
+	}
+	if s.cors.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(s.cors.ExposedHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(s.cors.ExposedHeaders, ", "))
+	}
+
+	preflight := string(c.Method()) == consts.MethodOptions && len(c.GetHeader("Access-Control-Request-Method")) > 0
+	if !preflight {
+		c.Next(ctx)
+		return
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(s.cors.AllowedMethods, ", "))
+	h.Set("Access-Control-Allow-Headers", strings.Join(s.cors.AllowedHeaders, ", "))
+	if s.cors.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(s.cors.MaxAge))
+	}
+	c.AbortWithStatus(consts.StatusNoContent)
+}
+
+// allowsAny reports whether every origin is allowed
+func (cfg *CORSConfig) allowsAny() bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/adaptor"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/mcp"
+)
+
+// WithDebug serves pprof under /debug/pprof and runtime state at /debug/status.
+// Requests must carry the token as a bearer token; without a token only loopback clients are served.
+func WithDebug(token string) Option {
+	return func(s *Server) {
+		s.debug = true
+		s.debugToken = token
+	}
+}
+
+// WithMCP reports the connection states of the MCP servers at /debug/status and enables their admin endpoints
+func WithMCP(manager *mcp.Manager) Option {
+	return func(s *Server) {
+		s.mcp = manager
+	}
+}
+
+// registerDebugRoutes registers the pprof and runtime status endpoints
+func (s *Server) registerDebugRoutes() {
+	if s.debugToken == "" {
+		logger.Warnf("[API] Debug endpoints enabled without a token, serving loopback clients only")
+	}
+	g := s.httpServer.Group("/debug", operatorAuth(s.debugToken, "debug"))
+	g.GET("/status", s.handleDebugStatus)
+	g.GET("/pprof/", adaptor.HertzHandler(http.HandlerFunc(pprof.Index)))
+	g.GET("/pprof/cmdline", adaptor.HertzHandler(http.HandlerFunc(pprof.Cmdline)))
+	g.GET("/pprof/profile", adaptor.HertzHandler(http.HandlerFunc(pprof.Profile)))
+	g.GET("/pprof/symbol", adaptor.HertzHandler(http.HandlerFunc(pprof.Symbol)))
+	g.POST("/pprof/symbol", adaptor.HertzHandler(http.HandlerFunc(pprof.Symbol)))
+	g.GET("/pprof/trace", adaptor.HertzHandler(http.HandlerFunc(pprof.Trace)))
+	// Named profiles such as heap, goroutine and mutex
+	g.GET("/pprof/:name", adaptor.HertzHandler(http.HandlerFunc(pprof.Index)))
+}
+
+// operatorAuth admits requests carrying token as a bearer token, or loopback requests when no token is set
+func operatorAuth(token, area string) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if token == "" {
+			host, _, _ := net.SplitHostPort(c.RemoteAddr().String())
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				abortWithError(c, consts.StatusForbidden, "", fmt.Errorf("%s endpoints are only served to loopback clients", area))
+				return
+			}
+			c.Next(ctx)
+			return
+		}
+
+		given, _ := strings.CutPrefix(string(c.GetHeader("Authorization")), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			abortWithError(c, consts.StatusUnauthorized, "invalid_api_key", fmt.Errorf("invalid %s token", area))
+			return
+		}
+		c.Next(ctx)
+	}
+}
+
+// handleDebugStatus reports goroutines, memory, active streams, resident sessions and MCP connection states
+func (s *Server) handleDebugStatus(ctx context.Context, c *app.RequestContext) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	sessions := make(map[string]int)
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		sessions[name] = len(a.ListSessions())
+	}
+
+	status := map[string]interface{}{
+		"go_version":     runtime.Version(),
+		"uptime_seconds": int64(time.Since(s.startedAt).Seconds()),
+		"goroutines":     runtime.NumGoroutine(),
+		"memory": map[string]uint64{
+			"heap_alloc_bytes": mem.HeapAlloc,
+			"sys_bytes":        mem.Sys,
+			"num_gc":           uint64(mem.NumGC),
+		},
+		"active_streams":    s.activeStreams.Load(),
+		"resident_sessions": sessions,
+	}
+	if s.mcp != nil {
+		status["mcp_servers"] = s.mcp.ServerStates()
+	}
+	c.JSON(consts.StatusOK, status)
+}
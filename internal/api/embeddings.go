@@ -0,0 +1,125 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// maxEmbeddingInputs caps the number of texts of one embeddings request
+const maxEmbeddingInputs = 2048
+
+// WithEmbeddings serves /v1/embeddings with the embedder; requests naming no model use defaultModel
+func WithEmbeddings(embedder embedding.Embedder, defaultModel string) Option {
+	return func(s *Server) {
+		s.embedder = embedder
+		s.embeddingModel = defaultModel
+	}
+}
+
+// EmbeddingRequest represents an OpenAI-compatible embeddings request
+type EmbeddingRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"`                     // A string or an array of strings
+	EncodingFormat string          `json:"encoding_format,omitempty"` // "float" (default) or "base64"
+	User           string          `json:"user,omitempty"`
+}
+
+// EmbeddingResponse represents an OpenAI-compatible embeddings response
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingUsage reports input tokens; the embedder does not return counts, so they are zero
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// EmbeddingData is the vector of one input; Embedding is a float array or a base64 string
+type EmbeddingData struct {
+	Object    string      `json:"object"`
+	Index     int         `json:"index"`
+	Embedding interface{} `json:"embedding"`
+}
+
+// inputs decodes the input texts
+func (r *EmbeddingRequest) inputs() ([]string, error) {
+	var single string
+	if err := json.Unmarshal(r.Input, &single); err == nil {
+		return []string{single}, nil
+	}
+	var texts []string
+	if err := json.Unmarshal(r.Input, &texts); err != nil {
+		return nil, invalidParam("input", "input must be a string or an array of strings")
+	}
+	if len(texts) == 0 {
+		return nil, invalidParam("input", "input must not be empty")
+	}
+	if len(texts) > maxEmbeddingInputs {
+		return nil, invalidParam("input", "input accepts at most %d strings", maxEmbeddingInputs)
+	}
+	return texts, nil
+}
+
+// handleEmbeddings embeds the request input with the configured embedding model
+func (s *Server) handleEmbeddings(ctx context.Context, c *app.RequestContext) {
+	var req EmbeddingRequest
+	if err := c.BindJSON(&req); err != nil {
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	texts, err := req.inputs()
+	if err != nil {
+		writeError(c, consts.StatusBadRequest, "", err)
+		return
+	}
+	if req.EncodingFormat != "" && req.EncodingFormat != "float" && req.EncodingFormat != "base64" {
+		writeError(c, consts.StatusBadRequest, "", invalidParam("encoding_format", "encoding_format must be float or base64"))
+		return
+	}
+	model := req.Model
+	if model == "" {
+		model = s.embeddingModel
+	}
+
+	vectors, err := s.embedder.EmbedStrings(ctx, texts, embedding.WithModel(model))
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Embedding failed - Model: %s, Error: %v", model, err)
+		writeError(c, consts.StatusBadGateway, "", fmt.Errorf("embedding failed: %w", err))
+		return
+	}
+
+	resp := EmbeddingResponse{Object: "list", Model: model, Data: make([]EmbeddingData, 0, len(vectors))}
+	for i, vector := range vectors {
+		data := EmbeddingData{Object: "embedding", Index: i, Embedding: vector}
+		if req.EncodingFormat == "base64" {
+			data.Embedding = encodeEmbedding(vector)
+		}
+		resp.Data = append(resp.Data, data)
+	}
+	logger.Ctx(ctx).Debugf("[API] Embedded %d inputs with model %s", len(texts), model)
+	c.JSON(consts.StatusOK, resp)
+}
+
+// encodeEmbedding encodes a vector as base64 little-endian float32 values, as OpenAI does
+func encodeEmbedding(vector []float64) string {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(v)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,93 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/hertz-contrib/sse"
+)
+
+// ErrorResponse is the OpenAI error envelope
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes a failed request; Param names the offending request field
+type APIError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+// paramError is a validation error of a single request field
+type paramError struct {
+	param string
+	err   error
+}
+
+func (e *paramError) Error() string { return e.err.Error() }
+
+func (e *paramError) Unwrap() error { return e.err }
+
+// invalidParam returns a validation error for the named request field
+func invalidParam(param, format string, args ...interface{}) error {
+	return &paramError{param: param, err: fmt.Errorf(format, args...)}
+}
+
+// errorType maps a status code to the error type reported with it
+func errorType(status int) string {
+	switch {
+	case status == consts.StatusUnauthorized:
+		return "authentication_error"
+	case status == consts.StatusPaymentRequired:
+		return "insufficient_quota"
+	case status == consts.StatusForbidden:
+		return "permission_error"
+	case status == consts.StatusNotFound:
+		return "not_found_error"
+	case status == consts.StatusConflict:
+		return "conflict_error"
+	case status == consts.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status == consts.StatusGatewayTimeout:
+		return "timeout_error"
+	case status >= 500:
+		return "server_error"
+	default:
+		return "invalid_request_error"
+	}
+}
+
+// newErrorResponse builds the envelope of err; the param of a paramError is reported and code may be empty
+func newErrorResponse(status int, code string, err error) ErrorResponse {
+	apiErr := APIError{Message: err.Error(), Type: errorType(status)}
+	var pe *paramError
+	if errors.As(err, &pe) {
+		apiErr.Param = &pe.param
+	}
+	if code != "" {
+		apiErr.Code = &code
+	}
+	return ErrorResponse{Error: apiErr}
+}
+
+// writeError answers with the OpenAI error envelope
+func writeError(c *app.RequestContext, status int, code string, err error) {
+	c.JSON(status, newErrorResponse(status, code, err))
+}
+
+// abortWithError answers with the OpenAI error envelope and stops the handler chain
+func abortWithError(c *app.RequestContext, status int, code string, err error) {
+	c.AbortWithStatusJSON(status, newErrorResponse(status, code, err))
+}
+
+// sendSSEError sends an error envelope as a stream event, as OpenAI does for failures after the stream started
+func (s *Server) sendSSEError(stream *sse.Stream, status int, code string, err error) {
+	data, _ := json.Marshal(newErrorResponse(status, code, err))
+	stream.Publish(&sse.Event{Data: data})
+}
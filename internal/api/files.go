@@ -0,0 +1,197 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/document"
+	"github.com/fourhu/eino-ai-agent/internal/files"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// FileRef references an uploaded file by ID or carries a file inline as a base64 data URL
+type FileRef struct {
+	FileID   string `json:"file_id,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+	Filename string `json:"filename,omitempty"`
+}
+
+// WithFiles enables the /v1/files endpoints and file_id references in chat requests
+func WithFiles(svc *files.Service) Option {
+	return func(s *Server) {
+		s.files = svc
+	}
+}
+
+// registerFileRoutes registers the file upload endpoints
+func (s *Server) registerFileRoutes() {
+	s.httpServer.POST("/v1/files", s.handleUploadFile)
+	s.httpServer.GET("/v1/files", s.handleListFiles)
+	s.httpServer.GET("/v1/files/:id", s.handleGetFile)
+	s.httpServer.GET("/v1/files/:id/content", s.handleGetFileContent)
+	s.httpServer.DELETE("/v1/files/:id", s.handleDeleteFile)
+}
+
+// handleUploadFile stores a multipart upload ("file" field, optional "purpose")
+func (s *Server) handleUploadFile(ctx context.Context, c *app.RequestContext) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		writeError(c, consts.StatusBadRequest, "", invalidParam("file", "a multipart file field is required"))
+		return
+	}
+	if fh.Size > int64(s.files.MaxBytes()) {
+		writeError(c, consts.StatusRequestEntityTooLarge, "", invalidParam("file", "file exceeds %d bytes", s.files.MaxBytes()))
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("failed to open upload: %w", err))
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, int64(s.files.MaxBytes())))
+	if err != nil {
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("failed to read upload: %w", err))
+		return
+	}
+
+	purpose := string(c.FormValue("purpose"))
+	if purpose == "" {
+		purpose = "user_data"
+	}
+	file, err := s.files.Upload(ctx, c.GetString(tenantKey), fh.Filename, fh.Header.Get("Content-Type"), purpose, data)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] File upload failed - Name: %s, Error: %v", fh.Filename, err)
+		writeError(c, consts.StatusInternalServerError, "", err)
+		return
+	}
+	logger.Ctx(ctx).Infof("[API] Stored file %s (%s, %d bytes)", file.ID, file.Filename, file.Bytes)
+	c.JSON(consts.StatusOK, file)
+}
+
+// handleListFiles lists the files uploaded by the request's tenant, newest first
+func (s *Server) handleListFiles(ctx context.Context, c *app.RequestContext) {
+	list, err := s.files.List(ctx, c.GetString(tenantKey))
+	if err != nil {
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("failed to list files: %w", err))
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   list,
+	})
+}
+
+// handleGetFile returns the metadata of a file
+func (s *Server) handleGetFile(ctx context.Context, c *app.RequestContext) {
+	file, err := s.files.Get(ctx, c.GetString(tenantKey), c.Param("id"))
+	if err != nil {
+		writeFileError(c, c.Param("id"), err)
+		return
+	}
+	c.JSON(consts.StatusOK, file)
+}
+
+// handleGetFileContent returns the raw contents of a file
+func (s *Server) handleGetFileContent(ctx context.Context, c *app.RequestContext) {
+	file, data, err := s.files.Content(ctx, c.GetString(tenantKey), c.Param("id"))
+	if err != nil {
+		writeFileError(c, c.Param("id"), err)
+		return
+	}
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Response.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	c.Data(consts.StatusOK, contentType, data)
+}
+
+// handleDeleteFile removes a file
+func (s *Server) handleDeleteFile(ctx context.Context, c *app.RequestContext) {
+	id := c.Param("id")
+	if err := s.files.Delete(ctx, c.GetString(tenantKey), id); err != nil {
+		writeFileError(c, id, err)
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"id": id, "object": "file", "deleted": true})
+}
+
+// writeFileError answers 404 for unknown files and 500 for store failures
+func writeFileError(c *app.RequestContext, id string, err error) {
+	if errors.Is(err, files.ErrNotFound) {
+		writeError(c, consts.StatusNotFound, "file_not_found", fmt.Errorf("file %s not found", id))
+		return
+	}
+	writeError(c, consts.StatusInternalServerError, "", err)
+}
+
+// attachFiles replaces the file parts of the messages with the extracted text of the files,
+// so the model reads the document as part of the conversation. Only files of the tenant can be referenced.
+func (s *Server) attachFiles(ctx context.Context, tenant string, msgs []OpenAIMessage) error {
+	for i := range msgs {
+		m := &msgs[i]
+		attached := false
+		for j, part := range m.Parts {
+			if part.Type != "file" {
+				continue
+			}
+			param := fmt.Sprintf("messages[%d].content[%d].file", i, j)
+			if part.File == nil {
+				return invalidParam(param, "file is required")
+			}
+			name, text, err := s.fileText(ctx, tenant, part.File)
+			if err != nil {
+				var pe *paramError
+				if errors.As(err, &pe) {
+					return err
+				}
+				return invalidParam(param, "%v", err)
+			}
+			m.Parts[j] = ContentPart{Type: "text", Text: fmt.Sprintf("<file name=%q>\n%s\n</file>", name, text)}
+			attached = true
+		}
+		if attached {
+			m.Content = m.partsContent()
+		}
+	}
+	return nil
+}
+
+// fileText returns the name and extracted text of a referenced or inline file
+func (s *Server) fileText(ctx context.Context, tenant string, ref *FileRef) (string, string, error) {
+	if ref.FileID != "" {
+		if s.files == nil {
+			return "", "", invalidParam("file_id", "file uploads are not enabled")
+		}
+		file, text, err := s.files.Text(ctx, tenant, ref.FileID)
+		if errors.Is(err, files.ErrNotFound) {
+			return "", "", invalidParam("file_id", "file %s not found", ref.FileID)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return file.Filename, text, nil
+	}
+
+	mime, encoded, ok := parseDataURL(ref.FileData)
+	if !ok {
+		return "", "", invalidParam("file_data", "file_data must be a base64 data URL")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", invalidParam("file_data", "invalid base64 data: %v", err)
+	}
+	text, err := document.ExtractText(ref.Filename, mime, data)
+	if err != nil {
+		return "", "", err
+	}
+	return ref.Filename, text, nil
+}
@@ -0,0 +1,84 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+)
+
+// ResponseFormat selects the output format of a chat completion
+type ResponseFormat struct {
+	Type       string            `json:"type"` // "text", "json_object" or "json_schema"
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat is the schema of a json_schema response format
+type JSONSchemaFormat struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+// outputSchema returns the JSON schema the answer must match, or nil for plain text
+func (f *ResponseFormat) outputSchema() (json.RawMessage, error) {
+	if f == nil {
+		return nil, nil
+	}
+	switch f.Type {
+	case "", "text":
+		return nil, nil
+	case "json_object":
+		return json.RawMessage(`{"type":"object"}`), nil
+	case "json_schema":
+		if f.JSONSchema == nil || len(f.JSONSchema.Schema) == 0 {
+			return nil, invalidParam("response_format.json_schema.schema", "a schema is required")
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(f.JSONSchema.Schema, &doc); err != nil {
+			return nil, invalidParam("response_format.json_schema.schema", "schema must be a JSON object")
+		}
+		return f.JSONSchema.Schema, nil
+	default:
+		return nil, invalidParam("response_format.type", "unknown response format %q", f.Type)
+	}
+}
+
+// structuredStream runs a turn with a JSON response format to completion, so the answer can be validated
+// and corrected before anything is sent, and replays the result as stream events
+func (s *Server) structuredStream(ctx context.Context, turn *chatTurn) (*schema.StreamReader[*agent.StreamEvent], error) {
+	response, err := s.chat(ctx, turn)
+	var approvalErr *agent.ApprovalRequiredError
+	if errors.As(err, &approvalErr) {
+		events := make([]*agent.StreamEvent, 0, len(approvalErr.Requests))
+		for _, req := range approvalErr.Requests {
+			events = append(events, &agent.StreamEvent{Type: agent.EventApprovalRequired, Approval: req})
+		}
+		return schema.StreamReaderFromArray(events), nil
+	}
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) && guardErr.Stage != agent.GuardrailInput {
+		return schema.StreamReaderFromArray([]*agent.StreamEvent{{Type: agent.EventGuardrailBlocked, Guardrail: guardErr}}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response, err = turn.agent.EnsureStructured(ctx, turn.sessionID, response, turn.outputSchema, turn.opts...); err != nil {
+		return nil, err
+	}
+
+	var events []*agent.StreamEvent
+	if response.Content != "" {
+		events = append(events, &agent.StreamEvent{Type: agent.EventAssistantDelta, Message: schema.AssistantMessage(response.Content, nil)})
+	}
+	for i := range response.ToolCalls {
+		events = append(events, &agent.StreamEvent{Type: agent.EventClientToolCall, ToolCall: &response.ToolCalls[i]})
+	}
+	events = append(events, &agent.StreamEvent{Type: agent.EventUsage, Usage: usageOf(response)})
+	return schema.StreamReaderFromArray(events), nil
+}
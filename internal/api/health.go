@@ -0,0 +1,154 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/mcp"
+)
+
+// healthCheckTimeout bounds each dependency check of a deep health check
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck verifies a dependency for /health?deep=true
+type HealthCheck struct {
+	Name     string
+	Critical bool // A failure answers 503 instead of reporting the service as degraded
+	Check    func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status    string `json:"status"` // up, degraded or down
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// WithHealthChecks adds dependency checks to /health?deep=true.
+// The enabled MCP servers are checked as well, as non-critical dependencies; servers under
+// health monitoring report their latest check instead of being pinged again.
+func WithHealthChecks(checks ...HealthCheck) Option {
+	return func(s *Server) {
+		s.healthChecks = append(s.healthChecks, checks...)
+	}
+}
+
+// ModelHealthCheck checks that an OpenAI-compatible endpoint is reachable and accepts the API key
+func ModelHealthCheck(name, baseURL, apiKey string) HealthCheck {
+	url := strings.TrimSuffix(baseURL, "/") + "/models"
+	return HealthCheck{
+		Name:     "model:" + name,
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			if apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+apiKey)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			// Endpoints without a model list answer 404 but are still reachable
+			if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode >= 500 {
+				return fmt.Errorf("model endpoint answered %d", resp.StatusCode)
+			}
+			return nil
+		},
+	}
+}
+
+// handleDeepHealth runs every dependency check concurrently. It answers 503 if a critical check fails,
+// and reports "degraded" if only non-critical ones do.
+func (s *Server) handleDeepHealth(ctx context.Context, c *app.RequestContext) {
+	checks := append([]HealthCheck(nil), s.healthChecks...)
+	results := make(map[string]CheckResult, len(checks))
+	if s.mcp != nil {
+		for _, server := range s.mcp.Status(ctx) {
+			if !server.Enabled {
+				continue
+			}
+			name := server.Name
+			if server.LastCheck != nil || server.State == mcp.StateDown {
+				results["mcp:"+name] = mcpCheckResult(server)
+				continue
+			}
+			checks = append(checks, HealthCheck{
+				Name:  "mcp:" + name,
+				Check: func(ctx context.Context) error { return s.mcp.Ping(ctx, name) },
+			})
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(checkCtx)
+			result := CheckResult{Status: "up", Critical: check.Critical, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status, result.Error = "down", err.Error()
+			}
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	status, code := "healthy", consts.StatusOK
+	for name, result := range results {
+		if result.Status == "up" {
+			continue
+		}
+		logger.Ctx(ctx).Warnf("[API] Health check %s failed: %s", name, result.Error)
+		if result.Critical {
+			status, code = "unhealthy", consts.StatusServiceUnavailable
+		} else if status == "healthy" {
+			status = "degraded"
+		}
+	}
+	c.JSON(code, map[string]interface{}{
+		"status": status,
+		"checks": results,
+	})
+}
+
+// mcpCheckResult reports the monitored state of an MCP server as a check result
+func mcpCheckResult(server mcp.ServerStatus) CheckResult {
+	result := CheckResult{Status: "up", LatencyMS: server.LatencyMS}
+	switch server.State {
+	case mcp.StateDegraded:
+		result.Status = "degraded"
+		if server.ConsecutiveFailures > 0 {
+			result.Error = server.LastError
+		} else {
+			result.Error = fmt.Sprintf("slow ping: %dms", server.LatencyMS)
+		}
+	case mcp.StateDown:
+		result.Status, result.Error = "down", server.LastError
+		if result.Error == "" {
+			result.Error = "not connected"
+		}
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// WithIdempotency caches successful non-streaming chat completions by their Idempotency-Key header for ttl
+// (default 24h), so retried requests get the prior response instead of running the agent again.
+// At most maxEntries (default 10000) responses are kept; keys are scoped to the client and tenant.
+func WithIdempotency(ttl time.Duration, maxEntries int) Option {
+	return func(s *Server) {
+		if ttl <= 0 {
+			ttl = 24 * time.Hour
+		}
+		if maxEntries <= 0 {
+			maxEntries = 10000
+		}
+		s.idempotency = &idempotencyCache{
+			ttl:        ttl,
+			maxEntries: maxEntries,
+			entries:    make(map[string]*idempotentResponse),
+		}
+	}
+}
+
+// idempotencyCache holds the responses of requests by client and idempotency key
+type idempotencyCache struct {
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*idempotentResponse
+	mu         sync.Mutex
+}
+
+// idempotentResponse is a request in flight, or its completed response until expires
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	done        chan struct{} // Closed when the first request finished
+	completed   bool
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// begin claims key for this request. If a response is cached it is replayed and begin returns false;
+// otherwise the caller handles the request and must call finish. A request with the same key in flight is
+// waited for; if it fails, the waiting request runs instead.
+func (ic *idempotencyCache) begin(ctx context.Context, c *app.RequestContext, key string) (finish func(), ok bool) {
+	scoped := clientKey(c) + "\x00" + c.GetString(tenantKey) + "\x00" + key
+	fingerprint := sha256.Sum256(c.Request.Body())
+
+	for {
+		ic.mu.Lock()
+		entry, found := ic.entries[scoped]
+		if found && entry.completed && time.Now().After(entry.expires) {
+			delete(ic.entries, scoped)
+			found = false
+		}
+		if !found {
+			ic.evict()
+			entry = &idempotentResponse{fingerprint: fingerprint, done: make(chan struct{})}
+			ic.entries[scoped] = entry
+			ic.mu.Unlock()
+			return func() { ic.finish(scoped, entry, c) }, true
+		}
+		ic.mu.Unlock()
+
+		if entry.fingerprint != fingerprint {
+			writeError(c, consts.StatusUnprocessableEntity, "idempotency_key_reused",
+				errors.New("idempotency key was already used with a different request body"))
+			return nil, false
+		}
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			writeError(c, consts.StatusConflict, "idempotency_key_in_use",
+				errors.New("a request with this idempotency key is still in progress"))
+			return nil, false
+		}
+		if entry.completed {
+			logger.Ctx(ctx).Infof("[API] Replaying response for idempotency key %s", key)
+			c.Response.Header.Set("Idempotent-Replayed", "true")
+			c.Data(entry.status, entry.contentType, entry.body)
+			return nil, false
+		}
+	}
+}
+
+// finish caches a successful response, or releases the key so a retry runs again
+func (ic *idempotencyCache) finish(scoped string, entry *idempotentResponse, c *app.RequestContext) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	if status := c.Response.StatusCode(); status >= 200 && status < 300 {
+		entry.completed = true
+		entry.status = status
+		entry.contentType = string(c.Response.Header.ContentType())
+		entry.body = append([]byte(nil), c.Response.Body()...)
+		entry.expires = time.Now().Add(ic.ttl)
+	} else {
+		delete(ic.entries, scoped)
+	}
+	close(entry.done)
+}
+
+// evict makes room for a new entry by dropping expired responses, then the one expiring first; ic.mu must be held
+func (ic *idempotencyCache) evict() {
+	if len(ic.entries) < ic.maxEntries {
+		return
+	}
+	now := time.Now()
+	oldest := ""
+	for key, entry := range ic.entries {
+		if !entry.completed {
+			continue
+		}
+		if now.After(entry.expires) {
+			delete(ic.entries, key)
+			continue
+		}
+		if oldest == "" || entry.expires.Before(ic.entries[oldest].expires) {
+			oldest = key
+		}
+	}
+	if len(ic.entries) >= ic.maxEntries && oldest != "" {
+		delete(ic.entries, oldest)
+	}
+}
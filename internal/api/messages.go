@@ -0,0 +1,357 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+)
+
+// OpenAITool declares a client-side function the model may call
+type OpenAITool struct {
+	Type     string            `json:"type"` // Only "function" is supported
+	Function OpenAIFunctionDef `json:"function"`
+}
+
+// OpenAIFunctionDef describes a client-side function and its JSON schema parameters
+type OpenAIFunctionDef struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Parameters  *jsonschema.Schema `json:"parameters,omitempty"`
+}
+
+// OpenAIToolCall is a tool call of an assistant message
+type OpenAIToolCall struct {
+	Index    *int               `json:"index,omitempty"` // Position in the message (stream deltas only)
+	ID       string             `json:"id,omitempty"`    // Only on the first delta of a streamed call
+	Type     string             `json:"type,omitempty"`  // Only on the first delta of a streamed call
+	Function OpenAIFunctionCall `json:"function"`
+}
+
+// OpenAIFunctionCall names the called function and its JSON arguments
+type OpenAIFunctionCall struct {
+	Name      string `json:"name,omitempty"` // Only on the first delta of a streamed call
+	Arguments string `json:"arguments"`
+}
+
+// chatTurn is a chat completion request mapped to agent input
+type chatTurn struct {
+	sessionID   string
+	userMessage string                    // Text of the final user message
+	parts       []schema.MessageInputPart // Content parts of a multi-modal user message
+	messages    []*schema.Message         // Conversation sent by the client; nil continues the stored session
+	opts        []agent.ChatOption
+
+	n            int             // Number of choices to generate
+	includeUsage bool            // Send a usage chunk at the end of a stream
+	outputSchema json.RawMessage // JSON schema the answer must match (response_format)
+
+	agent *agent.Agent // Agent serving the requested model
+	model string       // Model name reported in the response
+}
+
+// newChatTurn maps a chat completion request to a turn.
+// System messages become per-request instructions. A lone user message continues the stored session;
+// a longer conversation replaces it. Declared tools become client tools.
+func newChatTurn(req *OpenAIRequest) (*chatTurn, error) {
+	turn := &chatTurn{sessionID: req.Session, n: 1}
+	if req.N != nil {
+		if *req.N < 1 || *req.N > maxChoices {
+			return nil, invalidParam("n", "n must be between 1 and %d", maxChoices)
+		}
+		if *req.N > 1 && req.Stream {
+			return nil, invalidParam("n", "n greater than 1 is not supported with stream")
+		}
+		turn.n = *req.N
+	}
+	if req.StreamOptions != nil {
+		turn.includeUsage = req.StreamOptions.IncludeUsage
+	}
+
+	opts, err := generationOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	turn.opts = opts
+
+	opts, err = toolOptions(req.Tools, req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	turn.opts = append(turn.opts, opts...)
+
+	var instructions []string
+	var conversation []OpenAIMessage
+	for _, msg := range req.Messages {
+		if msg.Role == "system" || msg.Role == "developer" {
+			if msg.Content != "" {
+				instructions = append(instructions, msg.Content)
+			}
+			continue
+		}
+		conversation = append(conversation, msg)
+	}
+	if turn.outputSchema, err = req.ResponseFormat.outputSchema(); err != nil {
+		return nil, err
+	}
+	if turn.outputSchema != nil {
+		instructions = append(instructions, agent.StructuredInstructions(turn.outputSchema))
+	}
+	if len(instructions) > 0 {
+		turn.opts = append(turn.opts, agent.WithInstructions(strings.Join(instructions, "\n\n")))
+	}
+
+	if len(conversation) == 0 {
+		return nil, invalidParam("messages", "no user message found")
+	}
+	last := conversation[len(conversation)-1]
+	if len(conversation) == 1 {
+		// A lone user message continues the stored session
+		if last.Role != "user" {
+			return nil, invalidParam("messages", "no user message found")
+		}
+		turn.userMessage = last.Content
+		if last.hasImages() {
+			turn.parts = last.inputParts()
+		}
+		if turn.userMessage == "" && len(turn.parts) == 0 {
+			return nil, invalidParam("messages", "no user message found")
+		}
+		return turn, nil
+	}
+
+	// A conversation ends with a new user message or with the results of client tool calls
+	if last.Role != "user" && last.Role != "tool" {
+		return nil, invalidParam("messages", "last message must be a user or tool message")
+	}
+	if last.Role == "user" {
+		turn.userMessage = last.Content
+	}
+	turn.messages = make([]*schema.Message, 0, len(conversation))
+	for i := range conversation {
+		msg, err := conversation[i].toSchema()
+		if err != nil {
+			return nil, invalidParam("messages", "message %d: %w", i, err)
+		}
+		turn.messages = append(turn.messages, msg)
+	}
+	return turn, nil
+}
+
+// generationOptions converts the sampling parameters of a request to chat options
+func generationOptions(req *OpenAIRequest) ([]agent.ChatOption, error) {
+	var opts []agent.ChatOption
+	if req.Temperature != nil {
+		if *req.Temperature < 0 || *req.Temperature > 2 {
+			return nil, invalidParam("temperature", "temperature must be between 0 and 2")
+		}
+		opts = append(opts, agent.WithTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		if *req.TopP < 0 || *req.TopP > 1 {
+			return nil, invalidParam("top_p", "top_p must be between 0 and 1")
+		}
+		opts = append(opts, agent.WithTopP(*req.TopP))
+	}
+
+	maxTokens := req.MaxTokens
+	if req.MaxCompletionTokens != nil {
+		maxTokens = req.MaxCompletionTokens
+	}
+	if maxTokens != nil {
+		if *maxTokens <= 0 {
+			return nil, invalidParam("max_tokens", "max_tokens must be positive")
+		}
+		opts = append(opts, agent.WithMaxTokens(*maxTokens))
+	}
+	if req.Seed != nil {
+		opts = append(opts, agent.WithSeed(*req.Seed))
+	}
+	if len(req.LogitBias) > 0 {
+		for token, bias := range req.LogitBias {
+			if _, err := strconv.Atoi(token); err != nil {
+				return nil, invalidParam("logit_bias", "logit_bias keys must be token IDs, got %q", token)
+			}
+			if bias < -100 || bias > 100 {
+				return nil, invalidParam("logit_bias", "logit_bias values must be between -100 and 100")
+			}
+		}
+		opts = append(opts, agent.WithLogitBias(req.LogitBias))
+	}
+
+	if len(req.Stop) == 0 || string(req.Stop) == "null" {
+		return opts, nil
+	}
+	var stop []string
+	var single string
+	if err := json.Unmarshal(req.Stop, &single); err == nil {
+		stop = []string{single}
+	} else if err := json.Unmarshal(req.Stop, &stop); err != nil {
+		return nil, invalidParam("stop", "stop must be a string or an array of strings")
+	}
+	if len(stop) > 4 {
+		return nil, invalidParam("stop", "stop accepts at most 4 sequences")
+	}
+	if len(stop) > 0 {
+		opts = append(opts, agent.WithStop(stop...))
+	}
+	return opts, nil
+}
+
+// toolOptions converts the declared tools and the tool choice to chat options
+func toolOptions(tools []OpenAITool, toolChoice json.RawMessage) ([]agent.ChatOption, error) {
+	var opts []agent.ChatOption
+	if len(tools) > 0 {
+		infos := make([]*schema.ToolInfo, 0, len(tools))
+		for i, t := range tools {
+			if t.Type != "" && t.Type != "function" {
+				return nil, invalidParam("tools", "tool %d: unsupported type %q", i, t.Type)
+			}
+			if t.Function.Name == "" {
+				return nil, invalidParam("tools", "tool %d: function name is required", i)
+			}
+			info := &schema.ToolInfo{Name: t.Function.Name, Desc: t.Function.Description}
+			if t.Function.Parameters != nil {
+				info.ParamsOneOf = schema.NewParamsOneOfByJSONSchema(t.Function.Parameters)
+			}
+			infos = append(infos, info)
+		}
+		opts = append(opts, agent.WithClientTools(infos...))
+	}
+
+	if len(toolChoice) == 0 || string(toolChoice) == "null" {
+		return opts, nil
+	}
+	var mode string
+	if err := json.Unmarshal(toolChoice, &mode); err == nil {
+		switch mode {
+		case "auto":
+		case "none":
+			opts = append(opts, agent.WithToolChoice(schema.ToolChoiceForbidden))
+		case "required":
+			opts = append(opts, agent.WithToolChoice(schema.ToolChoiceForced))
+		default:
+			return nil, invalidParam("tool_choice", "invalid tool_choice %q", mode)
+		}
+		return opts, nil
+	}
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(toolChoice, &named); err != nil || named.Function.Name == "" {
+		return nil, invalidParam("tool_choice", "invalid tool_choice: %s", toolChoice)
+	}
+	return append(opts, agent.WithToolChoice(schema.ToolChoiceForced, named.Function.Name)), nil
+}
+
+// toOpenAIToolCalls converts tool calls for a response; stream deltas carry their index
+func toOpenAIToolCalls(calls []schema.ToolCall, withIndex bool) []OpenAIToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	out := make([]OpenAIToolCall, 0, len(calls))
+	for _, tc := range calls {
+		call := OpenAIToolCall{
+			ID:       tc.ID,
+			Type:     "function",
+			Function: OpenAIFunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+		}
+		if withIndex {
+			call.Index = tc.Index
+		}
+		out = append(out, call)
+	}
+	return out
+}
+
+// toolCallDelta converts a streamed tool call fragment; only the first fragment of a call has its ID and type
+func toolCallDelta(tc schema.ToolCall, index int) OpenAIToolCall {
+	call := OpenAIToolCall{
+		Index:    &index,
+		ID:       tc.ID,
+		Function: OpenAIFunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+	}
+	if tc.ID != "" {
+		call.Type = "function"
+	}
+	return call
+}
+
+// toSchema converts a history message to an agent message
+func (m *OpenAIMessage) toSchema() (*schema.Message, error) {
+	switch m.Role {
+	case "user":
+		if m.hasImages() {
+			return &schema.Message{Role: schema.User, UserInputMultiContent: m.inputParts()}, nil
+		}
+		return schema.UserMessage(m.Content), nil
+	case "assistant":
+		var toolCalls []schema.ToolCall
+		for _, tc := range m.ToolCalls {
+			toolCalls = append(toolCalls, schema.ToolCall{
+				ID:       tc.ID,
+				Type:     tc.Type,
+				Function: schema.FunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+			})
+		}
+		return schema.AssistantMessage(m.Content, toolCalls), nil
+	case "tool":
+		return schema.ToolMessage(m.Content, m.ToolCallID), nil
+	default:
+		return nil, fmt.Errorf("unsupported role %q", m.Role)
+	}
+}
+
+// chat runs a non-streaming turn, replaying the client history when one was sent
+func (s *Server) chat(ctx context.Context, turn *chatTurn) (*schema.Message, error) {
+	switch {
+	case turn.messages != nil:
+		return turn.agent.ChatWithMessages(ctx, turn.sessionID, turn.messages, turn.opts...)
+	case len(turn.parts) > 0:
+		return turn.agent.ChatMultiModal(ctx, turn.sessionID, turn.parts, turn.opts...)
+	default:
+		return turn.agent.Chat(ctx, turn.sessionID, turn.userMessage, turn.opts...)
+	}
+}
+
+// chatStream is the streaming variant of chat
+func (s *Server) chatStream(ctx context.Context, turn *chatTurn) (*schema.StreamReader[*agent.StreamEvent], error) {
+	switch {
+	case turn.messages != nil:
+		return turn.agent.ChatStreamWithMessages(ctx, turn.sessionID, turn.messages, turn.opts...)
+	case len(turn.parts) > 0:
+		return turn.agent.ChatStreamMultiModal(ctx, turn.sessionID, turn.parts, turn.opts...)
+	default:
+		return turn.agent.ChatStream(ctx, turn.sessionID, turn.userMessage, turn.opts...)
+	}
+}
+
+// fromSchema converts a stored session message for a response; multi-modal text parts are joined
+func fromSchema(msg *schema.Message) OpenAIMessage {
+	out := OpenAIMessage{
+		Role:             string(msg.Role),
+		Content:          msg.Content,
+		ReasoningContent: msg.ReasoningContent,
+		ToolCalls:        toOpenAIToolCalls(msg.ToolCalls, false),
+		ToolCallID:       msg.ToolCallID,
+	}
+	if out.Content == "" {
+		var texts []string
+		for _, part := range msg.UserInputMultiContent {
+			if part.Type == schema.ChatMessagePartTypeText {
+				texts = append(texts, part.Text)
+			}
+		}
+		out.Content = strings.Join(texts, "\n")
+	}
+	return out
+}
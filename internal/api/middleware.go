@@ -0,0 +1,26 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+// WithMiddleware installs middlewares for every route, such as authentication, tracing or custom headers.
+// They run in the given order after the built-in request ID, CORS and rate limit middlewares; repeated
+// options append.
+func WithMiddleware(middlewares ...app.HandlerFunc) Option {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, middlewares...)
+	}
+}
+
+// WithHertz registers the API on an existing Hertz instance instead of creating one, to compose it into
+// a service with routes of its own. The instance's address, TLS and body size settings are then the
+// caller's, so the address given to NewServer and WithTLS are ignored. Routes registered on the instance
+// before NewServer do not pass through the API's middlewares; routes registered afterwards do.
+func WithHertz(h *server.Hertz) Option {
+	return func(s *Server) {
+		s.httpServer = h
+	}
+}
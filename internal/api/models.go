@@ -0,0 +1,80 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+)
+
+// WithAgents serves every model of the pool and routes chat requests by their model field.
+// Requests without a model go to the pool's default model.
+func WithAgents(pool *agent.Pool) Option {
+	return func(s *Server) {
+		s.pool = pool
+	}
+}
+
+// modelAgent returns the agent serving a requested model and the model name to report
+func (s *Server) modelAgent(model string) (*agent.Agent, string, bool) {
+	if s.pool == nil {
+		// A single agent answers whatever model is requested
+		return s.agent, s.modelName, true
+	}
+	a, ok := s.pool.Get(model)
+	if !ok {
+		return nil, "", false
+	}
+	if model == "" {
+		model = s.pool.DefaultModel()
+	}
+	return a, model, true
+}
+
+// approvalAgent returns the agent with pending approvals for the session, or the default agent, and its model name
+func (s *Server) approvalAgent(sessionID string) (*agent.Agent, string) {
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		if len(a.PendingToolCalls(sessionID)) > 0 {
+			return a, name
+		}
+	}
+	a, name, _ := s.modelAgent("")
+	return a, name
+}
+
+// modelNames returns the served model names, the default first
+func (s *Server) modelNames() []string {
+	if s.pool == nil {
+		return []string{s.modelName}
+	}
+	names := []string{s.pool.DefaultModel()}
+	for _, name := range s.pool.Models() {
+		if name != names[0] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// handleListModels handles model listing requests
+func (s *Server) handleListModels(ctx context.Context, c *app.RequestContext) {
+	created := time.Now().Unix()
+	data := make([]map[string]interface{}, 0, len(s.modelNames()))
+	for _, name := range s.modelNames() {
+		data = append(data, map[string]interface{}{
+			"id":       name,
+			"object":   "model",
+			"created":  created,
+			"owned_by": "eino-ai-agent",
+		})
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   data,
+	})
+}
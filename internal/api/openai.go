@@ -2,21 +2,36 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/schema"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/config"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/google/uuid"
 	"github.com/hertz-contrib/sse"
 
 	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/files"
 	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/mcp"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+	"github.com/fourhu/eino-ai-agent/internal/metrics"
+	"github.com/fourhu/eino-ai-agent/internal/progress"
+	"github.com/fourhu/eino-ai-agent/internal/rag"
+	"github.com/fourhu/eino-ai-agent/internal/tasks"
 )
 
 // OpenAIRequest represents an OpenAI-compatible chat completion request
@@ -25,23 +40,59 @@ type OpenAIRequest struct {
 	Messages []OpenAIMessage        `json:"messages"`
 	Stream   bool                   `json:"stream,omitempty"`
 	Session  string                 `json:"session,omitempty"`
+	User     string                 `json:"user,omitempty"` // End-user identifier; selects the session when enabled (see WithUserSessions)
 	Options  map[string]interface{} `json:"options,omitempty"`
+
+	// Generation parameters; unset ones fall back to the model defaults
+	Temperature         *float32        `json:"temperature,omitempty"`
+	TopP                *float32        `json:"top_p,omitempty"`
+	MaxTokens           *int            `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int            `json:"max_completion_tokens,omitempty"` // Newer name of max_tokens, preferred when both are set
+	Stop                json.RawMessage `json:"stop,omitempty"`                  // A string or an array of up to 4 strings
+	Seed                *int            `json:"seed,omitempty"`                  // Deterministic sampling, on backends that support it
+	LogitBias           map[string]int  `json:"logit_bias,omitempty"`            // Token ID -> bias in [-100, 100], on backends that support it
+	N                   *int            `json:"n,omitempty"`                     // Number of choices; each is a full agent run, only the first is stored
+
+	// Tools declares client-side functions; calls to them are returned to the client instead of being run
+	Tools      []OpenAITool    `json:"tools,omitempty"`
+	ToolChoice json.RawMessage `json:"tool_choice,omitempty"` // "none", "auto", "required" or {"type":"function","function":{"name":...}}
+
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
+	// ResponseFormat requests a JSON answer; it is validated and corrected by the model before it is returned
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// StreamOptions configures a streaming response
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // Send a usage chunk without choices before [DONE]
 }
 
 // OpenAIMessage represents a message in OpenAI format
 type OpenAIMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+
+	// ReasoningContent is the model's thinking, kept apart from the answer (responses only)
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+
+	// ToolCalls and ToolCallID carry prior tool activity in the request history
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+
+	// Parts holds the content-part array of multi-modal requests; Content then joins the text parts
+	Parts []ContentPart `json:"-"`
 }
 
 // OpenAIResponse represents an OpenAI-compatible chat completion response
 type OpenAIResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
+	ID                string   `json:"id"`
+	Object            string   `json:"object"`
+	Created           int64    `json:"created"`
+	Model             string   `json:"model"`
+	SystemFingerprint string   `json:"system_fingerprint"` // Changes with the system prompt, tools or step limit
+	Choices           []Choice `json:"choices"`
+	Usage             Usage    `json:"usage"`
 }
 
 // Choice represents a completion choice
@@ -57,42 +108,227 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down prompt tokens
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// CompletionTokensDetails breaks down completion tokens
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// toUsage converts the usage the agent summed over every model call of a turn (zero if the model reported none)
+func toUsage(usage *schema.TokenUsage) Usage {
+	if usage == nil {
+		return Usage{}
+	}
+	u := Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+	if usage.PromptTokenDetails.CachedTokens > 0 {
+		u.PromptTokensDetails = &PromptTokensDetails{CachedTokens: usage.PromptTokenDetails.CachedTokens}
+	}
+	if usage.CompletionTokensDetails.ReasoningTokens > 0 {
+		u.CompletionTokensDetails = &CompletionTokensDetails{ReasoningTokens: usage.CompletionTokensDetails.ReasoningTokens}
+	}
+	return u
 }
 
 // OpenAIStreamEvent represents a server-sent event for streaming
 type OpenAIStreamEvent struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
+	ID                string   `json:"id"`
+	Object            string   `json:"object"`
+	Created           int64    `json:"created"`
+	Model             string   `json:"model"`
+	SystemFingerprint string   `json:"system_fingerprint"`
+	Choices           []Choice `json:"choices"`
+	Usage             *Usage   `json:"usage,omitempty"` // Final usage chunk only
+}
+
+// ToolActivityEvent describes a tool call, its progress or its result in the SSE stream
+type ToolActivityEvent struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	Arguments string           `json:"arguments,omitempty"`
+	Result    string           `json:"result,omitempty"`
+	Progress  *progress.Update `json:"progress,omitempty"`
 }
 
 // Server handles OpenAI-compatible API requests
 type Server struct {
-	agent      *agent.Agent
-	modelName  string
-	httpServer *server.Hertz
+	agent          *agent.Agent
+	modelName      string
+	httpServer     *server.Hertz
+	metricsPath    string
+	streamTrailers bool
+	rag            *rag.Service
+	tasks          *tasks.Scheduler
+	rateLimiter    *rateLimiter
+	cors           *CORSConfig
+	pool           *agent.Pool
+	requestTimeout time.Duration
+	debug          bool
+	debugToken     string
+	admin          bool
+	adminToken     string
+	mcp            *mcp.Manager
+	startedAt      time.Time
+	activeStreams  atomic.Int64
+	embedder       embedding.Embedder
+	embeddingModel string
+	tls            *tls.Config
+	files          *files.Service
+	transcription  *transcriptionBackend
+	healthChecks   []HealthCheck
+	idempotency    *idempotencyCache
+	runLimiter     *runLimiter
+
+	userSessions           bool
+	sessionPerConversation bool
+	middlewares            []app.HandlerFunc
+	webUI                  bool
+	openAPI                []byte
+	usage                  memory.UsageStore
+	slowRequest            time.Duration
+	tenancy                *tenancy
+	quotas                 *spendQuotas
+	reload                 ReloadFunc
 }
 
-// NewServer creates a new OpenAI-compatible API server
-func NewServer(agent *agent.Agent, modelName string, addr string) *Server {
-	h := server.Default(server.WithHostPorts(addr))
+// Option configures optional Server behavior
+type Option func(*Server)
+
+// WithMetrics exposes the metrics registry at the given path (default "/metrics")
+func WithMetrics(path string) Option {
+	return func(s *Server) {
+		if path == "" {
+			path = "/metrics"
+		}
+		s.metricsPath = path
+	}
+}
 
+// WithStreamTrailers emits streaming timings (TTFT, duration, chunk count) as HTTP trailers
+func WithStreamTrailers(enabled bool) Option {
+	return func(s *Server) {
+		s.streamTrailers = enabled
+	}
+}
+
+// NewServer creates a new OpenAI-compatible API server
+func NewServer(agent *agent.Agent, modelName string, addr string, opts ...Option) *Server {
 	s := &Server{
-		agent:      agent,
-		modelName:  modelName,
-		httpServer: h,
+		agent:     agent,
+		modelName: modelName,
+		startedAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.httpServer == nil {
+		// Sensing disconnections cancels the request context, and with it the run, when a client goes away
+		hertzOpts := []config.Option{server.WithHostPorts(addr), server.WithSenseClientDisconnection(true)}
+		if s.tls != nil {
+			hertzOpts = append(hertzOpts, server.WithTLS(s.tls))
+		}
+		if size := s.maxRequestBodySize(); size > 0 {
+			hertzOpts = append(hertzOpts, server.WithMaxRequestBodySize(size))
+		}
+		s.httpServer = server.Default(hertzOpts...)
+	}
+	h := s.httpServer
+
+	h.Use(s.requestIDMiddleware)
+	if s.cors != nil {
+		h.Use(s.corsMiddleware)
+		// Give preflight requests a route so the middleware runs for them
+		h.OPTIONS("/*path", func(ctx context.Context, c *app.RequestContext) {
+			c.Status(consts.StatusNoContent)
+		})
+	}
+	if s.rateLimiter != nil {
+		h.Use(s.rateLimitMiddleware)
+	}
+	if s.tenancy != nil {
+		h.Use(s.tenantMiddleware)
+	}
+	h.Use(s.callerMiddleware)
+	if len(s.middlewares) > 0 {
+		h.Use(s.middlewares...)
 	}
 
 	// Register routes
-	h.POST("/v1/chat/completions", s.handleChatCompletions)
+	h.POST("/v1/chat/completions", s.runHandlers(s.handleChatCompletions)...)
+	h.POST("/v1/messages", s.runHandlers(s.handleAnthropicMessages)...)
+	h.POST("/v1/responses", s.runHandlers(s.handleResponses)...)
 	h.GET("/v1/models", s.handleListModels)
+	if s.embedder != nil {
+		h.POST("/v1/embeddings", s.handleEmbeddings)
+	}
 	h.GET("/health", s.handleHealth)
+	if s.metricsPath != "" {
+		h.GET(s.metricsPath, s.handleMetrics)
+	}
+	if s.rag != nil {
+		s.registerRAGRoutes()
+	}
+	if s.tasks != nil {
+		s.registerTaskRoutes()
+	}
+	if s.files != nil {
+		s.registerFileRoutes()
+	}
+	if s.transcription != nil {
+		h.POST("/v1/audio/transcriptions", s.handleTranscription)
+	}
+	if s.usage != nil {
+		h.GET("/v1/usage", s.handleUsage)
+	}
+	s.registerSessionRoutes()
+	s.registerApprovalRoutes()
+	if s.debug {
+		s.registerDebugRoutes()
+	}
+	if s.admin {
+		s.registerAdminRoutes()
+	}
+	if s.webUI {
+		h.GET("/", s.handleWebUI)
+	}
+	h.GET(openAPIPath, s.handleOpenAPI)
+	s.openAPI = s.buildOpenAPI()
 
 	return s
 }
 
+// maxRequestBodySize returns a request body limit that fits the enabled upload endpoints,
+// or 0 to keep the Hertz default of 4 MiB
+func (s *Server) maxRequestBodySize() int {
+	size := 0
+	if s.rag != nil {
+		size = maxIngestBytes
+	}
+	if s.files != nil {
+		size = max(size, s.files.MaxBytes())
+	}
+	if s.transcription != nil {
+		size = max(size, maxAudioBytes)
+	}
+	if size == 0 {
+		return 0
+	}
+	return max(size+1<<20, 4<<20) // Room for the multipart envelope
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	return s.httpServer.Run()
@@ -107,116 +343,220 @@ func (s *Server) Stop(ctx context.Context) error {
 func (s *Server) handleChatCompletions(ctx context.Context, c *app.RequestContext) {
 	var req OpenAIRequest
 	if err := c.BindJSON(&req); err != nil {
-		logger.Errorf("Failed to parse request: %v", err)
-		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": fmt.Sprintf("invalid request: %v", err),
-		})
+		logger.Ctx(ctx).Errorf("Failed to parse request: %v", err)
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("invalid request: %w", err))
 		return
 	}
 
-	// Generate session ID if not provided
+	// Derive the session from the user, or generate one, if not provided
+	if req.Session == "" && req.User != "" && s.userSessions {
+		req.Session = userSession(req.User, req.Messages, s.sessionPerConversation)
+	}
 	if req.Session == "" {
 		req.Session = uuid.New().String()
 	}
+	session, err := s.scopeSession(c, req.Session)
+	if err != nil {
+		writeError(c, consts.StatusBadRequest, "", err)
+		return
+	}
+	req.Session = session
+	if quotaErr := s.checkSpend(ctx, c, req.Session); quotaErr != nil {
+		writeQuotaError(c, quotaErr)
+		return
+	}
 
-	logger.Debugf("[API] Received chat completion request - Session: %s, Model: %s, Stream: %v, Messages: %d",
+	logger.Ctx(ctx).Debugf("[API] Received chat completion request - Session: %s, Model: %s, Stream: %v, Messages: %d",
 		req.Session, req.Model, req.Stream, len(req.Messages))
 
-	// Convert messages to a single user message (simplified)
-	var userMessage string
-	if len(req.Messages) > 0 {
-		lastMsg := req.Messages[len(req.Messages)-1]
-		if lastMsg.Role == "user" {
-			userMessage = lastMsg.Content
-		}
+	if err := s.attachFiles(ctx, c.GetString(tenantKey), req.Messages); err != nil {
+		logger.Ctx(ctx).Errorf("[API] Invalid file reference - Session: %s, Error: %v", req.Session, err)
+		writeError(c, consts.StatusBadRequest, "", err)
+		return
+	}
+	turn, err := newChatTurn(&req)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Invalid request - Session: %s, Error: %v", req.Session, err)
+		writeError(c, consts.StatusBadRequest, "", err)
+		return
 	}
 
-	if userMessage == "" {
-		logger.Errorf("[API] No user message found in request - Session: %s", req.Session)
-		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "no user message found",
-		})
+	var ok bool
+	if turn.agent, turn.model, ok = s.modelAgent(req.Model); !ok {
+		writeError(c, consts.StatusNotFound, "model_not_found", invalidParam("model", "model %s does not exist", req.Model))
 		return
 	}
 
-	logger.Debugf("[API] Processing request - Session: %s, UserMessage: %s", req.Session, userMessage)
+	if key := string(c.GetHeader("Idempotency-Key")); key != "" && s.idempotency != nil && !req.Stream {
+		finish, ok := s.idempotency.begin(ctx, c, key)
+		if !ok {
+			return
+		}
+		defer finish()
+	}
+
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
 
 	if req.Stream {
-		s.handleStreamResponse(ctx, c, req.Session, userMessage)
+		s.handleStreamResponse(ctx, c, turn)
 	} else {
-		s.handleNonStreamResponse(ctx, c, req.Session, userMessage)
+		s.handleNonStreamResponse(ctx, c, turn)
 	}
 }
 
 // handleNonStreamResponse handles non-streaming responses
-func (s *Server) handleNonStreamResponse(ctx context.Context, c *app.RequestContext, sessionID, userMessage string) {
-	logger.Debugf("[API] Handling non-stream response - Session: %s", sessionID)
+func (s *Server) handleNonStreamResponse(ctx context.Context, c *app.RequestContext, turn *chatTurn) {
+	sessionID := turn.sessionID
+	recordSession(c, sessionID)
+	logger.Ctx(ctx).Debugf("[API] Handling non-stream response - Session: %s", sessionID)
 
-	response, err := s.agent.Chat(ctx, sessionID, userMessage)
+	finishReason := "stop"
+	waitChoices, err := s.startExtraChoices(ctx, turn)
 	if err != nil {
-		logger.Errorf("[API] Chat failed - Session: %s, Error: %v", sessionID, err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("chat failed: %v", err),
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			logger.Ctx(ctx).Warnf("[API] Timed out waiting for %d choices - Session: %s", turn.n, sessionID)
+			writeError(c, consts.StatusGatewayTimeout, "timeout", errors.New("request timed out"))
+			return
+		case ctx.Err() != nil:
+			writeError(c, consts.StatusServiceUnavailable, "cancelled", errors.New("request cancelled"))
+			return
+		}
+		logger.Ctx(ctx).Warnf("[API] Rejected %d choices - Session: %s: %v", turn.n, sessionID, err)
+		c.Response.Header.Set("Retry-After", "1")
+		writeError(c, consts.StatusTooManyRequests, "concurrency_limit_exceeded", err)
+		return
+	}
+	response, err := s.chat(ctx, turn)
+	if errors.Is(err, agent.ErrRunCancelled) {
+		writeError(c, consts.StatusConflict, "cancelled", agent.ErrRunCancelled)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Ctx(ctx).Warnf("[API] Chat timed out - Session: %s", sessionID)
+		writeError(c, consts.StatusGatewayTimeout, "timeout", errors.New("request timed out"))
+		return
+	}
+	var approvalErr *agent.ApprovalRequiredError
+	if errors.As(err, &approvalErr) {
+		logger.Ctx(ctx).Infof("[API] Run paused for approval - Session: %s", sessionID)
+		c.JSON(consts.StatusAccepted, map[string]interface{}{
+			"session":           s.publicSession(sessionID),
+			"status":            "approval_required",
+			"pending_approvals": approvalErr.Requests,
 		})
 		return
 	}
+	if writeLimitError(c, err) {
+		return
+	}
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) {
+		if guardErr.Stage == agent.GuardrailInput {
+			writeError(c, consts.StatusBadRequest, "content_filter", guardErr)
+			return
+		}
+		// A blocked answer is reported like OpenAI's content filter
+		response, err = schema.AssistantMessage("", nil), nil
+		finishReason = "content_filter"
+	}
+	if err == nil && turn.outputSchema != nil && finishReason != "content_filter" {
+		response, err = turn.agent.EnsureStructured(ctx, sessionID, response, turn.outputSchema, turn.opts...)
+	}
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Chat failed - Session: %s, Error: %v", sessionID, err)
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("chat failed: %w", err))
+		return
+	}
+
+	logger.Ctx(ctx).Debugf("[API] Chat completed - Session: %s, ResponseLength: %d", sessionID, len(response.Content))
 
-	logger.Debugf("[API] Chat completed - Session: %s, ResponseLength: %d", sessionID, len(response.Content))
+	choices := []Choice{toChoice(0, response, finishReason)}
+	usage := usageOf(response)
+	for i, extra := range waitChoices() {
+		if extra.err != nil {
+			logger.Ctx(ctx).Errorf("[API] Chat failed - Session: %s, Choice: %d, Error: %v", sessionID, i+1, extra.err)
+			writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("chat failed for choice %d: %w", i+1, extra.err))
+			return
+		}
+		choices = append(choices, toChoice(i+1, extra.message, extra.finishReason))
+		usage = sumUsage(usage, usageOf(extra.message))
+	}
 
 	resp := OpenAIResponse{
-		ID:      fmt.Sprintf("chatcmpl-%s", uuid.New().String()),
-		Object:  "chat.completion",
-		Created: time.Now().Unix(),
-		Model:   s.modelName,
-		Choices: []Choice{
-			{
-				Index: 0,
-				Message: &OpenAIMessage{
-					Role:    "assistant",
-					Content: response.Content,
-				},
-				FinishReason: "stop",
-			},
-		},
-		Usage: Usage{
-			PromptTokens:     len(userMessage),
-			CompletionTokens: len(response.Content),
-			TotalTokens:      len(userMessage) + len(response.Content),
-		},
+		ID:                fmt.Sprintf("chatcmpl-%s", uuid.New().String()),
+		Object:            "chat.completion",
+		Created:           time.Now().Unix(),
+		Model:             turn.model,
+		SystemFingerprint: turn.agent.Fingerprint(ctx),
+		Choices:           choices,
+		Usage:             toUsage(usage),
 	}
 
+	recordUsage(c, usage)
 	c.JSON(consts.StatusOK, resp)
 }
 
 // handleStreamResponse handles streaming responses
-func (s *Server) handleStreamResponse(ctx context.Context, c *app.RequestContext, sessionID, userMessage string) {
-	logger.Debugf("[API] Handling stream response - Session: %s", sessionID)
-
-	stream, err := s.agent.ChatStream(ctx, sessionID, userMessage)
+func (s *Server) handleStreamResponse(ctx context.Context, c *app.RequestContext, turn *chatTurn) {
+	sessionID := turn.sessionID
+	recordSession(c, sessionID)
+	logger.Ctx(ctx).Debugf("[API] Handling stream response - Session: %s", sessionID)
+
+	var stream *schema.StreamReader[*agent.StreamEvent]
+	var err error
+	if turn.outputSchema != nil {
+		stream, err = s.structuredStream(ctx, turn)
+	} else {
+		stream, err = s.chatStream(ctx, turn)
+	}
+	if writeLimitError(c, err) {
+		return
+	}
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) {
+		writeError(c, consts.StatusBadRequest, "content_filter", guardErr)
+		return
+	}
 	if err != nil {
-		logger.Errorf("[API] Chat stream failed - Session: %s, Error: %v", sessionID, err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("chat stream failed: %v", err),
-		})
+		logger.Ctx(ctx).Errorf("[API] Chat stream failed - Session: %s, Error: %v", sessionID, err)
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("chat stream failed: %w", err))
 		return
 	}
 
+	s.writeStream(ctx, c, turn.agent, turn.model, sessionID, stream, turn.includeUsage)
+}
+
+// writeStream relays agent stream events to the client as OpenAI-compatible SSE chunks.
+// The stream ends with the finish chunk, the usage chunk if requested, and the [DONE] sentinel.
+func (s *Server) writeStream(ctx context.Context, c *app.RequestContext, a *agent.Agent, model, sessionID string, stream *schema.StreamReader[*agent.StreamEvent], includeUsage bool) {
+	defer stream.Close()
+	s.activeStreams.Add(1)
+	defer s.activeStreams.Add(-1)
+
 	// Set SSE headers
 	c.SetContentType("text/event-stream")
 	c.Response.Header.Set("Cache-Control", "no-cache")
 	c.Response.Header.Set("Connection", "keep-alive")
+	if s.streamTrailers {
+		declareStreamTrailers(c)
+	}
 
+	stats := newStreamStats(model, requestStart(c))
 	sseStream := sse.NewStream(c)
 
 	completionID := fmt.Sprintf("chatcmpl-%s", uuid.New().String())
 	created := time.Now().Unix()
+	fingerprint := a.Fingerprint(ctx)
 
 	// Send initial role message
 	initialEvent := OpenAIStreamEvent{
-		ID:      completionID,
-		Object:  "chat.completion.chunk",
-		Created: created,
-		Model:   s.modelName,
+		ID:                completionID,
+		Object:            "chat.completion.chunk",
+		Created:           created,
+		Model:             model,
+		SystemFingerprint: fingerprint,
 		Choices: []Choice{
 			{
 				Index: 0,
@@ -229,49 +569,152 @@ func (s *Server) handleStreamResponse(ctx context.Context, c *app.RequestContext
 	s.sendSSEEvent(sseStream, initialEvent)
 
 	// Stream content
-	var fullContent string
+	var fullContent, fullReasoning string
+	var clientCalls []schema.ToolCall
+	streamedCalls := map[int]int{} // Delta index of each client tool call streamed in fragments, by model index
+	nextCallIndex := 0
+	var usage *schema.TokenUsage
+	var streamErr error
 	chunkCount := 0
+	paused, filtered := false, false
 	for {
 		chunk, err := stream.Recv()
 		if err == io.EOF {
-			logger.Debugf("[API] Stream ended - Session: %s, TotalChunks: %d", sessionID, chunkCount)
+			logger.Ctx(ctx).Debugf("[API] Stream ended - Session: %s, TotalChunks: %d", sessionID, chunkCount)
 			break
 		}
 		if err != nil {
-			logger.Errorf("[API] Stream error - Session: %s, Error: %v", sessionID, err)
+			logger.Ctx(ctx).Errorf("[API] Stream error - Session: %s, Error: %v", sessionID, err)
+			streamErr = err
+			break
+		}
+
+		switch chunk.Type {
+		case agent.EventToolCallStarted:
+			s.sendToolEvent(sseStream, string(chunk.Type), ToolActivityEvent{
+				ID:        chunk.ToolCall.ID,
+				Name:      chunk.ToolCall.Function.Name,
+				Arguments: chunk.ToolCall.Function.Arguments,
+			})
+		case agent.EventToolCallDelta:
+			index, ok := streamedCalls[*chunk.ToolCall.Index]
+			if !ok {
+				index = nextCallIndex
+				nextCallIndex++
+				streamedCalls[*chunk.ToolCall.Index] = index
+			}
+			stats.chunk()
 			s.sendSSEEvent(sseStream, OpenAIStreamEvent{
-				ID:      completionID,
-				Object:  "chat.completion.chunk",
-				Created: created,
-				Model:   s.modelName,
+				ID:                completionID,
+				Object:            "chat.completion.chunk",
+				Created:           created,
+				Model:             model,
+				SystemFingerprint: fingerprint,
 				Choices: []Choice{
 					{
 						Index: 0,
 						Delta: &OpenAIMessage{
-							Content: fmt.Sprintf("[ERROR: %v]", err),
+							ToolCalls: []OpenAIToolCall{toolCallDelta(*chunk.ToolCall, index)},
 						},
 					},
 				},
 			})
-			break
-		}
-
-		if chunk.Content != "" {
-			fullContent += chunk.Content
+		case agent.EventClientToolCall:
+			tc := *chunk.ToolCall
+			if tc.Index != nil {
+				if index, streamed := streamedCalls[*tc.Index]; streamed {
+					// Its fragments were already sent
+					tc.Index = &index
+					clientCalls = append(clientCalls, tc)
+					continue
+				}
+			}
+			index := nextCallIndex
+			nextCallIndex++
+			tc.Index = &index
+			clientCalls = append(clientCalls, tc)
+			s.sendSSEEvent(sseStream, OpenAIStreamEvent{
+				ID:                completionID,
+				Object:            "chat.completion.chunk",
+				Created:           created,
+				Model:             model,
+				SystemFingerprint: fingerprint,
+				Choices: []Choice{
+					{
+						Index: 0,
+						Delta: &OpenAIMessage{
+							ToolCalls: toOpenAIToolCalls([]schema.ToolCall{tc}, true),
+						},
+					},
+				},
+			})
+		case agent.EventToolProgress:
+			s.sendToolEvent(sseStream, string(chunk.Type), ToolActivityEvent{
+				ID:       chunk.ToolCallID,
+				Name:     chunk.ToolName,
+				Progress: chunk.Progress,
+			})
+		case agent.EventToolResult:
+			s.sendToolEvent(sseStream, string(chunk.Type), ToolActivityEvent{
+				ID:     chunk.ToolCallID,
+				Name:   chunk.ToolName,
+				Result: chunk.Result,
+			})
+		case agent.EventUsage:
+			usage = chunk.Usage
+		case agent.EventCancelled:
+			logger.Ctx(ctx).Infof("[API] Stream cancelled - Session: %s", sessionID)
+			sseStream.Publish(&sse.Event{Event: string(chunk.Type), Data: []byte(`{"cancelled":true}`)})
+		case agent.EventApprovalRequired:
+			paused = true
+			s.sendApprovalEvent(sseStream, chunk.Approval)
+		case agent.EventLimitExceeded:
+			logger.Ctx(ctx).Warnf("[API] Stream aborted by limit - Session: %s, Error: %v", sessionID, chunk.Limit)
+			data, _ := json.Marshal(chunk.Limit)
+			sseStream.Publish(&sse.Event{Event: string(chunk.Type), Data: data})
+		case agent.EventGuardrailBlocked:
+			filtered = true
+			data, _ := json.Marshal(chunk.Guardrail)
+			sseStream.Publish(&sse.Event{Event: string(chunk.Type), Data: data})
+		case agent.EventReasoningDelta:
+			fullReasoning += chunk.Message.ReasoningContent
+			stats.chunk()
+			s.sendSSEEvent(sseStream, OpenAIStreamEvent{
+				ID:                completionID,
+				Object:            "chat.completion.chunk",
+				Created:           created,
+				Model:             model,
+				SystemFingerprint: fingerprint,
+				Choices: []Choice{
+					{
+						Index: 0,
+						Delta: &OpenAIMessage{
+							ReasoningContent: chunk.Message.ReasoningContent,
+						},
+					},
+				},
+			})
+		case agent.EventAssistantDelta:
+			if chunk.Message.Content == "" {
+				continue
+			}
+			fullContent += chunk.Message.Content
 			chunkCount++
+			stats.chunk()
 			if logger.IsDebugEnabled() && chunkCount%10 == 0 {
-				logger.Debugf("[API] Streaming chunk %d - Session: %s", chunkCount, sessionID)
+				logger.Ctx(ctx).Debugf("[API] Streaming chunk %d - Session: %s", chunkCount, sessionID)
 			}
 			event := OpenAIStreamEvent{
-				ID:      completionID,
-				Object:  "chat.completion.chunk",
-				Created: created,
-				Model:   s.modelName,
+				ID:                completionID,
+				Object:            "chat.completion.chunk",
+				Created:           created,
+				Model:             model,
+				SystemFingerprint: fingerprint,
 				Choices: []Choice{
 					{
 						Index: 0,
 						Delta: &OpenAIMessage{
-							Content: chunk.Content,
+							Content: chunk.Message.Content,
 						},
 					},
 				},
@@ -280,25 +723,63 @@ func (s *Server) handleStreamResponse(ctx context.Context, c *app.RequestContext
 		}
 	}
 
-	logger.Debugf("[API] Stream completed - Session: %s, TotalContentLength: %d", sessionID, len(fullContent))
+	logger.Ctx(ctx).Debugf("[API] Stream completed - Session: %s, TotalContentLength: %d", sessionID, len(fullContent))
+	recordUsage(c, usage)
 
-	// Send finish message
-	finishEvent := OpenAIStreamEvent{
-		ID:      completionID,
-		Object:  "chat.completion.chunk",
-		Created: created,
-		Model:   s.modelName,
-		Choices: []Choice{
-			{
-				Index:        0,
-				FinishReason: "stop",
+	if streamErr != nil {
+		// SDK clients raise the error envelope; the stream ends without a finish chunk or [DONE]
+		s.sendSSEError(sseStream, consts.StatusInternalServerError, "", fmt.Errorf("chat stream failed: %w", streamErr))
+	} else {
+		// Send finish message; a paused run finishes with pending tool calls
+		finishReason := "stop"
+		switch {
+		case filtered:
+			finishReason = "content_filter"
+		case paused, len(clientCalls) > 0:
+			finishReason = "tool_calls"
+		}
+		finishEvent := OpenAIStreamEvent{
+			ID:                completionID,
+			Object:            "chat.completion.chunk",
+			Created:           created,
+			Model:             model,
+			SystemFingerprint: fingerprint,
+			Choices: []Choice{
+				{
+					Index:        0,
+					FinishReason: finishReason,
+				},
 			},
-		},
+		}
+		s.sendSSEEvent(sseStream, finishEvent)
+
+		// Report the usage summed over all model calls in a final chunk without choices
+		if includeUsage {
+			finalUsage := toUsage(usage)
+			s.sendSSEEvent(sseStream, OpenAIStreamEvent{
+				ID:                completionID,
+				Object:            "chat.completion.chunk",
+				Created:           created,
+				Model:             model,
+				SystemFingerprint: fingerprint,
+				Choices:           []Choice{},
+				Usage:             &finalUsage,
+			})
+		}
+		sseStream.Publish(&sse.Event{Data: []byte("[DONE]")})
+	}
+
+	duration := stats.finish()
+	if s.streamTrailers {
+		setStreamTrailers(c, stats, duration)
 	}
-	s.sendSSEEvent(sseStream, finishEvent)
 
 	// Update session with full response
-	s.agent.AppendAssistantMessage(sessionID, schema.AssistantMessage(fullContent, nil))
+	if !paused || fullContent != "" {
+		message := schema.AssistantMessage(fullContent, clientCalls)
+		message.ReasoningContent = fullReasoning
+		a.AppendAssistantMessage(sessionID, message)
+	}
 }
 
 // sendSSEEvent sends an SSE event
@@ -309,29 +790,60 @@ func (s *Server) sendSSEEvent(stream *sse.Stream, event OpenAIStreamEvent) {
 	})
 }
 
-// handleListModels handles model listing requests
-func (s *Server) handleListModels(ctx context.Context, c *app.RequestContext) {
-	c.JSON(consts.StatusOK, map[string]interface{}{
-		"object": "list",
-		"data": []map[string]interface{}{
-			{
-				"id":       s.modelName,
-				"object":   "model",
-				"created":  time.Now().Unix(),
-				"owned_by": "eino-ai-agent",
-			},
-		},
+// sendToolEvent sends a named SSE event describing tool activity.
+// Standard OpenAI clients ignore named events, while aware clients can render tool progress.
+func (s *Server) sendToolEvent(stream *sse.Stream, name string, event ToolActivityEvent) {
+	data, _ := json.Marshal(event)
+	stream.Publish(&sse.Event{
+		Event: name,
+		Data:  data,
 	})
 }
 
-// handleHealth handles health check requests
+// handleMetrics serves metrics in the Prometheus text exposition format
+func (s *Server) handleMetrics(ctx context.Context, c *app.RequestContext) {
+	var buf bytes.Buffer
+	if err := metrics.Default.WriteText(&buf); err != nil {
+		logger.Ctx(ctx).Errorf("[API] Failed to render metrics: %v", err)
+		c.String(consts.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(consts.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+}
+
+// handleHealth handles health check requests; ?deep=true also checks the dependencies
 func (s *Server) handleHealth(ctx context.Context, c *app.RequestContext) {
+	if deep, _ := strconv.ParseBool(string(c.Query("deep"))); deep {
+		s.handleDeepHealth(ctx, c)
+		return
+	}
 	c.JSON(consts.StatusOK, map[string]string{
 		"status": "healthy",
 	})
 }
 
-// RegisterRoutes registers additional custom routes
+// RegisterRoutes registers additional custom routes; they pass through the same middlewares as the API
 func (s *Server) RegisterRoutes(register func(h *server.Hertz)) {
 	register(s.httpServer)
 }
+
+// writeLimitError answers 429 with Retry-After if err is a session limit error and reports whether it did
+func writeLimitError(c *app.RequestContext, err error) bool {
+	var limitErr *agent.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	if limitErr.RetryAfter > 0 {
+		c.Response.Header.Set("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+	}
+	writeError(c, consts.StatusTooManyRequests, string(limitErr.Limit), limitErr)
+	return true
+}
+
+// usageOf returns the token usage attached to a response, if any
+func usageOf(msg *schema.Message) *schema.TokenUsage {
+	if msg == nil || msg.ResponseMeta == nil {
+		return nil
+	}
+	return msg.ResponseMeta.Usage
+}
@@ -0,0 +1,698 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/eino-contrib/jsonschema"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/files"
+	"github.com/fourhu/eino-ai-agent/internal/mcp"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+	"github.com/fourhu/eino-ai-agent/internal/rag"
+	"github.com/fourhu/eino-ai-agent/internal/tasks"
+)
+
+// openAPIPath is where the OpenAPI document of the enabled endpoints is served
+const openAPIPath = "/openapi.json"
+
+// openAPIFieldDocs describes request and response fields by "Type.json_name", notably the extensions to the
+// OpenAI and Anthropic APIs
+var openAPIFieldDocs = map[string]string{
+	"OpenAIRequest.model":                   "Served model; the default model answers when omitted",
+	"OpenAIRequest.session":                 "Extension: session whose stored history the messages continue; a new session is created when omitted",
+	"OpenAIRequest.user":                    "End-user identifier; with user sessions enabled it selects the session when none is given",
+	"OpenAIRequest.options":                 "Extension: agent-specific options",
+	"OpenAIRequest.response_format":         "Requests a JSON answer; it is validated and corrected by the model before it is returned",
+	"OpenAIRequest.stop":                    "A string or an array of up to 4 strings",
+	"OpenAIRequest.tool_choice":             `"none", "auto", "required" or {"type":"function","function":{"name":...}}`,
+	"OpenAIRequest.tools":                   "Client-side functions; calls to them are returned to the client instead of being run",
+	"OpenAIMessage.content":                 "A string, or in requests an array of content parts",
+	"OpenAIMessage.reasoning_content":       "Extension: the model's thinking, kept apart from the answer (responses only)",
+	"ContentPart.file":                      "Extension: an uploaded or inline file, replaced by its text before the turn runs",
+	"File.tenant":                           "Extension: tenant that uploaded the file; files are only listed, served and attached within it",
+	"StreamOptions.include_usage":           "Send a usage chunk without choices before [DONE]",
+	"ResponsesRequest.input":                "A string or an array of input items",
+	"ResponsesRequest.previous_response_id": "Continues the session of an earlier response",
+	"AnthropicRequest.system":               "A string or an array of text blocks",
+	"AnthropicMessage.content":              "A string or an array of content blocks",
+	"EmbeddingRequest.input":                "A string or an array of strings",
+	"TaskConfig.timeout":                    `Per-run timeout, e.g. "10m" (default 5m)`,
+}
+
+// openAPIRequired lists the required fields of request bodies
+var openAPIRequired = map[string][]string{
+	"OpenAIRequest":           {"messages"},
+	"OpenAIMessage":           {"role"},
+	"AnthropicRequest":        {"messages"},
+	"AnthropicMessage":        {"role", "content"},
+	"ResponsesRequest":        {"input"},
+	"EmbeddingRequest":        {"input"},
+	"RAGSearchRequest":        {"query"},
+	"ApprovalDecisionRequest": {"approved"},
+	"TaskConfig":              {"name", "schedule", "prompt"},
+}
+
+// openAPIOperation describes an endpoint for the OpenAPI document
+type openAPIOperation struct {
+	ID          string
+	Tag         string
+	Summary     string
+	Description string
+	Headers     map[string]string // Request headers by name, with their descriptions
+	Query       map[string]string // Query parameters by name, with their descriptions
+
+	Body      any            // JSON request body: a Go value to reflect, or a schema
+	Multipart map[string]any // Multipart form fields, accepted instead of or besides a JSON body
+	Response  any            // JSON 200 response: a Go value to reflect, or a schema
+	Stream    any            // SSE chunk when the request asks for a stream
+	Content   string         // Media type of a non-JSON 200 response
+	MayPause  bool           // The run may pause for tool approval, answering 202
+	Operator  bool           // Requires the operator bearer token
+	Error     any            // Error body, ErrorResponse by default
+}
+
+// handleOpenAPI serves the OpenAPI document of the enabled endpoints
+func (s *Server) handleOpenAPI(ctx context.Context, c *app.RequestContext) {
+	c.Data(consts.StatusOK, "application/json", s.openAPI)
+}
+
+// buildOpenAPI generates the OpenAPI 3 document of the endpoints enabled on this server
+func (s *Server) buildOpenAPI() []byte {
+	r := &openAPISchemas{components: make(map[string]any), types: make(map[string]reflect.Type)}
+	paths := make(map[string]map[string]any)
+	add := func(method, route string, op openAPIOperation) {
+		if s.tenancy != nil && strings.HasPrefix(route, "/v1/") {
+			op.Headers = maps.Clone(op.Headers)
+			if op.Headers == nil {
+				op.Headers = make(map[string]string)
+			}
+			op.Headers[tenantHeader] = "Tenant whose sessions the request uses, unless the API key belongs to one"
+		}
+		route, params := openAPIRoute(route)
+		if paths[route] == nil {
+			paths[route] = make(map[string]any)
+		}
+		paths[route][strings.ToLower(method)] = r.operation(op, params)
+	}
+
+	add("POST", "/v1/chat/completions", openAPIOperation{
+		ID: "createChatCompletion", Tag: "Chat", Summary: "Create a chat completion",
+		Description: "Runs the agent, with its tools and memory, on the session's history and the new messages. " +
+			"Streams chat.completion.chunk events when stream is true; tool activity is sent as named events " +
+			"(tool_call_started, tool_result, approval_required).",
+		Headers:  map[string]string{"Idempotency-Key": "Replays the response of an earlier non-streaming request with this key"},
+		Body:     OpenAIRequest{},
+		Response: OpenAIResponse{},
+		Stream:   OpenAIStreamEvent{},
+		MayPause: true,
+	})
+	add("POST", "/v1/messages", openAPIOperation{
+		ID: "createMessage", Tag: "Chat", Summary: "Create a message (Anthropic Messages API)",
+		Headers:  map[string]string{anthropicSessionHeader: "Extension: session to continue; a new session is created when absent"},
+		Body:     AnthropicRequest{},
+		Response: AnthropicResponse{},
+		Stream:   objectSchema(),
+		Error: objectSchema(map[string]any{
+			"type":  stringSchema("error"),
+			"error": objectSchema(map[string]any{"type": stringSchema(), "message": stringSchema()}),
+		}),
+	})
+	add("POST", "/v1/responses", openAPIOperation{
+		ID: "createResponse", Tag: "Chat", Summary: "Create a response (OpenAI Responses API)",
+		Body:     ResponsesRequest{},
+		Response: ResponseObject{},
+		Stream:   objectSchema(),
+	})
+	add("GET", "/v1/models", openAPIOperation{
+		ID: "listModels", Tag: "Models", Summary: "List the served models",
+		Response: listSchema(objectSchema(map[string]any{
+			"id": stringSchema(), "object": stringSchema("model"), "created": integerSchema(), "owned_by": stringSchema(),
+		})),
+	})
+	if s.embedder != nil {
+		add("POST", "/v1/embeddings", openAPIOperation{
+			ID: "createEmbedding", Tag: "Embeddings", Summary: "Create embeddings",
+			Body: EmbeddingRequest{}, Response: EmbeddingResponse{},
+		})
+	}
+	if s.transcription != nil {
+		add("POST", "/v1/audio/transcriptions", openAPIOperation{
+			ID: "createTranscription", Tag: "Audio", Summary: "Transcribe audio",
+			Description: "Relayed unchanged to the Whisper-compatible transcription backend.",
+			Multipart:   map[string]any{"file": binarySchema(), "model": stringSchema()},
+			Response:    objectSchema(map[string]any{"text": stringSchema()}),
+		})
+	}
+
+	usageQuery := map[string]string{
+		"from":        "Start of the range, as an RFC 3339 time or Unix seconds (default 30 days before to)",
+		"to":          "End of the range, as an RFC 3339 time or Unix seconds (default now)",
+		"granularity": `"hour", "day" or "total" (default)`,
+	}
+	if s.usage != nil {
+		add("GET", "/v1/usage", openAPIOperation{
+			ID: "getUsage", Tag: "Usage", Summary: "Get the requests, tokens and tool calls of the calling API key",
+			Query:    usageQuery,
+			Response: listSchema(r.of(reflect.TypeFor[UsageEntry]())),
+		})
+	}
+	add("GET", "/v1/sessions", openAPIOperation{
+		ID: "listSessions", Tag: "Sessions", Summary: "List sessions, most recently active first",
+		Response: listSchema(r.of(reflect.TypeFor[memory.SessionMeta]())),
+	})
+	add("GET", "/v1/sessions/:id/messages", openAPIOperation{
+		ID: "getSessionMessages", Tag: "Sessions", Summary: "Get the message history of a session",
+		Response: objectSchema(map[string]any{
+			"object":  stringSchema("list"),
+			"session": stringSchema(),
+			"data":    arraySchema(r.of(reflect.TypeFor[OpenAIMessage]())),
+		}),
+	})
+	add("DELETE", "/v1/sessions/:id", openAPIOperation{
+		ID: "deleteSession", Tag: "Sessions", Summary: "Delete a session and its history",
+		Response: objectSchema(map[string]any{"id": stringSchema(), "object": stringSchema("session.deleted"), "deleted": booleanSchema()}),
+	})
+	add("PATCH", "/v1/sessions/:id", openAPIOperation{
+		ID: "updateSession", Tag: "Sessions", Summary: "Change the settings of a session, such as read-only mode",
+		Body:     SessionUpdateRequest{},
+		Response: memory.SessionMeta{},
+	})
+	add("POST", "/v1/sessions/:id/cancel", openAPIOperation{
+		ID: "cancelSession", Tag: "Sessions", Summary: "Abort the runs in progress of a session",
+		Response: objectSchema(map[string]any{"session": stringSchema(), "cancelled": booleanSchema()}),
+	})
+	add("GET", "/v1/sessions/:id/trace", openAPIOperation{
+		ID: "getSessionTrace", Tag: "Sessions", Summary: "Get the ReAct trace of the session's latest turn",
+		Response: agent.Trace{},
+	})
+	add("GET", "/v1/sessions/:id/approvals", openAPIOperation{
+		ID: "listApprovals", Tag: "Sessions", Summary: "List tool calls waiting for approval",
+		Response: listSchema(r.of(reflect.TypeFor[agent.ApprovalRequest]())),
+	})
+	add("POST", "/v1/sessions/:id/approvals/:call_id", openAPIOperation{
+		ID: "decideApproval", Tag: "Sessions", Summary: "Approve or deny a paused tool call",
+		Description: "Once no call is pending anymore, the resumed run is streamed back as chat.completion.chunk events. " +
+			"Calls not waiting for approval are answered 404, calls already decided 409.",
+		Body:     ApprovalDecisionRequest{},
+		Content:  "text/event-stream",
+		MayPause: true,
+	})
+
+	if s.files != nil {
+		add("POST", "/v1/files", openAPIOperation{
+			ID: "uploadFile", Tag: "Files", Summary: "Upload a file",
+			Multipart: map[string]any{"file": binarySchema(), "purpose": stringSchema()},
+			Response:  files.File{},
+		})
+		add("GET", "/v1/files", openAPIOperation{
+			ID: "listFiles", Tag: "Files", Summary: "List uploaded files",
+			Response: listSchema(r.of(reflect.TypeFor[files.File]())),
+		})
+		add("GET", "/v1/files/:id", openAPIOperation{
+			ID: "getFile", Tag: "Files", Summary: "Get a file's metadata", Response: files.File{},
+		})
+		add("GET", "/v1/files/:id/content", openAPIOperation{
+			ID: "getFileContent", Tag: "Files", Summary: "Download a file", Content: "application/octet-stream",
+		})
+		add("DELETE", "/v1/files/:id", openAPIOperation{
+			ID: "deleteFile", Tag: "Files", Summary: "Delete a file",
+			Response: objectSchema(map[string]any{"id": stringSchema(), "object": stringSchema("file"), "deleted": booleanSchema()}),
+		})
+	}
+	if s.rag != nil {
+		add("POST", "/v1/rag/documents", openAPIOperation{
+			ID: "ingestDocument", Tag: "Knowledge", Summary: "Ingest a document into the knowledge base",
+			Description: "Accepts a JSON body or a multipart upload in the file field.",
+			Body:        RAGIngestRequest{},
+			Multipart:   map[string]any{"file": binarySchema()},
+			Response:    rag.Document{},
+		})
+		add("GET", "/v1/rag/documents", openAPIOperation{
+			ID: "listDocuments", Tag: "Knowledge", Summary: "List ingested documents",
+			Response: listSchema(r.of(reflect.TypeFor[rag.Document]())),
+		})
+		add("DELETE", "/v1/rag/documents/:id", openAPIOperation{
+			ID: "deleteDocument", Tag: "Knowledge", Summary: "Remove a document",
+			Response: objectSchema(map[string]any{"id": stringSchema(), "deleted": booleanSchema()}),
+		})
+		add("POST", "/v1/rag/search", openAPIOperation{
+			ID: "searchKnowledge", Tag: "Knowledge", Summary: "Search the knowledge base",
+			Body:     RAGSearchRequest{},
+			Response: listSchema(r.of(reflect.TypeFor[RAGSearchResult]())),
+		})
+	}
+	if s.tasks != nil {
+		add("GET", "/v1/tasks", openAPIOperation{
+			ID: "listTasks", Tag: "Tasks", Summary: "List scheduled tasks and their status",
+			Response: listSchema(r.of(reflect.TypeFor[tasks.TaskStatus]())),
+		})
+		add("POST", "/v1/tasks", openAPIOperation{
+			ID: "createTask", Tag: "Tasks", Summary: "Create or replace a scheduled task", Operator: true,
+			Description: "Webhook header values are sent as given, and file output paths are relative to the configured tasks output_dir.",
+			Body:        tasks.TaskConfig{},
+			Response:    objectSchema(map[string]any{"name": stringSchema(), "created": booleanSchema()}),
+		})
+		add("DELETE", "/v1/tasks/:name", openAPIOperation{
+			ID: "deleteTask", Tag: "Tasks", Summary: "Remove a scheduled task", Operator: true,
+			Response: objectSchema(map[string]any{"name": stringSchema(), "deleted": booleanSchema()}),
+		})
+		add("POST", "/v1/tasks/:name/run", openAPIOperation{
+			ID: "runTask", Tag: "Tasks", Summary: "Run a task now and return its result", Response: tasks.Result{}, Operator: true,
+		})
+	}
+
+	add("GET", "/health", openAPIOperation{
+		ID: "getHealth", Tag: "Operations", Summary: "Report service health",
+		Description: "With deep=true the dependencies are checked too, answering 503 if a critical one is down.",
+		Query:       map[string]string{"deep": `"true" checks the model backends, memory store and MCP servers`},
+		Response: objectSchema(map[string]any{
+			"status": stringSchema("healthy", "degraded", "unhealthy"),
+			"checks": mapSchema(r.of(reflect.TypeFor[CheckResult]())),
+		}),
+	})
+	if s.metricsPath != "" {
+		add("GET", s.metricsPath, openAPIOperation{
+			ID: "getMetrics", Tag: "Operations", Summary: "Prometheus metrics", Content: "text/plain",
+		})
+	}
+	if s.debug {
+		add("GET", "/debug/status", openAPIOperation{
+			ID: "getDebugStatus", Tag: "Operations", Summary: "Runtime state for diagnosing stalls",
+			Response: objectSchema(), Operator: true,
+		})
+		add("GET", "/debug/pprof/:name", openAPIOperation{
+			ID: "getProfile", Tag: "Operations", Summary: "pprof profile", Content: "application/octet-stream", Operator: true,
+		})
+	}
+	if s.admin && s.mcp != nil {
+		add("GET", "/admin/mcp/servers", openAPIOperation{
+			ID: "listMCPServers", Tag: "Admin", Summary: "List MCP servers with their status and tools",
+			Response: listSchema(r.of(reflect.TypeFor[mcp.ServerStatus]())), Operator: true,
+		})
+		add("GET", "/admin/mcp/servers/:name", openAPIOperation{
+			ID: "getMCPServer", Tag: "Admin", Summary: "Get the status of an MCP server",
+			Response: mcp.ServerStatus{}, Operator: true,
+		})
+		add("GET", "/admin/mcp/servers/:name/tools", openAPIOperation{
+			ID: "listMCPServerTools", Tag: "Admin", Summary: "List the tools of an MCP server",
+			Response: listSchema(r.of(reflect.TypeFor[mcp.ToolInfo]())), Operator: true,
+		})
+		add("GET", "/admin/mcp/tools", openAPIOperation{
+			ID: "listMCPTools", Tag: "Admin", Summary: "List the tools of all MCP servers",
+			Response: listSchema(r.of(reflect.TypeFor[mcp.ToolInfo]())), Operator: true,
+		})
+		add("GET", "/admin/mcp/stats", openAPIOperation{
+			ID: "getMCPToolStats", Tag: "Admin", Summary: "Get call statistics per MCP tool and server",
+			Response: mcp.ToolStats{}, Operator: true,
+		})
+		for _, action := range []string{"reconnect", "enable", "disable"} {
+			add("POST", "/admin/mcp/servers/:name/"+action, openAPIOperation{
+				ID: action + "MCPServer", Tag: "Admin", Summary: strings.ToUpper(action[:1]) + action[1:] + " an MCP server",
+				Response: mcp.ServerStatus{}, Operator: true,
+			})
+		}
+	}
+	if s.admin {
+		add("GET", "/admin/sessions", openAPIOperation{
+			ID: "listStoredSessions", Tag: "Admin", Summary: "Page through the IDs of the stored sessions of all tenants",
+			Query: map[string]string{
+				"prefix": `Only sessions whose ID starts with this, e.g. "<tenant>/"`,
+				"cursor": "Continue after this ID, the next_cursor of the previous page",
+				"limit":  fmt.Sprintf("Page size (default %d, at most %d)", defaultSessionPage, maxSessionPage),
+			},
+			Response: objectSchema(map[string]any{
+				"object": stringSchema("list"), "data": arraySchema(stringSchema()), "next_cursor": stringSchema(),
+			}),
+			Operator: true,
+		})
+		add("DELETE", "/admin/sessions/*id", openAPIOperation{
+			ID: "deleteStoredSession", Tag: "Admin", Summary: "Delete a session of any tenant by its full ID",
+			Response: objectSchema(map[string]any{"id": stringSchema(), "object": stringSchema("session.deleted"), "deleted": booleanSchema()}),
+			Operator: true,
+		})
+	}
+	if s.admin && s.usage != nil {
+		keyQuery := maps.Clone(usageQuery)
+		keyQuery["key"] = "Only the usage of this key"
+		add("GET", "/admin/usage", openAPIOperation{
+			ID: "listUsage", Tag: "Admin", Summary: "Get the usage of every API key",
+			Query:    keyQuery,
+			Response: listSchema(r.of(reflect.TypeFor[UsageEntry]())),
+			Operator: true,
+		})
+	}
+	if s.admin && s.reload != nil {
+		add("POST", "/admin/reload", openAPIOperation{
+			ID: "reloadConfig", Tag: "Admin", Summary: "Reload the configuration file",
+			Description: "Applies the log level, MCP servers, system prompt and rate limit without restarting.",
+			Response:    objectSchema(map[string]any{"status": stringSchema("reloaded")}), Operator: true,
+		})
+	}
+	if s.webUI {
+		add("GET", "/", openAPIOperation{ID: "getWebUI", Tag: "Operations", Summary: "Browser chat UI", Content: "text/html"})
+	}
+	add("GET", openAPIPath, openAPIOperation{
+		ID: "getOpenAPI", Tag: "Operations", Summary: "This OpenAPI document", Response: objectSchema(),
+	})
+
+	r.of(reflect.TypeFor[ErrorResponse]())
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "eino-ai-agent",
+			"description": "OpenAI-compatible agent API with sessions, tools and memory",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": r.components,
+			"securitySchemes": map[string]any{
+				"operatorToken": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		panic(err) // The document holds only JSON-safe values
+	}
+	return data
+}
+
+// routeParam matches the path parameters of a Hertz route, including catch-all ones
+var routeParam = regexp.MustCompile(`[:*](\w+)`)
+
+// openAPIRoute converts a Hertz route to an OpenAPI path and returns its parameter names
+func openAPIRoute(route string) (string, []string) {
+	var params []string
+	for _, m := range routeParam.FindAllStringSubmatch(route, -1) {
+		params = append(params, m[1])
+	}
+	return routeParam.ReplaceAllString(route, "{$1}"), params
+}
+
+// operation renders an operation object
+func (r *openAPISchemas) operation(op openAPIOperation, pathParams []string) map[string]any {
+	out := map[string]any{"operationId": op.ID, "tags": []string{op.Tag}, "summary": op.Summary}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+
+	var params []map[string]any
+	for _, name := range pathParams {
+		params = append(params, map[string]any{"name": name, "in": "path", "required": true, "schema": stringSchema()})
+	}
+	for _, name := range sortedKeys(op.Headers) {
+		params = append(params, map[string]any{"name": name, "in": "header", "description": op.Headers[name], "schema": stringSchema()})
+	}
+	for _, name := range sortedKeys(op.Query) {
+		params = append(params, map[string]any{"name": name, "in": "query", "description": op.Query[name], "schema": stringSchema()})
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	body := make(map[string]any)
+	if op.Body != nil {
+		body["application/json"] = map[string]any{"schema": r.schema(op.Body)}
+	}
+	if op.Multipart != nil {
+		body["multipart/form-data"] = map[string]any{"schema": objectSchema(op.Multipart)}
+	}
+	if len(body) > 0 {
+		out["requestBody"] = map[string]any{"required": true, "content": body}
+	}
+
+	ok := map[string]any{"description": "OK"}
+	content := make(map[string]any)
+	if op.Response != nil {
+		content["application/json"] = map[string]any{"schema": r.schema(op.Response)}
+	}
+	if op.Stream != nil {
+		content["text/event-stream"] = map[string]any{
+			"schema":         map[string]any{"type": "string", "description": "Server-sent events whose data is JSON of this shape, ending with [DONE]"},
+			"x-event-schema": r.schema(op.Stream),
+		}
+	}
+	if op.Content != "" {
+		content[op.Content] = map[string]any{"schema": map[string]any{"type": "string"}}
+	}
+	if len(content) > 0 {
+		ok["content"] = content
+	}
+	responses := map[string]any{"200": ok}
+	if op.MayPause {
+		responses["202"] = map[string]any{
+			"description": "The run paused for tool approval",
+			"content": map[string]any{"application/json": map[string]any{"schema": objectSchema(map[string]any{
+				"session":           stringSchema(),
+				"status":            stringSchema("approval_required"),
+				"pending_approvals": arraySchema(r.of(reflect.TypeFor[agent.ApprovalRequest]())),
+			})}},
+		}
+	}
+	errBody := op.Error
+	if errBody == nil {
+		errBody = ErrorResponse{}
+	}
+	responses["default"] = map[string]any{
+		"description": "Error",
+		"content":     map[string]any{"application/json": map[string]any{"schema": r.schema(errBody)}},
+	}
+	out["responses"] = responses
+
+	if op.Operator {
+		out["security"] = []map[string][]string{{"operatorToken": {}}}
+	}
+	return out
+}
+
+// openAPISchemas reflects Go types into JSON schemas, collecting named structs as components
+type openAPISchemas struct {
+	components map[string]any
+	types      map[string]reflect.Type
+}
+
+// schema returns v itself if it is already a schema, or the schema of its type
+func (r *openAPISchemas) schema(v any) map[string]any {
+	if s, ok := v.(map[string]any); ok {
+		return s
+	}
+	return r.of(reflect.TypeOf(v))
+}
+
+// Types with a fixed schema
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	durationType   = reflect.TypeFor[time.Duration]()
+	taskDuration   = reflect.TypeFor[tasks.Duration]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	jsonSchemaType = reflect.TypeFor[jsonschema.Schema]()
+)
+
+// of returns the schema of t; named structs are referenced from the components
+func (r *openAPISchemas) of(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case taskDuration:
+		return stringSchema()
+	case rawMessageType:
+		return map[string]any{}
+	case jsonSchemaType:
+		return map[string]any{"type": "object", "description": "JSON Schema"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return stringSchema()
+	case reflect.Bool:
+		return booleanSchema()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return integerSchema()
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return arraySchema(r.of(t.Elem()))
+	case reflect.Map:
+		return mapSchema(r.of(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.object(t)
+		}
+		name := r.componentName(t)
+		ref := map[string]any{"$ref": "#/components/schemas/" + name}
+		if _, ok := r.components[name]; !ok {
+			r.components[name] = map[string]any{} // Placeholder for recursive types
+			r.components[name] = r.object(t)
+		}
+		return ref
+	default:
+		return map[string]any{}
+	}
+}
+
+// componentName names the component of a struct, prefixing the package if another type took the name
+func (r *openAPISchemas) componentName(t reflect.Type) string {
+	name := t.Name()
+	if seen, ok := r.types[name]; ok && seen != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	r.types[name] = t
+	return name
+}
+
+// fieldSchema returns the schema of a field accepting several JSON shapes, by "Type.json_name"
+func (r *openAPISchemas) fieldSchema(field string) (map[string]any, bool) {
+	switch field {
+	case "OpenAIMessage.content":
+		return oneOf(stringSchema(), arraySchema(r.of(reflect.TypeFor[ContentPart]()))), true
+	case "OpenAIRequest.stop", "EmbeddingRequest.input":
+		return oneOf(stringSchema(), arraySchema(stringSchema())), true
+	case "OpenAIRequest.tool_choice", "ResponsesRequest.tool_choice":
+		return oneOf(stringSchema("none", "auto", "required"), objectSchema()), true
+	case "ResponsesRequest.input":
+		return oneOf(stringSchema(), arraySchema(r.of(reflect.TypeFor[ResponseInputItem]()))), true
+	case "ResponseObject.output":
+		return arraySchema(oneOf(r.of(reflect.TypeFor[ResponseMessageItem]()), r.of(reflect.TypeFor[ResponseFunctionCallItem]()))), true
+	case "AnthropicRequest.system", "AnthropicMessage.content":
+		return oneOf(stringSchema(), arraySchema(r.of(reflect.TypeFor[AnthropicContentBlock]()))), true
+	}
+	return nil, false
+}
+
+// object returns the schema of a struct's JSON fields, including those of embedded structs
+func (r *openAPISchemas) object(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	r.fields(t, t.Name(), props)
+	out := objectSchema(props)
+	if required := openAPIRequired[t.Name()]; len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// fields adds the JSON fields of t to props, documenting them under the name of the outer type
+func (r *openAPISchemas) fields(t reflect.Type, owner string, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.fields(ft, ft.Name(), props)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema, ok := r.fieldSchema(owner + "." + name)
+		if !ok {
+			schema = r.of(f.Type)
+		}
+		if doc, ok := openAPIFieldDocs[owner+"."+name]; ok {
+			if _, isRef := schema["$ref"]; isRef {
+				schema = map[string]any{"allOf": []any{schema}}
+			}
+			schema["description"] = doc
+		}
+		props[name] = schema
+	}
+}
+
+// stringSchema returns a string schema, restricted to values if any are given
+func stringSchema(values ...string) map[string]any {
+	s := map[string]any{"type": "string"}
+	if len(values) > 0 {
+		s["enum"] = values
+	}
+	return s
+}
+
+// booleanSchema returns a boolean schema
+func booleanSchema() map[string]any {
+	return map[string]any{"type": "boolean"}
+}
+
+// integerSchema returns an integer schema
+func integerSchema() map[string]any {
+	return map[string]any{"type": "integer"}
+}
+
+// binarySchema returns the schema of an uploaded file
+func binarySchema() map[string]any {
+	return map[string]any{"type": "string", "format": "binary"}
+}
+
+// arraySchema returns an array schema of items
+func arraySchema(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+// mapSchema returns an object schema with values of one schema
+func mapSchema(values map[string]any) map[string]any {
+	return map[string]any{"type": "object", "additionalProperties": values}
+}
+
+// objectSchema returns an object schema with the given properties, or any properties if none are given
+func objectSchema(props ...map[string]any) map[string]any {
+	s := map[string]any{"type": "object"}
+	if len(props) > 0 {
+		s["properties"] = props[0]
+	}
+	return s
+}
+
+// oneOf returns a schema matching exactly one of the given schemas
+func oneOf(schemas ...map[string]any) map[string]any {
+	return map[string]any{"oneOf": schemas}
+}
+
+// listSchema returns the schema of a list response of items
+func listSchema(items map[string]any) map[string]any {
+	return objectSchema(map[string]any{"object": stringSchema("list"), "data": arraySchema(items)})
+}
+
+// sortedKeys returns the keys of m in order, so the document is stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,160 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+)
+
+// Budget caps what is spent per UTC day; zero values mean unlimited
+type Budget struct {
+	Tokens int64   // Total tokens
+	Cost   float64 // Estimated cost, in the currency of the prices
+}
+
+// SpendQuotas are the daily budgets of each session and each tenant
+type SpendQuotas struct {
+	Session Budget
+	Tenant  Budget // Applies with WithTenants; requests without a tenant are not capped
+
+	// Prices per million tokens estimate the cost of a request
+	PromptPrice     float64
+	CompletionPrice float64
+}
+
+// spendQuotas enforces SpendQuotas with daily counters kept in a quota store
+type spendQuotas struct {
+	store  memory.QuotaStore
+	quotas SpendQuotas
+}
+
+// QuotaError is returned when a session or tenant has spent its daily budget
+type QuotaError struct {
+	Scope   string    `json:"scope"` // "session" or "tenant"
+	ID      string    `json:"id"`
+	Limit   string    `json:"limit"` // "tokens" or "cost"
+	Used    float64   `json:"used"`
+	Max     float64   `json:"max"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+func (e *QuotaError) Error() string {
+	if e.Limit == "cost" {
+		return fmt.Sprintf("%s %s spent %.6g of its daily budget of %.6g; the budget resets at %s",
+			e.Scope, e.ID, e.Used, e.Max, e.ResetAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s %s used %.0f of its %.0f daily tokens; the budget resets at %s",
+		e.Scope, e.ID, e.Used, e.Max, e.ResetAt.Format(time.RFC3339))
+}
+
+// WithSpendQuotas caps the tokens or estimated cost each session and tenant may spend per UTC day.
+// Spending is counted in store under "session:" and "tenant:" keys, apart from per-key usage, so budgets
+// survive restarts. Requests of an exhausted session or tenant are refused with 429 (tokens) or 402 (cost).
+func WithSpendQuotas(store memory.QuotaStore, quotas SpendQuotas) Option {
+	return func(s *Server) {
+		s.quotas = &spendQuotas{store: store, quotas: quotas}
+	}
+}
+
+// budgets returns the quota keys of a request with their budgets
+func (q *spendQuotas) budgets(c *app.RequestContext, sessionID string) map[string]Budget {
+	budgets := make(map[string]Budget)
+	if q.quotas.Session != (Budget{}) && sessionID != "" {
+		budgets["session:"+sessionID] = q.quotas.Session
+	}
+	if tenant := c.GetString(tenantKey); q.quotas.Tenant != (Budget{}) && tenant != "" {
+		budgets["tenant:"+tenant] = q.quotas.Tenant
+	}
+	return budgets
+}
+
+// cost estimates the cost of a request's token usage
+func (q *spendQuotas) cost(u *schema.TokenUsage) float64 {
+	return (float64(u.PromptTokens)*q.quotas.PromptPrice + float64(u.CompletionTokens)*q.quotas.CompletionPrice) / 1e6
+}
+
+// checkSpend returns a QuotaError if the session or tenant of a request has spent its budget for today.
+// Store failures are logged and the request is admitted.
+func (s *Server) checkSpend(ctx context.Context, c *app.RequestContext, sessionID string) *QuotaError {
+	if s.quotas == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	for key, budget := range s.quotas.budgets(c, sessionID) {
+		spent, err := s.quotas.store.ReadSpend(ctx, key, now)
+		if err != nil {
+			logger.Ctx(ctx).Errorf("[API] Failed to read spend of %s: %v", key, err)
+			continue
+		}
+
+		scope, id := "session", s.publicSession(sessionID)
+		if key != "session:"+sessionID {
+			scope, id = "tenant", c.GetString(tenantKey)
+		}
+		quotaErr := &QuotaError{Scope: scope, ID: id, ResetAt: day.Add(24 * time.Hour)}
+		switch {
+		case budget.Tokens > 0 && spent.Tokens >= budget.Tokens:
+			quotaErr.Limit, quotaErr.Used, quotaErr.Max = "tokens", float64(spent.Tokens), float64(budget.Tokens)
+		case budget.Cost > 0 && spent.Cost >= budget.Cost:
+			quotaErr.Limit, quotaErr.Used, quotaErr.Max = "cost", spent.Cost, budget.Cost
+		default:
+			continue
+		}
+		logger.Ctx(ctx).Warnf("[API] Refused request over quota: %v", quotaErr)
+		return quotaErr
+	}
+	return nil
+}
+
+// quotaStatus is the status a quota error is answered with
+func quotaStatus(err *QuotaError) int {
+	if err.Limit == "cost" {
+		return consts.StatusPaymentRequired
+	}
+	return consts.StatusTooManyRequests
+}
+
+// setQuotaRetryAfter tells clients when the exhausted budget resets
+func setQuotaRetryAfter(c *app.RequestContext, err *QuotaError) {
+	c.Response.Header.Set("Retry-After", strconv.Itoa(int(time.Until(err.ResetAt).Seconds())+1))
+}
+
+// writeQuotaError answers with the OpenAI error envelope of a quota error
+func writeQuotaError(c *app.RequestContext, err *QuotaError) {
+	setQuotaRetryAfter(c, err)
+	code := "quota_exceeded"
+	if err.Limit == "cost" {
+		code = "insufficient_quota"
+	}
+	writeError(c, quotaStatus(err), code, err)
+}
+
+// spendMiddleware adds the usage of an answered agent run request to the spend of its session and tenant
+func (s *Server) spendMiddleware(ctx context.Context, c *app.RequestContext) {
+	c.Next(ctx)
+
+	usage, ok := c.Get(accessUsageKey)
+	if !ok {
+		return
+	}
+	u := usage.(*schema.TokenUsage)
+	// Record even if the client went away mid-stream; its tokens were spent
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageWriteTimeout)
+	defer cancel()
+	spend := memory.Spend{Tokens: int64(u.TotalTokens), Cost: s.quotas.cost(u)}
+	for key := range s.quotas.budgets(c, c.GetString(accessSessionKey)) {
+		if err := s.quotas.store.AddSpend(ctx, key, time.Now(), spend); err != nil {
+			logger.Ctx(ctx).Errorf("[API] Failed to record spend of %s: %v", key, err)
+		}
+	}
+}
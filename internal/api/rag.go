@@ -0,0 +1,140 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/rag"
+)
+
+// maxIngestBytes limits the size of a single ingested document
+const maxIngestBytes = 20 << 20
+
+// RAGIngestRequest is the JSON form of a document ingestion request
+type RAGIngestRequest struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Content     string `json:"content"`
+}
+
+// RAGSearchRequest represents a knowledge base search request
+type RAGSearchRequest struct {
+	Query string `json:"query"`
+	TopK  int    `json:"top_k,omitempty"`
+}
+
+// RAGSearchResult represents a single retrieved chunk
+type RAGSearchResult struct {
+	ID      string         `json:"id"`
+	Content string         `json:"content"`
+	Score   float64        `json:"score"`
+	Meta    map[string]any `json:"metadata,omitempty"`
+}
+
+// WithRAG enables the document ingestion and knowledge search endpoints
+func WithRAG(svc *rag.Service) Option {
+	return func(s *Server) {
+		s.rag = svc
+	}
+}
+
+// registerRAGRoutes registers the knowledge base endpoints
+func (s *Server) registerRAGRoutes() {
+	s.httpServer.POST("/v1/rag/documents", s.handleRAGIngest)
+	s.httpServer.GET("/v1/rag/documents", s.handleRAGListDocuments)
+	s.httpServer.DELETE("/v1/rag/documents/:id", s.handleRAGDeleteDocument)
+	s.httpServer.POST("/v1/rag/search", s.handleRAGSearch)
+}
+
+// handleRAGIngest ingests a document from a multipart upload ("file" field) or a JSON body
+func (s *Server) handleRAGIngest(ctx context.Context, c *app.RequestContext) {
+	var name, contentType string
+	var data []byte
+
+	if fh, err := c.FormFile("file"); err == nil {
+		if fh.Size > maxIngestBytes {
+			writeError(c, consts.StatusRequestEntityTooLarge, "", invalidParam("file", "document exceeds %d bytes", maxIngestBytes))
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			writeError(c, consts.StatusBadRequest, "", fmt.Errorf("failed to open upload: %w", err))
+			return
+		}
+		defer f.Close()
+		data, err = io.ReadAll(io.LimitReader(f, maxIngestBytes))
+		if err != nil {
+			writeError(c, consts.StatusBadRequest, "", fmt.Errorf("failed to read upload: %w", err))
+			return
+		}
+		name = fh.Filename
+		contentType = fh.Header.Get("Content-Type")
+	} else {
+		var req RAGIngestRequest
+		if err := c.BindJSON(&req); err != nil {
+			writeError(c, consts.StatusBadRequest, "", fmt.Errorf("invalid request: %w", err))
+			return
+		}
+		name, contentType, data = req.Name, req.ContentType, []byte(req.Content)
+		if name == "" {
+			name = "untitled.txt"
+		}
+	}
+
+	doc, err := s.rag.Ingest(ctx, name, contentType, data)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Document ingestion failed - Name: %s, Error: %v", name, err)
+		writeError(c, consts.StatusUnprocessableEntity, "", fmt.Errorf("ingestion failed: %w", err))
+		return
+	}
+	c.JSON(consts.StatusOK, doc)
+}
+
+// handleRAGListDocuments lists ingested documents
+func (s *Server) handleRAGListDocuments(ctx context.Context, c *app.RequestContext) {
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   s.rag.ListDocuments(),
+	})
+}
+
+// handleRAGDeleteDocument removes an ingested document
+func (s *Server) handleRAGDeleteDocument(ctx context.Context, c *app.RequestContext) {
+	id := c.Param("id")
+	if !s.rag.DeleteDocument(ctx, id) {
+		writeError(c, consts.StatusNotFound, "document_not_found", fmt.Errorf("document %s not found", id))
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"id": id, "deleted": true})
+}
+
+// handleRAGSearch searches the knowledge base
+func (s *Server) handleRAGSearch(ctx context.Context, c *app.RequestContext) {
+	var req RAGSearchRequest
+	if err := c.BindJSON(&req); err != nil || req.Query == "" {
+		writeError(c, consts.StatusBadRequest, "", invalidParam("query", "query is required"))
+		return
+	}
+
+	docs, err := s.rag.Search(ctx, req.Query, req.TopK)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Knowledge search failed: %v", err)
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("search failed: %w", err))
+		return
+	}
+
+	results := make([]RAGSearchResult, len(docs))
+	for i, d := range docs {
+		results[i] = RAGSearchResult{ID: d.ID, Content: d.Content, Score: d.Score(), Meta: d.MetaData}
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   results,
+	})
+}
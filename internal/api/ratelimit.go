@@ -0,0 +1,155 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// rateLimitSweepInterval is how often idle client buckets are dropped
+const rateLimitSweepInterval = time.Minute
+
+// WithRateLimit limits each client, identified by its API key if it is one of the tenant keys or else its IP,
+// to rps requests per second
+// with bursts of up to burst requests (burst defaults to ceil(rps)). A zero rps serves clients without limit
+// but lets SetRateLimit impose one later.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(s *Server) {
+		s.rateLimiter = newRateLimiter(rps, burst)
+	}
+}
+
+// SetRateLimit changes the rate limit of a server created WithRateLimit; buckets start full at the new rate
+func (s *Server) SetRateLimit(rps float64, burst int) error {
+	if s.rateLimiter == nil {
+		return errors.New("rate limiting was not configured at startup")
+	}
+	s.rateLimiter.setRate(rps, burst)
+	logger.Infof("[API] Rate limit set to %g requests per second", rps)
+	return nil
+}
+
+// rateLimiter keeps a token bucket per client
+type rateLimiter struct {
+	rps       float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// bucket holds the tokens of one client as of last
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	l := &rateLimiter{lastSweep: time.Now()}
+	l.setRate(rps, burst)
+	return l
+}
+
+// setRate replaces the rate and drops the buckets of the previous one; rps <= 0 disables limiting
+func (l *rateLimiter) setRate(rps float64, burst int) {
+	if burst <= 0 {
+		burst = int(math.Ceil(rps))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rps = rps
+	l.burst = float64(burst)
+	l.buckets = make(map[string]*bucket)
+}
+
+// allow takes a token from the client's bucket, or returns how long to wait for the next one
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rps <= 0 {
+		return true, 0
+	}
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rps)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rps * float64(time.Second))
+}
+
+// sweep drops buckets that have refilled completely, as they behave like new ones; l.mu must be held
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rps >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientKey identifies the caller by bearer token or Anthropic-style x-api-key, falling back to the client IP
+func clientKey(c *app.RequestContext) string {
+	auth := string(c.GetHeader("Authorization"))
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok && token != "" {
+		return "key:" + token
+	}
+	if key := string(c.GetHeader("X-Api-Key")); key != "" {
+		return "key:" + key
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateLimitKey is the bucket of a request: its API key if the key is configured, else its IP. API keys are not
+// validated otherwise, so arbitrary keys would each get a fresh bucket.
+func (s *Server) rateLimitKey(c *app.RequestContext) string {
+	key := clientKey(c)
+	if token, ok := strings.CutPrefix(key, "key:"); ok && s.tenancy != nil {
+		if _, known := s.tenancy.keys[token]; known {
+			return key
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateLimitMiddleware rejects requests of clients over their rate with 429; health and metrics are exempt
+func (s *Server) rateLimitMiddleware(ctx context.Context, c *app.RequestContext) {
+	path := string(c.Path())
+	if path == "/health" || (s.metricsPath != "" && path == s.metricsPath) {
+		c.Next(ctx)
+		return
+	}
+
+	ok, retryAfter := s.rateLimiter.allow(s.rateLimitKey(c))
+	if !ok {
+		logger.Ctx(ctx).Debugf("[API] Rate limited client %s on %s", c.ClientIP(), path)
+		c.Response.Header.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		abortWithError(c, consts.StatusTooManyRequests, "rate_limit_exceeded", errors.New("rate limit exceeded"))
+		return
+	}
+	c.Next(ctx)
+}
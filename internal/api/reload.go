@@ -0,0 +1,36 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ReloadFunc re-reads the configuration and applies what can change at runtime
+type ReloadFunc func(ctx context.Context) error
+
+// WithReload serves POST /admin/reload, which calls reload; it requires WithAdmin
+func WithReload(reload ReloadFunc) Option {
+	return func(s *Server) {
+		s.reload = reload
+	}
+}
+
+// handleReload reloads the configuration; sessions and runs in progress are unaffected
+func (s *Server) handleReload(ctx context.Context, c *app.RequestContext) {
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	logger.Ctx(ctx).Info("[API] Reloading configuration")
+	if err := s.reload(ctx); err != nil {
+		logger.Ctx(ctx).Errorf("[API] Failed to reload configuration: %v", err)
+		writeError(c, consts.StatusInternalServerError, "reload_failed", fmt.Errorf("failed to reload configuration: %w", err))
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]string{"status": "reloaded"})
+}
@@ -0,0 +1,132 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/metrics"
+)
+
+// requestIDHeader carries the request ID; a valid client-supplied ID is kept, otherwise one is generated
+const requestIDHeader = "X-Request-ID"
+
+// Keys under which handlers record request details for the access log
+const (
+	accessSessionKey   = "access_session"
+	accessUsageKey     = "access_usage"
+	accessToolCallsKey = "access_tool_calls"
+	accessStartKey     = "access_start"
+)
+
+// requestDuration is the latency of requests by route template, so that tail latency can be traced to endpoints
+var requestDuration = metrics.NewHistogram("eino_http_request_duration_seconds",
+	"Time to answer HTTP requests, including streamed responses", metrics.DefBuckets, "method", "route", "status")
+
+// WithSlowRequestLog logs requests taking longer than threshold as warnings, with their session,
+// tool call count and token usage
+func WithSlowRequestLog(threshold time.Duration) Option {
+	return func(s *Server) {
+		s.slowRequest = threshold
+	}
+}
+
+// requestIDMiddleware assigns the request ID, echoes it in the response and carries it in the context,
+// so that log lines and agent events of the request can be correlated. It logs each request once it finishes.
+func (s *Server) requestIDMiddleware(ctx context.Context, c *app.RequestContext) {
+	started := time.Now()
+	c.Set(accessStartKey, started)
+	requestID := string(c.GetHeader(requestIDHeader))
+	if !validRequestID(requestID) {
+		requestID = uuid.New().String()
+	}
+	c.Response.Header.Set(requestIDHeader, requestID)
+	ctx = logger.WithRequestID(ctx, requestID)
+	ctx, tally := agent.WithToolCallTally(ctx)
+	c.Set(accessToolCallsKey, tally)
+
+	c.Next(ctx)
+	latency := time.Since(started)
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	requestDuration.Observe(latency.Seconds(), string(c.Method()), route, strconv.Itoa(c.Response.StatusCode()))
+
+	fields := []interface{}{
+		"method", string(c.Method()),
+		"path", string(c.Path()),
+		"status", c.Response.StatusCode(),
+		"latency_ms", latency.Milliseconds(),
+		"client_ip", c.ClientIP(),
+	}
+	if tenant := c.GetString(tenantKey); tenant != "" {
+		fields = append(fields, "tenant", tenant)
+	}
+	if sessionID := c.GetString(accessSessionKey); sessionID != "" {
+		fields = append(fields, "session", sessionID)
+	}
+	slow := s.slowRequest > 0 && latency >= s.slowRequest
+	if n := tally.Load(); n > 0 || slow {
+		fields = append(fields, "tool_calls", n)
+	}
+	if usage, ok := c.Get(accessUsageKey); ok {
+		u := usage.(*schema.TokenUsage)
+		fields = append(fields, "prompt_tokens", u.PromptTokens, "completion_tokens", u.CompletionTokens, "total_tokens", u.TotalTokens)
+	}
+	if slow {
+		logger.Ctx(ctx).Warnw("[API] Slow request", append(fields, "route", route)...)
+		return
+	}
+	logger.Ctx(ctx).Infow("[API] Request completed", fields...)
+}
+
+// toolCalls returns the number of tool calls the request's runs made so far
+func toolCalls(c *app.RequestContext) int64 {
+	if tally, ok := c.Get(accessToolCallsKey); ok {
+		return tally.(*atomic.Int64).Load()
+	}
+	return 0
+}
+
+// requestStart returns when the request arrived, or now if it was not recorded
+func requestStart(c *app.RequestContext) time.Time {
+	if started, ok := c.Get(accessStartKey); ok {
+		return started.(time.Time)
+	}
+	return time.Now()
+}
+
+// validRequestID reports whether a client-supplied ID is short printable ASCII without spaces
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// recordSession notes the session of a request for the access log
+func recordSession(c *app.RequestContext, sessionID string) {
+	c.Set(accessSessionKey, sessionID)
+}
+
+// recordUsage notes the token usage of a request for the access log
+func recordUsage(c *app.RequestContext, usage *schema.TokenUsage) {
+	if usage != nil {
+		c.Set(accessUsageKey, usage)
+	}
+}
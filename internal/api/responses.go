@@ -0,0 +1,713 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/google/uuid"
+	"github.com/hertz-contrib/sse"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ResponsesRequest represents an OpenAI Responses API request
+type ResponsesRequest struct {
+	Model        string          `json:"model"`
+	Input        json.RawMessage `json:"input"` // A string or an array of input items
+	Instructions string          `json:"instructions,omitempty"`
+	Stream       bool            `json:"stream,omitempty"`
+
+	// PreviousResponseID continues the session of an earlier response
+	PreviousResponseID string `json:"previous_response_id,omitempty"`
+
+	Temperature     *float32 `json:"temperature,omitempty"`
+	TopP            *float32 `json:"top_p,omitempty"`
+	MaxOutputTokens *int     `json:"max_output_tokens,omitempty"`
+
+	// Tools declares client-side functions; calls to them are returned as function_call items
+	Tools      []ResponsesTool   `json:"tools,omitempty"`
+	ToolChoice json.RawMessage   `json:"tool_choice,omitempty"` // "none", "auto", "required" or {"type":"function","name":...}
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// ResponsesTool declares a client-side function of a Responses request
+type ResponsesTool struct {
+	Type        string             `json:"type"` // Only "function" is supported
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Parameters  *jsonschema.Schema `json:"parameters,omitempty"`
+}
+
+// ResponseInputItem is a message, function_call or function_call_output input item
+type ResponseInputItem struct {
+	Type    string          `json:"type,omitempty"` // Defaults to "message"
+	Role    string          `json:"role,omitempty"`
+	Content json.RawMessage `json:"content,omitempty"` // A string or an array of input_text/input_image/output_text parts
+
+	CallID    string          `json:"call_id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Arguments string          `json:"arguments,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"` // A string or an array of input_text parts
+}
+
+// ResponseContentPart is a part of message content
+type ResponseContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ResponseObject represents a Responses API response
+type ResponseObject struct {
+	ID                 string             `json:"id"`
+	Object             string             `json:"object"`
+	CreatedAt          int64              `json:"created_at"`
+	Status             string             `json:"status"` // "in_progress", "completed", "incomplete" or "failed"
+	Model              string             `json:"model"`
+	Output             []interface{}      `json:"output"` // ResponseMessageItem and ResponseFunctionCallItem values
+	PreviousResponseID *string            `json:"previous_response_id"`
+	IncompleteDetails  *IncompleteDetails `json:"incomplete_details"`
+	Error              *ResponseError     `json:"error"`
+	Usage              *ResponseUsage     `json:"usage"`
+	ParallelToolCalls  bool               `json:"parallel_tool_calls"`
+	Tools              []ResponsesTool    `json:"tools"`
+	Metadata           map[string]string  `json:"metadata"`
+}
+
+// ResponseMessageItem is an assistant message output item
+type ResponseMessageItem struct {
+	Type    string               `json:"type"`
+	ID      string               `json:"id"`
+	Status  string               `json:"status"`
+	Role    string               `json:"role"`
+	Content []ResponseOutputText `json:"content"`
+}
+
+// ResponseOutputText is the text part of an output message
+type ResponseOutputText struct {
+	Type        string        `json:"type"`
+	Text        string        `json:"text"`
+	Annotations []interface{} `json:"annotations"`
+}
+
+// ResponseFunctionCallItem is a client function call output item
+type ResponseFunctionCallItem struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// IncompleteDetails explains an incomplete response
+type IncompleteDetails struct {
+	Reason string `json:"reason"` // "content_filter" or "approval_required"
+}
+
+// ResponseError describes a failed response
+type ResponseError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ResponseUsage represents token usage in the Responses format
+type ResponseUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	OutputTokens       int `json:"output_tokens"`
+	TotalTokens        int `json:"total_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+}
+
+// toResponseUsage converts agent token usage to the Responses format
+func toResponseUsage(usage *schema.TokenUsage) *ResponseUsage {
+	u := &ResponseUsage{}
+	if usage == nil {
+		return u
+	}
+	u.InputTokens = usage.PromptTokens
+	u.OutputTokens = usage.CompletionTokens
+	u.TotalTokens = usage.TotalTokens
+	u.InputTokensDetails.CachedTokens = usage.PromptTokenDetails.CachedTokens
+	u.OutputTokensDetails.ReasoningTokens = usage.CompletionTokensDetails.ReasoningTokens
+	return u
+}
+
+// newResponseID returns a response ID that carries the session, so previous_response_id resumes it
+func newResponseID(sessionID string) string {
+	random := strings.ReplaceAll(uuid.New().String(), "-", "")
+	return "resp_" + random + "_" + base64.RawURLEncoding.EncodeToString([]byte(sessionID))
+}
+
+// sessionOfResponse returns the session of a response ID
+func sessionOfResponse(responseID string) (string, bool) {
+	rest, ok := strings.CutPrefix(responseID, "resp_")
+	if !ok {
+		return "", false
+	}
+	_, encoded, ok := strings.Cut(rest, "_")
+	if !ok {
+		return "", false
+	}
+	sessionID, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sessionID) == 0 {
+		return "", false
+	}
+	return string(sessionID), true
+}
+
+// newItemID returns an output item ID with the given prefix
+func newItemID(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// handleResponses handles Responses API requests with the same agents as chat completions
+func (s *Server) handleResponses(ctx context.Context, c *app.RequestContext) {
+	var req ResponsesRequest
+	if err := c.BindJSON(&req); err != nil {
+		logger.Ctx(ctx).Errorf("[API] Failed to parse responses request: %v", err)
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	a, model, ok := s.modelAgent(req.Model)
+	if !ok {
+		writeError(c, consts.StatusNotFound, "model_not_found", invalidParam("model", "model %s does not exist", req.Model))
+		return
+	}
+	turn, err := newResponsesTurn(&req, a)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Invalid responses request: %v", err)
+		writeError(c, consts.StatusBadRequest, "", err)
+		return
+	}
+	turn.agent, turn.model = a, model
+	// Response IDs carry the client's session ID, so another tenant's IDs resolve to sessions of its own
+	publicID := turn.sessionID
+	if turn.sessionID, err = s.scopeSession(c, turn.sessionID); err != nil {
+		writeError(c, consts.StatusBadRequest, "", err)
+		return
+	}
+	if quotaErr := s.checkSpend(ctx, c, turn.sessionID); quotaErr != nil {
+		writeQuotaError(c, quotaErr)
+		return
+	}
+
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	resp := &ResponseObject{
+		ID:                newResponseID(publicID),
+		Object:            "response",
+		CreatedAt:         time.Now().Unix(),
+		Status:            "in_progress",
+		Model:             model,
+		Output:            []interface{}{},
+		ParallelToolCalls: true,
+		Tools:             req.Tools,
+		Metadata:          req.Metadata,
+	}
+	if req.PreviousResponseID != "" {
+		resp.PreviousResponseID = &req.PreviousResponseID
+	}
+	if resp.Tools == nil {
+		resp.Tools = []ResponsesTool{}
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = map[string]string{}
+	}
+
+	logger.Ctx(ctx).Debugf("[API] Received responses request - Session: %s, Model: %s, Stream: %v", turn.sessionID, model, req.Stream)
+	if req.Stream {
+		s.handleResponsesStream(ctx, c, turn, resp)
+	} else {
+		s.handleResponsesResponse(ctx, c, turn, resp)
+	}
+}
+
+// newResponsesTurn maps a Responses request to a turn. A previous_response_id continues that session with the
+// input items; otherwise a lone user message starts a new session and longer input replaces its history.
+func newResponsesTurn(req *ResponsesRequest, a *agent.Agent) (*chatTurn, error) {
+	turn := &chatTurn{sessionID: uuid.New().String()}
+	continued := false
+	if req.PreviousResponseID != "" {
+		sessionID, ok := sessionOfResponse(req.PreviousResponseID)
+		if !ok {
+			return nil, invalidParam("previous_response_id", "unknown response %s", req.PreviousResponseID)
+		}
+		turn.sessionID, continued = sessionID, true
+	}
+
+	opts, err := generationOptions(&OpenAIRequest{Temperature: req.Temperature, TopP: req.TopP})
+	if err != nil {
+		return nil, err
+	}
+	if req.MaxOutputTokens != nil {
+		if *req.MaxOutputTokens <= 0 {
+			return nil, invalidParam("max_output_tokens", "max_output_tokens must be positive")
+		}
+		opts = append(opts, agent.WithMaxTokens(*req.MaxOutputTokens))
+	}
+	turn.opts = opts
+
+	toolOpts, err := responsesToolOptions(req.Tools, req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	turn.opts = append(turn.opts, toolOpts...)
+
+	instructions, messages, err := responsesInput(req.Input, continued)
+	if err != nil {
+		return nil, err
+	}
+	if req.Instructions != "" {
+		instructions = append([]string{req.Instructions}, instructions...)
+	}
+	if len(instructions) > 0 {
+		turn.opts = append(turn.opts, agent.WithInstructions(strings.Join(instructions, "\n\n")))
+	}
+
+	if len(messages) == 0 {
+		return nil, invalidParam("input", "no user message found")
+	}
+	last := messages[len(messages)-1]
+	if last.Role != schema.User && last.Role != schema.Tool {
+		return nil, invalidParam("input", "last input item must be a user message or a function_call_output")
+	}
+	if last.Role == schema.User {
+		turn.userMessage = last.Content
+	}
+	if len(messages) == 1 && last.Role == schema.User {
+		turn.parts = last.UserInputMultiContent
+		return turn, nil
+	}
+	if continued {
+		// Function call outputs and further messages extend the stored conversation
+		history, _ := a.GetSessionHistory(turn.sessionID)
+		messages = append(history, messages...)
+	}
+	turn.messages = messages
+	return turn, nil
+}
+
+// responsesInput converts the input to messages; system and developer messages are returned as instructions.
+// Function calls are merged into the preceding assistant message; when continuing a response they are
+// already stored and skipped.
+func responsesInput(raw json.RawMessage, continued bool) ([]string, []*schema.Message, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return nil, []*schema.Message{schema.UserMessage(text)}, nil
+	}
+	var items []ResponseInputItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, nil, invalidParam("input", "input must be a string or an array of input items")
+	}
+
+	var instructions []string
+	var messages []*schema.Message
+	for i, item := range items {
+		switch item.Type {
+		case "", "message":
+			msg, err := item.toSchema()
+			if err != nil {
+				return nil, nil, invalidParam("input", "input item %d: %w", i, err)
+			}
+			if msg.Role == schema.System {
+				instructions = append(instructions, msg.Content)
+				continue
+			}
+			messages = append(messages, msg)
+		case "function_call":
+			if continued {
+				continue
+			}
+			call := schema.ToolCall{
+				ID:       item.CallID,
+				Type:     "function",
+				Function: schema.FunctionCall{Name: item.Name, Arguments: item.Arguments},
+			}
+			if n := len(messages); n > 0 && messages[n-1].Role == schema.Assistant {
+				messages[n-1].ToolCalls = append(messages[n-1].ToolCalls, call)
+			} else {
+				messages = append(messages, schema.AssistantMessage("", []schema.ToolCall{call}))
+			}
+		case "function_call_output":
+			output, err := partsText(item.Output)
+			if err != nil {
+				return nil, nil, invalidParam("input", "input item %d: %w", i, err)
+			}
+			messages = append(messages, schema.ToolMessage(output, item.CallID))
+		default:
+			return nil, nil, invalidParam("input", "input item %d: unsupported type %q", i, item.Type)
+		}
+	}
+	return instructions, messages, nil
+}
+
+// toSchema converts a message input item; system and developer messages become system messages
+func (item *ResponseInputItem) toSchema() (*schema.Message, error) {
+	var text string
+	if err := json.Unmarshal(item.Content, &text); err == nil {
+		switch item.Role {
+		case "user":
+			return schema.UserMessage(text), nil
+		case "assistant":
+			return schema.AssistantMessage(text, nil), nil
+		case "system", "developer":
+			return schema.SystemMessage(text), nil
+		default:
+			return nil, fmt.Errorf("unsupported role %q", item.Role)
+		}
+	}
+
+	var parts []ResponseContentPart
+	if err := json.Unmarshal(item.Content, &parts); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content parts")
+	}
+	if item.Role != "user" {
+		var texts []string
+		for _, part := range parts {
+			texts = append(texts, part.Text)
+		}
+		content := strings.Join(texts, "\n")
+		switch item.Role {
+		case "assistant":
+			return schema.AssistantMessage(content, nil), nil
+		case "system", "developer":
+			return schema.SystemMessage(content), nil
+		default:
+			return nil, fmt.Errorf("unsupported role %q", item.Role)
+		}
+	}
+
+	var texts []string
+	var inputParts []schema.MessageInputPart
+	hasImage := false
+	for _, part := range parts {
+		switch part.Type {
+		case "input_text", "text":
+			texts = append(texts, part.Text)
+			inputParts = append(inputParts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeText, Text: part.Text})
+		case "input_image":
+			image := &schema.MessageInputImage{Detail: schema.ImageURLDetail(part.Detail)}
+			if mime, data, ok := parseDataURL(part.ImageURL); ok {
+				image.Base64Data, image.MIMEType = &data, mime
+			} else {
+				url := part.ImageURL
+				image.URL = &url
+			}
+			hasImage = true
+			inputParts = append(inputParts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeImageURL, Image: image})
+		default:
+			return nil, fmt.Errorf("unsupported content part %q", part.Type)
+		}
+	}
+	if hasImage {
+		return &schema.Message{Role: schema.User, UserInputMultiContent: inputParts}, nil
+	}
+	return schema.UserMessage(strings.Join(texts, "\n")), nil
+}
+
+// partsText returns a string, or joins the text of an array of content parts
+func partsText(raw json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var parts []ResponseContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", fmt.Errorf("output must be a string or an array of content parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// responsesToolOptions converts the declared functions and the tool choice to chat options
+func responsesToolOptions(tools []ResponsesTool, toolChoice json.RawMessage) ([]agent.ChatOption, error) {
+	chatTools := make([]OpenAITool, 0, len(tools))
+	for _, t := range tools {
+		chatTools = append(chatTools, OpenAITool{
+			Type:     t.Type,
+			Function: OpenAIFunctionDef{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		})
+	}
+
+	// A named choice is flat in the Responses API and nested in chat completions
+	var named struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(toolChoice, &named); err == nil && named.Name != "" {
+		toolChoice, _ = json.Marshal(map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": named.Name},
+		})
+	}
+	return toolOptions(chatTools, toolChoice)
+}
+
+// toResponseOutput converts an answer to output items: the message, then the function calls
+func toResponseOutput(msg *schema.Message) []interface{} {
+	output := make([]interface{}, 0, 1+len(msg.ToolCalls))
+	if msg.Content != "" || len(msg.ToolCalls) == 0 {
+		output = append(output, ResponseMessageItem{
+			Type:    "message",
+			ID:      newItemID("msg"),
+			Status:  "completed",
+			Role:    "assistant",
+			Content: []ResponseOutputText{{Type: "output_text", Text: msg.Content, Annotations: []interface{}{}}},
+		})
+	}
+	for _, tc := range msg.ToolCalls {
+		output = append(output, ResponseFunctionCallItem{
+			Type:      "function_call",
+			ID:        newItemID("fc"),
+			Status:    "completed",
+			CallID:    tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return output
+}
+
+// handleResponsesResponse answers a non-streaming Responses request
+func (s *Server) handleResponsesResponse(ctx context.Context, c *app.RequestContext, turn *chatTurn, resp *ResponseObject) {
+	sessionID := turn.sessionID
+	recordSession(c, sessionID)
+	response, err := s.chat(ctx, turn)
+	if errors.Is(err, agent.ErrRunCancelled) {
+		writeError(c, consts.StatusConflict, "cancelled", err)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Ctx(ctx).Warnf("[API] Chat timed out - Session: %s", sessionID)
+		writeError(c, consts.StatusGatewayTimeout, "timeout", errors.New("request timed out"))
+		return
+	}
+	var approvalErr *agent.ApprovalRequiredError
+	if errors.As(err, &approvalErr) {
+		logger.Ctx(ctx).Infof("[API] Run paused for approval - Session: %s", sessionID)
+		c.JSON(consts.StatusAccepted, map[string]interface{}{
+			"session":           s.publicSession(sessionID),
+			"status":            "approval_required",
+			"pending_approvals": approvalErr.Requests,
+		})
+		return
+	}
+	if writeLimitError(c, err) {
+		return
+	}
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) {
+		if guardErr.Stage == agent.GuardrailInput {
+			writeError(c, consts.StatusBadRequest, "content_filter", guardErr)
+			return
+		}
+		resp.Status = "incomplete"
+		resp.IncompleteDetails = &IncompleteDetails{Reason: "content_filter"}
+		resp.Usage = toResponseUsage(nil)
+		c.JSON(consts.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Chat failed - Session: %s, Error: %v", sessionID, err)
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("chat failed: %w", err))
+		return
+	}
+
+	resp.Status = "completed"
+	resp.Output = toResponseOutput(response)
+	resp.Usage = toResponseUsage(usageOf(response))
+	recordUsage(c, usageOf(response))
+	c.JSON(consts.StatusOK, resp)
+}
+
+// handleResponsesStream answers a streaming Responses request with response.* events
+func (s *Server) handleResponsesStream(ctx context.Context, c *app.RequestContext, turn *chatTurn, resp *ResponseObject) {
+	stream, err := s.chatStream(ctx, turn)
+	if writeLimitError(c, err) {
+		return
+	}
+	var guardErr *agent.GuardrailError
+	if errors.As(err, &guardErr) {
+		writeError(c, consts.StatusBadRequest, "content_filter", guardErr)
+		return
+	}
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Chat stream failed - Session: %s, Error: %v", turn.sessionID, err)
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("chat stream failed: %w", err))
+		return
+	}
+	s.writeResponsesStream(ctx, c, turn, resp, stream)
+}
+
+// responsesStream tracks the output items of a streamed response
+type responsesStream struct {
+	sse      *sse.Stream
+	resp     *ResponseObject
+	sequence int
+	text     *ResponseMessageItem // Message item receiving text deltas, if open
+}
+
+// send publishes an event named after its type
+func (w *responsesStream) send(eventType string, payload map[string]interface{}) {
+	payload["type"] = eventType
+	payload["sequence_number"] = w.sequence
+	w.sequence++
+	data, _ := json.Marshal(payload)
+	w.sse.Publish(&sse.Event{Event: eventType, Data: data})
+}
+
+// delta appends text, opening a message item if none is open
+func (w *responsesStream) delta(text string) {
+	if w.text == nil {
+		w.text = &ResponseMessageItem{Type: "message", ID: newItemID("msg"), Status: "in_progress", Role: "assistant", Content: []ResponseOutputText{}}
+		w.send("response.output_item.added", map[string]interface{}{"output_index": len(w.resp.Output), "item": w.text})
+		w.text.Content = append(w.text.Content, ResponseOutputText{Type: "output_text", Annotations: []interface{}{}})
+		w.send("response.content_part.added", map[string]interface{}{
+			"item_id": w.text.ID, "output_index": len(w.resp.Output), "content_index": 0, "part": w.text.Content[0],
+		})
+	}
+	w.text.Content[0].Text += text
+	w.send("response.output_text.delta", map[string]interface{}{
+		"item_id": w.text.ID, "output_index": len(w.resp.Output), "content_index": 0, "delta": text,
+	})
+}
+
+// closeText completes the open message item, if any
+func (w *responsesStream) closeText() {
+	if w.text == nil {
+		return
+	}
+	item, index := w.text, len(w.resp.Output)
+	item.Status = "completed"
+	w.send("response.output_text.done", map[string]interface{}{
+		"item_id": item.ID, "output_index": index, "content_index": 0, "text": item.Content[0].Text,
+	})
+	w.send("response.content_part.done", map[string]interface{}{
+		"item_id": item.ID, "output_index": index, "content_index": 0, "part": item.Content[0],
+	})
+	w.send("response.output_item.done", map[string]interface{}{"output_index": index, "item": item})
+	w.resp.Output = append(w.resp.Output, *item)
+	w.text = nil
+}
+
+// functionCall sends a complete function_call item
+func (w *responsesStream) functionCall(tc *schema.ToolCall) {
+	w.closeText()
+	index := len(w.resp.Output)
+	item := ResponseFunctionCallItem{Type: "function_call", ID: newItemID("fc"), Status: "in_progress", CallID: tc.ID, Name: tc.Function.Name}
+	w.send("response.output_item.added", map[string]interface{}{"output_index": index, "item": item})
+	w.send("response.function_call_arguments.delta", map[string]interface{}{
+		"item_id": item.ID, "output_index": index, "delta": tc.Function.Arguments,
+	})
+	w.send("response.function_call_arguments.done", map[string]interface{}{
+		"item_id": item.ID, "output_index": index, "arguments": tc.Function.Arguments,
+	})
+	item.Status, item.Arguments = "completed", tc.Function.Arguments
+	w.send("response.output_item.done", map[string]interface{}{"output_index": index, "item": item})
+	w.resp.Output = append(w.resp.Output, item)
+}
+
+// writeResponsesStream relays agent stream events as Responses API events.
+// Server-side tool activity is not forwarded; a run paused for approval ends as an incomplete response.
+func (s *Server) writeResponsesStream(ctx context.Context, c *app.RequestContext, turn *chatTurn, resp *ResponseObject, stream *schema.StreamReader[*agent.StreamEvent]) {
+	defer stream.Close()
+	s.activeStreams.Add(1)
+	defer s.activeStreams.Add(-1)
+	sessionID := turn.sessionID
+	recordSession(c, sessionID)
+
+	c.SetContentType("text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("Connection", "keep-alive")
+
+	w := &responsesStream{sse: sse.NewStream(c), resp: resp}
+	w.send("response.created", map[string]interface{}{"response": resp})
+	w.send("response.in_progress", map[string]interface{}{"response": resp})
+
+	var fullContent string
+	var clientCalls []schema.ToolCall
+	var usage *schema.TokenUsage
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Ctx(ctx).Errorf("[API] Stream error - Session: %s, Error: %v", sessionID, err)
+			w.closeText()
+			resp.Status = "failed"
+			resp.Error = &ResponseError{Code: "server_error", Message: err.Error()}
+			w.send("response.failed", map[string]interface{}{"response": resp})
+			return
+		}
+
+		switch chunk.Type {
+		case agent.EventAssistantDelta:
+			if chunk.Message.Content == "" {
+				continue
+			}
+			fullContent += chunk.Message.Content
+			w.delta(chunk.Message.Content)
+		case agent.EventClientToolCall:
+			clientCalls = append(clientCalls, *chunk.ToolCall)
+			w.functionCall(chunk.ToolCall)
+		case agent.EventUsage:
+			usage = chunk.Usage
+		case agent.EventApprovalRequired:
+			logger.Ctx(ctx).Infof("[API] Run paused for approval - Session: %s", sessionID)
+			resp.IncompleteDetails = &IncompleteDetails{Reason: "approval_required"}
+		case agent.EventGuardrailBlocked:
+			resp.IncompleteDetails = &IncompleteDetails{Reason: "content_filter"}
+		case agent.EventLimitExceeded:
+			logger.Ctx(ctx).Warnf("[API] Stream aborted by limit - Session: %s, Error: %v", sessionID, chunk.Limit)
+			resp.Error = &ResponseError{Code: string(chunk.Limit.Limit), Message: chunk.Limit.Error()}
+		case agent.EventCancelled:
+			logger.Ctx(ctx).Infof("[API] Stream cancelled - Session: %s", sessionID)
+			resp.Error = &ResponseError{Code: "cancelled", Message: agent.ErrRunCancelled.Error()}
+		}
+	}
+	w.closeText()
+
+	resp.Usage = toResponseUsage(usage)
+	recordUsage(c, usage)
+	switch {
+	case resp.Error != nil:
+		resp.Status = "failed"
+		w.send("response.failed", map[string]interface{}{"response": resp})
+	case resp.IncompleteDetails != nil:
+		resp.Status = "incomplete"
+		w.send("response.incomplete", map[string]interface{}{"response": resp})
+	default:
+		resp.Status = "completed"
+		w.send("response.completed", map[string]interface{}{"response": resp})
+	}
+
+	paused := resp.IncompleteDetails != nil && resp.IncompleteDetails.Reason == "approval_required"
+	if !paused || fullContent != "" {
+		turn.agent.AppendAssistantMessage(sessionID, schema.AssistantMessage(fullContent, clientCalls))
+	}
+}
@@ -0,0 +1,281 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+)
+
+// WithUserSessions derives the session of requests that name none from the OpenAI "user" field, so stock
+// SDK clients get continuity. With perConversation the opening user message is hashed in as well, giving
+// each conversation of a user its own session.
+func WithUserSessions(perConversation bool) Option {
+	return func(s *Server) {
+		s.userSessions = true
+		s.sessionPerConversation = perConversation
+	}
+}
+
+// userSession returns a stable session ID for a user and, with perConversation, the opening user message
+func userSession(user string, msgs []OpenAIMessage, perConversation bool) string {
+	h := sha256.New()
+	h.Write([]byte(user))
+	if perConversation {
+		for _, msg := range msgs {
+			if msg.Role == "user" {
+				h.Write([]byte{0})
+				h.Write([]byte(msg.Content))
+				break
+			}
+		}
+	}
+	return "user-" + hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// registerSessionRoutes registers the session management endpoints
+func (s *Server) registerSessionRoutes() {
+	s.httpServer.GET("/v1/sessions", s.handleListSessions)
+	s.httpServer.GET("/v1/sessions/:id/messages", s.handleGetSessionMessages)
+	s.httpServer.DELETE("/v1/sessions/:id", s.handleDeleteSession)
+	s.httpServer.PATCH("/v1/sessions/:id", s.handleUpdateSession)
+	s.httpServer.POST("/v1/sessions/:id/cancel", s.handleCancelSession)
+	s.httpServer.GET("/v1/sessions/:id/trace", s.handleGetTrace)
+}
+
+// handleGetTrace returns the ReAct trace of the session's latest chat turn for debugging
+func (s *Server) handleGetTrace(ctx context.Context, c *app.RequestContext) {
+	sessionID := s.sessionParam(c)
+	// The session may have been served by several models; report the latest turn
+	var trace *agent.Trace
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		if t, ok := a.GetLastTrace(sessionID); ok && (trace == nil || t.StartedAt.After(trace.StartedAt)) {
+			trace = t
+		}
+	}
+	if trace == nil {
+		writeError(c, consts.StatusNotFound, "trace_not_found", fmt.Errorf("no trace recorded for session %s", c.Param("id")))
+		return
+	}
+	trace.SessionID = s.publicSession(trace.SessionID) // A snapshot, safe to modify
+	c.JSON(consts.StatusOK, trace)
+}
+
+// handleCancelSession aborts the in-flight runs of a session
+func (s *Server) handleCancelSession(ctx context.Context, c *app.RequestContext) {
+	sessionID := s.sessionParam(c)
+	cancelled := false
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		if a.CancelSession(sessionID) {
+			cancelled = true
+		}
+	}
+	if !cancelled {
+		writeError(c, consts.StatusNotFound, "run_not_found", fmt.Errorf("no run in progress for session %s", c.Param("id")))
+		return
+	}
+	logger.Ctx(ctx).Infof("[API] Cancelled session %s", sessionID)
+	c.JSON(consts.StatusOK, map[string]interface{}{"session": c.Param("id"), "cancelled": true})
+}
+
+// handleListSessions lists the sessions of all served models with their metadata, most recently active first
+func (s *Server) handleListSessions(ctx context.Context, c *app.RequestContext) {
+	byID := make(map[string]*memory.SessionMeta)
+	prefix := s.tenantPrefix(c)
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		for _, meta := range a.ListSessionsWithMeta(ctx, prefix) {
+			if !s.inTenant(c, meta.ID) {
+				continue
+			}
+			if seen, ok := byID[meta.ID]; !ok || meta.LastActiveAt.After(seen.LastActiveAt) {
+				byID[meta.ID] = meta
+			}
+		}
+	}
+
+	sessions := make([]*memory.SessionMeta, 0, len(byID))
+	for _, meta := range byID {
+		public := *meta
+		public.ID = s.publicSession(meta.ID)
+		sessions = append(sessions, &public)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastActiveAt.After(sessions[j].LastActiveAt) })
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   sessions,
+	})
+}
+
+// handleGetSessionMessages returns the message history of a session
+func (s *Server) handleGetSessionMessages(ctx context.Context, c *app.RequestContext) {
+	sessionID := s.sessionParam(c)
+	a, ok := s.sessionAgent(sessionID)
+	if !ok {
+		writeError(c, consts.StatusNotFound, "session_not_found", fmt.Errorf("session %s not found", c.Param("id")))
+		return
+	}
+	history, _ := a.GetSessionHistory(sessionID)
+	messages := make([]OpenAIMessage, 0, len(history))
+	for _, msg := range history {
+		messages = append(messages, fromSchema(msg))
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object":  "list",
+		"session": c.Param("id"),
+		"data":    messages,
+	})
+}
+
+// SessionUpdateRequest is the body of a session update; omitted fields are left unchanged
+type SessionUpdateRequest struct {
+	ReadOnly *bool `json:"read_only,omitempty"` // Block mutating tools in the session's later turns
+}
+
+// handleUpdateSession changes the settings of a session for every served model, creating it if needed
+// so that it can be restricted before its first turn
+func (s *Server) handleUpdateSession(ctx context.Context, c *app.RequestContext) {
+	sessionID := s.sessionParam(c)
+	var req SessionUpdateRequest
+	if err := c.BindJSON(&req); err != nil {
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	if req.ReadOnly != nil {
+		for _, name := range s.modelNames() {
+			a, _, _ := s.modelAgent(name)
+			a.SetSessionReadOnly(ctx, sessionID, *req.ReadOnly)
+		}
+		logger.Ctx(ctx).Infof("[API] Session %s read-only: %v", sessionID, *req.ReadOnly)
+	}
+
+	var meta *memory.SessionMeta
+	a, ok := s.sessionAgent(sessionID)
+	if ok {
+		meta, ok = a.GetSessionMeta(sessionID)
+	}
+	if !ok {
+		writeError(c, consts.StatusNotFound, "session_not_found", fmt.Errorf("session %s not found", c.Param("id")))
+		return
+	}
+	meta.ID = s.publicSession(meta.ID)
+	c.JSON(consts.StatusOK, meta)
+}
+
+// handleDeleteSession deletes a session and its stored history from every served model
+func (s *Server) handleDeleteSession(ctx context.Context, c *app.RequestContext) {
+	sessionID := s.sessionParam(c)
+	if _, ok := s.sessionAgent(sessionID); !ok {
+		writeError(c, consts.StatusNotFound, "session_not_found", fmt.Errorf("session %s not found", c.Param("id")))
+		return
+	}
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		if err := a.DeleteSession(ctx, sessionID); err != nil {
+			logger.Ctx(ctx).Errorf("[API] Failed to delete session %s: %v", sessionID, err)
+			writeError(c, consts.StatusInternalServerError, "", err)
+			return
+		}
+	}
+	logger.Ctx(ctx).Infof("[API] Deleted session %s", sessionID)
+	c.JSON(consts.StatusOK, map[string]interface{}{"id": c.Param("id"), "object": "session.deleted", "deleted": true})
+}
+
+// Page sizes of the stored session listing
+const (
+	defaultSessionPage = 100
+	maxSessionPage     = 1000
+)
+
+// handleAdminListSessions pages through the sessions in the memory store, across tenants: prefix narrows
+// them (e.g. to a tenant with "<tenant>/"), and cursor continues after the next_cursor of the previous page
+func (s *Server) handleAdminListSessions(ctx context.Context, c *app.RequestContext) {
+	limit := defaultSessionPage
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSessionPage {
+			writeError(c, consts.StatusBadRequest, "", invalidParam("limit", "limit must be between 1 and %d", maxSessionPage))
+			return
+		}
+		limit = n
+	}
+	// The agents of all models share the memory store
+	a, _, _ := s.modelAgent("")
+	ids, err := a.ListStoredSessions(ctx, c.Query("prefix"), c.Query("cursor"), limit)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Failed to list stored sessions: %v", err)
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("failed to list sessions: %w", err))
+		return
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	resp := map[string]interface{}{
+		"object": "list",
+		"data":   ids,
+	}
+	if len(ids) == limit {
+		resp["next_cursor"] = ids[len(ids)-1]
+	}
+	c.JSON(consts.StatusOK, resp)
+}
+
+// handleAdminDeleteSession deletes a session of any tenant by its full ID, including from the memory store
+func (s *Server) handleAdminDeleteSession(ctx context.Context, c *app.RequestContext) {
+	sessionID := strings.TrimPrefix(c.Param("id"), "/")
+	if sessionID == "" {
+		writeError(c, consts.StatusBadRequest, "", invalidParam("id", "session ID is required"))
+		return
+	}
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		if err := a.DeleteSession(ctx, sessionID); err != nil {
+			logger.Ctx(ctx).Errorf("[API] Failed to delete session %s: %v", sessionID, err)
+			writeError(c, consts.StatusInternalServerError, "", err)
+			return
+		}
+	}
+	logger.Ctx(ctx).Infof("[API] Deleted session %s by admin request", sessionID)
+	c.JSON(consts.StatusOK, map[string]interface{}{"id": sessionID, "object": "session.deleted", "deleted": true})
+}
+
+// sessionParam returns the session named by the id path parameter, in the namespace of the request's tenant
+func (s *Server) sessionParam(c *app.RequestContext) string {
+	// Path parameters cannot hold the tenant separator
+	sessionID, _ := s.scopeSession(c, c.Param("id"))
+	return sessionID
+}
+
+// sessionAgent returns the agent that most recently served the session
+func (s *Server) sessionAgent(sessionID string) (*agent.Agent, bool) {
+	var found *agent.Agent
+	var lastActive *memory.SessionMeta
+	for _, name := range s.modelNames() {
+		a, _, _ := s.modelAgent(name)
+		meta, ok := a.GetSessionMeta(sessionID)
+		if !ok {
+			if _, ok := a.GetSessionHistory(sessionID); ok && found == nil {
+				found = a
+			}
+			continue
+		}
+		if lastActive == nil || meta.LastActiveAt.After(lastActive.LastActiveAt) {
+			found, lastActive = a, meta
+		}
+	}
+	return found, found != nil
+}
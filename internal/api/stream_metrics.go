@@ -0,0 +1,114 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/metrics"
+)
+
+// Streaming latency metrics
+var (
+	streamTTFT = metrics.NewHistogram("eino_stream_ttft_seconds",
+		"Time from request start to the first streamed content chunk", metrics.DefBuckets, "model")
+	streamChunkGap = metrics.NewHistogram("eino_stream_inter_chunk_seconds",
+		"Gap between consecutive streamed content chunks",
+		[]float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}, "model")
+	streamDuration = metrics.NewHistogram("eino_stream_duration_seconds",
+		"Total duration of a streamed response", metrics.DefBuckets, "model")
+	streamChunks = metrics.NewCounter("eino_stream_chunks_total",
+		"Total number of streamed content chunks", "model")
+	streamRequests = metrics.NewCounter("eino_stream_requests_total",
+		"Total number of streamed responses", "model")
+)
+
+// Trailer names used when stream trailers are enabled
+const (
+	trailerTTFT     = "X-Stream-TTFT-Ms"
+	trailerDuration = "X-Stream-Duration-Ms"
+	trailerChunks   = "X-Stream-Chunks"
+	trailerMaxGap   = "X-Stream-Max-Gap-Ms"
+)
+
+// streamStats tracks timing information for a single streamed response
+type streamStats struct {
+	model     string
+	received  time.Time // Arrival of the request, the origin of the time to first token
+	start     time.Time
+	firstAt   time.Time
+	lastAt    time.Time
+	chunks    int
+	maxGap    time.Duration
+	totalGaps time.Duration
+}
+
+// newStreamStats starts timing a streamed response to a request received at the given time
+func newStreamStats(model string, received time.Time) *streamStats {
+	return &streamStats{
+		model:    model,
+		received: received,
+		start:    time.Now(),
+	}
+}
+
+// chunk records the arrival of a content chunk
+func (s *streamStats) chunk() {
+	now := time.Now()
+	if s.chunks == 0 {
+		s.firstAt = now
+		streamTTFT.Observe(now.Sub(s.received).Seconds(), s.model)
+	} else {
+		gap := now.Sub(s.lastAt)
+		s.totalGaps += gap
+		if gap > s.maxGap {
+			s.maxGap = gap
+		}
+		streamChunkGap.Observe(gap.Seconds(), s.model)
+	}
+	s.lastAt = now
+	s.chunks++
+	streamChunks.Inc(s.model)
+}
+
+// ttft returns the time to first token, or zero if nothing was streamed
+func (s *streamStats) ttft() time.Duration {
+	if s.chunks == 0 {
+		return 0
+	}
+	return s.firstAt.Sub(s.received)
+}
+
+// finish records the total stream duration and returns it
+func (s *streamStats) finish() time.Duration {
+	d := time.Since(s.start)
+	streamDuration.Observe(d.Seconds(), s.model)
+	streamRequests.Inc(s.model)
+
+	var avgGap time.Duration
+	if s.chunks > 1 {
+		avgGap = s.totalGaps / time.Duration(s.chunks-1)
+	}
+	logger.Debugf("[API] Stream timings - Model: %s, TTFT: %s, Duration: %s, Chunks: %d, AvgGap: %s, MaxGap: %s",
+		s.model, s.ttft(), d, s.chunks, avgGap, s.maxGap)
+	return d
+}
+
+// declareStreamTrailers announces the trailer names; must be called before the first SSE event is published
+func declareStreamTrailers(c *app.RequestContext) {
+	for _, name := range []string{trailerTTFT, trailerDuration, trailerChunks, trailerMaxGap} {
+		c.Response.Header.Trailer().Set(name, "")
+	}
+}
+
+// setStreamTrailers fills in the trailer values once the stream has completed
+func setStreamTrailers(c *app.RequestContext, s *streamStats, duration time.Duration) {
+	trailer := c.Response.Header.Trailer()
+	trailer.Set(trailerTTFT, strconv.FormatInt(s.ttft().Milliseconds(), 10))
+	trailer.Set(trailerDuration, strconv.FormatInt(duration.Milliseconds(), 10))
+	trailer.Set(trailerChunks, strconv.Itoa(s.chunks))
+	trailer.Set(trailerMaxGap, strconv.FormatInt(s.maxGap.Milliseconds(), 10))
+}
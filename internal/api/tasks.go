@@ -0,0 +1,90 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/tasks"
+)
+
+// WithTasks enables the scheduled task management endpoints
+func WithTasks(scheduler *tasks.Scheduler) Option {
+	return func(s *Server) {
+		s.tasks = scheduler
+	}
+}
+
+// registerTaskRoutes registers the scheduled task endpoints.
+// All of them take the admin token, or a loopback client without one.
+func (s *Server) registerTaskRoutes() {
+	auth := operatorAuth(s.adminToken, "admin")
+	s.httpServer.GET("/v1/tasks", auth, s.handleListTasks)
+	s.httpServer.POST("/v1/tasks", auth, s.handleCreateTask)
+	s.httpServer.DELETE("/v1/tasks/:name", auth, s.handleDeleteTask)
+	s.httpServer.POST("/v1/tasks/:name/run", append([]app.HandlerFunc{auth}, s.runHandlers(s.handleRunTask)...)...)
+}
+
+// handleListTasks lists scheduled tasks and their status, with output credentials redacted
+func (s *Server) handleListTasks(ctx context.Context, c *app.RequestContext) {
+	list := s.tasks.List()
+	for i := range list {
+		list[i].Output = list[i].Output.Redacted()
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   list,
+	})
+}
+
+// handleCreateTask creates or replaces a scheduled task
+func (s *Server) handleCreateTask(ctx context.Context, c *app.RequestContext) {
+	var cfg tasks.TaskConfig
+	if err := c.BindJSON(&cfg); err != nil {
+		writeError(c, consts.StatusBadRequest, "", fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if err := s.tasks.AddRuntime(cfg); err != nil {
+		writeError(c, consts.StatusBadRequest, "", err)
+		return
+	}
+	logger.Ctx(ctx).Infof("[API] Registered task %s", cfg.Name)
+	c.JSON(consts.StatusOK, map[string]interface{}{"name": cfg.Name, "created": true})
+}
+
+// handleDeleteTask removes a scheduled task
+func (s *Server) handleDeleteTask(ctx context.Context, c *app.RequestContext) {
+	name := c.Param("name")
+	if !s.tasks.Remove(name) {
+		writeError(c, consts.StatusNotFound, "task_not_found", fmt.Errorf("task %s not found", name))
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"name": name, "deleted": true})
+}
+
+// handleRunTask runs a task immediately and returns its result; the run counts against the quotas of the task's session
+func (s *Server) handleRunTask(ctx context.Context, c *app.RequestContext) {
+	name := c.Param("name")
+	task, ok := s.tasks.Get(name)
+	if !ok {
+		writeError(c, consts.StatusNotFound, "task_not_found", fmt.Errorf("task %s not found", name))
+		return
+	}
+	recordSession(c, task.Session)
+	if quotaErr := s.checkSpend(ctx, c, task.Session); quotaErr != nil {
+		writeQuotaError(c, quotaErr)
+		return
+	}
+
+	result, err := s.tasks.RunNow(ctx, name)
+	if err != nil {
+		writeError(c, consts.StatusNotFound, "task_not_found", err)
+		return
+	}
+	recordUsage(c, result.Usage)
+	c.JSON(consts.StatusOK, result)
+}
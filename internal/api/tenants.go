@@ -0,0 +1,146 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// tenantHeader names the tenant of a request whose API key is not mapped to one
+const tenantHeader = "X-Tenant-ID"
+
+// tenantKey is the key under which the tenant of a request is recorded
+const tenantKey = "tenant"
+
+// tenantSeparator joins a tenant and a client session ID into the session ID used by agents and the memory store
+const tenantSeparator = "/"
+
+// tenancy resolves request tenants
+type tenancy struct {
+	keys     map[string]string // Tenant by API key
+	keyed    map[string]bool   // Tenants some API key maps to
+	required bool
+	keysOnly bool
+}
+
+// WithTenants isolates sessions per tenant: session IDs, memory store keys, and the session endpoints are
+// scoped to the tenant of each request. The tenant is the one keys maps the request's API key to, or else
+// its X-Tenant-ID header, which cannot name a tenant that has keys, nor any tenant with keysOnly when keys
+// are configured. Requests with neither share the default namespace, or are refused if required.
+func WithTenants(keys map[string]string, required, keysOnly bool) Option {
+	return func(s *Server) {
+		keyed := make(map[string]bool, len(keys))
+		for _, tenant := range keys {
+			keyed[tenant] = true
+		}
+		s.tenancy = &tenancy{keys: keys, keyed: keyed, required: required, keysOnly: keysOnly && len(keys) > 0}
+	}
+}
+
+// tenantMiddleware resolves the tenant of API requests; health, metrics and operator endpoints are not scoped
+func (s *Server) tenantMiddleware(ctx context.Context, c *app.RequestContext) {
+	if !strings.HasPrefix(string(c.Path()), "/v1/") {
+		c.Next(ctx)
+		return
+	}
+
+	header := string(c.GetHeader(tenantHeader))
+	tenant, mapped := "", false
+	if key, ok := strings.CutPrefix(clientKey(c), "key:"); ok {
+		tenant, mapped = s.tenancy.keys[key]
+	}
+	switch {
+	case mapped:
+		if header != "" && header != tenant {
+			logger.Ctx(ctx).Warnf("[API] Rejected tenant %s for an API key of tenant %s", header, tenant)
+			abortWithError(c, consts.StatusForbidden, "tenant_mismatch", errors.New("API key does not belong to this tenant"))
+			return
+		}
+	case header == "":
+	case s.tenancy.keysOnly:
+		abortWithError(c, consts.StatusForbidden, "tenant_key_required", fmt.Errorf("the %s header is not accepted; use an API key of the tenant", tenantHeader))
+		return
+	case s.tenancy.keyed[header]:
+		logger.Ctx(ctx).Warnf("[API] Rejected tenant %s named without one of its API keys", header)
+		abortWithError(c, consts.StatusForbidden, "tenant_key_required", errors.New("tenant requires one of its API keys"))
+		return
+	default:
+		tenant = header
+	}
+	if tenant != "" && (!validRequestID(tenant) || strings.Contains(tenant, tenantSeparator)) {
+		abortWithError(c, consts.StatusBadRequest, "", invalidParam(tenantHeader, "tenant must be printable ASCII without spaces or %q", tenantSeparator))
+		return
+	}
+	if tenant == "" && s.tenancy.required {
+		abortWithError(c, consts.StatusUnauthorized, "tenant_required", errors.New("requests must name a tenant or use an API key of one"))
+		return
+	}
+	c.Set(tenantKey, tenant)
+	c.Next(ctx)
+}
+
+// scopeSession maps a client session ID into the namespace of the request's tenant
+func (s *Server) scopeSession(c *app.RequestContext, sessionID string) (string, error) {
+	if s.tenancy == nil {
+		return sessionID, nil
+	}
+	if strings.Contains(sessionID, tenantSeparator) {
+		return "", invalidParam("session", "session IDs must not contain %q", tenantSeparator)
+	}
+	if tenant := c.GetString(tenantKey); tenant != "" {
+		return tenant + tenantSeparator + sessionID, nil
+	}
+	return sessionID, nil
+}
+
+// scopeLike places an internal session ID in the tenant namespace of sessionID
+func (s *Server) scopeLike(sessionID, id string) string {
+	if s.tenancy == nil {
+		return id
+	}
+	if tenant, _, ok := strings.Cut(sessionID, tenantSeparator); ok {
+		return tenant + tenantSeparator + id
+	}
+	return id
+}
+
+// publicSession returns a session ID as the clients of its tenant know it
+func (s *Server) publicSession(sessionID string) string {
+	if s.tenancy == nil {
+		return sessionID
+	}
+	if _, id, ok := strings.Cut(sessionID, tenantSeparator); ok {
+		return id
+	}
+	return sessionID
+}
+
+// tenantPrefix returns the prefix of the session IDs of the request's tenant, or "" for unscoped requests
+func (s *Server) tenantPrefix(c *app.RequestContext) string {
+	if s.tenancy == nil {
+		return ""
+	}
+	if tenant := c.GetString(tenantKey); tenant != "" {
+		return tenant + tenantSeparator
+	}
+	return ""
+}
+
+// inTenant reports whether a session belongs to the request's tenant
+func (s *Server) inTenant(c *app.RequestContext, sessionID string) bool {
+	if s.tenancy == nil {
+		return true
+	}
+	tenant, _, scoped := strings.Cut(sessionID, tenantSeparator)
+	if !scoped {
+		return c.GetString(tenantKey) == ""
+	}
+	return tenant == c.GetString(tenantKey)
+}
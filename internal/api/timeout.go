@@ -0,0 +1,23 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// WithRequestTimeout aborts chat runs, including their model and tool calls, after d (0 = no timeout)
+func WithRequestTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.requestTimeout = d
+	}
+}
+
+// requestContext derives the context of a chat run from the request context, which the server
+// cancels when the client disconnects, and applies the request timeout
+func (s *Server) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.requestTimeout > 0 {
+		return context.WithTimeout(ctx, s.requestTimeout)
+	}
+	return context.WithCancel(ctx)
+}
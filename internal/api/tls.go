@@ -0,0 +1,58 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig configures HTTPS for the API server
+type TLSConfig struct {
+	CertFile          string // PEM certificate chain
+	KeyFile           string // PEM private key
+	ClientCAFile      string // PEM CA bundle; client certificates signed by it are verified (mTLS)
+	RequireClientCert bool   // Reject clients without a valid certificate (requires ClientCAFile)
+}
+
+// LoadTLSConfig loads the server certificate and, for mTLS, the client CA bundle
+func LoadTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile == "" {
+		if cfg.RequireClientCert {
+			return nil, errors.New("requiring client certificates needs a client CA file")
+		}
+		return tlsConfig, nil
+	}
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if cfg.RequireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// WithTLS serves HTTPS only, using the standard network library since netpoll does not support TLS
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.tls = cfg
+	}
+}
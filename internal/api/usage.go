@@ -0,0 +1,178 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+)
+
+// defaultUsageRange is the period reported by /v1/usage when no start is given
+const defaultUsageRange = 30 * 24 * time.Hour
+
+// usageWriteTimeout bounds recording the usage of a finished request
+const usageWriteTimeout = 5 * time.Second
+
+// UsageEntry is the usage of an API key in a period
+type UsageEntry struct {
+	Key              string    `json:"key"`   // Fingerprint of the configured API key, or the client IP of other callers
+	Start            time.Time `json:"start"` // Start of the period
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	ToolCalls        int64     `json:"tool_calls"`
+}
+
+// WithUsageAccounting counts the requests, tokens and tool calls of agent runs per API key into store.
+// Callers read their own usage at /v1/usage; operators read every key's at /admin/usage.
+func WithUsageAccounting(store memory.UsageStore) Option {
+	return func(s *Server) {
+		s.usage = store
+	}
+}
+
+// usageKey identifies the caller by the key of its rate limit bucket, with configured API keys replaced by a
+// fingerprint so keys are never stored; unknown keys share their IP's record instead of each adding one
+func (s *Server) usageKey(c *app.RequestContext) string {
+	key := s.rateLimitKey(c)
+	if token, ok := strings.CutPrefix(key, "key:"); ok {
+		sum := sha256.Sum256([]byte(token))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return key
+}
+
+// usageMiddleware records the usage of an agent run request once it is answered, including a streamed response
+func (s *Server) usageMiddleware(ctx context.Context, c *app.RequestContext) {
+	c.Next(ctx)
+
+	rec := &memory.UsageRecord{Key: s.usageKey(c), Hour: time.Now(), Requests: 1, ToolCalls: toolCalls(c)}
+	if usage, ok := c.Get(accessUsageKey); ok {
+		u := usage.(*schema.TokenUsage)
+		rec.PromptTokens = int64(u.PromptTokens)
+		rec.CompletionTokens = int64(u.CompletionTokens)
+		rec.TotalTokens = int64(u.TotalTokens)
+	}
+	// Record even if the client went away mid-stream; its tokens were spent
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageWriteTimeout)
+	defer cancel()
+	if err := s.usage.AddUsage(ctx, rec); err != nil {
+		logger.Ctx(ctx).Errorf("[API] Failed to record usage of %s: %v", rec.Key, err)
+	}
+}
+
+// handleUsage reports the caller's own usage
+func (s *Server) handleUsage(ctx context.Context, c *app.RequestContext) {
+	s.writeUsage(ctx, c, s.usageKey(c))
+}
+
+// handleAdminUsage reports the usage of every API key, or of the one given by the key parameter
+func (s *Server) handleAdminUsage(ctx context.Context, c *app.RequestContext) {
+	s.writeUsage(ctx, c, c.Query("key"))
+}
+
+// writeUsage answers with the usage of a key, or all keys, in the requested range and granularity.
+// from and to are RFC 3339 times or Unix seconds; granularity is "hour", "day" or "total" (default).
+func (s *Server) writeUsage(ctx context.Context, c *app.RequestContext, key string) {
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := parseUsageTime(v)
+		if err != nil {
+			writeError(c, consts.StatusBadRequest, "", invalidParam("to", "to must be an RFC 3339 time or Unix seconds"))
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultUsageRange)
+	if v := c.Query("from"); v != "" {
+		t, err := parseUsageTime(v)
+		if err != nil {
+			writeError(c, consts.StatusBadRequest, "", invalidParam("from", "from must be an RFC 3339 time or Unix seconds"))
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		writeError(c, consts.StatusBadRequest, "", invalidParam("from", "from must be before to"))
+		return
+	}
+	granularity := c.DefaultQuery("granularity", "total")
+	if granularity != "hour" && granularity != "day" && granularity != "total" {
+		writeError(c, consts.StatusBadRequest, "", invalidParam("granularity", `granularity must be "hour", "day" or "total"`))
+		return
+	}
+
+	// Hours are counted whole: the hour containing from is included
+	from = from.UTC().Truncate(time.Hour)
+	records, err := s.usage.ListUsage(ctx, key, from, to)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[API] Failed to read usage: %v", err)
+		writeError(c, consts.StatusInternalServerError, "", fmt.Errorf("failed to read usage: %w", err))
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"object":      "list",
+		"from":        from,
+		"to":          to.UTC(),
+		"granularity": granularity,
+		"data":        aggregateUsage(records, granularity, from),
+	})
+}
+
+// aggregateUsage sums hourly records into periods of the granularity, ordered by key and start
+func aggregateUsage(records []*memory.UsageRecord, granularity string, from time.Time) []*UsageEntry {
+	byPeriod := make(map[string]*UsageEntry)
+	for _, rec := range records {
+		start := rec.Hour
+		switch granularity {
+		case "day":
+			start = start.Truncate(24 * time.Hour)
+		case "total":
+			start = from
+		}
+		id := rec.Key + "\x00" + strconv.FormatInt(start.Unix(), 10)
+		entry, ok := byPeriod[id]
+		if !ok {
+			entry = &UsageEntry{Key: rec.Key, Start: start}
+			byPeriod[id] = entry
+		}
+		entry.Requests += rec.Requests
+		entry.PromptTokens += rec.PromptTokens
+		entry.CompletionTokens += rec.CompletionTokens
+		entry.TotalTokens += rec.TotalTokens
+		entry.ToolCalls += rec.ToolCalls
+	}
+
+	entries := make([]*UsageEntry, 0, len(byPeriod))
+	for _, entry := range byPeriod {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].Start.Before(entries[j].Start)
+	})
+	return entries
+}
+
+// parseUsageTime parses an RFC 3339 time or Unix seconds
+func parseUsageTime(v string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
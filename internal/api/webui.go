@@ -0,0 +1,28 @@
+// Package api provides OpenAI-compatible HTTP API endpoints.
+package api
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// webUI is a single-page chat client for the streaming chat and session endpoints
+//
+//go:embed web/index.html
+var webUI []byte
+
+// WithWebUI serves a minimal chat UI at / for trying the server from a browser
+func WithWebUI() Option {
+	return func(s *Server) {
+		s.webUI = true
+	}
+}
+
+// handleWebUI serves the chat UI
+func (s *Server) handleWebUI(ctx context.Context, c *app.RequestContext) {
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Data(consts.StatusOK, "text/html; charset=utf-8", webUI)
+}
@@ -0,0 +1,63 @@
+// Package audit records tool invocations to append-only sinks for forensic review.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// Caller identifies who caused a tool call
+type Caller struct {
+	Tenant string `json:"tenant,omitempty"`
+	KeyID  string `json:"key_id,omitempty"` // Fingerprint of the API key, never the key itself
+	IP     string `json:"ip,omitempty"`
+	Task   string `json:"task,omitempty"` // Scheduled task that started the run
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context carrying the caller of the runs started with it
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller carried by ctx, or the zero Caller
+func CallerFromContext(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// Record is one tool invocation
+type Record struct {
+	Time        time.Time `json:"time"` // When the call started
+	RequestID   string    `json:"request_id,omitempty"`
+	SessionID   string    `json:"session_id"`
+	Caller      Caller    `json:"caller"`
+	Tool        string    `json:"tool"`
+	CallID      string    `json:"call_id,omitempty"`
+	Arguments   string    `json:"arguments"`
+	Result      string    `json:"result"`       // Summary: the head of the result
+	ResultBytes int       `json:"result_bytes"` // Size of the full result
+	LatencyMs   int64     `json:"latency_ms"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Sink stores audit records; records are only ever appended
+type Sink interface {
+	Write(ctx context.Context, record *Record) error
+	Close() error
+}
+
+// Summarize keeps the first maxBytes of a result, cut at a rune boundary, noting how much was left out
+func Summarize(result string, maxBytes int) string {
+	if maxBytes <= 0 || len(result) <= maxBytes {
+		return result
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(result[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... [%d more bytes]", result[:cut], len(result)-cut)
+}
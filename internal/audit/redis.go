@@ -0,0 +1,65 @@
+// Package audit records tool invocations to append-only sinks for forensic review.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// RedisSink appends records to a Redis stream, one entry per call with the JSON record in its "record" field
+type RedisSink struct {
+	cli    *redis.Client
+	stream string
+	maxLen int64 // Approximate cap on the stream length (0 = unbounded)
+}
+
+// NewRedisSink creates a sink writing to stream with an existing client
+func NewRedisSink(cli *redis.Client, stream string, maxLen int64) *RedisSink {
+	if stream == "" {
+		stream = "eino:audit:tool_calls"
+	}
+	return &RedisSink{cli: cli, stream: stream, maxLen: maxLen}
+}
+
+// NewRedisSinkFromAddress connects to Redis at address and creates a sink writing to stream
+func NewRedisSinkFromAddress(ctx context.Context, address, stream string, maxLen int64) (*RedisSink, error) {
+	cli := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Protocol: 2,
+	})
+	if err := cli.Ping(ctx).Err(); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", address, err)
+	}
+	logger.Debugf("[Audit:Redis] Connected to Redis at %s", address)
+	return NewRedisSink(cli, stream, maxLen), nil
+}
+
+// Write adds one record to the stream
+func (s *RedisSink) Write(ctx context.Context, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	args := &redis.XAddArgs{
+		Stream: s.stream,
+		Values: map[string]any{"record": data},
+	}
+	if s.maxLen > 0 {
+		args.MaxLen, args.Approx = s.maxLen, true
+	}
+	if err := s.cli.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis client
+func (s *RedisSink) Close() error {
+	return s.cli.Close()
+}
@@ -6,26 +6,156 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/fourhu/eino-ai-agent/internal/mcp"
+	"github.com/fourhu/eino-ai-agent/internal/memory"
+	"github.com/fourhu/eino-ai-agent/internal/tasks"
+	"github.com/fourhu/eino-ai-agent/internal/tools"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the server configuration
 type Config struct {
-	Server ServerConfig `json:"server" yaml:"server"`
-	Model  ModelConfig  `json:"model" yaml:"model"`
-	MCP    MCPConfig    `json:"mcp" yaml:"mcp"`
-	Agent  AgentConfig  `json:"agent" yaml:"agent"`
-	Log    LogConfig    `json:"log" yaml:"log"`
-	Memory MemoryConfig `json:"memory" yaml:"memory"`
+	Server  ServerConfig  `json:"server" yaml:"server"`
+	Model   ModelConfig   `json:"model" yaml:"model"`
+	MCP     MCPConfig     `json:"mcp" yaml:"mcp"`
+	Agent   AgentConfig   `json:"agent" yaml:"agent"`
+	Log     LogConfig     `json:"log" yaml:"log"`
+	Memory  MemoryConfig  `json:"memory" yaml:"memory"`
+	Metrics MetricsConfig `json:"metrics" yaml:"metrics"`
+	RAG     RAGConfig     `json:"rag" yaml:"rag"`
+	Tasks   TasksConfig   `json:"tasks" yaml:"tasks"`
+
+	Embeddings EmbeddingsConfig `json:"embeddings,omitempty" yaml:"embeddings,omitempty"` // OpenAI-compatible /v1/embeddings passthrough
+	Files      FilesConfig      `json:"files,omitempty" yaml:"files,omitempty"`           // /v1/files uploads referenced by chat requests
+	Audio      AudioConfig      `json:"audio,omitempty" yaml:"audio,omitempty"`           // /v1/audio/transcriptions proxy
+	Audit      AuditConfig      `json:"audit,omitempty" yaml:"audit,omitempty"`           // Append-only log of every tool call
+
+	BuiltinTools tools.Config `json:"builtin_tools" yaml:"builtin_tools"`
+
+	// Models are further backends served next to Model; empty base_url, api_key and retry are inherited from Model,
+	// and tool_schema along with base_url
+	Models []ModelConfig `json:"models,omitempty" yaml:"models,omitempty"`
+	// DefaultModel answers requests that name no model (default: model.model)
+	DefaultModel string `json:"default_model,omitempty" yaml:"default_model,omitempty"`
 }
 
 // ServerConfig represents HTTP server configuration
 type ServerConfig struct {
 	Host string `json:"host" yaml:"host"`
 	Port int    `json:"port" yaml:"port"`
+
+	RateLimit RateLimitConfig `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"` // Per-client request rate limit
+	CORS      CORSConfig      `json:"cors,omitempty" yaml:"cors,omitempty"`             // Cross-origin access for browser clients
+
+	RequestTimeout time.Duration `json:"request_timeout,omitempty" yaml:"request_timeout,omitempty"` // Aborts a chat run and its model/tool calls (0 = no timeout)
+	SlowRequest    time.Duration `json:"slow_request,omitempty" yaml:"slow_request,omitempty"`       // Requests slower than this are logged as warnings (0 = off)
+
+	Debug DebugConfig `json:"debug,omitempty" yaml:"debug,omitempty"` // pprof and runtime status endpoints
+	Admin AdminConfig `json:"admin,omitempty" yaml:"admin,omitempty"` // Management endpoints under /admin
+
+	TLS TLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"` // Serve HTTPS directly instead of behind a proxy
+
+	Idempotency IdempotencyConfig `json:"idempotency,omitempty" yaml:"idempotency,omitempty"` // Replay retried requests carrying an Idempotency-Key
+	Concurrency ConcurrencyConfig `json:"concurrency,omitempty" yaml:"concurrency,omitempty"` // Cap on agent runs executing at once
+
+	UserSessions UserSessionsConfig `json:"user_sessions,omitempty" yaml:"user_sessions,omitempty"` // Session continuity for clients sending only "user"
+
+	WebUI bool `json:"web_ui,omitempty" yaml:"web_ui,omitempty"` // Serve a minimal chat UI at /
+
+	Usage UsageConfig `json:"usage,omitempty" yaml:"usage,omitempty"` // Per-API-key usage accounting
+
+	Tenants TenantsConfig `json:"tenants,omitempty" yaml:"tenants,omitempty"` // Session isolation between teams sharing the deployment
+
+	Quotas QuotasConfig `json:"quotas,omitempty" yaml:"quotas,omitempty"` // Daily token or cost budgets
+}
+
+// QuotasConfig represents daily spend budgets per session and tenant, counted in the memory store
+type QuotasConfig struct {
+	Enabled         bool         `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Session         BudgetConfig `json:"session,omitempty" yaml:"session,omitempty"`
+	Tenant          BudgetConfig `json:"tenant,omitempty" yaml:"tenant,omitempty"`                     // Requires tenants
+	PromptPrice     float64      `json:"prompt_price,omitempty" yaml:"prompt_price,omitempty"`         // Per million prompt tokens, for cost budgets
+	CompletionPrice float64      `json:"completion_price,omitempty" yaml:"completion_price,omitempty"` // Per million completion tokens
+}
+
+// BudgetConfig represents what may be spent per UTC day (0 = unlimited)
+type BudgetConfig struct {
+	Tokens int64   `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+	Cost   float64 `json:"cost,omitempty" yaml:"cost,omitempty"` // Estimated from the prices
+}
+
+// TenantsConfig represents scoping sessions to the tenant of each request
+type TenantsConfig struct {
+	Enabled  bool              `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Required bool              `json:"required,omitempty" yaml:"required,omitempty"`   // Refuse requests naming no tenant instead of sharing a default one
+	Keys     map[string]string `json:"keys,omitempty" yaml:"keys,omitempty"`           // Tenant by API key; such keys cannot act for other tenants
+	KeysOnly bool              `json:"keys_only,omitempty" yaml:"keys_only,omitempty"` // Refuse the X-Tenant-ID header when keys are configured
+}
+
+// UsageConfig represents counting requests, tokens and tool calls per API key in the memory store
+type UsageConfig struct {
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+}
+
+// UserSessionsConfig represents deriving sessions from the OpenAI "user" field of requests without a session
+type UserSessionsConfig struct {
+	Enabled         bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	PerConversation bool `json:"per_conversation,omitempty" yaml:"per_conversation,omitempty"` // Separate sessions per opening user message
+}
+
+// ConcurrencyConfig represents the global limit on simultaneous agent runs
+type ConcurrencyConfig struct {
+	MaxRuns      int           `json:"max_runs,omitempty" yaml:"max_runs,omitempty"`           // Runs executing at once (0 = unlimited)
+	MaxQueue     int           `json:"max_queue,omitempty" yaml:"max_queue,omitempty"`         // Requests waiting for a slot; beyond it 429 is answered
+	QueueTimeout time.Duration `json:"queue_timeout,omitempty" yaml:"queue_timeout,omitempty"` // Longest wait for a slot (default 30s)
+}
+
+// IdempotencyConfig represents the cache of responses to requests with an Idempotency-Key header
+type IdempotencyConfig struct {
+	Enabled    bool          `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	TTL        time.Duration `json:"ttl,omitempty" yaml:"ttl,omitempty"`                 // How long responses are replayed (default 24h)
+	MaxEntries int           `json:"max_entries,omitempty" yaml:"max_entries,omitempty"` // Cached responses kept at most (default 10000)
+}
+
+// TLSConfig represents HTTPS and optional client certificate (mTLS) settings of the API server
+type TLSConfig struct {
+	CertFile          string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"` // PEM certificate chain (empty = plain HTTP)
+	KeyFile           string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	ClientCAFile      string `json:"client_ca_file,omitempty" yaml:"client_ca_file,omitempty"`           // Verifies client certificates signed by these CAs
+	RequireClientCert bool   `json:"require_client_cert,omitempty" yaml:"require_client_cert,omitempty"` // Reject clients without a valid certificate
+}
+
+// DebugConfig represents the /debug/pprof and /debug/status endpoints
+type DebugConfig struct {
+	Enabled bool   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Token   string `json:"token,omitempty" yaml:"token,omitempty"` // Bearer token required by the endpoints (empty = loopback clients only)
+}
+
+// AdminConfig represents the /admin management endpoints
+type AdminConfig struct {
+	Enabled bool   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Token   string `json:"token,omitempty" yaml:"token,omitempty"` // Bearer token required by the endpoints (empty = loopback clients only)
+}
+
+// CORSConfig represents the CORS policy of the API server
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins,omitempty" yaml:"allowed_origins,omitempty"` // Origins or globs; "*" allows any (empty = CORS disabled)
+	AllowedMethods   []string `json:"allowed_methods,omitempty" yaml:"allowed_methods,omitempty"` // Default GET, POST, DELETE, OPTIONS
+	AllowedHeaders   []string `json:"allowed_headers,omitempty" yaml:"allowed_headers,omitempty"` // Default Authorization, Content-Type, Accept
+	ExposedHeaders   []string `json:"exposed_headers,omitempty" yaml:"exposed_headers,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty" yaml:"allow_credentials,omitempty"`
+	MaxAge           int      `json:"max_age,omitempty" yaml:"max_age,omitempty"` // Preflight cache lifetime in seconds
+}
+
+// RateLimitConfig represents a token-bucket rate limit keyed by tenant API key or client IP
+type RateLimitConfig struct {
+	RPS   float64 `json:"rps,omitempty" yaml:"rps,omitempty"`     // Sustained requests per second (0 = unlimited)
+	Burst int     `json:"burst,omitempty" yaml:"burst,omitempty"` // Requests allowed at once (default ceil(rps))
 }
 
 // ModelConfig represents LLM model configuration
@@ -34,18 +164,128 @@ type ModelConfig struct {
 	BaseURL  string `json:"base_url" yaml:"base_url"`
 	APIKey   string `json:"api_key" yaml:"api_key"`
 	Model    string `json:"model" yaml:"model"`
+
+	// ToolSchema adapts tool parameter schemas to the provider: strict (OpenAI/Azure strict mode), gemini or claude
+	ToolSchema string `json:"tool_schema,omitempty" yaml:"tool_schema,omitempty"`
+
+	Retry RetryConfig `json:"retry" yaml:"retry"` // Retry policy for transient model errors
+}
+
+// RetryConfig represents the retry policy for model calls
+type RetryConfig struct {
+	MaxAttempts    int           `json:"max_attempts" yaml:"max_attempts"`       // Total attempts including the first (<= 1 disables retries)
+	InitialBackoff time.Duration `json:"initial_backoff" yaml:"initial_backoff"` // Delay before the first retry (default 500ms)
+	MaxBackoff     time.Duration `json:"max_backoff" yaml:"max_backoff"`         // Upper bound for the delay (default 10s)
 }
 
 // MCPConfig represents MCP server configurations
 type MCPConfig struct {
-	Servers []mcp.ServerConfig `json:"servers" yaml:"servers"`
+	Servers   []mcp.ServerConfig `json:"servers" yaml:"servers"`
+	Health    HealthConfig       `json:"health" yaml:"health"`
+	Reconnect ReconnectConfig    `json:"reconnect" yaml:"reconnect"`
+
+	// DesktopServers takes the mcpServers map of claude_desktop_config.json as is; its entries are
+	// appended to Servers, named by their key and enabled unless marked disabled
+	DesktopServers map[string]DesktopServerConfig `json:"mcpServers,omitempty" yaml:"mcpServers,omitempty"`
+}
+
+// DesktopServerConfig is an entry of the mcpServers map of claude_desktop_config.json
+type DesktopServerConfig struct {
+	mcp.ServerConfig `yaml:",inline"`
+	Disabled         bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// HealthConfig controls the periodic health checks of connected MCP servers
+type HealthConfig struct {
+	Enabled          bool          `json:"enabled" yaml:"enabled"`
+	Interval         time.Duration `json:"interval" yaml:"interval"`                   // Between pings (default 30s)
+	Timeout          time.Duration `json:"timeout" yaml:"timeout"`                     // A slower ping fails (default 10s)
+	DegradedLatency  time.Duration `json:"degraded_latency" yaml:"degraded_latency"`   // A slower ping marks the server degraded (default 2s)
+	FailureThreshold int           `json:"failure_threshold" yaml:"failure_threshold"` // Consecutive failed pings that take a server down (default 3)
+}
+
+// ReconnectConfig controls the reconnection of MCP servers that went down; it relies on the health checks
+type ReconnectConfig struct {
+	Enabled        bool          `json:"enabled" yaml:"enabled"`
+	InitialBackoff time.Duration `json:"initial_backoff" yaml:"initial_backoff"` // Delay before the first reconnect attempt (default 1s)
+	MaxBackoff     time.Duration `json:"max_backoff" yaml:"max_backoff"`         // Upper bound for the delay (default 1m)
 }
 
 // MemoryConfig represents memory storage configuration
 type MemoryConfig struct {
-	Type    string `json:"type" yaml:"type"`       // "inmem" or "redis"
-	Address string `json:"address" yaml:"address"` // Redis address (e.g., "localhost:6379")
-	Prefix  string `json:"prefix" yaml:"prefix"`   // Key prefix for Redis
+	Type    string `json:"type" yaml:"type"`                   // "inmem", "redis", "file" or "s3"
+	Address string `json:"address" yaml:"address"`             // Redis address (e.g., "localhost:6379")
+	Prefix  string `json:"prefix" yaml:"prefix"`               // Key prefix for Redis
+	Dir     string `json:"dir,omitempty" yaml:"dir,omitempty"` // Directory of the file store, one JSONL file per session
+
+	// Encoding of the messages in Redis and S3: "gob" (default) or "json", readable outside Go. Sessions
+	// stored with either are read, so the encoding can be changed at any time.
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+
+	S3 memory.S3Config `json:"s3,omitempty" yaml:"s3,omitempty"` // Bucket of the S3 store, for deployments without disk or Redis
+
+	// TTL expires Redis sessions not read or written for this long (0 = never); TenantTTLs overrides it for
+	// the sessions of a tenant, 0 keeping them forever
+	TTL        time.Duration            `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	TenantTTLs map[string]time.Duration `json:"tenant_ttls,omitempty" yaml:"tenant_ttls,omitempty"`
+}
+
+// MetricsConfig represents metrics exposition configuration
+type MetricsConfig struct {
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	Path           string `json:"path" yaml:"path"`                       // Metrics endpoint path (default "/metrics")
+	StreamTrailers bool   `json:"stream_trailers" yaml:"stream_trailers"` // Emit streaming timings as HTTP trailers
+}
+
+// RAGConfig represents retrieval-augmented generation configuration
+type RAGConfig struct {
+	Enabled        bool    `json:"enabled" yaml:"enabled"`
+	BaseURL        string  `json:"base_url" yaml:"base_url"`               // Embedding API base URL (defaults to model base URL)
+	APIKey         string  `json:"api_key" yaml:"api_key"`                 // Embedding API key (defaults to model API key)
+	EmbeddingModel string  `json:"embedding_model" yaml:"embedding_model"` // e.g. "text-embedding-3-small"
+	ChunkSize      int     `json:"chunk_size" yaml:"chunk_size"`           // Max characters per chunk
+	ChunkOverlap   int     `json:"chunk_overlap" yaml:"chunk_overlap"`     // Characters shared between consecutive chunks
+	TopK           int     `json:"top_k" yaml:"top_k"`                     // Default number of chunks to retrieve
+	MinScore       float64 `json:"min_score" yaml:"min_score"`             // Minimum similarity for retrieved chunks
+}
+
+// EmbeddingsConfig represents the /v1/embeddings endpoint
+type EmbeddingsConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"` // Embedding API base URL (defaults to rag.base_url, then model.base_url)
+	APIKey  string `json:"api_key,omitempty" yaml:"api_key,omitempty"`   // Embedding API key (defaults to rag.api_key, then model.api_key)
+	Model   string `json:"model,omitempty" yaml:"model,omitempty"`       // Used when a request names no model (defaults to rag.embedding_model)
+}
+
+// FilesConfig represents the /v1/files endpoints
+type FilesConfig struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Dir      string `json:"dir,omitempty" yaml:"dir,omitempty"`             // Directory holding uploads (empty = in memory)
+	MaxBytes int    `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"` // Largest accepted upload (default 20 MiB)
+}
+
+// AudioConfig represents the /v1/audio/transcriptions proxy to a Whisper-compatible backend
+type AudioConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"` // Backend API base URL (defaults to model.base_url)
+	APIKey  string `json:"api_key,omitempty" yaml:"api_key,omitempty"`   // Backend API key (defaults to model.api_key)
+}
+
+// AuditConfig represents the tool call audit log
+type AuditConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Type    string `json:"type,omitempty" yaml:"type,omitempty"`       // "file" (default) or "redis"
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`       // JSON lines file (type file)
+	Address string `json:"address,omitempty" yaml:"address,omitempty"` // Redis address (type redis, defaults to memory.address)
+	Stream  string `json:"stream,omitempty" yaml:"stream,omitempty"`   // Redis stream (type redis, default "eino:audit:tool_calls")
+	MaxLen  int64  `json:"max_len,omitempty" yaml:"max_len,omitempty"` // Approximate cap on the stream length (type redis, 0 = unbounded)
+}
+
+// TasksConfig represents scheduled task configuration
+type TasksConfig struct {
+	Enabled   bool               `json:"enabled" yaml:"enabled"`
+	OutputDir string             `json:"output_dir,omitempty" yaml:"output_dir,omitempty"` // File outputs of tasks created over the API go here (unset = not allowed)
+	Tasks     []tasks.TaskConfig `json:"tasks" yaml:"tasks"`
 }
 
 // AgentConfig represents agent behavior configuration
@@ -53,6 +293,73 @@ type AgentConfig struct {
 	SystemPrompt string `json:"system_prompt" yaml:"system_prompt"`
 	MaxSteps     int    `json:"max_steps" yaml:"max_steps"`
 	MaxHistory   int    `json:"max_history" yaml:"max_history"` // Max conversation rounds to keep (0 = unlimited)
+
+	MaxSessions int           `json:"max_sessions,omitempty" yaml:"max_sessions,omitempty"` // Max sessions kept in memory (0 = unlimited)
+	SessionTTL  time.Duration `json:"session_ttl,omitempty" yaml:"session_ttl,omitempty"`   // Idle time before a session is evicted from memory (0 = never)
+
+	ToolTimeout  time.Duration            `json:"tool_timeout,omitempty" yaml:"tool_timeout,omitempty"`   // Default timeout per tool call (0 = none)
+	ToolTimeouts map[string]time.Duration `json:"tool_timeouts,omitempty" yaml:"tool_timeouts,omitempty"` // Per-tool overrides (name or glob)
+
+	MaxToolResultBytes  int `json:"max_tool_result_bytes,omitempty" yaml:"max_tool_result_bytes,omitempty"`   // Truncate larger tool results (0 = unlimited)
+	MaxToolResultTokens int `json:"max_tool_result_tokens,omitempty" yaml:"max_tool_result_tokens,omitempty"` // Same limit in approximate tokens
+
+	// ToolResultTransforms maps tool names or globs to transformer specs, e.g. {"kubectl_*": ["strip_base64", "head_lines:200"]}
+	ToolResultTransforms map[string][]string `json:"tool_result_transforms,omitempty" yaml:"tool_result_transforms,omitempty"`
+
+	// ApprovalTools lists tool name patterns (e.g. "k8s_delete_*") that pause until approved
+	ApprovalTools []string `json:"approval_tools,omitempty" yaml:"approval_tools,omitempty"`
+	// ApprovalTTL is how long a paused run waits for decisions (default 24h); paused runs do not survive a restart
+	ApprovalTTL time.Duration `json:"approval_ttl,omitempty" yaml:"approval_ttl,omitempty"`
+
+	// ReadOnly blocks mutating tools in every session; MutatingTools adds tool name patterns to the tools
+	// annotated as mutating (MCP tools that are not read-only or are destructive, write_file, run_command)
+	ReadOnly      bool     `json:"read_only,omitempty" yaml:"read_only,omitempty"`
+	MutatingTools []string `json:"mutating_tools,omitempty" yaml:"mutating_tools,omitempty"`
+
+	// SubAgents are specialist agents the main agent delegates to
+	SubAgents []SubAgentConfig `json:"sub_agents,omitempty" yaml:"sub_agents,omitempty"`
+
+	// Guardrails check user input and assistant output, in order
+	Guardrails []GuardrailConfig `json:"guardrails,omitempty" yaml:"guardrails,omitempty"`
+
+	// Limits caps what a single session may consume
+	Limits LimitsConfig `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// LimitsConfig represents per-session limits (0 = unlimited)
+type LimitsConfig struct {
+	MaxTurnsPerMinute   int `json:"max_turns_per_minute,omitempty" yaml:"max_turns_per_minute,omitempty"`
+	MaxToolCallsPerTurn int `json:"max_tool_calls_per_turn,omitempty" yaml:"max_tool_calls_per_turn,omitempty"` // The run is aborted when exceeded
+	MaxSessionTokens    int `json:"max_session_tokens,omitempty" yaml:"max_session_tokens,omitempty"`           // Lifetime token budget per session
+
+	ToolCosts          map[string]float64 `json:"tool_costs,omitempty" yaml:"tool_costs,omitempty"`                         // Cost weight per tool name or glob pattern (unlisted = free)
+	MaxToolCostPerTurn float64            `json:"max_tool_cost_per_turn,omitempty" yaml:"max_tool_cost_per_turn,omitempty"` // The run is aborted before a call exceeding it
+	MaxSessionToolCost float64            `json:"max_session_tool_cost,omitempty" yaml:"max_session_tool_cost,omitempty"`   // Lifetime tool budget per session
+}
+
+// SubAgentConfig represents a specialist agent configuration
+type SubAgentConfig struct {
+	Name         string   `json:"name" yaml:"name"`
+	Description  string   `json:"description" yaml:"description"`                     // Tells the main agent when to delegate
+	SystemPrompt string   `json:"system_prompt" yaml:"system_prompt"`                 // Instruction for the sub-agent
+	MCPServers   []string `json:"mcp_servers,omitempty" yaml:"mcp_servers,omitempty"` // MCP servers whose tools the sub-agent gets
+	MaxSteps     int      `json:"max_steps,omitempty" yaml:"max_steps,omitempty"`     // 0 = same as main agent
+}
+
+// GuardrailConfig represents an input/output guardrail
+type GuardrailConfig struct {
+	Type      string   `json:"type" yaml:"type"`                                 // keywords, patterns, max_length, prompt_injection, moderation
+	Keywords  []string `json:"keywords,omitempty" yaml:"keywords,omitempty"`     // Whole words, case-insensitive (type keywords)
+	Patterns  []string `json:"patterns,omitempty" yaml:"patterns,omitempty"`     // Regular expressions (type patterns)
+	MaxLength int      `json:"max_length,omitempty" yaml:"max_length,omitempty"` // Characters (type max_length)
+	Action    string   `json:"action,omitempty" yaml:"action,omitempty"`         // block (default), redact, flag
+	Apply     string   `json:"apply,omitempty" yaml:"apply,omitempty"`           // input (default), output, both
+
+	// Moderation provider (type moderation); without a base URL the chat model classifies the text
+	BaseURL    string   `json:"base_url,omitempty" yaml:"base_url,omitempty"`     // OpenAI-compatible API with a /moderations endpoint
+	APIKey     string   `json:"api_key,omitempty" yaml:"api_key,omitempty"`       // Defaults to model.api_key
+	Model      string   `json:"model,omitempty" yaml:"model,omitempty"`           // Provider default if empty
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"` // Flagged categories that fail the check (empty = any)
 }
 
 // LogConfig represents logging configuration
@@ -71,9 +378,26 @@ func DefaultConfig() *Config {
 			Provider: "openai",
 			BaseURL:  "https://api.openai.com/v1",
 			Model:    "gpt-4o",
+			Retry: RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: 500 * time.Millisecond,
+				MaxBackoff:     10 * time.Second,
+			},
 		},
 		MCP: MCPConfig{
 			Servers: []mcp.ServerConfig{},
+			Health: HealthConfig{
+				Enabled:          true,
+				Interval:         30 * time.Second,
+				Timeout:          10 * time.Second,
+				DegradedLatency:  2 * time.Second,
+				FailureThreshold: 3,
+			},
+			Reconnect: ReconnectConfig{
+				Enabled:        true,
+				InitialBackoff: time.Second,
+				MaxBackoff:     time.Minute,
+			},
 		},
 		Agent: AgentConfig{
 			SystemPrompt: "You are a helpful AI assistant with access to various tools through MCP servers.",
@@ -86,6 +410,16 @@ func DefaultConfig() *Config {
 			Type:   "inmem",
 			Prefix: "eino:session:",
 		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		RAG: RAGConfig{
+			EmbeddingModel: "text-embedding-3-small",
+			ChunkSize:      1000,
+			ChunkOverlap:   100,
+			TopK:           4,
+		},
 	}
 	cfg.loadFromEnv()
 	return cfg
@@ -121,12 +455,36 @@ func LoadFromFile(path string) (*Config, error) {
 		}
 	}
 
+	if err := config.MCP.mergeDesktopServers(); err != nil {
+		return nil, err
+	}
+
 	// Override with environment variables
 	config.loadFromEnv()
 
 	return config, nil
 }
 
+// mergeDesktopServers appends the mcpServers entries to Servers, in name order
+func (c *MCPConfig) mergeDesktopServers() error {
+	names := make([]string, 0, len(c.DesktopServers))
+	for name := range c.DesktopServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if slices.ContainsFunc(c.Servers, func(s mcp.ServerConfig) bool { return s.Name == name }) {
+			return fmt.Errorf("MCP server %s is configured in both servers and mcpServers", name)
+		}
+		entry := c.DesktopServers[name]
+		server := entry.ServerConfig
+		server.Name, server.Enabled = name, !entry.Disabled
+		c.Servers = append(c.Servers, server)
+	}
+	c.DesktopServers = nil
+	return nil
+}
+
 // loadFromEnv overrides configuration with environment variables
 func (c *Config) loadFromEnv() {
 	if host := os.Getenv("SERVER_HOST"); host != "" {
@@ -161,6 +519,38 @@ func (c *Config) loadFromEnv() {
 	if memoryAddr := os.Getenv("MEMORY_ADDRESS"); memoryAddr != "" {
 		c.Memory.Address = memoryAddr
 	}
+	if memoryDir := os.Getenv("MEMORY_DIR"); memoryDir != "" {
+		c.Memory.Dir = memoryDir
+	}
+	if memoryTTL := os.Getenv("MEMORY_TTL"); memoryTTL != "" {
+		if ttl, err := time.ParseDuration(memoryTTL); err == nil {
+			c.Memory.TTL = ttl
+		}
+	}
+}
+
+// ModelBackends returns the primary model followed by the additional ones, with inherited settings filled in
+func (c *Config) ModelBackends() []ModelConfig {
+	backends := []ModelConfig{c.Model}
+	for _, m := range c.Models {
+		if m.Provider == "" {
+			m.Provider = c.Model.Provider
+		}
+		if m.BaseURL == "" {
+			m.BaseURL = c.Model.BaseURL
+			if m.ToolSchema == "" {
+				m.ToolSchema = c.Model.ToolSchema
+			}
+		}
+		if m.APIKey == "" {
+			m.APIKey = c.Model.APIKey
+		}
+		if m.Retry == (RetryConfig{}) {
+			m.Retry = c.Model.Retry
+		}
+		backends = append(backends, m)
+	}
+	return backends
 }
 
 // GetAddress returns the server address in host:port format
@@ -172,7 +562,7 @@ func (c *Config) GetAddress() string {
 func (c *Config) GetEnabledMCPServers() []mcp.ServerConfig {
 	var enabled []mcp.ServerConfig
 	for _, s := range c.MCP.Servers {
-		if s.Enabled && s.BaseURL != "" {
+		if s.Enabled && s.Configured() {
 			enabled = append(enabled, s)
 		}
 	}
@@ -198,6 +588,11 @@ func GenerateDefaultConfigFile(path string) error {
 			BaseURL:  "https://api.openai.com/v1",
 			APIKey:   "${MODEL_API_KEY}",
 			Model:    "gpt-4o",
+			Retry: RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: 500 * time.Millisecond,
+				MaxBackoff:     10 * time.Second,
+			},
 		},
 		MCP: MCPConfig{
 			Servers: []mcp.ServerConfig{
@@ -224,6 +619,16 @@ func GenerateDefaultConfigFile(path string) error {
 			Type:   "inmem",
 			Prefix: "eino:session:",
 		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		RAG: RAGConfig{
+			EmbeddingModel: "text-embedding-3-small",
+			ChunkSize:      1000,
+			ChunkOverlap:   100,
+			TopK:           4,
+		},
 	}
 
 	ext := strings.ToLower(filepath.Ext(path))
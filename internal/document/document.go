@@ -0,0 +1,60 @@
+// Package document provides plain-text extraction for uploaded documents.
+package document
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported document formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatPDF      = "pdf"
+)
+
+// DetectFormat determines the document format from the file name and content type
+func DetectFormat(name, contentType string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		return FormatPDF
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".txt", ".text", ".log":
+		return FormatText
+	}
+
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "pdf"):
+		return FormatPDF
+	case strings.Contains(ct, "markdown"):
+		return FormatMarkdown
+	case strings.HasPrefix(ct, "text/"):
+		return FormatText
+	}
+
+	if len(data) >= 5 && string(data[:5]) == "%PDF-" {
+		return FormatPDF
+	}
+	return FormatText
+}
+
+// ExtractText returns the plain-text content of a document
+func ExtractText(name, contentType string, data []byte) (string, error) {
+	switch DetectFormat(name, contentType, data) {
+	case FormatPDF:
+		text, err := extractPDFText(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract PDF text: %w", err)
+		}
+		return text, nil
+	default:
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("document %q is not valid UTF-8 text", name)
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,266 @@
+// Package document provides plain-text extraction for uploaded documents.
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	pdfStreamRe = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+	pdfEndRe    = []byte("endstream")
+)
+
+// Inflation limits guarding against decompression bombs
+const (
+	maxPDFStreamSize   = 16 << 20 // Per content stream
+	maxPDFInflatedSize = 64 << 20 // Across all streams of a document
+)
+
+// extractPDFText is a best-effort text extractor for PDFs with simple (non-CID) fonts.
+// It inflates Flate-encoded content streams and collects the string operands of text operators.
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", fmt.Errorf("missing PDF header")
+	}
+
+	var out strings.Builder
+	inflated := 0
+	for _, loc := range pdfStreamRe.FindAllSubmatchIndex(data, -1) {
+		dict := string(data[loc[2]:loc[3]])
+		start := loc[1]
+		end := bytes.Index(data[start:], pdfEndRe)
+		if end < 0 {
+			break
+		}
+		raw := data[start : start+end]
+
+		// Skip images, fonts and other binary streams
+		if strings.Contains(dict, "/Image") || strings.Contains(dict, "/FontFile") || strings.Contains(dict, "/Length1") {
+			continue
+		}
+
+		content := raw
+		if strings.Contains(dict, "/FlateDecode") {
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			limit := min(maxPDFStreamSize, maxPDFInflatedSize-inflated)
+			content, err = io.ReadAll(io.LimitReader(r, int64(limit)+1))
+			r.Close()
+			if len(content) > limit {
+				if limit < maxPDFStreamSize {
+					return "", fmt.Errorf("inflated content streams exceed %d MiB", maxPDFInflatedSize>>20)
+				}
+				return "", fmt.Errorf("inflated content stream exceeds %d MiB", maxPDFStreamSize>>20)
+			}
+			inflated += len(content)
+			if err != nil && len(content) == 0 {
+				continue
+			}
+		} else if strings.Contains(dict, "/Filter") {
+			// Unsupported filter (DCT, LZW, ...)
+			continue
+		}
+
+		if text := parseContentStream(content); text != "" {
+			out.WriteString(text)
+			out.WriteString("\n")
+		}
+	}
+
+	text := strings.TrimSpace(out.String())
+	if text == "" {
+		return "", fmt.Errorf("no extractable text found (scanned or CID-font PDFs are not supported)")
+	}
+	return text, nil
+}
+
+// parseContentStream extracts text shown by Tj, TJ, ' and " operators
+func parseContentStream(content []byte) string {
+	var out strings.Builder
+	var operands []string
+	inText := false
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := readLiteralString(content[i:])
+			operands = append(operands, s)
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return out.String()
+			}
+			operands = append(operands, decodeHexString(content[i+1:i+end]))
+			i += end + 1
+		case c == '[' || c == ']':
+			i++
+		case isPDFSpace(c):
+			i++
+		default:
+			start := i
+			for i < len(content) && !isPDFSpace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			op := string(content[start:i])
+			switch op {
+			case "BT":
+				inText = true
+				operands = operands[:0]
+			case "ET":
+				inText = false
+				out.WriteString("\n")
+				operands = operands[:0]
+			case "Tj", "TJ":
+				if inText {
+					out.WriteString(strings.Join(operands, ""))
+				}
+				operands = operands[:0]
+			case "'", "\"":
+				if inText {
+					out.WriteString("\n")
+					out.WriteString(strings.Join(operands, ""))
+				}
+				operands = operands[:0]
+			case "T*", "Td", "TD":
+				if inText {
+					out.WriteString("\n")
+				}
+				operands = operands[:0]
+			default:
+				// Numbers and names are operands of other operators; strings are only kept until the next operator
+				if !isNumeric(op) && !strings.HasPrefix(op, "/") {
+					operands = operands[:0]
+				}
+			}
+		}
+	}
+	return collapseBlankLines(out.String())
+}
+
+// readLiteralString decodes a PDF literal string starting at '(' and returns the consumed length
+func readLiteralString(b []byte) (string, int) {
+	var out []byte
+	depth := 0
+	i := 0
+	for i < len(b) {
+		c := b[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(out), i + 1
+			}
+			out = append(out, c)
+		case '\\':
+			i++
+			if i >= len(b) {
+				break
+			}
+			switch e := b[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					j := 0
+					for ; j < 3 && i+j < len(b) && b[i+j] >= '0' && b[i+j] <= '7'; j++ {
+						v = v*8 + int(b[i+j]-'0')
+					}
+					out = append(out, byte(v))
+					i += j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+		i++
+	}
+	return string(out), len(b)
+}
+
+func decodeHexString(b []byte) string {
+	h := strings.Map(func(r rune) rune {
+		if isPDFSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, string(b))
+	if len(h)%2 == 1 {
+		h += "0"
+	}
+	decoded, err := hex.DecodeString(h)
+	if err != nil {
+		return ""
+	}
+	// Only keep printable single-byte text; multi-byte CID strings need font maps
+	for _, c := range decoded {
+		if c < 0x20 && c != '\n' && c != '\t' {
+			return ""
+		}
+	}
+	return string(decoded)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return c == '(' || c == ')' || c == '<' || c == '>' || c == '[' || c == ']' || c == '{' || c == '}' || c == '/' || c == '%'
+}
+
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && c != '.' && c != '-' && c != '+' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,137 @@
+// Package files stores uploaded files that chat requests can reference by ID.
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirStore keeps each file in a directory as "<id>" with its metadata in "<id>.json"
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a file store in dir, creating the directory if needed
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create file directory: %w", err)
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+// path returns the location of a file's contents, rejecting IDs that could escape the directory
+func (s *DirStore) path(id string) (string, bool) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", false
+	}
+	return filepath.Join(s.dir, id), true
+}
+
+// Put writes the contents first, so listed metadata always has its contents
+func (s *DirStore) Put(ctx context.Context, file *File, data []byte) error {
+	path, ok := s.path(file.ID)
+	if !ok {
+		return fmt.Errorf("invalid file ID %q", file.ID)
+	}
+	meta, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(path+".json", meta)
+}
+
+// Get returns a file and its contents
+func (s *DirStore) Get(ctx context.Context, id string) (*File, []byte, error) {
+	path, ok := s.path(id)
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	file, err := readMeta(path + ".json")
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, data, nil
+}
+
+// List returns the metadata of all stored files
+func (s *DirStore) List(ctx context.Context) ([]*File, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*File, 0, len(matches))
+	for _, match := range matches {
+		file, err := readMeta(match)
+		if err != nil {
+			continue // Removed concurrently or not ours
+		}
+		list = append(list, file)
+	}
+	return list, nil
+}
+
+// Delete removes the metadata first, so a partially deleted file is no longer listed
+func (s *DirStore) Delete(ctx context.Context, id string) error {
+	path, ok := s.path(id)
+	if !ok {
+		return ErrNotFound
+	}
+	if err := os.Remove(path + ".json"); errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readMeta reads a metadata file
+func readMeta(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid file metadata %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// writeFileAtomic writes through a temporary file renamed into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
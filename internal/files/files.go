@@ -0,0 +1,201 @@
+// Package files stores uploaded files that chat requests can reference by ID.
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fourhu/eino-ai-agent/internal/document"
+)
+
+// ErrNotFound is returned for unknown file IDs
+var ErrNotFound = errors.New("file not found")
+
+// File describes an uploaded file in the OpenAI file object format
+type File struct {
+	ID          string `json:"id"`
+	Object      string `json:"object"` // Always "file"
+	Bytes       int    `json:"bytes"`
+	CreatedAt   int64  `json:"created_at"`
+	Filename    string `json:"filename"`
+	Purpose     string `json:"purpose"`
+	ContentType string `json:"content_type,omitempty"`
+	Tenant      string `json:"tenant,omitempty"` // Tenant that uploaded the file; only it can see and use the file
+}
+
+// Store persists file metadata and contents
+type Store interface {
+	// Put stores a file and its contents
+	Put(ctx context.Context, file *File, data []byte) error
+	// Get returns a file and its contents, or ErrNotFound
+	Get(ctx context.Context, id string) (*File, []byte, error)
+	// List returns the metadata of all stored files
+	List(ctx context.Context) ([]*File, error)
+	// Delete removes a file, or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+}
+
+// Service manages uploads on top of a store
+type Service struct {
+	store    Store
+	maxBytes int
+}
+
+// NewService creates a file service; uploads above maxBytes are rejected (0 = 20 MiB)
+func NewService(store Store, maxBytes int) *Service {
+	if maxBytes <= 0 {
+		maxBytes = 20 << 20
+	}
+	return &Service{store: store, maxBytes: maxBytes}
+}
+
+// MaxBytes returns the upload size limit
+func (s *Service) MaxBytes() int {
+	return s.maxBytes
+}
+
+// Upload stores a new file of a tenant ("" = the default namespace) and returns its metadata
+func (s *Service) Upload(ctx context.Context, tenant, filename, contentType, purpose string, data []byte) (*File, error) {
+	if len(data) > s.maxBytes {
+		return nil, fmt.Errorf("file exceeds %d bytes", s.maxBytes)
+	}
+	file := &File{
+		ID:          "file-" + strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Object:      "file",
+		Bytes:       len(data),
+		CreatedAt:   time.Now().Unix(),
+		Filename:    filename,
+		Purpose:     purpose,
+		ContentType: contentType,
+		Tenant:      tenant,
+	}
+	if err := s.store.Put(ctx, file, data); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	return file, nil
+}
+
+// Get returns the metadata of a tenant's file
+func (s *Service) Get(ctx context.Context, tenant, id string) (*File, error) {
+	file, _, err := s.get(ctx, tenant, id)
+	return file, err
+}
+
+// Content returns a tenant's file and its raw contents
+func (s *Service) Content(ctx context.Context, tenant, id string) (*File, []byte, error) {
+	return s.get(ctx, tenant, id)
+}
+
+// Text returns a tenant's file and its extracted plain text (txt, md and pdf)
+func (s *Service) Text(ctx context.Context, tenant, id string) (*File, string, error) {
+	file, data, err := s.get(ctx, tenant, id)
+	if err != nil {
+		return nil, "", err
+	}
+	text, err := document.ExtractText(file.Filename, file.ContentType, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return file, text, nil
+}
+
+// List returns the files of a tenant, newest first
+func (s *Service) List(ctx context.Context, tenant string) ([]*File, error) {
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*File, 0, len(all))
+	for _, file := range all {
+		if file.Tenant == tenant {
+			list = append(list, file)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt > list[j].CreatedAt })
+	return list, nil
+}
+
+// Delete removes a tenant's file
+func (s *Service) Delete(ctx context.Context, tenant, id string) error {
+	if _, _, err := s.get(ctx, tenant, id); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, id)
+}
+
+// get returns a file and its contents; files of other tenants are reported as not found
+func (s *Service) get(ctx context.Context, tenant, id string) (*File, []byte, error) {
+	file, data, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.Tenant != tenant {
+		return nil, nil, ErrNotFound
+	}
+	return file, data, nil
+}
+
+// MemoryStore keeps files in process memory
+type MemoryStore struct {
+	files map[string]*File
+	data  map[string][]byte
+	mu    sync.RWMutex
+}
+
+// NewMemoryStore creates an empty in-memory file store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		files: make(map[string]*File),
+		data:  make(map[string][]byte),
+	}
+}
+
+// Put stores a file and its contents
+func (s *MemoryStore) Put(ctx context.Context, file *File, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[file.ID] = file
+	s.data[file.ID] = data
+	return nil
+}
+
+// Get returns a file and its contents
+func (s *MemoryStore) Get(ctx context.Context, id string) (*File, []byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[id]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	return file, s.data[id], nil
+}
+
+// List returns the metadata of all stored files
+func (s *MemoryStore) List(ctx context.Context) ([]*File, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]*File, 0, len(s.files))
+	for _, file := range s.files {
+		list = append(list, file)
+	}
+	return list, nil
+}
+
+// Delete removes a file
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.files, id)
+	delete(s.data, id)
+	return nil
+}
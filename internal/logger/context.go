@@ -0,0 +1,29 @@
+// Package logger provides structured logging with zap.
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the API request it serves
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" outside a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Ctx returns the global logger, adding a request_id field to every line when ctx carries one
+func Ctx(ctx context.Context) *zap.SugaredLogger {
+	if id := RequestID(ctx); id != "" {
+		return Log.With("request_id", id)
+	}
+	return Log
+}
@@ -11,29 +11,23 @@ import (
 var (
 	// Log is the global logger instance
 	Log *zap.SugaredLogger
+
+	// level is the level of Log, changeable at runtime
+	level = zap.NewAtomicLevel()
 )
 
 // Init initializes the global logger with the specified log level
-func Init(level string) error {
+func Init(lvl string) error {
 	var logger *zap.Logger
 	var err error
 
 	// Always use JSON format for structured logging
 	config := zap.NewProductionConfig()
 
-	// Set log level
-	switch level {
-	case "debug":
-		config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
-	case "info":
-		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	case "warn":
-		config.Level = zap.NewAtomicLevelAt(zapcore.WarnLevel)
-	case "error":
-		config.Level = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
-	default:
-		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	}
+	// Set log level; unknown levels log at info
+	l, _ := parseLevel(lvl)
+	level.SetLevel(l)
+	config.Level = level
 
 	logger, err = config.Build()
 	if err != nil {
@@ -44,9 +38,30 @@ func Init(level string) error {
 	return nil
 }
 
-// SetLevel dynamically changes the log level (not supported in simplified mode)
-func SetLevel(level string) error {
-	return fmt.Errorf("dynamic log level change not supported, restart with --debug flag")
+// SetLevel changes the log level at runtime
+func SetLevel(lvl string) error {
+	l, err := parseLevel(lvl)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(l)
+	return nil
+}
+
+// parseLevel converts a configured level name
+func parseLevel(lvl string) (zapcore.Level, error) {
+	switch lvl {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("unknown log level %q", lvl)
+	}
 }
 
 // IsDebugEnabled returns true if debug level is enabled
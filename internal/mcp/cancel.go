@@ -0,0 +1,102 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// cancelNotifyTimeout bounds sending a cancellation notification
+const cancelNotifyTimeout = 5 * time.Second
+
+// cancellingTransport notifies the server of the requests the client stopped waiting for, because their
+// turn was cancelled or timed out, so the server can stop working on them
+type cancellingTransport struct {
+	transport.Interface
+	name string
+}
+
+// withCancellation wraps the transport of a server's client
+func withCancellation(name string, t transport.Interface) *cancellingTransport {
+	return &cancellingTransport{Interface: t, name: name}
+}
+
+func (t *cancellingTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	response, err := t.Interface.SendRequest(ctx, request)
+	// The initialize request must not be cancelled, per the protocol
+	if err != nil && ctx.Err() != nil && request.Method != string(mcp.MethodInitialize) {
+		go t.cancel(request, context.Cause(ctx))
+	}
+	return response, err
+}
+
+// cancel sends the notifications/cancelled notification for an abandoned request
+func (t *cancellingTransport) cancel(request transport.JSONRPCRequest, cause error) {
+	reason := "cancelled by the client"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		reason = "timed out on the client"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cancelNotifyTimeout)
+	defer cancel()
+
+	err := t.Interface.SendNotification(ctx, mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: "notifications/cancelled",
+			Params: mcp.NotificationParams{AdditionalFields: map[string]any{
+				"requestId": request.ID,
+				"reason":    reason,
+			}},
+		},
+	})
+	if err != nil {
+		logger.Debugf("[MCP:%s] Failed to cancel %s request %v: %v", t.name, request.Method, request.ID.Value(), err)
+		return
+	}
+	logger.Debugf("[MCP:%s] Cancelled %s request %v: %s", t.name, request.Method, request.ID.Value(), reason)
+}
+
+// The optional methods of the wrapped transport are forwarded, as the client looks for them
+
+func (t *cancellingTransport) SetRequestHandler(handler transport.RequestHandler) {
+	if bidirectional, ok := t.Interface.(transport.BidirectionalInterface); ok {
+		bidirectional.SetRequestHandler(handler)
+	}
+}
+
+func (t *cancellingTransport) SetConnectionLostHandler(handler func(error)) {
+	if setter, ok := t.Interface.(interface{ SetConnectionLostHandler(func(error)) }); ok {
+		setter.SetConnectionLostHandler(handler)
+	}
+}
+
+func (t *cancellingTransport) SetProtocolVersion(version string) {
+	if conn, ok := t.Interface.(transport.HTTPConnection); ok {
+		conn.SetProtocolVersion(version)
+	}
+}
+
+func (t *cancellingTransport) Stderr() io.Reader {
+	if stdio, ok := t.Interface.(*transport.Stdio); ok {
+		return stdio.Stderr()
+	}
+	return nil
+}
+
+// stderrOf returns the error output of a command server's process
+func stderrOf(cli *client.Client) (io.Reader, bool) {
+	t, ok := cli.GetTransport().(interface{ Stderr() io.Reader })
+	if !ok {
+		return nil, false
+	}
+	stderr := t.Stderr()
+	return stderr, stderr != nil
+}
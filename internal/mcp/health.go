@@ -0,0 +1,161 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// Server states reported by ServerStates and Status
+const (
+	StateConnected = "connected" // Connected and answering pings
+	StateDegraded  = "degraded"  // Connected, but the last ping failed or was slow
+	StateDown      = "down"      // Enabled but not connected
+	StateDisabled  = "disabled"
+)
+
+// HealthPolicy configures the periodic health checks of connected servers
+type HealthPolicy struct {
+	Interval         time.Duration    // Between pings (default 30s)
+	Timeout          time.Duration    // A slower ping fails (default 10s)
+	DegradedLatency  time.Duration    // A slower ping marks the server degraded (default 2s)
+	FailureThreshold int              // Consecutive failed pings that take a server down (default 3)
+	Reconnect        *ReconnectPolicy // Reconnects servers that go down; nil leaves them down until reconnected by hand
+}
+
+// withDefaults fills unset fields with their defaults
+func (p HealthPolicy) withDefaults() HealthPolicy {
+	if p.Interval <= 0 {
+		p.Interval = 30 * time.Second
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = 10 * time.Second
+	}
+	if p.DegradedLatency <= 0 {
+		p.DegradedLatency = 2 * time.Second
+	}
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = 3
+	}
+	if p.Reconnect != nil {
+		reconnect := p.Reconnect.withDefaults()
+		p.Reconnect = &reconnect
+	}
+	return p
+}
+
+// serverHealth is the outcome of the latest health checks of a connection
+type serverHealth struct {
+	lastCheck time.Time
+	latency   time.Duration
+	failures  int // Consecutive failed pings
+}
+
+// MonitorHealth pings connected servers periodically, tracking their latency and failures for
+// ServerStates and Status. A server whose pings keep failing is disconnected and, with a reconnect
+// policy, reconnected. Monitoring stops on Close.
+func (m *Manager) MonitorHealth(policy HealthPolicy) {
+	policy = policy.withDefaults()
+
+	m.mu.Lock()
+	if m.healthPolicy != nil {
+		m.mu.Unlock()
+		return
+	}
+	m.healthPolicy = &policy
+	// Servers connected before monitoring started report drops too
+	for name, cli := range m.clients {
+		m.watchClient(name, cli)
+	}
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(policy.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.checkHealth(policy)
+			}
+		}
+	}()
+}
+
+// checkHealth pings every connected server concurrently
+func (m *Manager) checkHealth(policy HealthPolicy) {
+	m.mu.RLock()
+	clients := make(map[string]*client.Client, len(m.clients))
+	for name, cli := range m.clients {
+		clients[name] = cli
+	}
+	m.mu.RUnlock()
+
+	for name, cli := range clients {
+		go m.pingServer(name, cli, policy)
+	}
+}
+
+// pingServer runs one health check and takes the server down after too many consecutive failures
+func (m *Manager) pingServer(name string, cli *client.Client, policy HealthPolicy) {
+	ctx, cancel := context.WithTimeout(context.Background(), policy.Timeout)
+	start := time.Now()
+	err := cli.Ping(ctx)
+	latency := time.Since(start)
+	cancel()
+
+	m.mu.Lock()
+	if m.clients[name] != cli {
+		// Replaced or removed meanwhile
+		m.mu.Unlock()
+		return
+	}
+	health := m.health[name]
+	if health == nil {
+		health = &serverHealth{}
+		m.health[name] = health
+	}
+	health.lastCheck, health.latency = time.Now(), latency
+	if err == nil {
+		if health.failures > 0 {
+			logger.Infof("[MCP:%s] Health check recovered after %d failures", name, health.failures)
+		}
+		health.failures = 0
+		m.mu.Unlock()
+		if latency > policy.DegradedLatency {
+			logger.Warnf("[MCP:%s] Slow health check: %v", name, latency)
+		}
+		return
+	}
+	health.failures++
+	failures := health.failures
+	m.lastErrors[name] = err.Error()
+	m.mu.Unlock()
+
+	logger.Warnf("[MCP:%s] Health check failed (%d/%d): %v", name, failures, policy.FailureThreshold, err)
+	if failures >= policy.FailureThreshold {
+		m.connectionLost(name, cli, err)
+	}
+}
+
+// state derives the state of a configured server; callers hold mu
+func (m *Manager) state(cfg ServerConfig) string {
+	switch {
+	case m.clients[cfg.Name] != nil:
+		health := m.health[cfg.Name]
+		if health != nil && m.healthPolicy != nil &&
+			(health.failures > 0 || health.latency > m.healthPolicy.DegradedLatency) {
+			return StateDegraded
+		}
+		return StateConnected
+	case !cfg.Enabled || !cfg.Configured():
+		return StateDisabled
+	default:
+		return StateDown
+	}
+}
@@ -2,14 +2,25 @@
 package mcp
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	mcptool "github.com/cloudwego/eino-ext/components/tool/mcp"
 	"github.com/cloudwego/eino/components/tool"
+	"github.com/eino-contrib/jsonschema"
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/fourhu/eino-ai-agent/internal/logger"
@@ -20,27 +31,226 @@ type ServerConfig struct {
 	Name    string `json:"name" yaml:"name"`
 	BaseURL string `json:"base_url" yaml:"base_url"`
 	Enabled bool   `json:"enabled" yaml:"enabled"`
+
+	// Command runs the server as a subprocess speaking MCP over stdin and stdout, instead of connecting to
+	// BaseURL. The fields follow claude_desktop_config.json, so its server entries can be copied over;
+	// $VAR and ${VAR} references in them are expanded on each start.
+	Command string            `json:"command,omitempty" yaml:"command,omitempty"`
+	Args    []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty" yaml:"env,omitempty"` // Added to the agent's environment
+	Cwd     string            `json:"cwd,omitempty" yaml:"cwd,omitempty"` // Working directory (default: the agent's)
+
+	// Headers and BearerToken are sent on every request to the server, e.g. for an authenticating gateway.
+	// Values may reference environment variables as $VAR or ${VAR}; they are expanded on each connect.
+	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	BearerToken string            `json:"bearer_token,omitempty" yaml:"bearer_token,omitempty"` // Sent as "Authorization: Bearer <token>"
+
+	// OAuth obtains and renews the bearer token with client credentials instead
+	OAuth *OAuthConfig `json:"oauth,omitempty" yaml:"oauth,omitempty"`
+
+	// TLS trusts a private CA, presents a client certificate or skips verification on the server's connections
+	TLS *TLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	// IncludeTools and ExcludeTools are tool names or globs (e.g. "get_*"); only tools matching an include
+	// pattern (any tool if none) and no exclude pattern are loaded
+	IncludeTools []string `json:"include_tools,omitempty" yaml:"include_tools,omitempty"`
+	ExcludeTools []string `json:"exclude_tools,omitempty" yaml:"exclude_tools,omitempty"`
+
+	ConnectTimeout    time.Duration `json:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty"`       // Opening the connection (default 30s)
+	InitializeTimeout time.Duration `json:"initialize_timeout,omitempty" yaml:"initialize_timeout,omitempty"` // The MCP handshake and tool listing (default 30s)
+	CallTimeout       time.Duration `json:"call_timeout,omitempty" yaml:"call_timeout,omitempty"`             // Each tool call attempt (0 = no limit besides the agent's tool timeout)
+
+	// Retry retries failed tool calls; ToolRetries overrides it for tools matching the key (name or glob)
+	Retry       *RetryPolicy           `json:"retry,omitempty" yaml:"retry,omitempty"`
+	ToolRetries map[string]RetryPolicy `json:"tool_retries,omitempty" yaml:"tool_retries,omitempty"`
+
+	// SizeLimits caps the arguments and results of tool calls; ToolSizeLimits overrides it for tools matching
+	// the key (name or glob)
+	SizeLimits     *SizeLimits           `json:"size_limits,omitempty" yaml:"size_limits,omitempty"`
+	ToolSizeLimits map[string]SizeLimits `json:"tool_size_limits,omitempty" yaml:"tool_size_limits,omitempty"`
+
+	// ToolRefreshInterval polls the tool list of servers that do not send tools/list_changed
+	// notifications (default 5m, negative = never)
+	ToolRefreshInterval time.Duration `json:"tool_refresh_interval,omitempty" yaml:"tool_refresh_interval,omitempty"`
+
+	// Sessions is the number of connections (or processes) opened to the server; tool calls are spread
+	// over them round-robin to raise throughput (default 1)
+	Sessions int `json:"sessions,omitempty" yaml:"sessions,omitempty"`
+
+	// MaxConcurrentCalls caps the tool calls in flight on the server, across all its sessions; further
+	// calls wait for a free slot, while other servers' calls proceed (0 = unlimited)
+	MaxConcurrentCalls int `json:"max_concurrent_calls,omitempty" yaml:"max_concurrent_calls,omitempty"`
+
+	// Restart restarts the process of a command server when it exits
+	Restart RestartPolicy `json:"restart,omitempty" yaml:"restart,omitempty"`
+}
+
+// Default connection timeouts of a server
+const (
+	defaultConnectTimeout    = 30 * time.Second
+	defaultInitializeTimeout = 30 * time.Second
+	defaultToolRefresh       = 5 * time.Minute
+)
+
+// equal reports whether two configurations connect the same way
+func (c ServerConfig) equal(other ServerConfig) bool {
+	return reflect.DeepEqual(c, other)
+}
+
+// headers returns the request headers of a server with environment variables expanded.
+// A reference to an unset variable is an error rather than an empty credential.
+func (c ServerConfig) headers() (map[string]string, error) {
+	if len(c.Headers) == 0 && c.BearerToken == "" {
+		return nil, nil
+	}
+	env := &envExpander{}
+	headers := make(map[string]string, len(c.Headers)+1)
+	for name, value := range c.Headers {
+		headers[name] = env.expand(value)
+	}
+	if c.BearerToken != "" {
+		headers["Authorization"] = "Bearer " + env.expand(c.BearerToken)
+	}
+	if err := env.err("headers"); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// exposes reports whether a tool passes the include and exclude patterns
+func (c ServerConfig) exposes(toolName string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, toolName); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(c.IncludeTools) == 0 || matches(c.IncludeTools)) && !matches(c.ExcludeTools)
+}
+
+// validatePatterns rejects malformed include and exclude globs
+func (c ServerConfig) validatePatterns() error {
+	for _, pattern := range slices.Concat(c.IncludeTools, c.ExcludeTools) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// envExpander expands $VAR and ${VAR} references, remembering the variables that are not set
+type envExpander struct {
+	missing []string
+}
+
+func (e *envExpander) expand(value string) string {
+	return os.Expand(value, func(name string) string {
+		v, ok := os.LookupEnv(name)
+		if !ok && !slices.Contains(e.missing, name) {
+			e.missing = append(e.missing, name)
+		}
+		return v
+	})
+}
+
+// err reports the unset variables referenced by what was expanded
+func (e *envExpander) err(what string) error {
+	if len(e.missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s reference unset environment variables: %s", what, strings.Join(e.missing, ", "))
+}
+
+// ErrUnknownServer is returned for server names that are not configured
+var ErrUnknownServer = errors.New("unknown MCP server")
+
+// ErrServerExists is returned when adding a server whose name is already configured
+var ErrServerExists = errors.New("MCP server already exists")
+
+// ErrServerDisabled is returned when reconnecting a disabled server or enabling one without a base URL or command
+var ErrServerDisabled = errors.New("MCP server is disabled")
+
+// ToolsChangedFunc is called after a server's tools were unloaded or (re)loaded at runtime
+type ToolsChangedFunc func(ctx context.Context, removed []string, added []tool.BaseTool)
+
+// ServerStatus describes a configured server and its connection
+type ServerStatus struct {
+	Name      string   `json:"name"`
+	BaseURL   string   `json:"base_url"`
+	Command   string   `json:"command,omitempty"`
+	Enabled   bool     `json:"enabled"`
+	State     string   `json:"state"` // connected, degraded, down or disabled
+	Tools     []string `json:"tools"`
+	LastError string   `json:"last_error,omitempty"`
+	Restarts  int      `json:"restarts,omitempty"` // Consecutive restarts of a command server's process
+	Sessions  int      `json:"sessions,omitempty"` // Open sessions when the server pools connections
+
+	// Latest health check of the connection, with MonitorHealth
+	LastCheck           *time.Time `json:"last_check,omitempty"`
+	LatencyMS           int64      `json:"latency_ms,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+}
+
+// ToolInfo describes a tool loaded from an MCP server
+type ToolInfo struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Parameters  *jsonschema.Schema `json:"parameters,omitempty"` // JSON Schema of the arguments
+	Server      string             `json:"server"`
+	Enabled     bool               `json:"enabled"`            // Callable now; false while the server is down
+	Mutating    bool               `json:"mutating,omitempty"` // Blocked in read-only sessions
 }
 
 // Manager manages multiple MCP clients and tools
 type Manager struct {
-	configs []ServerConfig
-	clients map[string]*client.Client
-	tools   []tool.BaseTool
-	toolMap map[string]tool.BaseTool // tool name -> tool
-	mu      sync.RWMutex
+	configs    []ServerConfig
+	clients    map[string]*client.Client
+	tools      []tool.BaseTool
+	toolMap    map[string]tool.BaseTool   // tool name -> tool
+	byServer   map[string][]tool.BaseTool // server name -> tools
+	lastErrors map[string]string          // server name -> last connection error
+	onChange   ToolsChangedFunc
+	mu         sync.RWMutex
+	opMu       sync.Mutex // Serializes runtime connects and disconnects
+
+	healthPolicy *HealthPolicy            // Set by MonitorHealth
+	health       map[string]*serverHealth // server name -> latest health checks of its connection
+	reconnecting map[string]bool          // Servers being reconnected after a drop
+	restarts     map[string]int           // Consecutive restarts of command servers whose process exited
+	dropped      map[string]chan struct{} // Closed when the server's current client is dropped
+	pools        map[string]*clientPool   // server name -> sessions of its current client
+	stop         chan struct{}            // Closed by Close
+	stopOnce     sync.Once
 }
 
-// NewManager creates a new MCP manager
+// NewManager creates a new MCP manager; disabled servers are listed and can be enabled at runtime
 func NewManager(configs []ServerConfig) *Manager {
 	return &Manager{
-		configs: configs,
-		clients: make(map[string]*client.Client),
-		tools:   make([]tool.BaseTool, 0),
-		toolMap: make(map[string]tool.BaseTool),
+		configs:      configs,
+		clients:      make(map[string]*client.Client),
+		tools:        make([]tool.BaseTool, 0),
+		toolMap:      make(map[string]tool.BaseTool),
+		byServer:     make(map[string][]tool.BaseTool),
+		lastErrors:   make(map[string]string),
+		health:       make(map[string]*serverHealth),
+		reconnecting: make(map[string]bool),
+		restarts:     make(map[string]int),
+		dropped:      make(map[string]chan struct{}),
+		pools:        make(map[string]*clientPool),
+		stop:         make(chan struct{}),
 	}
 }
 
+// OnToolsChanged registers fn to keep tool consumers in sync with runtime reconnects and enable/disable
+func (m *Manager) OnToolsChanged(fn ToolsChangedFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onChange = fn
+}
+
 // Initialize initializes connections to all configured MCP servers
 func (m *Manager) Initialize(ctx context.Context) error {
 	m.mu.Lock()
@@ -53,16 +263,19 @@ func (m *Manager) Initialize(ctx context.Context) error {
 			logger.Debugf("MCP server %s is disabled, skipping", cfg.Name)
 			continue
 		}
-		if cfg.BaseURL == "" {
-			logger.Debugf("MCP server %s has no base URL, skipping", cfg.Name)
+		if !cfg.Configured() {
+			logger.Debugf("MCP server %s has no base URL or command, skipping", cfg.Name)
 			continue
 		}
 
-		logger.Debugf("Connecting to MCP server: %s at %s", cfg.Name, cfg.BaseURL)
-		if err := m.connectServer(ctx, cfg); err != nil {
+		logger.Debugf("Connecting to MCP server: %s at %s", cfg.Name, cmp.Or(cfg.BaseURL, cfg.Command))
+		cli, tools, err := m.connectServer(ctx, cfg)
+		if err != nil {
 			logger.Errorf("Failed to connect to MCP server %s: %v", cfg.Name, err)
+			m.lastErrors[cfg.Name] = err.Error()
 			return fmt.Errorf("failed to connect to MCP server %s: %w", cfg.Name, err)
 		}
+		m.addServer(ctx, cfg.Name, cli, tools)
 		logger.Debugf("Successfully connected to MCP server: %s", cfg.Name)
 	}
 
@@ -74,18 +287,34 @@ func (m *Manager) Initialize(ctx context.Context) error {
 	return nil
 }
 
-// connectServer connects to a single MCP server
-func (m *Manager) connectServer(ctx context.Context, cfg ServerConfig) error {
-	logger.Debugf("[MCP:%s] Creating SSE client", cfg.Name)
-	cli, err := client.NewSSEMCPClient(cfg.BaseURL)
+// connectServer connects to a single MCP server and fetches its tools; the clients are closed on failure
+func (m *Manager) connectServer(ctx context.Context, cfg ServerConfig) (*clientPool, []tool.BaseTool, error) {
+	if err := cfg.validateTransport(); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.validatePatterns(); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.validateRetries(); err != nil {
+		return nil, nil, err
+	}
+	cli, err := newClient(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create MCP client: %w", err)
+		return nil, nil, err
 	}
 
+	// The SSE stream and the subprocess live as long as the client, not as long as the request that connects it
 	logger.Debugf("[MCP:%s] Starting client", cfg.Name)
-	if err := cli.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start MCP client: %w", err)
+	if err := startClient(ctx, cli, cmp.Or(cfg.ConnectTimeout, defaultConnectTimeout)); err != nil {
+		cli.Close()
+		return nil, nil, fmt.Errorf("failed to start MCP client: %w", err)
 	}
+	if cfg.Command != "" {
+		m.superviseProcess(cfg.Name, cli, cfg.Restart.withDefaults())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(cfg.InitializeTimeout, defaultInitializeTimeout))
+	defer cancel()
 
 	// Initialize client
 	logger.Debugf("[MCP:%s] Initializing client", cfg.Name)
@@ -96,29 +325,105 @@ func (m *Manager) connectServer(ctx context.Context, cfg ServerConfig) error {
 		Version: "1.0.0",
 	}
 
-	if _, err := cli.Initialize(ctx, initRequest); err != nil {
-		return fmt.Errorf("failed to initialize MCP client: %w", err)
-	}
+	// Tool list changes are picked up once the client is registered
+	cli.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationToolsListChanged {
+			logger.Debugf("[MCP:%s] Tool list changed", cfg.Name)
+			go m.refreshTools(cfg.Name, cli)
+		}
+	})
+	cli.OnNotification(relayProgress)
 
-	m.clients[cfg.Name] = cli
+	initResult, err := cli.Initialize(ctx, initRequest)
+	if err != nil {
+		cli.Close()
+		return nil, nil, fmt.Errorf("failed to initialize MCP client: %w", err)
+	}
 	logger.Debugf("[MCP:%s] Client initialized successfully", cfg.Name)
 
-	// Get tools from MCP server
+	pool := m.openPool(ctx, cfg, cli, initRequest)
+	tools, err := m.listTools(ctx, cfg, pool)
+	if err != nil {
+		pool.closeSessions()
+		cli.Close()
+		return nil, nil, err
+	}
+
+	if tools := initResult.Capabilities.Tools; tools == nil || !tools.ListChanged {
+		if interval := cmp.Or(cfg.ToolRefreshInterval, defaultToolRefresh); interval > 0 {
+			go m.pollTools(cfg.Name, cli, interval)
+		}
+	}
+	return pool, tools, nil
+}
+
+// newClient creates the client of a server: a subprocess for command servers, an SSE connection otherwise
+func newClient(ctx context.Context, cfg ServerConfig) (*client.Client, error) {
+	if cfg.Command != "" {
+		logger.Debugf("[MCP:%s] Creating stdio client", cfg.Name)
+		return newStdioClient(cfg)
+	}
+
+	headers, err := cfg.headers()
+	if err != nil {
+		return nil, err
+	}
+	var opts []transport.ClientOption
+	if headers != nil {
+		opts = append(opts, transport.WithHeaders(headers))
+	}
+	httpTransport, err := cfg.httpTransport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	if httpTransport != nil {
+		opts = append(opts, transport.WithHTTPClient(&http.Client{Transport: httpTransport}))
+	}
+	if cfg.OAuth != nil {
+		if cfg.BearerToken != "" {
+			return nil, fmt.Errorf("bearer_token and oauth cannot both be set")
+		}
+		tokens, err := newOAuthTokenSource(cfg.Name, cfg.BaseURL, *cfg.OAuth, httpTransport)
+		if err != nil {
+			return nil, err
+		}
+		// Fail the connect on bad credentials rather than on the server's 401
+		if _, err := tokens.Token(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get OAuth token: %w", err)
+		}
+		opts = append(opts, transport.WithHeaderFunc(tokens.headerFunc))
+	}
+
+	logger.Debugf("[MCP:%s] Creating SSE client", cfg.Name)
+	sse, err := transport.NewSSE(cfg.BaseURL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCP client: %w", err)
+	}
+	return client.NewClient(withCancellation(cfg.Name, sse)), nil
+}
+
+// listTools fetches the tools a server exposes
+func (m *Manager) listTools(ctx context.Context, cfg ServerConfig, cli client.MCPClient) ([]tool.BaseTool, error) {
 	logger.Debugf("[MCP:%s] Fetching tools", cfg.Name)
-	tools, err := mcptool.GetTools(ctx, &mcptool.Config{Cli: cli})
+	listed := &toolLister{MCPClient: cli}
+	tools, err := mcptool.GetTools(ctx, &mcptool.Config{Cli: listed})
 	if err != nil {
-		return fmt.Errorf("failed to get tools from MCP server: %w", err)
+		return nil, fmt.Errorf("failed to get tools from MCP server: %w", err)
 	}
 
 	logger.Debugf("[MCP:%s] Found %d tools", cfg.Name, len(tools))
+	loaded := make([]tool.BaseTool, 0, len(tools))
 	for _, t := range tools {
 		info, err := t.Info(ctx)
 		if err != nil {
 			logger.Warnf("[MCP:%s] Failed to get tool info: %v", cfg.Name, err)
 			continue
 		}
-		m.toolMap[info.Name] = t
-		m.tools = append(m.tools, t)
+		if !cfg.exposes(info.Name) {
+			logger.Debugf("[MCP:%s] Tool filtered out: %s", cfg.Name, info.Name)
+			continue
+		}
+		loaded = append(loaded, t)
 
 		if logger.IsDebugEnabled() {
 			paramsJSON, _ := json.Marshal(info.ParamsOneOf)
@@ -127,6 +432,323 @@ func (m *Manager) connectServer(ctx context.Context, cfg ServerConfig) error {
 		}
 	}
 
+	return m.wrapTools(ctx, cfg, loaded, listed.tools), nil
+}
+
+// toolLister keeps the tool definitions a server listed, with the fields the eino tools leave out
+type toolLister struct {
+	client.MCPClient
+	tools map[string]mcp.Tool
+}
+
+func (l *toolLister) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	result, err := l.MCPClient.ListTools(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	l.tools = make(map[string]mcp.Tool, len(result.Tools))
+	for _, t := range result.Tools {
+		l.tools[t.Name] = t
+	}
+	return result, nil
+}
+
+// startClient opens the connection of a client, giving up after timeout or when ctx is done.
+// The client is started without ctx's cancellation since its stream outlives the connect.
+func startClient(ctx context.Context, cli *client.Client, timeout time.Duration) error {
+	started := make(chan error, 1)
+	go func() {
+		started <- cli.Start(context.WithoutCancel(ctx))
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-started:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("connection timed out after %s", timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// addServer registers a connected client and its tools; callers hold mu
+func (m *Manager) addServer(ctx context.Context, name string, pool *clientPool, tools []tool.BaseTool) {
+	m.clients[name] = pool.Client
+	m.pools[name] = pool
+	m.dropped[name] = make(chan struct{})
+	m.watchClient(name, pool.Client)
+	delete(m.lastErrors, name)
+	m.registerTools(ctx, name, tools)
+}
+
+// registerTools adds the tools of a server to the registry; callers hold mu
+func (m *Manager) registerTools(ctx context.Context, name string, tools []tool.BaseTool) {
+	m.byServer[name] = tools
+	for _, t := range tools {
+		if info, err := t.Info(ctx); err == nil {
+			m.toolMap[info.Name] = t
+		}
+		m.tools = append(m.tools, t)
+	}
+}
+
+// removeServer closes a server's client and unregisters its tools, returning their names; callers hold mu
+func (m *Manager) removeServer(ctx context.Context, name string) []string {
+	if cli := m.clients[name]; cli != nil {
+		if err := cli.Close(); err != nil {
+			logger.Warnf("[MCP:%s] Failed to close client: %v", name, err)
+		}
+		m.dropClient(name)
+	}
+	delete(m.health, name)
+	return m.unregisterTools(ctx, name)
+}
+
+// unregisterTools removes the tools of a server from the registry, returning their names; callers hold mu
+func (m *Manager) unregisterTools(ctx context.Context, name string) []string {
+	dropped := make(map[tool.BaseTool]bool, len(m.byServer[name]))
+	var removed []string
+	for _, t := range m.byServer[name] {
+		dropped[t] = true
+		if info, err := t.Info(ctx); err == nil {
+			removed = append(removed, info.Name)
+			if m.toolMap[info.Name] == t {
+				delete(m.toolMap, info.Name)
+			}
+		}
+	}
+	delete(m.byServer, name)
+
+	kept := make([]tool.BaseTool, 0, len(m.tools))
+	for _, t := range m.tools {
+		if !dropped[t] {
+			kept = append(kept, t)
+		}
+	}
+	m.tools = kept
+	return removed
+}
+
+// config returns the configuration of a server; callers hold mu
+func (m *Manager) config(name string) (*ServerConfig, error) {
+	for i := range m.configs {
+		if m.configs[i].Name == name {
+			return &m.configs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownServer, name)
+}
+
+// Reconnect replaces a server's connection and reloads its tools.
+// If the new connection fails, the previous one is kept and the error is reported in Status.
+func (m *Manager) Reconnect(ctx context.Context, name string) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.RLock()
+	cfg, err := m.config(name)
+	var snapshot ServerConfig
+	if err == nil {
+		snapshot = *cfg
+	}
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if !snapshot.Enabled {
+		return fmt.Errorf("%w: %s", ErrServerDisabled, name)
+	}
+	if err := m.connect(ctx, snapshot); err != nil {
+		return err
+	}
+	// A server restarted by hand gets its full restart budget again
+	m.mu.Lock()
+	delete(m.restarts, name)
+	m.mu.Unlock()
+	return nil
+}
+
+// SetEnabled connects an enabled server or disconnects a disabled one, updating the tool registry
+func (m *Manager) SetEnabled(ctx context.Context, name string, enabled bool) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	cfg, err := m.config(name)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if enabled && !cfg.Configured() {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: %s has no base URL or command", ErrServerDisabled, name)
+	}
+	cfg.Enabled = enabled
+	snapshot, connected := *cfg, m.clients[name] != nil
+	if enabled {
+		m.mu.Unlock()
+		if connected {
+			return nil
+		}
+		return m.connect(ctx, snapshot)
+	}
+
+	removed := m.removeServer(ctx, name)
+	delete(m.lastErrors, name)
+	delete(m.restarts, name)
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	logger.Ctx(ctx).Infof("[MCP:%s] Disabled, unloaded %d tools", name, len(removed))
+	if onChange != nil && len(removed) > 0 {
+		onChange(ctx, removed, nil)
+	}
+	return nil
+}
+
+// AddServer adds a server at runtime; an enabled server is connected and its tools registered along
+// with its configuration. If it fails to connect, nothing is added and the error is returned.
+func (m *Manager) AddServer(ctx context.Context, cfg ServerConfig) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	if cfg.Name == "" {
+		return fmt.Errorf("MCP server name is required")
+	}
+	m.mu.RLock()
+	_, err := m.config(cfg.Name)
+	m.mu.RUnlock()
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrServerExists, cfg.Name)
+	}
+
+	var pool *clientPool
+	var tools []tool.BaseTool
+	if cfg.Enabled && cfg.Configured() {
+		if pool, tools, err = m.connectServer(ctx, cfg); err != nil {
+			logger.Ctx(ctx).Errorf("[MCP:%s] Failed to connect: %v", cfg.Name, err)
+			return fmt.Errorf("failed to connect to MCP server %s: %w", cfg.Name, err)
+		}
+	}
+
+	m.mu.Lock()
+	m.configs = append(slices.Clone(m.configs), cfg)
+	if pool != nil {
+		m.addServer(ctx, cfg.Name, pool, tools)
+	}
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	logger.Ctx(ctx).Infof("[MCP:%s] Added, loaded %d tools", cfg.Name, len(tools))
+	if onChange != nil && len(tools) > 0 {
+		onChange(ctx, nil, tools)
+	}
+	return nil
+}
+
+// RemoveServer disconnects a server added at runtime or configured at startup, unregisters its tools
+// and forgets its configuration
+func (m *Manager) RemoveServer(name string) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	ctx := context.Background()
+	m.mu.Lock()
+	if _, err := m.config(name); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	removed := m.removeServer(ctx, name)
+	m.configs = slices.DeleteFunc(slices.Clone(m.configs), func(cfg ServerConfig) bool { return cfg.Name == name })
+	delete(m.lastErrors, name)
+	delete(m.restarts, name)
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	logger.Infof("[MCP:%s] Removed, unloaded %d tools", name, len(removed))
+	if onChange != nil && len(removed) > 0 {
+		onChange(ctx, removed, nil)
+	}
+	return nil
+}
+
+// Sync applies a new server configuration at runtime: removed and disabled servers are disconnected,
+// added and changed enabled servers are (re)connected, and unchanged servers keep their connections.
+// Servers that fail to connect stay configured with the error reported in Status.
+func (m *Manager) Sync(ctx context.Context, configs []ServerConfig) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	previous := make(map[string]ServerConfig, len(m.configs))
+	for _, cfg := range m.configs {
+		previous[cfg.Name] = cfg
+	}
+	next := make(map[string]ServerConfig, len(configs))
+	for _, cfg := range configs {
+		next[cfg.Name] = cfg
+	}
+	var removed []string
+	for name := range previous {
+		if cfg, ok := next[name]; ok && cfg.Enabled && cfg.Configured() {
+			continue
+		}
+		if m.clients[name] != nil {
+			logger.Ctx(ctx).Infof("[MCP:%s] No longer configured or enabled, disconnecting", name)
+		}
+		removed = append(removed, m.removeServer(ctx, name)...)
+		delete(m.lastErrors, name)
+		delete(m.restarts, name)
+	}
+	m.configs = slices.Clone(configs)
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	if onChange != nil && len(removed) > 0 {
+		onChange(ctx, removed, nil)
+	}
+
+	var errs []error
+	for _, cfg := range configs {
+		if !cfg.Enabled || !cfg.Configured() {
+			continue
+		}
+		m.mu.RLock()
+		connected := m.clients[cfg.Name] != nil
+		m.mu.RUnlock()
+		if connected && previous[cfg.Name].equal(cfg) {
+			continue
+		}
+		if err := m.connect(ctx, cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// connect (re)connects a server and swaps its tools; callers hold opMu
+func (m *Manager) connect(ctx context.Context, cfg ServerConfig) error {
+	cli, tools, err := m.connectServer(ctx, cfg)
+	if err != nil {
+		m.mu.Lock()
+		m.lastErrors[cfg.Name] = err.Error()
+		m.mu.Unlock()
+		logger.Ctx(ctx).Errorf("[MCP:%s] Failed to connect: %v", cfg.Name, err)
+		return fmt.Errorf("failed to connect to MCP server %s: %w", cfg.Name, err)
+	}
+
+	m.mu.Lock()
+	removed := m.removeServer(ctx, cfg.Name)
+	m.addServer(ctx, cfg.Name, cli, tools)
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	logger.Ctx(ctx).Infof("[MCP:%s] Connected, loaded %d tools", cfg.Name, len(tools))
+	if onChange != nil {
+		onChange(ctx, removed, tools)
+	}
 	return nil
 }
 
@@ -140,6 +762,18 @@ func (m *Manager) GetTools() []tool.BaseTool {
 	return result
 }
 
+// GetServerTools returns the tools provided by the named servers
+func (m *Manager) GetServerTools(names ...string) []tool.BaseTool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []tool.BaseTool
+	for _, name := range names {
+		result = append(result, m.byServer[name]...)
+	}
+	return result
+}
+
 // GetToolByName gets a specific tool by name
 func (m *Manager) GetToolByName(name string) (tool.BaseTool, bool) {
 	m.mu.RLock()
@@ -151,6 +785,8 @@ func (m *Manager) GetToolByName(name string) (tool.BaseTool, bool) {
 
 // Close closes all MCP client connections
 func (m *Manager) Close() error {
+	m.stopOnce.Do(func() { close(m.stop) })
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -159,6 +795,7 @@ func (m *Manager) Close() error {
 		if err := cli.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("failed to close client %s: %w", name, err))
 		}
+		m.dropClient(name)
 	}
 
 	if len(errs) > 0 {
@@ -178,3 +815,113 @@ func (m *Manager) GetServerNames() []string {
 	}
 	return names
 }
+
+// ServerStates reports each configured server as "connected", "degraded", "down" or "disabled"
+func (m *Manager) ServerStates() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := make(map[string]string, len(m.configs))
+	for _, cfg := range m.configs {
+		states[cfg.Name] = m.state(cfg)
+	}
+	return states
+}
+
+// Status describes every configured server, in configuration order
+func (m *Manager) Status(ctx context.Context) []ServerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]ServerStatus, 0, len(m.configs))
+	for _, cfg := range m.configs {
+		names := make([]string, 0, len(m.byServer[cfg.Name]))
+		for _, t := range m.byServer[cfg.Name] {
+			if info, err := t.Info(ctx); err == nil {
+				names = append(names, info.Name)
+			}
+		}
+		status := ServerStatus{
+			Name:      cfg.Name,
+			BaseURL:   cfg.BaseURL,
+			Command:   cfg.Command,
+			Enabled:   cfg.Enabled,
+			State:     m.state(cfg),
+			Tools:     names,
+			LastError: m.lastErrors[cfg.Name],
+			Restarts:  m.restarts[cfg.Name],
+		}
+		if pool := m.pools[cfg.Name]; pool != nil && cfg.Sessions > 1 {
+			status.Sessions = pool.size()
+		}
+		if health := m.health[cfg.Name]; health != nil {
+			lastCheck := health.lastCheck
+			status.LastCheck = &lastCheck
+			status.LatencyMS = health.latency.Milliseconds()
+			status.ConsecutiveFailures = health.failures
+		}
+		list = append(list, status)
+	}
+	return list
+}
+
+// ListToolInfos describes the tools of the named servers, or of every server if none is named, in
+// configuration order; tools of a server that is down are listed as not enabled
+func (m *Manager) ListToolInfos(ctx context.Context, servers ...string) ([]ToolInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	configs := m.configs
+	if len(servers) > 0 {
+		configs = make([]ServerConfig, 0, len(servers))
+		for _, name := range servers {
+			cfg, err := m.config(name)
+			if err != nil {
+				return nil, err
+			}
+			configs = append(configs, *cfg)
+		}
+	}
+
+	infos := make([]ToolInfo, 0)
+	for _, cfg := range configs {
+		enabled := m.clients[cfg.Name] != nil
+		for _, t := range m.byServer[cfg.Name] {
+			info, err := t.Info(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tool info: %w", err)
+			}
+			toolInfo := ToolInfo{
+				Name:        info.Name,
+				Description: info.Desc,
+				Server:      cfg.Name,
+				Enabled:     enabled,
+			}
+			if info.ParamsOneOf != nil {
+				if params, err := info.ParamsOneOf.ToJSONSchema(); err == nil {
+					toolInfo.Parameters = params
+				}
+			}
+			if st, ok := t.(*serverTool); ok {
+				toolInfo.Mutating = st.mutating
+			}
+			infos = append(infos, toolInfo)
+		}
+	}
+	return infos, nil
+}
+
+// Ping verifies a connected server answers
+func (m *Manager) Ping(ctx context.Context, name string) error {
+	m.mu.RLock()
+	_, err := m.config(name)
+	cli := m.clients[name]
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if cli == nil {
+		return fmt.Errorf("MCP server %s is not connected", name)
+	}
+	return cli.Ping(ctx)
+}
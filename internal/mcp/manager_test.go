@@ -0,0 +1,88 @@
+package mcp_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/mcp"
+	"github.com/fourhu/eino-ai-agent/internal/mcp/mcptest"
+)
+
+func TestManagerTools(t *testing.T) {
+	logger.Init("error")
+	ctx := context.Background()
+
+	srv := mcptest.RunT(t)
+	srv.SetResult("get_weather", "sunny")
+	srv.AddTool("echo", "Echoes text", func(_ context.Context, args map[string]any) (string, error) {
+		text, _ := args["text"].(string)
+		return text, nil
+	}, mcpgo.WithString("text", mcpgo.Required()))
+
+	manager := mcp.NewManager([]mcp.ServerConfig{{Name: "mock", BaseURL: srv.URL(), Enabled: true}})
+	t.Cleanup(func() { manager.Close() })
+	changed := make(chan []string, 1)
+	manager.OnToolsChanged(func(_ context.Context, removed []string, _ []tool.BaseTool) {
+		changed <- removed
+	})
+	if err := manager.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	// Connect and list tools
+	infos, err := manager.ListToolInfos(ctx)
+	if err != nil {
+		t.Fatalf("ListToolInfos: %v", err)
+	}
+	var names []string
+	for _, info := range infos {
+		if info.Server != "mock" || !info.Enabled {
+			t.Errorf("tool %s: server %q, enabled %v", info.Name, info.Server, info.Enabled)
+		}
+		names = append(names, info.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"echo", "get_weather"}) {
+		t.Fatalf("tools = %v, want [echo get_weather]", names)
+	}
+
+	// Call a tool
+	echo, ok := manager.GetToolByName("echo")
+	if !ok {
+		t.Fatal("echo tool not found")
+	}
+	result, err := echo.(tool.InvokableTool).InvokableRun(ctx, `{"text":"hello"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if want := `{"content":[{"type":"text","text":"hello"}]}`; result != want {
+		t.Errorf("result = %s, want %s", result, want)
+	}
+	if calls := srv.Calls(); len(calls) != 1 || calls[0].Tool != "echo" || calls[0].Arguments["text"] != "hello" {
+		t.Errorf("server calls = %+v, want one echo call with text hello", calls)
+	}
+
+	// Remove a tool on the server; the manager reloads its tools on the list change notification
+	srv.RemoveTool("get_weather")
+	select {
+	case removed := <-changed:
+		slices.Sort(removed)
+		if !slices.Equal(removed, []string{"echo", "get_weather"}) {
+			t.Errorf("removed = %v, want the previous tools [echo get_weather]", removed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools were not reloaded after the server removed one")
+	}
+	if _, ok := manager.GetToolByName("get_weather"); ok {
+		t.Error("get_weather is still loaded after the server removed it")
+	}
+	if _, ok := manager.GetToolByName("echo"); !ok {
+		t.Error("echo was unloaded along with get_weather")
+	}
+}
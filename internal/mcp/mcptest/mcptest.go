@@ -0,0 +1,165 @@
+// Package mcptest runs an in-process MCP server with scriptable tools, so tests of the MCP manager and the
+// agent need no external server binaries.
+//
+//	srv := mcptest.RunT(t)
+//	srv.SetResult("get_weather", "sunny")
+//	manager := mcp.NewManager([]mcp.ServerConfig{{Name: "mock", BaseURL: srv.URL(), Enabled: true}})
+package mcptest
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Handler scripts a tool: it receives the call's arguments and returns the result text, or an error
+// the client receives as a tool error result
+type Handler func(ctx context.Context, args map[string]any) (string, error)
+
+// Call is a tool call the server received
+type Call struct {
+	Tool      string
+	Arguments map[string]any
+}
+
+// Server is an MCP server speaking SSE on a local port
+type Server struct {
+	mcp  *server.MCPServer
+	http *httptest.Server
+
+	mu            sync.Mutex
+	calls         []Call
+	cancellations []string
+}
+
+// Run starts a server without tools; add them with AddTool, SetResult or SetError
+func Run() *Server {
+	s := &Server{
+		mcp: server.NewMCPServer("mcptest", "1.0.0", server.WithToolCapabilities(true)),
+	}
+	s.mcp.AddNotificationHandler("notifications/cancelled", func(_ context.Context, notification mcp.JSONRPCNotification) {
+		reason, _ := notification.Params.AdditionalFields["reason"].(string)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.cancellations = append(s.cancellations, reason)
+	})
+	s.http = server.NewTestServer(s.mcp)
+	return s
+}
+
+// RunT starts a server that is closed when the test ends
+func RunT(t testing.TB) *Server {
+	t.Helper()
+	s := Run()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// URL is the SSE endpoint to use as the base_url of a server config
+func (s *Server) URL() string {
+	return s.http.URL + "/sse"
+}
+
+// Close disconnects all clients and stops the server
+func (s *Server) Close() {
+	// Open SSE streams would otherwise keep Close waiting
+	s.http.CloseClientConnections()
+	s.http.Close()
+}
+
+// AddTool adds or replaces a tool; options such as mcp.WithString describe its input schema and
+// mcp.WithReadOnlyHintAnnotation its annotations. Connected clients are notified of the change.
+func (s *Server) AddTool(name, description string, handler Handler, opts ...mcp.ToolOption) {
+	tool := mcp.NewTool(name, append([]mcp.ToolOption{mcp.WithDescription(description)}, opts...)...)
+	s.mcp.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		s.record(Call{Tool: name, Arguments: args})
+
+		if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+			ctx = context.WithValue(ctx, progressTokenKey{}, request.Params.Meta.ProgressToken)
+		}
+		result, err := handler(ctx, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	})
+}
+
+// SetResult adds a tool that always returns result
+func (s *Server) SetResult(name, result string) {
+	s.AddTool(name, "Returns "+result, func(context.Context, map[string]any) (string, error) {
+		return result, nil
+	})
+}
+
+// SetError adds a tool that always fails with message
+func (s *Server) SetError(name, message string) {
+	s.AddTool(name, "Fails with "+message, func(context.Context, map[string]any) (string, error) {
+		return "", fmt.Errorf("%s", message)
+	})
+}
+
+// RemoveTool removes tools; connected clients are notified of the change
+func (s *Server) RemoveTool(names ...string) {
+	s.mcp.DeleteTools(names...)
+}
+
+// Calls returns the tool calls received so far, in order
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallCount returns how often a tool was called
+func (s *Server) CallCount(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, call := range s.calls {
+		if call.Tool == name {
+			count++
+		}
+	}
+	return count
+}
+
+// Cancellations returns the reasons of the cancellation notifications received so far, in order
+func (s *Server) Cancellations() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.cancellations...)
+}
+
+func (s *Server) record(call Call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+// progressTokenKey is the context key carrying the progress token of the call being handled
+type progressTokenKey struct{}
+
+// Progress sends a progress notification for the call handled with ctx, if the client asked for them;
+// total is 0 if unknown
+func Progress(ctx context.Context, progress, total float64, message string) {
+	token := ctx.Value(progressTokenKey{})
+	srv := server.ServerFromContext(ctx)
+	if token == nil || srv == nil {
+		return
+	}
+	params := map[string]any{"progressToken": token, "progress": progress}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	_ = srv.SendNotificationToClient(ctx, "notifications/progress", params)
+}
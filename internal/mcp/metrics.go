@@ -0,0 +1,149 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/fourhu/eino-ai-agent/internal/metrics"
+)
+
+// sizeBuckets are the payload size buckets (in bytes)
+var sizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+
+// Tool call metrics, labelled by server and tool
+var (
+	toolCalls = metrics.NewCounter("eino_mcp_tool_calls_total",
+		"Total number of MCP tool calls", "server", "tool")
+	toolErrors = metrics.NewCounter("eino_mcp_tool_errors_total",
+		"Total number of MCP tool calls that failed", "server", "tool")
+	toolRetries = metrics.NewCounter("eino_mcp_tool_retries_total",
+		"Total number of MCP tool call attempts that failed and were retried", "server", "tool")
+	toolDuration = metrics.NewHistogram("eino_mcp_tool_duration_seconds",
+		"Duration of MCP tool calls", metrics.DefBuckets, "server", "tool")
+	toolRequestBytes = metrics.NewHistogram("eino_mcp_tool_request_bytes",
+		"Size of the arguments sent to MCP tools", sizeBuckets, "server", "tool")
+	toolResponseBytes = metrics.NewHistogram("eino_mcp_tool_response_bytes",
+		"Size of the results returned by MCP tools", sizeBuckets, "server", "tool")
+)
+
+// observeCall records one tool call
+func observeCall(server, tool string, elapsed time.Duration, requestBytes, responseBytes int, err error) {
+	toolCalls.Inc(server, tool)
+	if err != nil {
+		toolErrors.Inc(server, tool)
+	}
+	toolDuration.Observe(elapsed.Seconds(), server, tool)
+	toolRequestBytes.Observe(float64(requestBytes), server, tool)
+	if err == nil {
+		toolResponseBytes.Observe(float64(responseBytes), server, tool)
+	}
+}
+
+// CallStats summarizes the calls of a tool or of all the tools of a server
+type CallStats struct {
+	Server           string  `json:"server"`
+	Tool             string  `json:"tool,omitempty"` // Empty for server totals
+	Calls            int64   `json:"calls"`
+	Errors           int64   `json:"errors"`
+	ErrorRate        float64 `json:"error_rate"`
+	P50MS            float64 `json:"p50_ms"`
+	P95MS            float64 `json:"p95_ms"`
+	P99MS            float64 `json:"p99_ms"`
+	AvgRequestBytes  float64 `json:"avg_request_bytes"`
+	AvgResponseBytes float64 `json:"avg_response_bytes"`
+}
+
+// ToolStats is the call summary of every loaded tool and of every configured server
+type ToolStats struct {
+	Servers []CallStats `json:"servers"`
+	Tools   []CallStats `json:"tools"`
+}
+
+// callSeries is the raw data of one or more label sets
+type callSeries struct {
+	calls, errors     float64
+	duration          metrics.HistogramSnapshot
+	request, response metrics.HistogramSnapshot
+}
+
+func seriesOf(server, tool string) callSeries {
+	return callSeries{
+		calls:    toolCalls.Value(server, tool),
+		errors:   toolErrors.Value(server, tool),
+		duration: toolDuration.Snapshot(server, tool),
+		request:  toolRequestBytes.Snapshot(server, tool),
+		response: toolResponseBytes.Snapshot(server, tool),
+	}
+}
+
+// add merges the data of another series
+func (s *callSeries) add(other callSeries) {
+	s.calls += other.calls
+	s.errors += other.errors
+	s.duration = mergeSnapshots(s.duration, other.duration)
+	s.request = mergeSnapshots(s.request, other.request)
+	s.response = mergeSnapshots(s.response, other.response)
+}
+
+// mergeSnapshots adds up two snapshots of the same histogram
+func mergeSnapshots(a, b metrics.HistogramSnapshot) metrics.HistogramSnapshot {
+	if a.Buckets == nil {
+		a.Buckets, a.Counts = b.Buckets, make([]uint64, len(b.Counts))
+	}
+	merged := metrics.HistogramSnapshot{
+		Count:   a.Count + b.Count,
+		Sum:     a.Sum + b.Sum,
+		Buckets: a.Buckets,
+		Counts:  make([]uint64, len(a.Counts)),
+	}
+	for i := range merged.Counts {
+		merged.Counts[i] = a.Counts[i] + b.Counts[i]
+	}
+	return merged
+}
+
+func (s callSeries) stats(server, tool string) CallStats {
+	stats := CallStats{
+		Server: server,
+		Tool:   tool,
+		Calls:  int64(s.calls),
+		Errors: int64(s.errors),
+		P50MS:  s.duration.Quantile(0.50) * 1000,
+		P95MS:  s.duration.Quantile(0.95) * 1000,
+		P99MS:  s.duration.Quantile(0.99) * 1000,
+	}
+	if s.calls > 0 {
+		stats.ErrorRate = s.errors / s.calls
+	}
+	if s.request.Count > 0 {
+		stats.AvgRequestBytes = s.request.Sum / float64(s.request.Count)
+	}
+	if s.response.Count > 0 {
+		stats.AvgResponseBytes = s.response.Sum / float64(s.response.Count)
+	}
+	return stats
+}
+
+// ToolStats summarizes the calls of the loaded tools since the process started.
+// Latency percentiles are estimated from the histogram buckets.
+func (m *Manager) ToolStats(ctx context.Context) ToolStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := ToolStats{Servers: make([]CallStats, 0, len(m.configs)), Tools: []CallStats{}}
+	for _, cfg := range m.configs {
+		var total callSeries
+		for _, t := range m.byServer[cfg.Name] {
+			info, err := t.Info(ctx)
+			if err != nil {
+				continue
+			}
+			series := seriesOf(cfg.Name, info.Name)
+			total.add(series)
+			result.Tools = append(result.Tools, series.stats(cfg.Name, info.Name))
+		}
+		result.Servers = append(result.Servers, total.stats(cfg.Name, ""))
+	}
+	return result
+}
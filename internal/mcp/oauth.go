@@ -0,0 +1,222 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// OAuthConfig configures the OAuth 2.0 client credentials grant for a server
+type OAuthConfig struct {
+	ClientID     string   `json:"client_id" yaml:"client_id"`
+	ClientSecret string   `json:"client_secret" yaml:"client_secret"`             // May reference environment variables like Headers
+	TokenURL     string   `json:"token_url,omitempty" yaml:"token_url,omitempty"` // Discovered from the server's authorization metadata when empty
+	Scopes       []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// tokenExpiryMargin renews tokens this long before they expire
+const tokenExpiryMargin = 30 * time.Second
+
+// oauthToken is an access token with what is needed to renew it
+type oauthToken struct {
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time // Zero if the server did not say
+}
+
+func (t *oauthToken) valid() bool {
+	return t != nil && (t.expiresAt.IsZero() || time.Now().Add(tokenExpiryMargin).Before(t.expiresAt))
+}
+
+// tokenResponse is the token endpoint answer (RFC 6749 section 5)
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	ExpiresIn        int64  `json:"expires_in,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// oauthTokenSource obtains and renews the access token of a server.
+// Tokens are renewed with the refresh token when one was issued, otherwise with new client credentials.
+type oauthTokenSource struct {
+	name      string // Server name, for logs
+	serverURL string
+	config    OAuthConfig // With environment variables expanded
+	client    *http.Client
+
+	mu       sync.Mutex
+	tokenURL string
+	token    *oauthToken
+}
+
+// newOAuthTokenSource expands the credentials of a server's OAuth configuration; the token endpoint is
+// reached over transport (nil for the default), so it shares the server's TLS settings
+func newOAuthTokenSource(name, serverURL string, config OAuthConfig, transport http.RoundTripper) (*oauthTokenSource, error) {
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("oauth client_id is required")
+	}
+	env := &envExpander{}
+	config.ClientID = env.expand(config.ClientID)
+	config.ClientSecret = env.expand(config.ClientSecret)
+	if err := env.err("oauth credentials"); err != nil {
+		return nil, err
+	}
+	return &oauthTokenSource{
+		name:      name,
+		serverURL: serverURL,
+		config:    config,
+		client:    &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		tokenURL:  config.TokenURL,
+	}, nil
+}
+
+// Token returns a valid access token, fetching or renewing it as needed
+func (s *oauthTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.valid() {
+		return s.token.accessToken, nil
+	}
+	if s.tokenURL == "" {
+		tokenURL, err := s.discoverTokenURL(ctx)
+		if err != nil {
+			return "", err
+		}
+		s.tokenURL = tokenURL
+	}
+
+	if s.token != nil && s.token.refreshToken != "" {
+		token, err := s.requestToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {s.token.refreshToken},
+		})
+		if err == nil {
+			s.token = token
+			return token.accessToken, nil
+		}
+		logger.Ctx(ctx).Warnf("[MCP:%s] Failed to refresh OAuth token, requesting a new one: %v", s.name, err)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	token, err := s.requestToken(ctx, form)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	logger.Ctx(ctx).Debugf("[MCP:%s] Obtained OAuth token (expires %v)", s.name, token.expiresAt)
+	return token.accessToken, nil
+}
+
+// requestToken posts a grant to the token endpoint, authenticating the client with HTTP Basic
+func (s *oauthTokenSource) requestToken(ctx context.Context, form url.Values) (*oauthToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	var result tokenResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != "" {
+		if result.Error != "" {
+			return nil, fmt.Errorf("token request returned status %d: %s %s", resp.StatusCode, result.Error, result.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token request returned status %d", resp.StatusCode)
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+
+	token := &oauthToken{accessToken: result.AccessToken, refreshToken: result.RefreshToken}
+	if result.ExpiresIn > 0 {
+		token.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// discoverTokenURL finds the token endpoint as MCP authorization specifies: the protected resource metadata
+// names the authorization server, whose metadata names the token endpoint. Without metadata the
+// authorization server is the server's origin and the endpoint is its /token path.
+func (s *oauthTokenSource) discoverTokenURL(ctx context.Context) (string, error) {
+	server, err := url.Parse(s.serverURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL: %w", err)
+	}
+	issuer := server.Scheme + "://" + server.Host
+
+	var resource struct {
+		AuthorizationServers []string `json:"authorization_servers"`
+	}
+	if s.getJSON(ctx, issuer+"/.well-known/oauth-protected-resource", &resource) == nil && len(resource.AuthorizationServers) > 0 {
+		issuer = strings.TrimSuffix(resource.AuthorizationServers[0], "/")
+	}
+
+	var metadata struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	for _, path := range []string{"/.well-known/oauth-authorization-server", "/.well-known/openid-configuration"} {
+		if s.getJSON(ctx, issuer+path, &metadata) == nil && metadata.TokenEndpoint != "" {
+			return metadata.TokenEndpoint, nil
+		}
+	}
+	logger.Ctx(ctx).Debugf("[MCP:%s] No authorization server metadata found, using %s/token", s.name, issuer)
+	return issuer + "/token", nil
+}
+
+// getJSON fetches a metadata document
+func (s *oauthTokenSource) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// headerFunc adds the access token to every request of a connection; failures are logged and the
+// request goes out without it, so the server's 401 is what the caller sees
+func (s *oauthTokenSource) headerFunc(ctx context.Context) map[string]string {
+	token, err := s.Token(ctx)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("[MCP:%s] Failed to get OAuth token: %v", s.name, err)
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
@@ -0,0 +1,195 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// session is one connection of a server's pool
+type session struct {
+	cli  *client.Client
+	done chan struct{} // Closed when the session is evicted; nil for the primary client
+}
+
+// clientPool is the client of a server with extra sessions. Tool calls are spread over all sessions
+// round-robin; everything else, including pings and notifications, goes through the primary client.
+type clientPool struct {
+	*client.Client
+	name     string
+	sessions []*session // The primary client first
+	next     atomic.Uint64
+	mu       sync.RWMutex
+	slots    chan struct{} // One per call in flight; nil = unlimited
+}
+
+// openPool opens the extra sessions of a server next to its initialized primary client.
+// Sessions that fail to open are skipped, leaving a smaller pool.
+func (m *Manager) openPool(ctx context.Context, cfg ServerConfig, primary *client.Client, init mcp.InitializeRequest) *clientPool {
+	pool := &clientPool{Client: primary, name: cfg.Name, sessions: []*session{{cli: primary}}}
+	if cfg.MaxConcurrentCalls > 0 {
+		pool.slots = make(chan struct{}, cfg.MaxConcurrentCalls)
+	}
+	for i := 1; i < cfg.Sessions; i++ {
+		cli, err := openSession(ctx, cfg, init)
+		if err != nil {
+			logger.Warnf("[MCP:%s] Failed to open session %d of %d: %v", cfg.Name, i+1, cfg.Sessions, err)
+			continue
+		}
+		s := &session{cli: cli, done: make(chan struct{})}
+		pool.sessions = append(pool.sessions, s)
+		pool.watch(s, cfg.Command != "")
+	}
+	if len(pool.sessions) > 1 {
+		logger.Debugf("[MCP:%s] Opened %d sessions", cfg.Name, len(pool.sessions))
+	}
+	return pool
+}
+
+// openSession connects and initializes one extra client of a server
+func openSession(ctx context.Context, cfg ServerConfig, init mcp.InitializeRequest) (*client.Client, error) {
+	cli, err := newClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cli.OnNotification(relayProgress)
+	if err := startClient(ctx, cli, cmp.Or(cfg.ConnectTimeout, defaultConnectTimeout)); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to start MCP client: %w", err)
+	}
+	if _, err := cli.Initialize(ctx, init); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to initialize MCP client: %w", err)
+	}
+	return cli, nil
+}
+
+// watch evicts a session whose connection drops or whose process exits
+func (p *clientPool) watch(s *session, process bool) {
+	if !process {
+		s.cli.OnConnectionLost(func(err error) {
+			logger.Warnf("[MCP:%s] Session connection lost, removing it from the pool: %v", p.name, err)
+			p.evict(s)
+		})
+		return
+	}
+	stderr, ok := stderrOf(s.cli)
+	if !ok || stderr == nil {
+		return
+	}
+	go func() {
+		// The output ends when the process exits
+		_, _ = io.Copy(io.Discard, stderr)
+		p.mu.RLock()
+		active := slices.Contains(p.sessions, s)
+		p.mu.RUnlock()
+		if active {
+			logger.Warnf("[MCP:%s] Session process exited, removing it from the pool", p.name)
+			p.evict(s)
+		}
+	}()
+}
+
+// evict removes an extra session from the rotation, aborts its calls in flight and closes it
+func (p *clientPool) evict(s *session) {
+	p.mu.Lock()
+	i := slices.Index(p.sessions, s)
+	if i < 0 {
+		p.mu.Unlock()
+		return
+	}
+	p.sessions = slices.Delete(p.sessions, i, i+1)
+	p.mu.Unlock()
+
+	close(s.done)
+	if err := s.cli.Close(); err != nil {
+		logger.Debugf("[MCP:%s] Failed to close session: %v", p.name, err)
+	}
+}
+
+// closeSessions closes the extra sessions; the primary client is closed by its owner
+func (p *clientPool) closeSessions() {
+	p.mu.RLock()
+	extra := slices.Clone(p.sessions[1:])
+	p.mu.RUnlock()
+	for _, s := range extra {
+		p.evict(s)
+	}
+}
+
+// size returns the number of open sessions
+func (p *clientPool) size() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.sessions)
+}
+
+// acquireCall waits until the server admits another tool call and returns the function ending it
+func (m *Manager) acquireCall(ctx context.Context, name string) (func(), error) {
+	m.mu.RLock()
+	pool := m.pools[name]
+	m.mu.RUnlock()
+	if pool == nil {
+		return func() {}, nil
+	}
+	return pool.acquire(ctx)
+}
+
+// acquire waits for a free call slot and returns the function releasing it
+func (p *clientPool) acquire(ctx context.Context) (func(), error) {
+	if p.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	default:
+	}
+	logger.Ctx(ctx).Debugf("[MCP:%s] %d calls in flight, waiting for a free slot", p.name, cap(p.slots))
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// CallTool sends the call over the next session in turn
+func (p *clientPool) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p.mu.RLock()
+	s := p.sessions[int(p.next.Add(1)-1)%len(p.sessions)]
+	p.mu.RUnlock()
+	if s.done == nil {
+		return s.cli.CallTool(ctx, request)
+	}
+
+	// A response never comes once the session is gone
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	result, err := s.cli.CallTool(ctx, request)
+	select {
+	case <-s.done:
+		if err != nil {
+			return nil, fmt.Errorf("MCP session went down during the call: %w", err)
+		}
+	default:
+	}
+	return result, err
+}
@@ -0,0 +1,76 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/fourhu/eino-ai-agent/internal/progress"
+)
+
+// progressCall is a tool call in flight that asked for progress notifications
+type progressCall struct {
+	ctx  context.Context
+	tool string
+	mu   sync.Mutex
+	done bool // Set once the call returned; later notifications are dropped
+}
+
+// progressCalls maps the progress tokens of the calls in flight to their calls, across all clients
+var progressCalls = struct {
+	sync.Mutex
+	calls map[string]*progressCall
+	next  atomic.Uint64
+}{calls: make(map[string]*progressCall)}
+
+// trackProgress registers a call whose progress is reported to the reporter of ctx. It returns the token
+// sent with the call and a function to call once the call returned.
+func trackProgress(ctx context.Context, toolName string) (string, func()) {
+	token := fmt.Sprintf("eino-%d", progressCalls.next.Add(1))
+	call := &progressCall{ctx: ctx, tool: toolName}
+
+	progressCalls.Lock()
+	progressCalls.calls[token] = call
+	progressCalls.Unlock()
+
+	return token, func() {
+		progressCalls.Lock()
+		delete(progressCalls.calls, token)
+		progressCalls.Unlock()
+
+		call.mu.Lock()
+		call.done = true
+		call.mu.Unlock()
+	}
+}
+
+// relayProgress passes a progress notification to the call it belongs to
+func relayProgress(notification mcp.JSONRPCNotification) {
+	if notification.Method != "notifications/progress" {
+		return
+	}
+	fields := notification.Params.AdditionalFields
+	token := fmt.Sprint(fields["progressToken"])
+
+	progressCalls.Lock()
+	call := progressCalls.calls[token]
+	progressCalls.Unlock()
+	if call == nil {
+		return
+	}
+
+	update := progress.Update{}
+	update.Progress, _ = fields["progress"].(float64)
+	update.Total, _ = fields["total"].(float64)
+	update.Message, _ = fields["message"].(string)
+
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	if !call.done {
+		progress.Report(call.ctx, call.tool, update)
+	}
+}
@@ -0,0 +1,122 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// ReconnectPolicy configures the reconnection of servers that went down (see HealthPolicy)
+type ReconnectPolicy struct {
+	InitialBackoff time.Duration // Delay before the first reconnect attempt (default 1s)
+	MaxBackoff     time.Duration // Upper bound for the delay (default 1m)
+}
+
+// withDefaults fills unset fields with their defaults
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = time.Second
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = max(time.Minute, p.InitialBackoff)
+	}
+	return p
+}
+
+// watchClient reports drops the transport notices itself; callers hold mu
+func (m *Manager) watchClient(name string, cli *client.Client) {
+	if m.healthPolicy == nil {
+		return
+	}
+	cli.OnConnectionLost(func(err error) {
+		m.connectionLost(name, cli, err)
+	})
+}
+
+// connectionLost closes a dropped client and, with a reconnect policy, starts reconnecting its server.
+// Nothing happens if the client was already replaced.
+func (m *Manager) connectionLost(name string, cli *client.Client, cause error) {
+	m.mu.Lock()
+	if m.clients[name] != cli || m.reconnecting[name] {
+		m.mu.Unlock()
+		return
+	}
+	// The tools stay registered so the agents keep their tool set; calls fail until the server is back
+	m.dropClient(name)
+	delete(m.health, name)
+	m.lastErrors[name] = cause.Error()
+	reconnect := m.healthPolicy.Reconnect
+	if reconnect != nil {
+		m.reconnecting[name] = true
+	}
+	m.mu.Unlock()
+
+	if err := cli.Close(); err != nil {
+		logger.Debugf("[MCP:%s] Failed to close dropped client: %v", name, err)
+	}
+	if reconnect == nil {
+		logger.Warnf("[MCP:%s] Connection lost: %v", name, cause)
+		return
+	}
+	logger.Warnf("[MCP:%s] Connection lost, reconnecting: %v", name, cause)
+	go m.reconnectLoop(name, *reconnect)
+}
+
+// reconnectLoop retries a dropped server with exponential backoff.
+// Servers that are disabled, removed or reconnected by hand meanwhile are no longer retried.
+func (m *Manager) reconnectLoop(name string, policy ReconnectPolicy) {
+	defer func() {
+		m.mu.Lock()
+		delete(m.reconnecting, name)
+		m.mu.Unlock()
+	}()
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		err := m.reconnectOnce(name)
+		if err == nil {
+			logger.Infof("[MCP:%s] Reconnected after %d attempts", name, attempt)
+			return
+		}
+		if errors.Is(err, errStopReconnecting) {
+			logger.Debugf("[MCP:%s] Stopped reconnecting: %v", name, err)
+			return
+		}
+
+		backoff = min(backoff*2, policy.MaxBackoff)
+		logger.Debugf("[MCP:%s] Reconnect attempt %d failed, retrying in %v", name, attempt, backoff)
+	}
+}
+
+// errStopReconnecting reports that a dropped server no longer needs reconnecting
+var errStopReconnecting = errors.New("server was removed, disabled or reconnected")
+
+// reconnectOnce makes one reconnect attempt for a dropped server, or returns errStopReconnecting
+func (m *Manager) reconnectOnce(name string) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.RLock()
+	cfg, err := m.config(name)
+	var snapshot ServerConfig
+	if err == nil {
+		snapshot = *cfg
+	}
+	connected := m.clients[name] != nil
+	m.mu.RUnlock()
+	if err != nil || !snapshot.Enabled || !snapshot.Configured() || connected {
+		return errStopReconnecting
+	}
+	return m.connect(context.Background(), snapshot)
+}
@@ -0,0 +1,92 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"maps"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/mark3labs/mcp-go/client"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// pollTools refreshes the tools of a server that does not notify list changes, until its client is replaced
+func (m *Manager) pollTools(name string, cli *client.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+		if !m.refreshTools(name, cli) {
+			return
+		}
+	}
+}
+
+// refreshTools reloads the tool list of a connected server and, if it changed, swaps the server's tools
+// and notifies OnToolsChanged. It returns false once cli is no longer the server's client.
+func (m *Manager) refreshTools(name string, cli *client.Client) bool {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.RLock()
+	cfg, err := m.config(name)
+	var snapshot ServerConfig
+	if err == nil {
+		snapshot = *cfg
+	}
+	current, pool := m.clients[name] == cli, m.pools[name]
+	m.mu.RUnlock()
+	if err != nil || !current {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cmp.Or(snapshot.InitializeTimeout, defaultInitializeTimeout))
+	defer cancel()
+	tools, err := m.listTools(ctx, snapshot, pool)
+	if err != nil {
+		logger.Warnf("[MCP:%s] Failed to refresh tools: %v", name, err)
+		return true
+	}
+
+	m.mu.Lock()
+	if m.clients[name] != cli {
+		m.mu.Unlock()
+		return false
+	}
+	if maps.Equal(toolSignatures(ctx, m.byServer[name]), toolSignatures(ctx, tools)) {
+		m.mu.Unlock()
+		return true
+	}
+	removed := m.unregisterTools(ctx, name)
+	m.registerTools(ctx, name, tools)
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	logger.Infof("[MCP:%s] Tool list changed, loaded %d tools", name, len(tools))
+	if onChange != nil {
+		onChange(ctx, removed, tools)
+	}
+	return true
+}
+
+// toolSignatures maps tool names to their descriptions and parameter schemas, to detect changes
+func toolSignatures(ctx context.Context, tools []tool.BaseTool) map[string]string {
+	signatures := make(map[string]string, len(tools))
+	for _, t := range tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			continue
+		}
+		params, _ := json.Marshal(info.ParamsOneOf)
+		signatures[info.Name] = info.Desc + "\x00" + string(params)
+	}
+	return signatures
+}
@@ -0,0 +1,164 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Error classes a RetryPolicy can retry
+const (
+	RetryOnTimeout     = "timeout"      // The call timeout passed or the server reported the request interrupted
+	RetryOnConnection  = "connection"   // The connection failed or dropped
+	RetryOnServerError = "server_error" // The server answered with an internal error, a 5xx status or 429
+	RetryOnToolError   = "tool_error"   // The tool ran and reported a failure; only for idempotent tools
+)
+
+// defaultRetryOn are the classes retried when a policy names none; they cover failures where the tool
+// most likely did not run, as retrying a tool that mutates state may repeat its effect
+var defaultRetryOn = []string{RetryOnTimeout, RetryOnConnection}
+
+// RetryPolicy retries failed tool calls
+type RetryPolicy struct {
+	MaxAttempts    int           `json:"max_attempts" yaml:"max_attempts"`                           // Total attempts including the first (<= 1 disables retries)
+	InitialBackoff time.Duration `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"` // Delay before the first retry (default 200ms)
+	MaxBackoff     time.Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`         // Upper bound for the delay (default 5s)
+	// RetryOn lists the error classes retried: timeout, connection, server_error, tool_error
+	// (default timeout and connection)
+	RetryOn []string `json:"retry_on,omitempty" yaml:"retry_on,omitempty"`
+}
+
+// withDefaults fills unset fields with their defaults
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 200 * time.Millisecond
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = max(5*time.Second, p.InitialBackoff)
+	}
+	if len(p.RetryOn) == 0 {
+		p.RetryOn = defaultRetryOn
+	}
+	return p
+}
+
+// retries reports whether a failed attempt of the given class is retried
+func (p RetryPolicy) retries(attempt int, class string) bool {
+	return attempt < p.MaxAttempts && class != "" && slices.Contains(p.RetryOn, class)
+}
+
+// backoff returns the delay after the given failed attempt, with up to 20% jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxBackoff)
+	return time.Duration(float64(delay) * (1 + 0.2*rand.Float64()))
+}
+
+// retryPolicy returns the retry policy of a tool: an exact override, then a glob override, then the
+// server's policy; nil if its calls are not retried
+func (c ServerConfig) retryPolicy(toolName string) *RetryPolicy {
+	policy, ok := c.ToolRetries[toolName]
+	if !ok {
+		for pattern, p := range c.ToolRetries {
+			if matched, _ := path.Match(pattern, toolName); matched {
+				policy, ok = p, true
+				break
+			}
+		}
+	}
+	if !ok {
+		if c.Retry == nil {
+			return nil
+		}
+		policy = *c.Retry
+	}
+	if policy.MaxAttempts <= 1 {
+		return nil
+	}
+	policy = policy.withDefaults()
+	return &policy
+}
+
+// validateRetries rejects malformed tool retry globs and unknown error classes
+func (c ServerConfig) validateRetries() error {
+	policies := make(map[string]RetryPolicy, len(c.ToolRetries)+1)
+	if c.Retry != nil {
+		policies["retry"] = *c.Retry
+	}
+	for pattern, policy := range c.ToolRetries {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tool retry pattern %q: %w", pattern, err)
+		}
+		policies["tool_retries."+pattern] = policy
+	}
+	for name, policy := range policies {
+		for _, class := range policy.RetryOn {
+			switch class {
+			case RetryOnTimeout, RetryOnConnection, RetryOnServerError, RetryOnToolError:
+			default:
+				return fmt.Errorf("%s: unknown retry_on class %q", name, class)
+			}
+		}
+	}
+	return nil
+}
+
+// statusPattern matches the HTTP status in MCP transport errors ("request failed with status 503")
+var statusPattern = regexp.MustCompile(`status(?: code)?:? (\d{3})`)
+
+// classifyCallError returns the retry class of a failed tool call, or "" if it is not retryable.
+// ctx is the context of the whole call: once it is done, nothing is retried.
+func classifyCallError(ctx context.Context, err error) string {
+	if err == nil || ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, mcp.ErrRequestInterrupted):
+		return RetryOnTimeout
+	case errors.Is(err, mcp.ErrInternalError):
+		return RetryOnServerError
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return RetryOnConnection
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return RetryOnTimeout
+		}
+		return RetryOnConnection
+	}
+
+	msg := err.Error()
+	if strings.Contains(msg, "mcp server return error") {
+		return RetryOnToolError
+	}
+	if m := statusPattern.FindStringSubmatch(msg); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		if code == 429 || code >= 500 {
+			return RetryOnServerError
+		}
+		return ""
+	}
+	msg = strings.ToLower(msg)
+	for _, marker := range []string{"connection refused", "connection reset", "broken pipe", "has been closed", "failed to send request"} {
+		if strings.Contains(msg, marker) {
+			return RetryOnConnection
+		}
+	}
+	return ""
+}
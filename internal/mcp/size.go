@@ -0,0 +1,111 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// SizeLimits caps the size of tool calls; zero values mean unlimited
+type SizeLimits struct {
+	MaxArgumentBytes int `json:"max_argument_bytes,omitempty" yaml:"max_argument_bytes,omitempty"` // Larger arguments are not sent; the model is told to send less
+	MaxResultBytes   int `json:"max_result_bytes,omitempty" yaml:"max_result_bytes,omitempty"`     // Larger results are cut to fit, binary content first
+}
+
+// sizeLimits returns the size limits of a tool: an exact override, then a glob override, then the server's limits
+func (c ServerConfig) sizeLimits(toolName string) SizeLimits {
+	if limits, ok := c.ToolSizeLimits[toolName]; ok {
+		return limits
+	}
+	for pattern, limits := range c.ToolSizeLimits {
+		if matched, _ := path.Match(pattern, toolName); matched {
+			return limits
+		}
+	}
+	if c.SizeLimits == nil {
+		return SizeLimits{}
+	}
+	return *c.SizeLimits
+}
+
+// argumentsTooLargeResult is the tool result the model sees when its arguments were over the limit
+func argumentsTooLargeResult(toolName string, size, limit int) string {
+	return fmt.Sprintf("Tool %s was not called: its arguments are %d bytes, over the limit of %d bytes. "+
+		"Send less data at once, e.g. split the input over several calls.", toolName, size, limit)
+}
+
+// resultNote is appended to the content of results cut to fit the limit
+const resultNote = "\n[... result truncated from %d to %d bytes; request a smaller part if more is needed]"
+
+// limitResult cuts a tool result over limit bytes. MCP results stay valid JSON: binary content (images,
+// audio, embedded resources) is replaced by a note, structured content dropped and text cut to fit.
+func limitResult(result string, limit int) string {
+	if limit <= 0 || len(result) <= limit {
+		return result
+	}
+	raw := json.RawMessage(result)
+	parsed, err := mcp.ParseCallToolResult(&raw)
+	note := fmt.Sprintf(resultNote, len(result), limit)
+	if err != nil {
+		return cutText(result, limit-len(note)) + note
+	}
+
+	// The budget covers the text of the kept content; the JSON around it takes the reserve
+	budget := limit - len(note) - 256
+	content := make([]mcp.Content, 0, len(parsed.Content)+1)
+	for _, c := range parsed.Content {
+		switch c := c.(type) {
+		case mcp.TextContent:
+			c.Text = cutText(c.Text, budget)
+			budget -= len(c.Text)
+			if c.Text != "" {
+				content = append(content, c)
+			}
+		case mcp.ImageContent:
+			content = append(content, mcp.NewTextContent(fmt.Sprintf("[%s image of %d bytes omitted]", c.MIMEType, len(c.Data))))
+		case mcp.AudioContent:
+			content = append(content, mcp.NewTextContent(fmt.Sprintf("[%s audio of %d bytes omitted]", c.MIMEType, len(c.Data))))
+		case mcp.EmbeddedResource:
+			content = append(content, mcp.NewTextContent(fmt.Sprintf("[embedded resource %s omitted]", resourceURI(c.Resource))))
+		default:
+			content = append(content, c)
+		}
+	}
+	content = append(content, mcp.NewTextContent(note))
+
+	limited, err := json.Marshal(&mcp.CallToolResult{Content: content, IsError: parsed.IsError})
+	if err != nil || len(limited) > limit {
+		return cutText(result, limit-len(note)) + note
+	}
+	return string(limited)
+}
+
+// cutText returns the longest prefix of s within limit bytes that does not split a UTF-8 character
+func cutText(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if len(s) <= limit {
+		return s
+	}
+	s = s[:limit]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// resourceURI returns the URI of an embedded resource
+func resourceURI(resource mcp.ResourceContents) string {
+	switch r := resource.(type) {
+	case mcp.TextResourceContents:
+		return r.URI
+	case mcp.BlobResourceContents:
+		return r.URI
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// Configured reports whether the server has a transport: a base URL or a command
+func (c ServerConfig) Configured() bool {
+	return c.BaseURL != "" || c.Command != ""
+}
+
+// validateTransport rejects settings that do not apply to the server's transport
+func (c ServerConfig) validateTransport() error {
+	if c.Command == "" {
+		if len(c.Args) > 0 || len(c.Env) > 0 || c.Cwd != "" {
+			return fmt.Errorf("args, env and cwd apply to command servers only")
+		}
+		return nil
+	}
+	if c.BaseURL != "" {
+		return fmt.Errorf("base_url and command cannot both be set")
+	}
+	if len(c.Headers) > 0 || c.BearerToken != "" || c.OAuth != nil || c.TLS != nil {
+		return fmt.Errorf("headers, bearer_token, oauth and tls apply to base_url servers only")
+	}
+	return nil
+}
+
+// stdioCommand is the subprocess of a command server, with environment variables expanded
+type stdioCommand struct {
+	command string
+	args    []string
+	env     []string // KEY=value pairs added to the agent's environment
+	dir     string
+}
+
+// command expands the command, arguments, environment and working directory of a server.
+// A reference to an unset variable is an error rather than an empty argument.
+func (c ServerConfig) command() (*stdioCommand, error) {
+	env := &envExpander{}
+	cmd := &stdioCommand{
+		command: env.expand(c.Command),
+		args:    make([]string, len(c.Args)),
+		dir:     env.expand(c.Cwd),
+	}
+	for i, arg := range c.Args {
+		cmd.args[i] = env.expand(arg)
+	}
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd.env = append(cmd.env, name+"="+env.expand(c.Env[name]))
+	}
+	if err := env.err("command, args, env and cwd"); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// newStdioClient creates a client for a command server; the process is spawned when the client starts
+func newStdioClient(cfg ServerConfig) (*client.Client, error) {
+	cmd, err := cfg.command()
+	if err != nil {
+		return nil, err
+	}
+	stdio := transport.NewStdioWithOptions(cmd.command, cmd.env, cmd.args,
+		transport.WithCommandFunc(func(_ context.Context, command string, env []string, args []string) (*exec.Cmd, error) {
+			// Not bound to the starting context: the process lives as long as the client
+			c := exec.Command(command, args...)
+			c.Env = append(os.Environ(), env...)
+			c.Dir = cmd.dir
+			return c, nil
+		}),
+		transport.WithCommandLogger(transportLogger{name: cfg.Name}))
+	return client.NewClient(withCancellation(cfg.Name, stdio)), nil
+}
+
+// transportLogger routes the stdio transport's own messages to the debug log;
+// they mostly report the pipes closing when the process exits
+type transportLogger struct {
+	name string
+}
+
+func (l transportLogger) Infof(format string, v ...any) {
+	logger.Debugf("[MCP:%s] %s", l.name, fmt.Sprintf(format, v...))
+}
+
+func (l transportLogger) Errorf(format string, v ...any) {
+	logger.Debugf("[MCP:%s] %s", l.name, fmt.Sprintf(format, v...))
+}
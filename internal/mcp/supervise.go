@@ -0,0 +1,201 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// stderrTailLines is how much of a crashed process's stderr output is logged
+const stderrTailLines = 20
+
+// RestartPolicy configures the restart of command servers whose process exited
+type RestartPolicy struct {
+	MaxRestarts    int           `json:"max_restarts,omitempty" yaml:"max_restarts,omitempty"`       // Consecutive restarts before giving up (default 5, negative = never restart)
+	InitialBackoff time.Duration `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"` // Delay before the first restart (default 1s)
+	MaxBackoff     time.Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`         // Upper bound for the delay (default 1m)
+	ResetAfter     time.Duration `json:"reset_after,omitempty" yaml:"reset_after,omitempty"`         // A process that ran this long starts the count over (default 10m)
+}
+
+// withDefaults fills unset fields with their defaults
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.MaxRestarts == 0 {
+		p.MaxRestarts = 5
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = time.Second
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = max(time.Minute, p.InitialBackoff)
+	}
+	if p.ResetAfter <= 0 {
+		p.ResetAfter = 10 * time.Minute
+	}
+	return p
+}
+
+// backoff returns the delay before the given restart
+func (p RestartPolicy) backoff(restart int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < restart && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// superviseProcess follows the stderr output of a command server's process, logging it at debug level,
+// and reports the exit of the process once its output ends
+func (m *Manager) superviseProcess(name string, cli *client.Client, policy RestartPolicy) {
+	stderr, ok := stderrOf(cli)
+	if !ok || stderr == nil {
+		return
+	}
+	started := time.Now()
+	go func() {
+		var tail []string
+		scanner := bufio.NewScanner(stderr)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			logger.Debugf("[MCP:%s] stderr: %s", name, line)
+			if len(tail) == stderrTailLines {
+				tail = tail[1:]
+			}
+			tail = append(tail, line)
+		}
+		// Keep draining so that the process never blocks on a full pipe
+		_, _ = io.Copy(io.Discard, stderr)
+		m.processExited(name, cli, time.Since(started), tail, policy)
+	}()
+}
+
+// processExited takes down a command server whose process ended and restarts it within the policy.
+// Nothing happens if the client was closed on purpose or already replaced.
+func (m *Manager) processExited(name string, cli *client.Client, uptime time.Duration, tail []string, policy RestartPolicy) {
+	m.mu.Lock()
+	select {
+	case <-m.stop:
+		m.mu.Unlock()
+		return
+	default:
+	}
+	if m.clients[name] != cli || m.reconnecting[name] {
+		m.mu.Unlock()
+		return
+	}
+	// The tools stay registered so the agents keep their tool set; calls are refused until the server is back
+	m.dropClient(name)
+	delete(m.health, name)
+	if uptime >= policy.ResetAfter {
+		delete(m.restarts, name)
+	}
+	restart := policy.MaxRestarts > 0 && m.restarts[name] < policy.MaxRestarts
+	if restart {
+		m.reconnecting[name] = true
+	}
+	m.mu.Unlock()
+
+	// Closing reaps the process and returns its exit status
+	cause := "process exited"
+	if err := cli.Close(); err != nil {
+		cause += ": " + err.Error()
+	}
+	m.mu.Lock()
+	m.lastErrors[name] = cause
+	m.mu.Unlock()
+
+	output := "no stderr output"
+	if len(tail) > 0 {
+		output = "last stderr output:\n" + strings.Join(tail, "\n")
+	}
+	logger.Errorf("[MCP:%s] Server %s after %v, %s", name, cause, uptime.Round(time.Millisecond), output)
+	if !restart {
+		logger.Errorf("[MCP:%s] Not restarting after %d restarts; reconnect the server to start it again", name, m.restartCount(name))
+		return
+	}
+	go m.restartLoop(name, policy)
+}
+
+// restartLoop restarts an exited command server with exponential backoff until it runs or the policy is exhausted
+func (m *Manager) restartLoop(name string, policy RestartPolicy) {
+	defer func() {
+		m.mu.Lock()
+		delete(m.reconnecting, name)
+		m.mu.Unlock()
+	}()
+
+	for {
+		m.mu.Lock()
+		m.restarts[name]++
+		restart := m.restarts[name]
+		m.mu.Unlock()
+
+		delay := policy.backoff(restart)
+		logger.Infof("[MCP:%s] Restarting in %v (restart %d/%d)", name, delay, restart, policy.MaxRestarts)
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		err := m.reconnectOnce(name)
+		switch {
+		case err == nil:
+			logger.Infof("[MCP:%s] Restarted", name)
+			return
+		case errors.Is(err, errStopReconnecting):
+			logger.Debugf("[MCP:%s] Stopped restarting: %v", name, err)
+			return
+		case restart >= policy.MaxRestarts:
+			logger.Errorf("[MCP:%s] Giving up after %d restarts: %v", name, restart, err)
+			return
+		}
+	}
+}
+
+// restartCount returns the consecutive restarts of a server
+func (m *Manager) restartCount(name string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.restarts[name]
+}
+
+// dropClient forgets the client of a server and aborts the tool calls in flight on it; callers hold mu
+func (m *Manager) dropClient(name string) {
+	delete(m.clients, name)
+	if pool := m.pools[name]; pool != nil {
+		pool.closeSessions()
+		delete(m.pools, name)
+	}
+	if dropped := m.dropped[name]; dropped != nil {
+		close(dropped)
+		delete(m.dropped, name)
+	}
+}
+
+// droppedSignal returns a channel closed when the current client of a server is dropped, or nil if not connected
+func (m *Manager) droppedSignal(name string) <-chan struct{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.dropped[name]
+}
+
+// unavailable explains why the tools of a server cannot be called, or returns "" if they can
+func (m *Manager) unavailable(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.clients[name] != nil {
+		return ""
+	}
+	if m.reconnecting[name] {
+		return "MCP server " + name + " is down and being restarted or reconnected"
+	}
+	return "MCP server " + name + " is down"
+}
@@ -0,0 +1,81 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// TLSConfig configures the TLS connection to a base_url server. Paths may reference environment variables
+// as $VAR or ${VAR}; the files are read on each connect, so renewed certificates are picked up on reconnect.
+type TLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`                           // PEM CA bundle trusted in addition to the system roots, e.g. a private CA
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`                       // PEM client certificate, for servers requiring mTLS
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty"`                         // PEM private key of the client certificate
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"`                   // Name verified against the server certificate (default: the URL's host)
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"` // Accept any server certificate; for development only
+}
+
+// load builds the client TLS settings of a server
+func (c *TLSConfig) load(name string) (*tls.Config, error) {
+	env := &envExpander{}
+	caFile, certFile, keyFile := env.expand(c.CAFile), env.expand(c.CertFile), env.expand(c.KeyFile)
+	if err := env.err("tls files"); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.InsecureSkipVerify {
+		logger.Warnf("[MCP:%s] TLS certificate verification is disabled", name)
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("tls cert_file and key_file must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// httpTransport returns the HTTP transport of a server's connections, or nil for the default one
+func (c ServerConfig) httpTransport() (http.RoundTripper, error) {
+	if c.TLS == nil {
+		return nil, nil
+	}
+	tlsConfig, err := c.TLS.load(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -0,0 +1,173 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	mcptool "github.com/cloudwego/eino-ext/components/tool/mcp"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+	"github.com/fourhu/eino-ai-agent/internal/progress"
+)
+
+// serverTool is an MCP tool bound to the call settings of its server
+type serverTool struct {
+	tool.InvokableTool
+	manager     *Manager
+	name        string
+	server      string
+	callTimeout time.Duration // Per attempt; 0 = no limit
+	retry       *RetryPolicy  // nil = no retries
+	sizeLimits  SizeLimits
+	params      map[string]any // Parameter schema the arguments are checked against; nil skips the check
+	mutating    bool           // Annotated as changing its environment
+}
+
+// wrapTools binds the tools of a server to its call settings and to the annotations of their definitions
+func (m *Manager) wrapTools(ctx context.Context, cfg ServerConfig, tools []tool.BaseTool, definitions map[string]mcp.Tool) []tool.BaseTool {
+	wrapped := make([]tool.BaseTool, 0, len(tools))
+	for _, t := range tools {
+		invokable, ok := t.(tool.InvokableTool)
+		info, err := t.Info(ctx)
+		if !ok || err != nil {
+			wrapped = append(wrapped, t)
+			continue
+		}
+		wrapped = append(wrapped, &serverTool{
+			InvokableTool: invokable,
+			manager:       m,
+			name:          info.Name,
+			server:        cfg.Name,
+			callTimeout:   cfg.CallTimeout,
+			retry:         cfg.retryPolicy(info.Name),
+			sizeLimits:    cfg.sizeLimits(info.Name),
+			params:        paramsSchema(info),
+			mutating:      mutating(definitions[info.Name].Annotations),
+		})
+	}
+	return wrapped
+}
+
+// mutating reports whether a tool's annotations declare that it changes its environment: it is not
+// read-only, or it is destructive. Tools without annotations are not considered mutating.
+func mutating(annotations mcp.ToolAnnotation) bool {
+	if annotations.ReadOnlyHint != nil {
+		return !*annotations.ReadOnlyHint
+	}
+	return annotations.DestructiveHint != nil && *annotations.DestructiveHint
+}
+
+// Mutating reports whether the tool is blocked in read-only sessions
+func (t *serverTool) Mutating() bool {
+	return t.mutating
+}
+
+// errServerDropped cancels the calls in flight on a client that was dropped
+var errServerDropped = errors.New("MCP server went down")
+
+// InvokableRun calls the tool, retrying failures its retry policy covers, and records the call's metrics.
+// Arguments that do not match the tool's schema or are over the size limit are not sent, nor are calls
+// while the server is down; the model is told why instead. Results over the size limit are cut.
+func (t *serverTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (result string, err error) {
+	if reason := t.manager.unavailable(t.server); reason != "" {
+		return fmt.Sprintf("Tool %s is temporarily unavailable: %s. Try again later or use another approach.", t.name, reason), nil
+	}
+	if limit := t.sizeLimits.MaxArgumentBytes; limit > 0 && len(argumentsInJSON) > limit {
+		logger.Ctx(ctx).Warnf("[MCP:%s] Rejected call to %s with %d bytes of arguments (limit %d)", t.server, t.name, len(argumentsInJSON), limit)
+		return argumentsTooLargeResult(t.name, len(argumentsInJSON), limit), nil
+	}
+	if t.params != nil {
+		if problems := validateArguments(t.params, argumentsInJSON); len(problems) > 0 {
+			logger.Debugf("[MCP:%s] Rejected call to %s with invalid arguments: %d problems", t.server, t.name, len(problems))
+			return invalidArgumentsResult(t.name, problems), nil
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		observeCall(t.server, t.name, time.Since(start), len(argumentsInJSON), len(result), err)
+	}()
+
+	// A response never comes once the connection or process is gone, so the call is abandoned then
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if dropped := t.manager.droppedSignal(t.server); dropped != nil {
+		go func() {
+			select {
+			case <-dropped:
+				cancel(errServerDropped)
+			case <-ctx.Done():
+			}
+		}()
+	}
+	defer func() {
+		if err != nil && errors.Is(context.Cause(ctx), errServerDropped) {
+			result, err = "", fmt.Errorf("MCP server %s went down during the call: %w", t.server, err)
+		}
+	}()
+
+	// Servers only send progress notifications for calls carrying a progress token
+	if progress.Enabled(ctx) {
+		token, done := trackProgress(ctx, t.name)
+		defer done()
+		opts = append(opts, mcptool.WithMeta(&mcp.Meta{ProgressToken: token}))
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err = t.call(ctx, argumentsInJSON, opts...)
+		if err == nil {
+			if limit := t.sizeLimits.MaxResultBytes; limit > 0 && len(result) > limit {
+				logger.Ctx(ctx).Warnf("[MCP:%s] Truncating %s result from %d to %d bytes", t.server, t.name, len(result), limit)
+				result = limitResult(result, limit)
+			}
+			return result, nil
+		}
+		if t.retry == nil {
+			return result, err
+		}
+		class := classifyCallError(ctx, err)
+		if !t.retry.retries(attempt, class) {
+			if attempt > 1 {
+				err = fmt.Errorf("failed after %d attempts: %w", attempt, err)
+			}
+			return result, err
+		}
+
+		delay := t.retry.backoff(attempt)
+		toolRetries.Inc(t.server, t.name)
+		logger.Ctx(ctx).Warnf("[MCP:%s] Tool %s failed (%s), retrying in %v (attempt %d/%d): %v",
+			t.server, t.name, class, delay.Round(time.Millisecond), attempt, t.retry.MaxAttempts, err)
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// call makes one attempt, giving up once the server's call timeout passes. The wait for a free
+// call slot of the server does not count against the timeout.
+func (t *serverTool) call(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	release, err := t.manager.acquireCall(ctx, t.server)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	if t.callTimeout <= 0 {
+		return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, t.callTimeout)
+	defer cancel()
+	result, err := t.InvokableTool.InvokableRun(attemptCtx, argumentsInJSON, opts...)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("MCP server %s did not answer within %s: %w", t.server, t.callTimeout, context.DeadlineExceeded)
+	}
+	return result, err
+}
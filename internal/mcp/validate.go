@@ -0,0 +1,195 @@
+// Package mcp provides MCP (Model Context Protocol) client management functionality.
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// argumentProblem is one mismatch between the arguments of a call and the tool's parameter schema
+type argumentProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// invalidArguments is returned to the model instead of calling the tool, so it can fix the call
+type invalidArguments struct {
+	Error    string            `json:"error"`
+	Tool     string            `json:"tool"`
+	Problems []argumentProblem `json:"problems"`
+	Hint     string            `json:"hint"`
+}
+
+// paramsSchema returns the parameter schema of a tool as decoded JSON, or nil if it has none
+func paramsSchema(info *schema.ToolInfo) map[string]any {
+	if info.ParamsOneOf == nil {
+		return nil
+	}
+	js, err := info.ParamsOneOf.ToJSONSchema()
+	if err != nil || js == nil {
+		return nil
+	}
+	raw, err := json.Marshal(js)
+	if err != nil {
+		return nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+// validateArguments checks the JSON arguments of a call against a parameter schema and returns every problem found
+func validateArguments(params map[string]any, argumentsInJSON string) []argumentProblem {
+	if strings.TrimSpace(argumentsInJSON) == "" {
+		argumentsInJSON = "{}"
+	}
+	var args any
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return []argumentProblem{{Path: "$", Message: fmt.Sprintf("arguments are not valid JSON: %v", err)}}
+	}
+	var problems []argumentProblem
+	checkValue(params, args, "$", &problems)
+	return problems
+}
+
+// invalidArgumentsResult renders the problems of a call as the tool result
+func invalidArgumentsResult(tool string, problems []argumentProblem) string {
+	result, _ := json.Marshal(invalidArguments{
+		Error:    "invalid_arguments",
+		Tool:     tool,
+		Problems: problems,
+		Hint:     "The tool was not called. Fix the arguments to match the tool's parameter schema and call it again.",
+	})
+	return string(result)
+}
+
+// checkValue validates a decoded JSON value against the commonly used subset of JSON schema:
+// type, enum, const, properties, required, additionalProperties, items, minItems/maxItems
+func checkValue(schema map[string]any, value any, path string, problems *[]argumentProblem) {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, argumentProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		report("expected type %v, got %s", t, jsonTypeOf(value))
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok && !containsJSON(enum, value) {
+		report("value must be one of %s", encodeJSON(enum))
+	}
+	if c, ok := schema["const"]; ok && !equalJSON(c, value) {
+		report("value must be %s", encodeJSON(c))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, exists := v[name]; !exists {
+					report("missing required property %q", name)
+				}
+			}
+		}
+		// Sorted so the same call always reports the same problems in the same order
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if propSchema, ok := props[name].(map[string]any); ok {
+				checkValue(propSchema, v[name], path+"."+name, problems)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					report("unexpected property %q", name)
+				}
+			case map[string]any:
+				checkValue(extra, v[name], path+"."+name, problems)
+			}
+		}
+	case []any:
+		if n, ok := schema["minItems"].(float64); ok && float64(len(v)) < n {
+			report("expected at least %v items", n)
+		}
+		if n, ok := schema["maxItems"].(float64); ok && float64(len(v)) > n {
+			report("expected at most %v items", n)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				checkValue(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	}
+}
+
+// matchesType reports whether value matches a schema "type" (string or list of strings)
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		actual := jsonTypeOf(value)
+		return actual == t || (t == "number" && actual == "integer")
+	case []any:
+		for _, candidate := range t {
+			if matchesType(candidate, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// jsonTypeOf returns the JSON schema type name of a decoded value
+func jsonTypeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// containsJSON reports whether value equals one of the candidates
+func containsJSON(candidates []any, value any) bool {
+	for _, c := range candidates {
+		if equalJSON(c, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalJSON compares two decoded JSON values by their encoding
+func equalJSON(a, b any) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ab, bb)
+}
+
+func encodeJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
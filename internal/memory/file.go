@@ -0,0 +1,418 @@
+// Package memory provides conversation history storage implementations.
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// FileStore persists conversation history in a directory, for deployments without Redis. Each session is kept
+// as "<id>.jsonl", one message per line, with its metadata in "<id>.meta.json"; usage is kept per hour under
+// "usage" and quota spend per day under "quota". Files are replaced atomically, so a crash never leaves a session half written.
+type FileStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	for _, sub := range []string{"usage", "quota"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create memory directory: %w", err)
+		}
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Ping verifies the directory is still accessible
+func (s *FileStore) Ping(ctx context.Context) error {
+	_, err := os.Stat(s.dir)
+	return err
+}
+
+// path returns the location of a session's file with the given suffix; IDs are escaped, so tenant-scoped
+// IDs containing "/" stay inside the directory
+func (s *FileStore) path(sessionID, suffix string) (string, error) {
+	if sessionID == "" {
+		return "", errors.New("empty session ID")
+	}
+	return filepath.Join(s.dir, url.QueryEscape(sessionID)+suffix), nil
+}
+
+// Write stores messages as JSON lines, replacing the session's file
+func (s *FileStore) Write(ctx context.Context, sessionID string, msgs []*schema.Message) error {
+	path, err := s.path(sessionID, ".jsonl")
+	if err != nil {
+		return err
+	}
+	logger.Debugf("[Memory:File] Writing session %s (%d messages)", sessionID, len(msgs))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, msg := range msgs {
+			if err := enc.Encode(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("[Memory:File] Failed to write session %s: %v", sessionID, err)
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// Read returns the messages of a session; returns nil if not found
+func (s *FileStore) Read(ctx context.Context, sessionID string) ([]*schema.Message, error) {
+	path, err := s.path(sessionID, ".jsonl")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Debugf("[Memory:File] Session %s not found", sessionID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", sessionID, err)
+	}
+	defer f.Close()
+
+	var msgs []*schema.Message
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var msg schema.Message
+		if err := dec.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			logger.Errorf("[Memory:File] Failed to decode messages for session %s: %v", sessionID, err)
+			return nil, fmt.Errorf("failed to decode session %s: %w", sessionID, err)
+		}
+		msgs = append(msgs, &msg)
+	}
+	return msgs, nil
+}
+
+// Delete removes the metadata first, so a partially deleted session is no longer listed
+func (s *FileStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, suffix := range []string{".meta.json", ".jsonl"} {
+		path, err := s.path(sessionID, suffix)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Errorf("[Memory:File] Failed to delete session %s: %v", sessionID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the IDs of the sessions with a messages or metadata file
+func (s *FileStore) List(ctx context.Context, prefix, cursor string, limit int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	ids := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		escaped, ok := strings.CutSuffix(name, ".meta.json")
+		if !ok {
+			escaped, ok = strings.CutSuffix(name, ".jsonl")
+		}
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if id, err := url.QueryUnescape(escaped); err == nil && id != "" {
+			ids[id] = true
+		}
+	}
+	return pageSessions(ids, prefix, cursor, limit), nil
+}
+
+// WriteMeta stores session metadata as JSON
+func (s *FileStore) WriteMeta(ctx context.Context, sessionID string, meta *SessionMeta) error {
+	path, err := s.path(sessionID, ".meta.json")
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	}); err != nil {
+		logger.Errorf("[Memory:File] Failed to write metadata for session %s: %v", sessionID, err)
+		return fmt.Errorf("failed to write metadata for session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// ReadMeta returns session metadata; returns nil if not found
+func (s *FileStore) ReadMeta(ctx context.Context, sessionID string) (*SessionMeta, error) {
+	path, err := s.path(sessionID, ".meta.json")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return readMetaFile(path)
+}
+
+// ListMeta reads the metadata files of sessions starting with prefix; escaped IDs hold no glob metacharacters
+func (s *FileStore) ListMeta(ctx context.Context, prefix string) ([]*SessionMeta, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches, err := filepath.Glob(filepath.Join(s.dir, url.QueryEscape(prefix)+"*.meta.json"))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SessionMeta, 0, len(matches))
+	for _, match := range matches {
+		meta, err := readMetaFile(match)
+		if err != nil {
+			return nil, err
+		}
+		if meta != nil {
+			result = append(result, meta)
+		}
+	}
+	return result, nil
+}
+
+// readMetaFile reads a metadata file; returns nil if not found
+func readMetaFile(path string) (*SessionMeta, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta SessionMeta
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata %s: %w", path, err)
+	}
+	return &meta, nil
+}
+
+// usagePath returns the file holding the usage records of an hour
+func (s *FileStore) usagePath(hour time.Time) string {
+	return filepath.Join(s.dir, "usage", strconv.FormatInt(hour.Unix(), 10)+".json")
+}
+
+// AddUsage adds the counters of rec to the record of its key in its hour's file
+func (s *FileStore) AddUsage(ctx context.Context, rec *UsageRecord) error {
+	hour := usageHour(rec.Hour)
+	path := s.usagePath(hour)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := readUsageFile(path)
+	if err != nil {
+		return err
+	}
+	stored, exists := records[rec.Key]
+	if !exists {
+		stored = &UsageRecord{Key: rec.Key, Hour: hour}
+		records[rec.Key] = stored
+	}
+	stored.Add(rec)
+
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(records)
+	}); err != nil {
+		logger.Errorf("[Memory:File] Failed to record usage of %s: %v", rec.Key, err)
+		return err
+	}
+	return nil
+}
+
+// ListUsage reads the hourly files in [from, to) and returns the records of a key, or of all keys if key is empty
+func (s *FileStore) ListUsage(ctx context.Context, key string, from, to time.Time) ([]*UsageRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches, err := filepath.Glob(filepath.Join(s.dir, "usage", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var result []*UsageRecord
+	for _, match := range matches {
+		unix, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(match), ".json"), 10, 64)
+		if err != nil {
+			continue // Not ours
+		}
+		if hour := time.Unix(unix, 0).UTC(); hour.Before(from) || !hour.Before(to) {
+			continue
+		}
+		records, err := readUsageFile(match)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			if inUsageRange(rec, key, from, to) {
+				result = append(result, rec)
+			}
+		}
+	}
+	return result, nil
+}
+
+// readUsageFile reads the usage records of an hour by key; returns an empty map if not found
+func readUsageFile(path string) (map[string]*UsageRecord, error) {
+	records := make(map[string]*UsageRecord)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode usage %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// quotaPath returns the file holding the spend counters of the day of t
+func (s *FileStore) quotaPath(t time.Time) string {
+	return filepath.Join(s.dir, "quota", quotaDay(t)+".json")
+}
+
+// AddSpend adds spend to the counter of key in its day's file; starting a day removes the files of past days
+func (s *FileStore) AddSpend(ctx context.Context, key string, t time.Time, spend Spend) error {
+	path := s.quotaPath(t)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counters, err := readQuotaFile(path)
+	if err != nil {
+		return err
+	}
+	if len(counters) == 0 {
+		s.pruneQuotaLocked(t)
+	}
+	stored := counters[key]
+	stored.Tokens += spend.Tokens
+	stored.Cost += spend.Cost
+	counters[key] = stored
+
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(counters)
+	}); err != nil {
+		logger.Errorf("[Memory:File] Failed to record spend of %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+// ReadSpend returns the counter of key for the day of t
+func (s *FileStore) ReadSpend(ctx context.Context, key string, t time.Time) (Spend, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counters, err := readQuotaFile(s.quotaPath(t))
+	if err != nil {
+		return Spend{}, err
+	}
+	return counters[key], nil
+}
+
+// pruneQuotaLocked removes the spend files of days past the retention; s.mu must be held
+func (s *FileStore) pruneQuotaLocked(t time.Time) {
+	oldest := quotaDay(t.Add(-quotaRetention))
+	matches, _ := filepath.Glob(filepath.Join(s.dir, "quota", "*.json"))
+	for _, match := range matches {
+		if strings.TrimSuffix(filepath.Base(match), ".json") < oldest {
+			if err := os.Remove(match); err != nil {
+				logger.Warnf("[Memory:File] Failed to remove expired spend %s: %v", match, err)
+			}
+		}
+	}
+}
+
+// readQuotaFile reads the spend counters of a day by key; returns an empty map if not found
+func readQuotaFile(path string) (map[string]Spend, error) {
+	counters := make(map[string]Spend)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return counters, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &counters); err != nil {
+		return nil, fmt.Errorf("failed to decode spend %s: %w", path, err)
+	}
+	return counters, nil
+}
+
+// writeFileAtomic writes through a synced temporary file renamed into place
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".write-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -3,21 +3,30 @@ package memory
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
 )
 
 // InMemoryStore stores conversation history in memory
 type InMemoryStore struct {
-	data map[string][]*schema.Message
-	mu   sync.RWMutex
+	data  map[string][]*schema.Message
+	meta  map[string]*SessionMeta
+	usage map[string]*UsageRecord     // By key and hour
+	quota map[string]map[string]Spend // By day and key
+	mu    sync.RWMutex
 }
 
 // NewInMemoryStore creates a new in-memory store
 func NewInMemoryStore() *InMemoryStore {
 	return &InMemoryStore{
-		data: make(map[string][]*schema.Message),
+		data:  make(map[string][]*schema.Message),
+		meta:  make(map[string]*SessionMeta),
+		usage: make(map[string]*UsageRecord),
+		quota: make(map[string]map[string]Spend),
 	}
 }
 
@@ -48,3 +57,127 @@ func (s *InMemoryStore) Read(ctx context.Context, sessionID string) ([]*schema.M
 	copy(msgsCopy, msgs)
 	return msgsCopy, nil
 }
+
+// Delete removes messages and metadata of a session
+func (s *InMemoryStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, sessionID)
+	delete(s.meta, sessionID)
+	return nil
+}
+
+// List returns the IDs of the sessions with messages or metadata
+func (s *InMemoryStore) List(ctx context.Context, prefix, cursor string, limit int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make(map[string]bool, len(s.data))
+	for id := range s.data {
+		ids[id] = true
+	}
+	for id := range s.meta {
+		ids[id] = true
+	}
+	return pageSessions(ids, prefix, cursor, limit), nil
+}
+
+// WriteMeta stores metadata for a session
+func (s *InMemoryStore) WriteMeta(ctx context.Context, sessionID string, meta *SessionMeta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.meta[sessionID] = copyMeta(meta)
+	return nil
+}
+
+// ReadMeta retrieves metadata for a session
+func (s *InMemoryStore) ReadMeta(ctx context.Context, sessionID string) (*SessionMeta, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	meta, exists := s.meta[sessionID]
+	if !exists {
+		return nil, nil
+	}
+	return copyMeta(meta), nil
+}
+
+// ListMeta returns metadata of the stored sessions starting with prefix
+func (s *InMemoryStore) ListMeta(ctx context.Context, prefix string) ([]*SessionMeta, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*SessionMeta
+	for id, meta := range s.meta {
+		if !strings.HasPrefix(id, prefix) {
+			continue
+		}
+		result = append(result, copyMeta(meta))
+	}
+	return result, nil
+}
+
+// AddUsage adds the counters of rec to the record of its key and hour
+func (s *InMemoryStore) AddUsage(ctx context.Context, rec *UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hour := usageHour(rec.Hour)
+	id := rec.Key + "\x00" + strconv.FormatInt(hour.Unix(), 10)
+	stored, exists := s.usage[id]
+	if !exists {
+		stored = &UsageRecord{Key: rec.Key, Hour: hour}
+		s.usage[id] = stored
+	}
+	stored.Add(rec)
+	return nil
+}
+
+// ListUsage returns the records of the hours starting in [from, to) of a key, or of all keys if key is empty
+func (s *InMemoryStore) ListUsage(ctx context.Context, key string, from, to time.Time) ([]*UsageRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*UsageRecord
+	for _, rec := range s.usage {
+		if inUsageRange(rec, key, from, to) {
+			c := *rec
+			result = append(result, &c)
+		}
+	}
+	return result, nil
+}
+
+// AddSpend adds spend to the counter of key for the day of t, dropping the counters of past days
+func (s *InMemoryStore) AddSpend(ctx context.Context, key string, t time.Time, spend Spend) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := quotaDay(t)
+	counters, exists := s.quota[day]
+	if !exists {
+		oldest := quotaDay(t.Add(-quotaRetention))
+		for d := range s.quota {
+			if d < oldest {
+				delete(s.quota, d)
+			}
+		}
+		counters = make(map[string]Spend)
+		s.quota[day] = counters
+	}
+	stored := counters[key]
+	stored.Tokens += spend.Tokens
+	stored.Cost += spend.Cost
+	counters[key] = stored
+	return nil
+}
+
+// ReadSpend returns the counter of key for the day of t
+func (s *InMemoryStore) ReadSpend(ctx context.Context, key string, t time.Time) (Spend, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.quota[quotaDay(t)][key], nil
+}
@@ -0,0 +1,36 @@
+// Package memory provides conversation history storage implementations.
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// SessionMeta describes a conversation session
+type SessionMeta struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	Tags         []string  `json:"tags,omitempty"`
+	TotalTokens  int       `json:"total_tokens,omitempty"` // Tokens used by all turns, counted against the session budget
+	ToolCost     float64   `json:"tool_cost,omitempty"`    // Tool cost spent by all turns, counted against the session tool budget
+	ReadOnly     bool      `json:"read_only,omitempty"`    // Mutating tools are blocked in the session
+}
+
+// MetaStore is implemented by stores that persist session metadata alongside messages
+type MetaStore interface {
+	// WriteMeta stores metadata for a session
+	WriteMeta(ctx context.Context, sessionID string, meta *SessionMeta) error
+	// ReadMeta retrieves metadata for a session; returns nil if not found
+	ReadMeta(ctx context.Context, sessionID string) (*SessionMeta, error)
+	// ListMeta returns metadata of the stored sessions whose IDs start with prefix ("" = all)
+	ListMeta(ctx context.Context, prefix string) ([]*SessionMeta, error)
+}
+
+// copyMeta returns a deep copy of meta
+func copyMeta(meta *SessionMeta) *SessionMeta {
+	c := *meta
+	c.Tags = append([]string(nil), meta.Tags...)
+	return &c
+}
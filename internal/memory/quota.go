@@ -0,0 +1,29 @@
+// Package memory provides conversation history storage implementations.
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// Spend is what a quota key spent within one UTC day
+type Spend struct {
+	Tokens int64   `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// QuotaStore is implemented by stores that keep the daily spend counters of quotas, apart from usage accounting
+type QuotaStore interface {
+	// AddSpend adds spend to the counter of key for the UTC day of t
+	AddSpend(ctx context.Context, key string, t time.Time, spend Spend) error
+	// ReadSpend returns the counter of key for the UTC day of t
+	ReadSpend(ctx context.Context, key string, t time.Time) (Spend, error)
+}
+
+// quotaRetention is how long daily spend counters are kept; only today's are read
+const quotaRetention = 48 * time.Hour
+
+// quotaDay returns the UTC day of t as "2006-01-02"
+func quotaDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
@@ -3,7 +3,11 @@ package memory
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/cloudwego/eino/schema"
@@ -12,30 +16,60 @@ import (
 	"github.com/fourhu/eino-ai-agent/internal/logger"
 )
 
-// RedisStore persists conversation history in Redis
+// RedisStore persists conversation history in Redis. Messages, metadata and usage are kept in separate
+// namespaces under the prefix ("sessions:", "meta:", "usage:" and "quota:"), so no session ID collides with other records.
 type RedisStore struct {
-	cli    *redis.Client
-	prefix string
+	cli        *redis.Client
+	prefix     string
+	ttl        time.Duration            // Expiry of inactive sessions; 0 = never
+	tenantTTLs map[string]time.Duration // Expiry by tenant, overriding ttl
+	codec      Codec
+}
+
+// RedisOption configures a RedisStore
+type RedisOption func(*RedisStore)
+
+// WithTTL expires sessions not read or written for ttl, so abandoned sessions do not accumulate
+func WithTTL(ttl time.Duration) RedisOption {
+	return func(s *RedisStore) {
+		s.ttl = ttl
+	}
+}
+
+// WithTenantTTLs expires the sessions of the given tenants after their own TTL instead, 0 keeping them forever.
+// The tenant of a session is the part of its ID before the first "/".
+func WithTenantTTLs(ttls map[string]time.Duration) RedisOption {
+	return func(s *RedisStore) {
+		s.tenantTTLs = ttls
+	}
+}
+
+// WithCodec encodes messages with codec instead of gob
+func WithCodec(codec Codec) RedisOption {
+	return func(s *RedisStore) {
+		s.codec = codec
+	}
 }
 
 // NewRedisStore creates a new Redis-backed store with an existing client
-func NewRedisStore(cli *redis.Client, prefix string) *RedisStore {
+func NewRedisStore(cli *redis.Client, prefix string, opts ...RedisOption) *RedisStore {
 	if prefix == "" {
 		prefix = "eino:session:"
 	}
-	return &RedisStore{
+	s := &RedisStore{
 		cli:    cli,
 		prefix: prefix,
+		codec:  GobCodec{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // NewRedisStoreFromAddress creates a new Redis-backed store from address
 // This function tests the connection before returning
-func NewRedisStoreFromAddress(ctx context.Context, address, prefix string) (*RedisStore, error) {
-	if prefix == "" {
-		prefix = "eino:session:"
-	}
-
+func NewRedisStoreFromAddress(ctx context.Context, address, prefix string, opts ...RedisOption) (*RedisStore, error) {
 	logger.Debugf("[Memory:Redis] Connecting to Redis at %s", address)
 
 	cli := redis.NewClient(&redis.Options{
@@ -51,10 +85,7 @@ func NewRedisStoreFromAddress(ctx context.Context, address, prefix string) (*Red
 
 	logger.Debugf("[Memory:Redis] Successfully connected to Redis at %s", address)
 
-	return &RedisStore{
-		cli:    cli,
-		prefix: prefix,
-	}, nil
+	return NewRedisStore(cli, prefix, opts...), nil
 }
 
 // Close closes the Redis client connection
@@ -65,18 +96,75 @@ func (s *RedisStore) Close() error {
 	return nil
 }
 
-// Write encodes and stores messages using Redis SET
+// Ping verifies Redis answers
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.cli.Ping(ctx).Err()
+}
+
+// sessionTTL returns the expiry of a session: its tenant's TTL if configured, else the store's
+func (s *RedisStore) sessionTTL(sessionID string) time.Duration {
+	if tenant, _, ok := strings.Cut(sessionID, "/"); ok {
+		if ttl, ok := s.tenantTTLs[tenant]; ok {
+			return ttl
+		}
+	}
+	return s.ttl
+}
+
+// touch restarts the expiry of a session's messages and metadata
+func (s *RedisStore) touch(ctx context.Context, sessionID string) error {
+	ttl := s.sessionTTL(sessionID)
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Expire(ctx, s.sessionKey(sessionID), ttl)
+		pipe.Expire(ctx, s.metaKey(sessionID), ttl)
+		return nil
+	})
+	return err
+}
+
+// sessionKey returns the Redis key holding a session's messages
+func (s *RedisStore) sessionKey(sessionID string) string {
+	return s.prefix + "sessions:" + sessionID
+}
+
+// legacyKey returns the key messages were kept under before they got their own namespace, or "" if that key
+// may hold another record
+func (s *RedisStore) legacyKey(sessionID string) string {
+	for _, ns := range []string{"sessions:", "meta:", "usage:", "quota:"} {
+		if strings.HasPrefix(sessionID, ns) {
+			return ""
+		}
+	}
+	return s.prefix + sessionID
+}
+
+// Write encodes and stores messages using Redis SET, restarting the session's expiry.
+// A session still under its legacy key is moved.
 func (s *RedisStore) Write(ctx context.Context, sessionID string, msgs []*schema.Message) error {
-	key := s.prefix + sessionID
+	key := s.sessionKey(sessionID)
 	logger.Debugf("[Memory:Redis] Writing session %s (%d messages)", sessionID, len(msgs))
 
-	b, err := EncodeMessages(msgs)
+	b, err := s.codec.Encode(msgs)
 	if err != nil {
 		logger.Errorf("[Memory:Redis] Failed to encode messages for session %s: %v", sessionID, err)
 		return err
 	}
 
-	if err := s.cli.Set(ctx, key, b, 0).Err(); err != nil {
+	ttl := s.sessionTTL(sessionID)
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, b, max(ttl, 0))
+		if ttl > 0 {
+			pipe.Expire(ctx, s.metaKey(sessionID), ttl)
+		}
+		if legacy := s.legacyKey(sessionID); legacy != "" {
+			pipe.Del(ctx, legacy)
+		}
+		return nil
+	})
+	if err != nil {
 		logger.Errorf("[Memory:Redis] Failed to write session %s: %v", sessionID, err)
 		return err
 	}
@@ -85,31 +173,252 @@ func (s *RedisStore) Write(ctx context.Context, sessionID string, msgs []*schema
 	return nil
 }
 
-// Read returns decoded messages from Redis GET; returns nil if not found
+// Read returns decoded messages from Redis, falling back to the legacy key, and restarts the session's expiry;
+// returns nil if not found
 func (s *RedisStore) Read(ctx context.Context, sessionID string) ([]*schema.Message, error) {
-	key := s.prefix + sessionID
 	logger.Debugf("[Memory:Redis] Reading session %s", sessionID)
 
-	res, err := s.cli.Get(ctx, key).Bytes()
-	if err == redis.Nil {
-		logger.Debugf("[Memory:Redis] Session %s not found", sessionID)
-		return nil, nil
+	keys := []string{s.sessionKey(sessionID)}
+	if legacy := s.legacyKey(sessionID); legacy != "" {
+		keys = append(keys, legacy)
 	}
+	vals, err := s.cli.MGet(ctx, keys...).Result()
 	if err != nil {
 		logger.Errorf("[Memory:Redis] Failed to read session %s: %v", sessionID, err)
 		return nil, err
 	}
+	var res []byte
+	for _, val := range vals {
+		if str, ok := val.(string); ok {
+			res = []byte(str)
+			break
+		}
+	}
+	if res == nil {
+		logger.Debugf("[Memory:Redis] Session %s not found", sessionID)
+		return nil, nil
+	}
 
-	msgs, err := DecodeMessages(res)
+	msgs, err := s.codec.Decode(res)
 	if err != nil {
 		logger.Errorf("[Memory:Redis] Failed to decode messages for session %s: %v", sessionID, err)
 		return nil, err
 	}
+	if err := s.touch(ctx, sessionID); err != nil {
+		logger.Warnf("[Memory:Redis] Failed to refresh expiry of session %s: %v", sessionID, err)
+	}
 
 	logger.Debugf("[Memory:Redis] Successfully read session %s (%d messages)", sessionID, len(msgs))
 	return msgs, nil
 }
 
+// Delete removes the messages and metadata keys of a session using Redis DEL
+func (s *RedisStore) Delete(ctx context.Context, sessionID string) error {
+	logger.Debugf("[Memory:Redis] Deleting session %s", sessionID)
+
+	keys := []string{s.sessionKey(sessionID), s.metaKey(sessionID)}
+	if legacy := s.legacyKey(sessionID); legacy != "" {
+		keys = append(keys, legacy)
+	}
+	if err := s.cli.Del(ctx, keys...).Err(); err != nil {
+		logger.Errorf("[Memory:Redis] Failed to delete session %s: %v", sessionID, err)
+		return err
+	}
+	return nil
+}
+
+// List scans the message and metadata keys of sessions under the prefix; sessions still under their legacy key
+// are listed once written again
+func (s *RedisStore) List(ctx context.Context, prefix, cursor string, limit int) ([]string, error) {
+	ids := make(map[string]bool)
+	for _, ns := range []string{"sessions:", "meta:"} {
+		keyPrefix := s.prefix + ns
+		iter := s.cli.Scan(ctx, 0, escapeGlob(keyPrefix+prefix)+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			ids[strings.TrimPrefix(iter.Val(), keyPrefix)] = true
+		}
+		if err := iter.Err(); err != nil {
+			return nil, fmt.Errorf("failed to scan sessions: %w", err)
+		}
+	}
+	return pageSessions(ids, prefix, cursor, limit), nil
+}
+
+// escapeGlob escapes the characters SCAN patterns treat specially
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// metaKey returns the Redis key holding a session's metadata
+func (s *RedisStore) metaKey(sessionID string) string {
+	return s.prefix + "meta:" + sessionID
+}
+
+// WriteMeta stores session metadata as JSON, restarting the session's expiry
+func (s *RedisStore) WriteMeta(ctx context.Context, sessionID string, meta *SessionMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	ttl := s.sessionTTL(sessionID)
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.metaKey(sessionID), b, max(ttl, 0))
+		if ttl > 0 {
+			pipe.Expire(ctx, s.sessionKey(sessionID), ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("[Memory:Redis] Failed to write metadata for session %s: %v", sessionID, err)
+		return err
+	}
+	return nil
+}
+
+// ReadMeta returns session metadata; returns nil if not found
+func (s *RedisStore) ReadMeta(ctx context.Context, sessionID string) (*SessionMeta, error) {
+	res, err := s.cli.Get(ctx, s.metaKey(sessionID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Errorf("[Memory:Redis] Failed to read metadata for session %s: %v", sessionID, err)
+		return nil, err
+	}
+
+	var meta SessionMeta
+	if err := json.Unmarshal(res, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata for session %s: %w", sessionID, err)
+	}
+	return &meta, nil
+}
+
+// ListMeta scans the metadata keys of sessions starting with prefix and returns their decoded values
+func (s *RedisStore) ListMeta(ctx context.Context, prefix string) ([]*SessionMeta, error) {
+	var result []*SessionMeta
+	iter := s.cli.Scan(ctx, 0, escapeGlob(s.prefix+"meta:"+prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		sessionID := strings.TrimPrefix(iter.Val(), s.prefix+"meta:")
+		meta, err := s.ReadMeta(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if meta != nil {
+			result = append(result, meta)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan session metadata: %w", err)
+	}
+	return result, nil
+}
+
+// usageKey returns the Redis hash counting the usage of an API key in an hour
+func (s *RedisStore) usageKey(key string, hour time.Time) string {
+	return s.prefix + "usage:" + strconv.FormatInt(hour.Unix(), 10) + ":" + key
+}
+
+// AddUsage increments the counters of the key's hourly usage hash
+func (s *RedisStore) AddUsage(ctx context.Context, rec *UsageRecord) error {
+	hour := usageHour(rec.Hour)
+	key := s.usageKey(rec.Key, hour)
+	_, err := s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "key", rec.Key, "hour", hour.Unix())
+		pipe.HIncrBy(ctx, key, "requests", rec.Requests)
+		pipe.HIncrBy(ctx, key, "prompt_tokens", rec.PromptTokens)
+		pipe.HIncrBy(ctx, key, "completion_tokens", rec.CompletionTokens)
+		pipe.HIncrBy(ctx, key, "total_tokens", rec.TotalTokens)
+		pipe.HIncrBy(ctx, key, "tool_calls", rec.ToolCalls)
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("[Memory:Redis] Failed to record usage of %s: %v", rec.Key, err)
+		return err
+	}
+	return nil
+}
+
+// ListUsage scans the usage hashes and returns those of the hours starting in [from, to)
+func (s *RedisStore) ListUsage(ctx context.Context, key string, from, to time.Time) ([]*UsageRecord, error) {
+	pattern := s.prefix + "usage:*"
+	if key != "" {
+		pattern += ":" + key
+	}
+	var result []*UsageRecord
+	iter := s.cli.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		fields, err := s.cli.HGetAll(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read usage %s: %w", iter.Val(), err)
+		}
+		hour, _ := strconv.ParseInt(fields["hour"], 10, 64)
+		count := func(name string) int64 {
+			n, _ := strconv.ParseInt(fields[name], 10, 64)
+			return n
+		}
+		rec := &UsageRecord{
+			Key:              fields["key"],
+			Hour:             time.Unix(hour, 0).UTC(),
+			Requests:         count("requests"),
+			PromptTokens:     count("prompt_tokens"),
+			CompletionTokens: count("completion_tokens"),
+			TotalTokens:      count("total_tokens"),
+			ToolCalls:        count("tool_calls"),
+		}
+		if inUsageRange(rec, key, from, to) {
+			result = append(result, rec)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan usage: %w", err)
+	}
+	return result, nil
+}
+
+// quotaKey returns the Redis hash counting the spend of a quota key in a day
+func (s *RedisStore) quotaKey(key string, t time.Time) string {
+	return s.prefix + "quota:" + quotaDay(t) + ":" + key
+}
+
+// AddSpend increments the counters of the key's daily quota hash, which expires after the day
+func (s *RedisStore) AddSpend(ctx context.Context, key string, t time.Time, spend Spend) error {
+	hash := s.quotaKey(key, t)
+	_, err := s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, hash, "tokens", spend.Tokens)
+		pipe.HIncrByFloat(ctx, hash, "cost", spend.Cost)
+		pipe.Expire(ctx, hash, quotaRetention)
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("[Memory:Redis] Failed to record spend of %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+// ReadSpend returns the counters of the key's daily quota hash
+func (s *RedisStore) ReadSpend(ctx context.Context, key string, t time.Time) (Spend, error) {
+	vals, err := s.cli.HMGet(ctx, s.quotaKey(key, t), "tokens", "cost").Result()
+	if err != nil {
+		return Spend{}, fmt.Errorf("failed to read spend of %s: %w", key, err)
+	}
+	var spend Spend
+	if str, ok := vals[0].(string); ok {
+		spend.Tokens, _ = strconv.ParseInt(str, 10, 64)
+	}
+	if str, ok := vals[1].(string); ok {
+		spend.Cost, _ = strconv.ParseFloat(str, 64)
+	}
+	return spend, nil
+}
+
 // NewMiniRedisClient starts an embedded Redis server for local demos/tests
 func NewMiniRedisClient() (*redis.Client, func(), error) {
 	logger.Debug("[Memory:Redis] Starting embedded miniredis server")
@@ -0,0 +1,521 @@
+// Package memory provides conversation history storage implementations.
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// S3Config configures a store in an S3-compatible bucket (AWS S3, MinIO, R2, ...)
+type S3Config struct {
+	Endpoint  string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`     // e.g. "https://s3.eu-west-1.amazonaws.com" (default: AWS in Region)
+	Region    string `json:"region,omitempty" yaml:"region,omitempty"`         // Signing region (default: AWS_REGION, else "us-east-1")
+	Bucket    string `json:"bucket" yaml:"bucket"`                             // Required
+	Prefix    string `json:"prefix,omitempty" yaml:"prefix,omitempty"`         // Key prefix of the objects (default "eino/")
+	PathStyle bool   `json:"path_style,omitempty" yaml:"path_style,omitempty"` // Address the bucket in the path instead of the host, as MinIO needs
+
+	// Credentials default to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, as set in AWS Lambda
+	AccessKeyID     string `json:"access_key_id,omitempty" yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty" yaml:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty" yaml:"session_token,omitempty"`
+
+	// CacheTTL keeps the sessions read and written in memory for this long, serving reads without a request;
+	// other instances' writes show up after it at the latest (0 = no cache)
+	CacheTTL time.Duration `json:"cache_ttl,omitempty" yaml:"cache_ttl,omitempty"`
+}
+
+// maxCachedSessions bounds the read cache; expired sessions are dropped first
+const maxCachedSessions = 1024
+
+// S3Store persists conversation history in an S3-compatible bucket: messages as "<prefix>sessions/<id>" and
+// metadata as "<prefix>meta/<id>"
+type S3Store struct {
+	cfg    S3Config
+	codec  Codec
+	signer *s3Signer
+	cli    *http.Client
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedSession
+}
+
+// cachedSession is the encoded messages of a session and when they were read or written
+type cachedSession struct {
+	data []byte
+	at   time.Time
+}
+
+// NewS3Store creates a store in the configured bucket and checks it can be reached; a nil codec means gob
+func NewS3Store(ctx context.Context, cfg S3Config, codec Codec) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3 bucket is required")
+	}
+	cfg.Region = cmp.Or(cfg.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	cfg.Endpoint = strings.TrimSuffix(cmp.Or(cfg.Endpoint, "https://s3."+cfg.Region+".amazonaws.com"), "/")
+	if cfg.Prefix == "" {
+		cfg.Prefix = "eino/"
+	}
+	if cfg.AccessKeyID == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 credentials are required (access_key_id and secret_access_key, or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	if codec == nil {
+		codec = GobCodec{}
+	}
+
+	s := &S3Store{
+		cfg:   cfg,
+		codec: codec,
+		signer: &s3Signer{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			sessionToken:    cfg.SessionToken,
+			region:          cfg.Region,
+		},
+		cli:   &http.Client{Timeout: 30 * time.Second},
+		cache: make(map[string]cachedSession),
+	}
+	logger.Debugf("[Memory:S3] Connecting to bucket %s at %s", cfg.Bucket, cfg.Endpoint)
+	if err := s.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to reach S3 bucket %s: %w", cfg.Bucket, err)
+	}
+	return s, nil
+}
+
+// Ping verifies the bucket answers with a listing of at most one key
+func (s *S3Store) Ping(ctx context.Context) error {
+	_, _, err := s.list(ctx, s.cfg.Prefix, "", 1)
+	return err
+}
+
+// sessionKey returns the object key of a session's messages
+func (s *S3Store) sessionKey(sessionID string) string {
+	return s.cfg.Prefix + "sessions/" + sessionID
+}
+
+// metaKey returns the object key of a session's metadata
+func (s *S3Store) metaKey(sessionID string) string {
+	return s.cfg.Prefix + "meta/" + sessionID
+}
+
+// Write encodes messages and uploads them, replacing the session's object
+func (s *S3Store) Write(ctx context.Context, sessionID string, msgs []*schema.Message) error {
+	logger.Debugf("[Memory:S3] Writing session %s (%d messages)", sessionID, len(msgs))
+
+	b, err := s.codec.Encode(msgs)
+	if err != nil {
+		logger.Errorf("[Memory:S3] Failed to encode messages for session %s: %v", sessionID, err)
+		return err
+	}
+	if err := s.put(ctx, s.sessionKey(sessionID), b, "application/octet-stream"); err != nil {
+		logger.Errorf("[Memory:S3] Failed to write session %s: %v", sessionID, err)
+		return err
+	}
+	s.cacheSession(sessionID, b)
+	return nil
+}
+
+// Read returns the decoded messages of a session, from the cache if fresh; returns nil if not found
+func (s *S3Store) Read(ctx context.Context, sessionID string) ([]*schema.Message, error) {
+	b, ok := s.cachedSession(sessionID)
+	if !ok {
+		var err error
+		if b, err = s.get(ctx, s.sessionKey(sessionID)); err != nil {
+			logger.Errorf("[Memory:S3] Failed to read session %s: %v", sessionID, err)
+			return nil, err
+		}
+		if b == nil {
+			logger.Debugf("[Memory:S3] Session %s not found", sessionID)
+			return nil, nil
+		}
+		s.cacheSession(sessionID, b)
+	}
+
+	msgs, err := s.codec.Decode(b)
+	if err != nil {
+		logger.Errorf("[Memory:S3] Failed to decode messages for session %s: %v", sessionID, err)
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// Delete removes the metadata first, so a partially deleted session is no longer listed
+func (s *S3Store) Delete(ctx context.Context, sessionID string) error {
+	logger.Debugf("[Memory:S3] Deleting session %s", sessionID)
+
+	s.cacheMu.Lock()
+	delete(s.cache, sessionID)
+	s.cacheMu.Unlock()
+
+	for _, key := range []string{s.metaKey(sessionID), s.sessionKey(sessionID)} {
+		if _, err := s.do(ctx, http.MethodDelete, key, nil, nil, ""); err != nil && !errors.Is(err, errS3NotFound) {
+			logger.Errorf("[Memory:S3] Failed to delete session %s: %v", sessionID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// List lists the message and metadata objects of sessions under the prefix; the bucket returns keys in
+// order, so only up to limit keys after the cursor are listed of each
+func (s *S3Store) List(ctx context.Context, prefix, cursor string, limit int) ([]string, error) {
+	ids := make(map[string]bool)
+	for _, dir := range []string{s.cfg.Prefix + "sessions/", s.cfg.Prefix + "meta/"} {
+		query := url.Values{"list-type": {"2"}, "prefix": {dir + prefix}}
+		if cursor != "" {
+			query.Set("start-after", dir+cursor)
+		}
+		listed := 0
+		for limit <= 0 || listed < limit {
+			if limit > 0 {
+				query.Set("max-keys", strconv.Itoa(min(limit-listed, 1000)))
+			}
+			result, err := s.listObjects(ctx, query)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list sessions: %w", err)
+			}
+			for _, c := range result.Contents {
+				ids[strings.TrimPrefix(c.Key, dir)] = true
+			}
+			listed += len(result.Contents)
+			if !result.IsTruncated {
+				break
+			}
+			query.Set("continuation-token", result.NextContinuationToken)
+		}
+	}
+	return pageSessions(ids, prefix, cursor, limit), nil
+}
+
+// WriteMeta stores session metadata as JSON
+func (s *S3Store) WriteMeta(ctx context.Context, sessionID string, meta *SessionMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := s.put(ctx, s.metaKey(sessionID), b, "application/json"); err != nil {
+		logger.Errorf("[Memory:S3] Failed to write metadata for session %s: %v", sessionID, err)
+		return err
+	}
+	return nil
+}
+
+// ReadMeta returns session metadata; returns nil if not found
+func (s *S3Store) ReadMeta(ctx context.Context, sessionID string) (*SessionMeta, error) {
+	b, err := s.get(ctx, s.metaKey(sessionID))
+	if err != nil {
+		logger.Errorf("[Memory:S3] Failed to read metadata for session %s: %v", sessionID, err)
+		return nil, err
+	}
+	if b == nil {
+		return nil, nil
+	}
+	var meta SessionMeta
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata for session %s: %w", sessionID, err)
+	}
+	return &meta, nil
+}
+
+// ListMeta lists the metadata objects of sessions starting with idPrefix and returns their decoded values
+func (s *S3Store) ListMeta(ctx context.Context, idPrefix string) ([]*SessionMeta, error) {
+	prefix := s.cfg.Prefix + "meta/"
+	var result []*SessionMeta
+	token := ""
+	for {
+		keys, next, err := s.list(ctx, prefix+idPrefix, token, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list session metadata: %w", err)
+		}
+		for _, key := range keys {
+			meta, err := s.ReadMeta(ctx, strings.TrimPrefix(key, prefix))
+			if err != nil {
+				return nil, err
+			}
+			if meta != nil {
+				result = append(result, meta)
+			}
+		}
+		if next == "" {
+			return result, nil
+		}
+		token = next
+	}
+}
+
+// cachedSession returns the encoded messages of a session if cached within the TTL
+func (s *S3Store) cachedSession(sessionID string) ([]byte, bool) {
+	if s.cfg.CacheTTL <= 0 {
+		return nil, false
+	}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	cached, ok := s.cache[sessionID]
+	if !ok || time.Since(cached.at) > s.cfg.CacheTTL {
+		return nil, false
+	}
+	return cached.data, true
+}
+
+// cacheSession keeps the encoded messages of a session, making room by dropping expired sessions
+func (s *S3Store) cacheSession(sessionID string, data []byte) {
+	if s.cfg.CacheTTL <= 0 {
+		return
+	}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if _, ok := s.cache[sessionID]; !ok && len(s.cache) >= maxCachedSessions {
+		for id, cached := range s.cache {
+			if time.Since(cached.at) > s.cfg.CacheTTL {
+				delete(s.cache, id)
+			}
+		}
+		// All fresh: drop an arbitrary one
+		for id := range s.cache {
+			if len(s.cache) < maxCachedSessions {
+				break
+			}
+			delete(s.cache, id)
+		}
+	}
+	s.cache[sessionID] = cachedSession{data: data, at: time.Now()}
+}
+
+// put uploads an object
+func (s *S3Store) put(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.do(ctx, http.MethodPut, key, nil, data, contentType)
+	return err
+}
+
+// get downloads an object; returns nil if not found
+func (s *S3Store) get(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.do(ctx, http.MethodGet, key, nil, nil, "")
+	if errors.Is(err, errS3NotFound) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// listResult is the response of ListObjectsV2
+type listResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// list returns a page of the keys under prefix and the token of the next page, "" if none
+func (s *S3Store) list(ctx context.Context, prefix, token string, maxKeys int) ([]string, string, error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}, "max-keys": {strconv.Itoa(maxKeys)}}
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	result, err := s.listObjects(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+	keys := make([]string, 0, len(result.Contents))
+	for _, c := range result.Contents {
+		keys = append(keys, c.Key)
+	}
+	if !result.IsTruncated {
+		return keys, "", nil
+	}
+	return keys, result.NextContinuationToken, nil
+}
+
+// listObjects sends a ListObjectsV2 request with the given parameters
+func (s *S3Store) listObjects(ctx context.Context, query url.Values) (*listResult, error) {
+	b, err := s.do(ctx, http.MethodGet, "", query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	var result listResult
+	if err := xml.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode object listing: %w", err)
+	}
+	return &result, nil
+}
+
+// errS3NotFound is returned for missing objects
+var errS3NotFound = errors.New("S3 object not found")
+
+// s3Error is the error document of a failed request
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do sends a signed request for an object, or for the bucket if key is empty, and returns the response body
+func (s *S3Store) do(ctx context.Context, method, key string, query url.Values, body []byte, contentType string) ([]byte, error) {
+	endpoint, err := url.Parse(s.cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	objectPath := "/" + key
+	if s.cfg.PathStyle {
+		objectPath = "/" + s.cfg.Bucket + objectPath
+	} else {
+		endpoint.Host = s.cfg.Bucket + "." + endpoint.Host
+	}
+	endpoint.Path = objectPath
+	endpoint.RawPath = s3Escape(objectPath, false)
+	endpoint.RawQuery = s3Query(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.signer.sign(req, body, time.Now())
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound && key != "":
+		return nil, errS3NotFound
+	case resp.StatusCode >= 300:
+		var e s3Error
+		if xml.Unmarshal(b, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("S3 %s %s: %s: %s", method, key, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("S3 %s %s: %s", method, key, resp.Status)
+	}
+	return b, nil
+}
+
+// s3Signer signs requests with AWS Signature Version 4
+type s3Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+}
+
+// sign adds the authentication headers to a request; every header already set is signed
+func (sg *s3Signer) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	date := now.Format("20060102T150405Z")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sg.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sg.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3Query(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date[:8] + "/" + sg.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + sg.secretAccessKey)
+	for _, part := range []string{date[:8], sg.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+sg.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Query encodes query parameters sorted by name, as signing requires
+func s3Query(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes all but the unreserved characters, and slashes unless encodeSlash is set
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -5,6 +5,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/cloudwego/eino/schema"
 )
@@ -15,8 +19,67 @@ type Store interface {
 	Write(ctx context.Context, sessionID string, msgs []*schema.Message) error
 	// Read retrieves messages for a session
 	Read(ctx context.Context, sessionID string) ([]*schema.Message, error)
+	// Delete removes all persisted data of a session; deleting a missing session is not an error
+	Delete(ctx context.Context, sessionID string) error
+	// List returns the IDs of stored sessions starting with prefix in ascending order: up to limit (0 = all)
+	// after cursor, or from the first if cursor is empty. The next page starts after the last ID returned.
+	List(ctx context.Context, prefix, cursor string, limit int) ([]string, error)
 }
 
+// pageSessions returns the page of a List call from a set of session IDs
+func pageSessions(ids map[string]bool, prefix, cursor string, limit int) []string {
+	page := make([]string, 0, len(ids))
+	for id := range ids {
+		if strings.HasPrefix(id, prefix) && id > cursor {
+			page = append(page, id)
+		}
+	}
+	slices.Sort(page)
+	if limit > 0 && len(page) > limit {
+		page = page[:limit]
+	}
+	return page
+}
+
+// Pinger is implemented by stores backed by a service whose reachability can be checked
+type Pinger interface {
+	// Ping verifies the backing service answers
+	Ping(ctx context.Context) error
+}
+
+// Codec serializes the messages of a session for stores that keep them as one value
+type Codec interface {
+	Encode(msgs []*schema.Message) ([]byte, error)
+	// Decode reads messages written by any codec, so switching codecs keeps stored sessions readable
+	Decode(data []byte) ([]*schema.Message, error)
+}
+
+// NewCodec returns the codec of an encoding: "gob" (default) or "json", which tools outside Go can read
+func NewCodec(encoding string) (Codec, error) {
+	switch encoding {
+	case "", "gob":
+		return GobCodec{}, nil
+	case "json":
+		return JSONCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported message encoding: %s", encoding)
+	}
+}
+
+// GobCodec encodes messages with gob
+type GobCodec struct{}
+
+func (GobCodec) Encode(msgs []*schema.Message) ([]byte, error) { return EncodeMessages(msgs) }
+
+func (GobCodec) Decode(data []byte) ([]*schema.Message, error) { return DecodeMessages(data) }
+
+// JSONCodec encodes messages as a JSON array
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(msgs []*schema.Message) ([]byte, error) { return json.Marshal(msgs) }
+
+func (JSONCodec) Decode(data []byte) ([]*schema.Message, error) { return DecodeMessages(data) }
+
 // EncodeMessages serializes messages using gob
 func EncodeMessages(msgs []*schema.Message) ([]byte, error) {
 	var buf bytes.Buffer
@@ -27,9 +90,16 @@ func EncodeMessages(msgs []*schema.Message) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// DecodeMessages deserializes messages using gob
+// DecodeMessages deserializes messages encoded with gob or as JSON, telling them apart by content:
+// a gob stream is binary and never valid JSON
 func DecodeMessages(data []byte) ([]*schema.Message, error) {
 	var msgs []*schema.Message
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '[' || bytes.Equal(trimmed, []byte("null"))) && json.Valid(trimmed) {
+		if err := json.Unmarshal(trimmed, &msgs); err != nil {
+			return nil, err
+		}
+		return msgs, nil
+	}
 	dec := gob.NewDecoder(bytes.NewReader(data))
 	if err := dec.Decode(&msgs); err != nil {
 		return nil, err
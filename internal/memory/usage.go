@@ -0,0 +1,48 @@
+// Package memory provides conversation history storage implementations.
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// UsageRecord is the usage of one API key within one hour
+type UsageRecord struct {
+	Key              string    `json:"key"`  // Fingerprint of the API key, or the client IP of unauthenticated callers
+	Hour             time.Time `json:"hour"` // Start of the hour, in UTC
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	ToolCalls        int64     `json:"tool_calls"`
+}
+
+// Add adds the counters of other to r
+func (r *UsageRecord) Add(other *UsageRecord) {
+	r.Requests += other.Requests
+	r.PromptTokens += other.PromptTokens
+	r.CompletionTokens += other.CompletionTokens
+	r.TotalTokens += other.TotalTokens
+	r.ToolCalls += other.ToolCalls
+}
+
+// UsageStore is implemented by stores that persist per-key usage accounting
+type UsageStore interface {
+	// AddUsage adds the counters of rec to the record of its key and hour
+	AddUsage(ctx context.Context, rec *UsageRecord) error
+	// ListUsage returns the records of the hours starting in [from, to) of a key, or of all keys if key is empty
+	ListUsage(ctx context.Context, key string, from, to time.Time) ([]*UsageRecord, error)
+}
+
+// usageHour returns the start of the hour of t in UTC
+func usageHour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+// inUsageRange reports whether a record matches a key filter and time range
+func inUsageRange(rec *UsageRecord, key string, from, to time.Time) bool {
+	if key != "" && rec.Key != key {
+		return false
+	}
+	return !rec.Hour.Before(from) && rec.Hour.Before(to)
+}
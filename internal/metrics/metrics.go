@@ -0,0 +1,385 @@
+// Package metrics provides a minimal Prometheus-compatible metrics registry.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default histogram buckets (in seconds)
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// Default is the process-wide registry exposed by the API server
+var Default = NewRegistry()
+
+// collector is implemented by every metric type
+type collector interface {
+	write(w io.Writer) error
+}
+
+// Registry holds a set of named metrics
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+// NewCounter registers (or returns the existing) counter with the given name
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.collectors[name].(*Counter); ok {
+		return c
+	}
+	c := &Counter{desc: desc{name: name, help: help, labels: labels}, values: make(map[string]*counterValue)}
+	r.collectors[name] = c
+	return c
+}
+
+// NewGauge registers (or returns the existing) gauge with the given name
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if g, ok := r.collectors[name].(*Gauge); ok {
+		return g
+	}
+	g := &Gauge{desc: desc{name: name, help: help, labels: labels}, values: make(map[string]*counterValue)}
+	r.collectors[name] = g
+	return g
+}
+
+// NewHistogram registers (or returns the existing) histogram with the given name
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if h, ok := r.collectors[name].(*Histogram); ok {
+		return h
+	}
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{desc: desc{name: name, help: help, labels: labels}, buckets: sorted, values: make(map[string]*histogramValue)}
+	r.collectors[name] = h
+	return h
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCounter registers a counter on the default registry
+func NewCounter(name, help string, labels ...string) *Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+// NewGauge registers a gauge on the default registry
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return Default.NewGauge(name, help, labels...)
+}
+
+// NewHistogram registers a histogram on the default registry
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labels...)
+}
+
+// desc describes a metric family
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// key joins label values into a map key
+func (d *desc) key(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// labelString renders label pairs, optionally with an extra trailing pair
+func (d *desc) labelString(values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range d.labels {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, v))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extraName, extraValue))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (d *desc) header(w io.Writer, typ string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, typ)
+	return err
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+// Inc increments the counter for the given label values by 1
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by v
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := c.key(labelValues)
+	cv, ok := c.values[k]
+	if !ok {
+		cv = &counterValue{labels: append([]string(nil), labelValues...)}
+		c.values[k] = cv
+	}
+	cv.value += v
+}
+
+// Value returns the current value for the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cv, ok := c.values[c.key(labelValues)]; ok {
+		return cv.value
+	}
+	return 0
+}
+
+func (c *Counter) write(w io.Writer) error {
+	return writeScalar(w, &c.desc, "counter", &c.mu, c.values)
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+// Set sets the gauge for the given label values
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	k := g.key(labelValues)
+	gv, ok := g.values[k]
+	if !ok {
+		gv = &counterValue{labels: append([]string(nil), labelValues...)}
+		g.values[k] = gv
+	}
+	gv.value = v
+}
+
+// Add adds v (which may be negative) to the gauge for the given label values
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	k := g.key(labelValues)
+	gv, ok := g.values[k]
+	if !ok {
+		gv = &counterValue{labels: append([]string(nil), labelValues...)}
+		g.values[k] = gv
+	}
+	gv.value += v
+}
+
+// Value returns the current value for the given label values
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if gv, ok := g.values[g.key(labelValues)]; ok {
+		return gv.value
+	}
+	return 0
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	return writeScalar(w, &g.desc, "gauge", &g.mu, g.values)
+}
+
+// writeScalar renders a counter or gauge family
+func writeScalar(w io.Writer, d *desc, typ string, mu *sync.Mutex, values map[string]*counterValue) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := d.header(w, typ); err != nil {
+		return err
+	}
+	for _, k := range sortedKeys(values) {
+		v := values[k]
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", d.name, d.labelString(v.labels, "", ""), formatFloat(v.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Histogram samples observations into configurable buckets
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+// Observe records a single observation for the given label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := h.key(labelValues)
+	hv, ok := h.values[k]
+	if !ok {
+		hv = &histogramValue{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[k] = hv
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			hv.counts[i]++
+		}
+	}
+	hv.count++
+	hv.sum += v
+}
+
+// HistogramSnapshot is a point-in-time copy of a single histogram series
+type HistogramSnapshot struct {
+	Count   uint64
+	Sum     float64
+	Buckets []float64
+	Counts  []uint64 // Cumulative count per bucket
+}
+
+// Quantile estimates the q-th quantile (0..1) by linear interpolation within buckets
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := q * float64(s.Count)
+	prevBound, prevCount := 0.0, uint64(0)
+	for i, bound := range s.Buckets {
+		if float64(s.Counts[i]) >= rank {
+			inBucket := s.Counts[i] - prevCount
+			if inBucket == 0 {
+				return bound
+			}
+			return prevBound + (bound-prevBound)*(rank-float64(prevCount))/float64(inBucket)
+		}
+		prevBound, prevCount = bound, s.Counts[i]
+	}
+	return s.Buckets[len(s.Buckets)-1]
+}
+
+// Snapshot returns the current state of the series for the given label values
+func (h *Histogram) Snapshot(labelValues ...string) HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snap := HistogramSnapshot{Buckets: append([]float64(nil), h.buckets...), Counts: make([]uint64, len(h.buckets))}
+	if hv, ok := h.values[h.key(labelValues)]; ok {
+		snap.Count = hv.count
+		snap.Sum = hv.sum
+		copy(snap.Counts, hv.counts)
+	}
+	return snap
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+	for _, k := range sortedKeys(h.values) {
+		hv := h.values[k]
+		for i, b := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(hv.labels, "le", formatFloat(b)), hv.counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(hv.labels, "le", "+Inf"), hv.count); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(hv.labels, "", ""), formatFloat(hv.sum)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(hv.labels, "", ""), hv.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprintf("%g", v)
+}
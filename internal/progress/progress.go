@@ -0,0 +1,34 @@
+// Package progress carries progress reports of long-running tool calls from the tools to the agent's event stream.
+package progress
+
+import "context"
+
+// Update is a progress report of a tool call
+type Update struct {
+	Progress float64 `json:"progress"`          // Work done so far; increases with every update
+	Total    float64 `json:"total,omitempty"`   // Total work if known, in the unit of Progress (0 = unknown)
+	Message  string  `json:"message,omitempty"` // Human-readable description of the current step
+}
+
+// Reporter receives the progress of the tool calls of a run; ctx is the context of the reporting call
+type Reporter func(ctx context.Context, tool string, update Update)
+
+type reporterKey struct{}
+
+// WithReporter returns a context whose tool calls report their progress to r
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, r)
+}
+
+// Enabled reports whether progress reported with ctx reaches anyone, so tools can skip asking for it
+func Enabled(ctx context.Context) bool {
+	r, _ := ctx.Value(reporterKey{}).(Reporter)
+	return r != nil
+}
+
+// Report passes the progress of a tool call to the reporter of ctx, if any
+func Report(ctx context.Context, tool string, update Update) {
+	if r, _ := ctx.Value(reporterKey{}).(Reporter); r != nil {
+		r(ctx, tool, update)
+	}
+}
@@ -0,0 +1,118 @@
+// Package rag provides document ingestion and retrieval-augmented generation support.
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// OpenAIEmbedderConfig configures an OpenAI-compatible embeddings client
+type OpenAIEmbedderConfig struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+}
+
+// OpenAIEmbedder implements embedding.Embedder against an OpenAI-compatible /embeddings endpoint
+type OpenAIEmbedder struct {
+	config *OpenAIEmbedderConfig
+	client *http.Client
+}
+
+// NewOpenAIEmbedder creates a new OpenAI-compatible embedder
+func NewOpenAIEmbedder(config *OpenAIEmbedderConfig) (*OpenAIEmbedder, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("embedding base URL is required")
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("embedding model is required")
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	return &OpenAIEmbedder{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// EmbedStrings embeds the given texts, preserving input order
+func (e *OpenAIEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	options := embedding.GetCommonOptions(&embedding.Options{Model: &e.config.Model}, opts...)
+
+	body, err := json.Marshal(embeddingRequest{Model: *options.Model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	url := strings.TrimSuffix(e.config.BaseURL, "/") + "/embeddings"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+	}
+
+	logger.Debugf("[RAG] Embedding %d texts with model %s", len(texts), *options.Model)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+
+	var result embeddingResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil {
+			return nil, fmt.Errorf("embedding request returned status %d: %s", resp.StatusCode, result.Error.Message)
+		}
+		return nil, fmt.Errorf("embedding request returned status %d", resp.StatusCode)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding response has %d vectors for %d inputs", len(result.Data), len(texts))
+	}
+
+	sort.Slice(result.Data, func(i, j int) bool { return result.Data[i].Index < result.Data[j].Index })
+	vectors := make([][]float64, len(result.Data))
+	for i, d := range result.Data {
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,202 @@
+// Package rag provides document ingestion and retrieval-augmented generation support.
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/fourhu/eino-ai-agent/internal/document"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// Chunk metadata keys
+const (
+	metaSourceID   = "source_id"
+	metaSourceName = "source_name"
+	metaChunkIndex = "chunk_index"
+)
+
+// SearchToolName is the name of the knowledge search tool exposed to the agent
+const SearchToolName = "search_knowledge"
+
+// Config is the RAG service configuration
+type Config struct {
+	ChunkSize    int     // Max runes per chunk (default 1000)
+	ChunkOverlap int     // Runes repeated between consecutive chunks (default 100)
+	TopK         int     // Default number of chunks to retrieve (default 4)
+	MinScore     float64 // Minimum similarity score for retrieved chunks (0 = no threshold)
+}
+
+// Document describes an ingested source document
+type Document struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Format     string    `json:"format"`
+	Chunks     int       `json:"chunks"`
+	Bytes      int       `json:"bytes"`
+	IngestedAt time.Time `json:"ingested_at"`
+}
+
+// Service ingests documents into a vector store and retrieves relevant chunks
+type Service struct {
+	config *Config
+	store  *MemoryVectorStore
+	docs   map[string]*Document
+	mu     sync.RWMutex
+}
+
+// NewService creates a new RAG service backed by an in-memory vector store
+func NewService(embedder embedding.Embedder, config *Config) *Service {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 1000
+	}
+	if config.ChunkOverlap <= 0 {
+		config.ChunkOverlap = 100
+	}
+	if config.TopK <= 0 {
+		config.TopK = 4
+	}
+	return &Service{
+		config: config,
+		store:  NewMemoryVectorStore(embedder),
+		docs:   make(map[string]*Document),
+	}
+}
+
+// Ingest extracts, chunks and embeds a document
+func (s *Service) Ingest(ctx context.Context, name, contentType string, data []byte) (*Document, error) {
+	text, err := document.ExtractText(name, contentType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := SplitText(text, s.config.ChunkSize, s.config.ChunkOverlap)
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("document %q has no text content", name)
+	}
+
+	doc := &Document{
+		ID:         uuid.New().String(),
+		Name:       name,
+		Format:     document.DetectFormat(name, contentType, data),
+		Chunks:     len(chunks),
+		Bytes:      len(data),
+		IngestedAt: time.Now(),
+	}
+
+	chunkDocs := make([]*schema.Document, len(chunks))
+	for i, chunk := range chunks {
+		chunkDocs[i] = &schema.Document{
+			ID:      fmt.Sprintf("%s#%d", doc.ID, i),
+			Content: chunk,
+			MetaData: map[string]any{
+				metaSourceID:   doc.ID,
+				metaSourceName: name,
+				metaChunkIndex: i,
+			},
+		}
+	}
+
+	if _, err := s.store.Store(ctx, chunkDocs); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.docs[doc.ID] = doc
+	s.mu.Unlock()
+
+	logger.Infof("[RAG] Ingested document %s (%s, %d chunks)", name, doc.Format, len(chunks))
+	return doc, nil
+}
+
+// Search retrieves the chunks most relevant to the query
+func (s *Service) Search(ctx context.Context, query string, topK int) ([]*schema.Document, error) {
+	if topK <= 0 {
+		topK = s.config.TopK
+	}
+	opts := []retriever.Option{retriever.WithTopK(topK)}
+	if s.config.MinScore > 0 {
+		opts = append(opts, retriever.WithScoreThreshold(s.config.MinScore))
+	}
+	docs, err := s.store.Retrieve(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("[RAG] Search %q returned %d chunks", query, len(docs))
+	return docs, nil
+}
+
+// ListDocuments returns all ingested documents, newest first
+func (s *Service) ListDocuments() []*Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	docs := make([]*Document, 0, len(s.docs))
+	for _, d := range s.docs {
+		docs = append(docs, d)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].IngestedAt.After(docs[j].IngestedAt) })
+	return docs
+}
+
+// DeleteDocument removes a document and all of its chunks
+func (s *Service) DeleteDocument(ctx context.Context, id string) bool {
+	s.mu.Lock()
+	_, exists := s.docs[id]
+	delete(s.docs, id)
+	s.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+	removed := s.store.Delete(ctx, id)
+	logger.Debugf("[RAG] Deleted document %s (%d chunks)", id, removed)
+	return true
+}
+
+// searchInput is the argument schema of the search_knowledge tool
+type searchInput struct {
+	Query string `json:"query" jsonschema:"description=natural language query describing the information to look up"`
+	TopK  int    `json:"top_k,omitempty" jsonschema:"description=maximum number of passages to return"`
+}
+
+// SearchTool returns a tool that lets the agent search the knowledge base
+func (s *Service) SearchTool() (tool.BaseTool, error) {
+	return utils.InferTool(SearchToolName,
+		"Search the knowledge base of ingested documents and return the most relevant passages with their sources. "+
+			"Use this before answering questions about internal documentation.",
+		func(ctx context.Context, in *searchInput) (string, error) {
+			docs, err := s.Search(ctx, in.Query, in.TopK)
+			if err != nil {
+				return "", err
+			}
+			return FormatResults(docs), nil
+		})
+}
+
+// FormatResults renders retrieved chunks as numbered passages with their source names
+func FormatResults(docs []*schema.Document) string {
+	if len(docs) == 0 {
+		return "No relevant documents found."
+	}
+	var sb strings.Builder
+	for i, d := range docs {
+		source, _ := d.MetaData[metaSourceName].(string)
+		fmt.Fprintf(&sb, "[%d] (source: %s, score: %.3f)\n%s\n\n", i+1, source, d.Score(), d.Content)
+	}
+	return strings.TrimSpace(sb.String())
+}
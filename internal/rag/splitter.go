@@ -0,0 +1,103 @@
+// Package rag provides document ingestion and retrieval-augmented generation support.
+package rag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SplitText splits text into chunks of at most size runes, preferring paragraph and sentence
+// boundaries, with overlap runes repeated between consecutive chunks
+func SplitText(text string, size, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if size <= 0 {
+		size = 1000
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	// Split into paragraphs first, then further split any paragraph that is too long
+	var pieces []string
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if utf8.RuneCountInString(para) <= size {
+			pieces = append(pieces, para)
+			continue
+		}
+		pieces = append(pieces, splitLong(para, size)...)
+	}
+
+	// Greedily merge pieces into chunks
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	for _, piece := range pieces {
+		pieceLen := utf8.RuneCountInString(piece)
+		if currentLen > 0 && currentLen+2+pieceLen > size {
+			chunk := current.String()
+			chunks = append(chunks, chunk)
+			current.Reset()
+			currentLen = 0
+			if overlap > 0 {
+				tail := lastRunes(chunk, overlap)
+				current.WriteString(tail)
+				currentLen = utf8.RuneCountInString(tail)
+			}
+		}
+		if currentLen > 0 {
+			current.WriteString("\n\n")
+			currentLen += 2
+		}
+		current.WriteString(piece)
+		currentLen += pieceLen
+	}
+	if currentLen > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// splitLong splits a single paragraph at sentence or word boundaries
+func splitLong(para string, size int) []string {
+	var out []string
+	runes := []rune(para)
+	for len(runes) > size {
+		cut := size
+		for i := size; i > size/2; i-- {
+			r := runes[i-1]
+			if r == '.' || r == '!' || r == '?' || r == '\n' || r == '。' {
+				cut = i
+				break
+			}
+		}
+		if cut == size {
+			for i := size; i > size/2; i-- {
+				if runes[i-1] == ' ' {
+					cut = i
+					break
+				}
+			}
+		}
+		out = append(out, strings.TrimSpace(string(runes[:cut])))
+		runes = runes[cut:]
+	}
+	if rest := strings.TrimSpace(string(runes)); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
+
+func lastRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[len(runes)-n:])
+}
@@ -0,0 +1,159 @@
+// Package rag provides document ingestion and retrieval-augmented generation support.
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+)
+
+// MemoryVectorStore is an in-process vector store implementing both indexer.Indexer and retriever.Retriever
+type MemoryVectorStore struct {
+	embedder embedding.Embedder
+	docs     map[string]*schema.Document
+	order    []string
+	mu       sync.RWMutex
+}
+
+// NewMemoryVectorStore creates a new in-memory vector store using the given embedder
+func NewMemoryVectorStore(embedder embedding.Embedder) *MemoryVectorStore {
+	return &MemoryVectorStore{
+		embedder: embedder,
+		docs:     make(map[string]*schema.Document),
+	}
+}
+
+// Store embeds (when needed) and stores documents, returning their IDs
+func (s *MemoryVectorStore) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) ([]string, error) {
+	options := indexer.GetCommonOptions(&indexer.Options{Embedding: s.embedder}, opts...)
+
+	var texts []string
+	var pending []*schema.Document
+	for _, doc := range docs {
+		if len(doc.DenseVector()) == 0 {
+			texts = append(texts, doc.Content)
+			pending = append(pending, doc)
+		}
+	}
+	if len(pending) > 0 {
+		if options.Embedding == nil {
+			return nil, fmt.Errorf("no embedder configured for vector store")
+		}
+		vectors, err := options.Embedding.EmbedStrings(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed documents: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d documents", len(vectors), len(texts))
+		}
+		for i, doc := range pending {
+			doc.WithDenseVector(vectors[i])
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if doc.ID == "" {
+			doc.ID = uuid.New().String()
+		}
+		if _, exists := s.docs[doc.ID]; !exists {
+			s.order = append(s.order, doc.ID)
+		}
+		s.docs[doc.ID] = doc
+		ids = append(ids, doc.ID)
+	}
+	return ids, nil
+}
+
+// Retrieve returns the documents most similar to the query
+func (s *MemoryVectorStore) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	defaultTopK := 4
+	options := retriever.GetCommonOptions(&retriever.Options{TopK: &defaultTopK, Embedding: s.embedder}, opts...)
+	if options.Embedding == nil {
+		return nil, fmt.Errorf("no embedder configured for vector store")
+	}
+
+	vectors, err := options.Embedding.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("embedder returned no vector for query")
+	}
+	queryVec := vectors[0]
+
+	s.mu.RLock()
+	results := make([]*schema.Document, 0, len(s.docs))
+	for _, id := range s.order {
+		doc := s.docs[id]
+		score := cosineSimilarity(queryVec, doc.DenseVector())
+		if options.ScoreThreshold != nil && score < *options.ScoreThreshold {
+			continue
+		}
+		result := &schema.Document{ID: doc.ID, Content: doc.Content, MetaData: make(map[string]any, len(doc.MetaData))}
+		for k, v := range doc.MetaData {
+			result.MetaData[k] = v
+		}
+		results = append(results, result.WithScore(score))
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score() > results[j].Score() })
+	if options.TopK != nil && *options.TopK > 0 && len(results) > *options.TopK {
+		results = results[:*options.TopK]
+	}
+	return results, nil
+}
+
+// Delete removes all chunks whose source metadata matches the given document ID
+func (s *MemoryVectorStore) Delete(ctx context.Context, sourceID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if src, _ := s.docs[id].MetaData[metaSourceID].(string); src == sourceID {
+			delete(s.docs, id)
+			removed++
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+	return removed
+}
+
+// Len returns the number of stored chunks
+func (s *MemoryVectorStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.docs)
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
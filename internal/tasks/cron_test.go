@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleDayOfWeek(t *testing.T) {
+	tests := []struct {
+		spec string
+		want uint64 // bit n set = weekday n, Sunday = 0
+	}{
+		{"0 0 * * 1-7", 0b1111111},
+		{"0 0 * * 5-7", 1<<0 | 1<<5 | 1<<6},
+		{"0 0 * * 7", 1 << 0},
+		{"0 0 * * 0,7", 1 << 0},
+		{"0 0 * * */2", 1<<0 | 1<<2 | 1<<4 | 1<<6},
+		{"0 0 * * *", 0b1111111},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sched, err := ParseSchedule(tt.spec, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+			}
+			if got := sched.(*cronSchedule).dow; got != tt.want {
+				t.Errorf("dow = %08b, want %08b", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleDayOfWeekOutOfRange(t *testing.T) {
+	for _, spec := range []string{"0 0 * * 8", "0 0 * * 5-8", "0 0 * * 7-1"} {
+		if _, err := ParseSchedule(spec, time.UTC); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestCronScheduleNextSunday(t *testing.T) {
+	sched, err := ParseSchedule("30 9 * * 7", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-10-16 is a Friday
+	got := sched.Next(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	want := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
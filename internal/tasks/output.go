@@ -0,0 +1,210 @@
+// Package tasks provides scheduled agent runs with result delivery.
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// Output types
+const (
+	OutputLog     = "log"
+	OutputFile    = "file"
+	OutputWebhook = "webhook"
+	OutputSlack   = "slack"
+)
+
+// OutputConfig describes where a task result is delivered
+type OutputConfig struct {
+	Type    string            `json:"type" yaml:"type"`                           // log, file, webhook, slack
+	URL     string            `json:"url,omitempty" yaml:"url,omitempty"`         // Webhook / Slack incoming webhook URL
+	Path    string            `json:"path,omitempty" yaml:"path,omitempty"`       // File path; results are appended (relative to the output directory for tasks added at runtime)
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"` // Extra webhook headers; $VAR references are expanded for configured tasks only
+}
+
+// Redacted returns the config without its headers and with the URL cut down to scheme and host, since webhook URLs and headers carry credentials
+func (o OutputConfig) Redacted() OutputConfig {
+	o.Headers = nil
+	if o.URL != "" {
+		u, err := url.Parse(o.URL)
+		if err != nil || u.Host == "" {
+			o.URL = "***"
+		} else {
+			o.URL = u.Scheme + "://" + u.Host + "/***"
+		}
+	}
+	return o
+}
+
+// Result is the outcome of a single task run
+type Result struct {
+	Task       string             `json:"task"`
+	Session    string             `json:"session"`
+	Prompt     string             `json:"prompt"`
+	Output     string             `json:"output,omitempty"`
+	Usage      *schema.TokenUsage `json:"usage,omitempty"` // Tokens used by the run
+	Error      string             `json:"error,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt time.Time          `json:"finished_at"`
+	Duration   time.Duration      `json:"duration_ns"`
+}
+
+// Sink delivers task results
+type Sink interface {
+	Deliver(ctx context.Context, result *Result) error
+}
+
+// NewSink creates a sink from a trusted configuration; webhook header values may reference environment variables
+func NewSink(cfg OutputConfig) (Sink, error) {
+	return newSink(cfg, "", true)
+}
+
+// newSink creates a sink; a non-empty root confines file outputs to that directory
+func newSink(cfg OutputConfig, root string, expandEnv bool) (Sink, error) {
+	switch cfg.Type {
+	case "", OutputLog:
+		return logSink{}, nil
+	case OutputFile:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("file output requires a path")
+		}
+		if root != "" && !filepath.IsLocal(cfg.Path) {
+			return nil, fmt.Errorf("file output path %q must be relative and stay within the output directory", cfg.Path)
+		}
+		return &fileSink{root: root, path: cfg.Path}, nil
+	case OutputWebhook, OutputSlack:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("%s output requires a url", cfg.Type)
+		}
+		return &webhookSink{
+			url:       cfg.URL,
+			headers:   cfg.Headers,
+			expandEnv: expandEnv,
+			slack:     cfg.Type == OutputSlack,
+			client:    &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output type: %s", cfg.Type)
+	}
+}
+
+// logSink writes results to the server log
+type logSink struct{}
+
+func (logSink) Deliver(ctx context.Context, result *Result) error {
+	if result.Error != "" {
+		logger.Warnf("[Tasks:%s] Run failed: %s", result.Task, result.Error)
+		return nil
+	}
+	logger.Infof("[Tasks:%s] Result: %s", result.Task, result.Output)
+	return nil
+}
+
+// fileSink appends results as JSON lines
+type fileSink struct {
+	root string // Directory the path is resolved in and may not escape, if set
+	path string
+}
+
+func (s *fileSink) Deliver(ctx context.Context, result *Result) error {
+	f, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// open opens the output file for appending, creating it and its directory as needed
+func (s *fileSink) open() (*os.File, error) {
+	if err := os.MkdirAll(filepath.Join(s.root, filepath.Dir(s.path)), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	flag := os.O_CREATE | os.O_APPEND | os.O_WRONLY
+	if s.root == "" {
+		f, err := os.OpenFile(s.path, flag, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open output file: %w", err)
+		}
+		return f, nil
+	}
+
+	// Opening through os.Root also refuses symlinks that lead out of the directory
+	root, err := os.OpenRoot(s.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output directory: %w", err)
+	}
+	defer root.Close()
+	f, err := root.OpenFile(s.path, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	return f, nil
+}
+
+// webhookSink posts results to an HTTP endpoint
+type webhookSink struct {
+	url       string
+	headers   map[string]string
+	expandEnv bool // Header values reference environment variables; only for configured tasks
+	slack     bool
+	client    *http.Client
+}
+
+func (s *webhookSink) Deliver(ctx context.Context, result *Result) error {
+	var payload interface{} = result
+	if s.slack {
+		text := result.Output
+		if result.Error != "" {
+			text = "Task failed: " + result.Error
+		}
+		payload = map[string]string{"text": fmt.Sprintf("*%s*\n%s", result.Task, text)}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		if s.expandEnv {
+			v = os.ExpandEnv(v)
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+// Package tasks provides scheduled agent runs with result delivery.
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fourhu/eino-ai-agent/internal/agent"
+	"github.com/fourhu/eino-ai-agent/internal/audit"
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+// TaskConfig defines a scheduled task
+type TaskConfig struct {
+	Name     string       `json:"name" yaml:"name"`
+	Schedule string       `json:"schedule" yaml:"schedule"`                     // Cron expression, alias (@daily) or "@every 1h"
+	Prompt   string       `json:"prompt" yaml:"prompt"`                         // Prompt sent to the agent on each run
+	Agent    string       `json:"agent,omitempty" yaml:"agent,omitempty"`       // Agent name (empty = default agent)
+	Session  string       `json:"session,omitempty" yaml:"session,omitempty"`   // Session ID (default "task:<name>")
+	Timeout  Duration     `json:"timeout,omitempty" yaml:"timeout,omitempty"`   // Per-run timeout, e.g. "10m" (default 5m)
+	Enabled  *bool        `json:"enabled,omitempty" yaml:"enabled,omitempty"`   // Disabled tasks are registered but never fire (default true)
+	Output   OutputConfig `json:"output" yaml:"output"`                         // Result delivery target
+	Fresh    bool         `json:"fresh,omitempty" yaml:"fresh,omitempty"`       // Clear the session before every run
+	Timezone string       `json:"timezone,omitempty" yaml:"timezone,omitempty"` // IANA timezone for cron schedules
+}
+
+// Duration is a time.Duration written as a string like "10m" in JSON and YAML; numbers are read as nanoseconds
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var ns int64
+		if err := json.Unmarshal(data, &ns); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(ns)
+		return nil
+	}
+	return d.parse(s)
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if ns, err := strconv.ParseInt(node.Value, 10, 64); err == nil {
+		*d = Duration(ns)
+		return nil
+	}
+	return d.parse(node.Value)
+}
+
+// parse reads a duration string such as "1h30m"
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ChatRunner is the subset of the agent used to run a task prompt
+type ChatRunner interface {
+	Chat(ctx context.Context, sessionID string, userMessage string, opts ...agent.ChatOption) (*schema.Message, error)
+	ClearSession(sessionID string)
+}
+
+// AgentResolver returns the agent registered under the given name
+type AgentResolver func(name string) (ChatRunner, error)
+
+// TaskStatus reports the state of a scheduled task
+type TaskStatus struct {
+	TaskConfig
+	NextRun    time.Time `json:"next_run,omitempty"`
+	LastRun    time.Time `json:"last_run,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+	RunCount   int       `json:"run_count"`
+	Running    bool      `json:"running"`
+	LastOutput string    `json:"last_output,omitempty"`
+}
+
+// task is a registered task with its runtime state
+type task struct {
+	config   TaskConfig
+	schedule Schedule
+	sink     Sink
+	status   TaskStatus
+	timer    *time.Timer
+}
+
+// Scheduler runs tasks on their schedules
+type Scheduler struct {
+	resolve   AgentResolver
+	outputDir string // File outputs of runtime tasks are confined here
+	tasks     map[string]*task
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+}
+
+// Option configures a Scheduler
+type Option func(*Scheduler)
+
+// WithOutputDir lets tasks added at runtime write file outputs, at relative paths within dir.
+// Without it, runtime tasks cannot use file outputs.
+func WithOutputDir(dir string) Option {
+	return func(s *Scheduler) {
+		s.outputDir = dir
+	}
+}
+
+// NewScheduler creates a new task scheduler
+func NewScheduler(resolve AgentResolver, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		resolve: resolve,
+		tasks:   make(map[string]*task),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start begins firing scheduled tasks; tasks added afterwards are scheduled immediately
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, t := range s.tasks {
+		s.scheduleLocked(t)
+	}
+	logger.Infof("[Tasks] Scheduler started with %d tasks", len(s.tasks))
+}
+
+// Stop cancels pending timers and waits for running tasks to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	for _, t := range s.tasks {
+		if t.timer != nil {
+			t.timer.Stop()
+		}
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// Add registers (or replaces) a task from the configuration file; its outputs are trusted
+func (s *Scheduler) Add(cfg TaskConfig) error {
+	return s.add(cfg, false)
+}
+
+// AddRuntime registers (or replaces) a task submitted at runtime, e.g. over the API.
+// Its webhook headers are sent verbatim rather than expanded from the environment, and file outputs
+// are confined to the output directory.
+func (s *Scheduler) AddRuntime(cfg TaskConfig) error {
+	return s.add(cfg, true)
+}
+
+func (s *Scheduler) add(cfg TaskConfig, runtime bool) error {
+	if cfg.Name == "" {
+		return fmt.Errorf("task name is required")
+	}
+	if cfg.Prompt == "" {
+		return fmt.Errorf("task %s: prompt is required", cfg.Name)
+	}
+	loc := time.Local
+	if cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return fmt.Errorf("task %s: invalid timezone: %w", cfg.Name, err)
+		}
+	}
+	schedule, err := ParseSchedule(cfg.Schedule, loc)
+	if err != nil {
+		return fmt.Errorf("task %s: invalid schedule: %w", cfg.Name, err)
+	}
+	var sink Sink
+	switch {
+	case !runtime:
+		sink, err = NewSink(cfg.Output)
+	case cfg.Output.Type == OutputFile && s.outputDir == "":
+		err = fmt.Errorf("file outputs are not allowed for tasks added at runtime without an output directory")
+	default:
+		sink, err = newSink(cfg.Output, s.outputDir, false)
+	}
+	if err != nil {
+		return fmt.Errorf("task %s: %w", cfg.Name, err)
+	}
+	if cfg.Session == "" {
+		cfg.Session = "task:" + cfg.Name
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = Duration(5 * time.Minute)
+	}
+	if cfg.Enabled == nil {
+		enabled := true
+		cfg.Enabled = &enabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, exists := s.tasks[cfg.Name]; exists && old.timer != nil {
+		old.timer.Stop()
+	}
+	t := &task{
+		config:   cfg,
+		schedule: schedule,
+		sink:     sink,
+		status:   TaskStatus{TaskConfig: cfg},
+	}
+	s.tasks[cfg.Name] = t
+	if s.ctx != nil {
+		s.scheduleLocked(t)
+	}
+	logger.Debugf("[Tasks] Registered task %s (%s)", cfg.Name, cfg.Schedule)
+	return nil
+}
+
+// Remove unregisters a task
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, exists := s.tasks[name]
+	if !exists {
+		return false
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	delete(s.tasks, name)
+	return true
+}
+
+// List returns the status of all tasks sorted by name
+func (s *Scheduler) List() []TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]TaskStatus, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		result = append(result, t.status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Get returns the status of a task
+func (s *Scheduler) Get(name string) (TaskStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, exists := s.tasks[name]
+	if !exists {
+		return TaskStatus{}, false
+	}
+	return t.status, true
+}
+
+// RunNow runs a task immediately and waits for the result
+func (s *Scheduler) RunNow(ctx context.Context, name string) (*Result, error) {
+	s.mu.Lock()
+	t, exists := s.tasks[name]
+	if !exists {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("task %s not found", name)
+	}
+	if s.ctx != nil && s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("scheduler is stopped")
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	return s.run(ctx, t), nil
+}
+
+// scheduleLocked arms the timer for the next activation; s.mu must be held
+func (s *Scheduler) scheduleLocked(t *task) {
+	if !*t.config.Enabled || s.ctx.Err() != nil {
+		return
+	}
+	next := t.schedule.Next(time.Now())
+	if next.IsZero() {
+		logger.Warnf("[Tasks:%s] Schedule never fires", t.config.Name)
+		return
+	}
+	t.status.NextRun = next
+	t.timer = time.AfterFunc(time.Until(next), func() {
+		s.mu.Lock()
+		current, ok := s.tasks[t.config.Name]
+		if !ok || current != t || s.ctx.Err() != nil {
+			s.mu.Unlock()
+			return
+		}
+		// Stop cancels under s.mu before waiting, so no run is added once it waits
+		s.wg.Add(1)
+		s.mu.Unlock()
+		defer s.wg.Done()
+
+		s.run(s.ctx, t)
+
+		s.mu.Lock()
+		if current, ok := s.tasks[t.config.Name]; ok && current == t {
+			s.scheduleLocked(t)
+		}
+		s.mu.Unlock()
+	})
+}
+
+// run executes a task once and delivers the result; the caller has added it to s.wg
+func (s *Scheduler) run(ctx context.Context, t *task) *Result {
+	s.mu.Lock()
+	if t.status.Running {
+		s.mu.Unlock()
+		logger.Warnf("[Tasks:%s] Previous run still in progress, skipping", t.config.Name)
+		return &Result{Task: t.config.Name, Session: t.config.Session, Prompt: t.config.Prompt, Error: "previous run still in progress"}
+	}
+	t.status.Running = true
+	s.mu.Unlock()
+
+	result := &Result{
+		Task:      t.config.Name,
+		Session:   t.config.Session,
+		Prompt:    t.config.Prompt,
+		StartedAt: time.Now(),
+	}
+	logger.Infof("[Tasks:%s] Running task", t.config.Name)
+
+	caller := audit.CallerFromContext(ctx)
+	caller.Task = t.config.Name
+	runCtx, cancel := context.WithTimeout(audit.WithCaller(ctx, caller), time.Duration(t.config.Timeout))
+	defer cancel()
+
+	if runner, err := s.resolve(t.config.Agent); err != nil {
+		result.Error = err.Error()
+	} else {
+		if t.config.Fresh {
+			runner.ClearSession(t.config.Session)
+		}
+		msg, err := runner.Chat(runCtx, t.config.Session, t.config.Prompt)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Output = msg.Content
+			if msg.ResponseMeta != nil {
+				result.Usage = msg.ResponseMeta.Usage
+			}
+		}
+	}
+	result.FinishedAt = time.Now()
+	result.Duration = result.FinishedAt.Sub(result.StartedAt)
+
+	if err := t.sink.Deliver(ctx, result); err != nil {
+		logger.Errorf("[Tasks:%s] Failed to deliver result: %v", t.config.Name, err)
+		if result.Error == "" {
+			result.Error = fmt.Sprintf("delivery failed: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	t.status.Running = false
+	t.status.LastRun = result.StartedAt
+	t.status.LastError = result.Error
+	t.status.LastOutput = result.Output
+	t.status.RunCount++
+	s.mu.Unlock()
+
+	logger.Infof("[Tasks:%s] Task finished in %s", t.config.Name, result.Duration)
+	return result
+}
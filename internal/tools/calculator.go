@@ -0,0 +1,235 @@
+// Package tools provides optional built-in local tools that run without an MCP server.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const calculatorToolName = "calculate"
+
+type calculatorInput struct {
+	Expression string `json:"expression" jsonschema:"description=arithmetic expression using + - * / % ^ parentheses and sqrt abs floor ceil round ln log exp sin cos tan pi e"`
+}
+
+// newCalculatorTool returns a tool evaluating arithmetic expressions
+func newCalculatorTool() (tool.BaseTool, error) {
+	return utils.InferTool(calculatorToolName,
+		"Evaluate an arithmetic expression exactly instead of calculating in your head.",
+		reportErrors(func(ctx context.Context, in *calculatorInput) (string, error) {
+			value, err := evaluate(in.Expression)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatFloat(value, 'g', -1, 64), nil
+		}))
+}
+
+// evaluate parses and evaluates an arithmetic expression
+func evaluate(expr string) (float64, error) {
+	p := &exprParser{input: expr}
+	value, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return value, nil
+}
+
+// exprParser is a recursive descent parser with the usual precedence:
+// expr = term {(+|-) term}, term = unary {(*|/|%) unary}, unary = (-|+) unary | power, power = primary [^ unary]
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// consume advances past c if it is the next non-space character
+func (p *exprParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.consume('+'):
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			left += right
+		case p.consume('-'):
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			left -= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.consume('*'):
+			op = '*'
+		case p.consume('/'):
+			op = '/'
+		case p.consume('%'):
+			op = '%'
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			left *= right
+		case '/':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case '%':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	if p.consume('^') {
+		exp, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(base, exp), nil
+	}
+	return base, nil
+}
+
+func (p *exprParser) parseUnary() (float64, error) {
+	if p.consume('-') {
+		v, err := p.parseUnary()
+		return -v, err
+	}
+	if p.consume('+') {
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+// functions are the single-argument functions supported in expressions
+var functions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+	"ln":    math.Log,
+	"log":   math.Log10,
+	"exp":   math.Exp,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+}
+
+func (p *exprParser) parsePrimary() (float64, error) {
+	p.skipSpace()
+	if p.consume('(') {
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.consume(')') {
+			return 0, fmt.Errorf("missing closing parenthesis at position %d", p.pos)
+		}
+		return v, nil
+	}
+
+	start := p.pos
+	if p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+		for p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		name := strings.ToLower(p.input[start:p.pos])
+		switch name {
+		case "pi":
+			return math.Pi, nil
+		case "e":
+			return math.E, nil
+		}
+		fn, ok := functions[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown function %q", name)
+		}
+		if !p.consume('(') {
+			return 0, fmt.Errorf("expected ( after %s", name)
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.consume(')') {
+			return 0, fmt.Errorf("missing closing parenthesis at position %d", p.pos)
+		}
+		return fn(arg), nil
+	}
+
+	for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos >= len(p.input) {
+			return 0, fmt.Errorf("unexpected end of expression")
+		}
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos)
+	}
+	v, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+	}
+	return v, nil
+}
@@ -0,0 +1,145 @@
+// Package tools provides optional built-in local tools that run without an MCP server.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+type readFileInput struct {
+	Path string `json:"path" jsonschema:"description=file path relative to the sandbox directory"`
+}
+
+type writeFileInput struct {
+	Path    string `json:"path" jsonschema:"description=file path relative to the sandbox directory"`
+	Content string `json:"content" jsonschema:"description=full new file content"`
+}
+
+type listFilesInput struct {
+	Path string `json:"path,omitempty" jsonschema:"description=directory relative to the sandbox directory (default the sandbox root)"`
+}
+
+// sandbox confines file access to a root directory
+type sandbox struct {
+	root     string
+	maxBytes int
+}
+
+// resolve maps a model-supplied path into the sandbox, rejecting escapes through .. or symlinks
+func (s *sandbox) resolve(p string) (string, error) {
+	full := filepath.Join(s.root, filepath.Clean("/"+p))
+
+	// Resolve symlinks of the longest existing prefix so new files can still be created
+	existing := full
+	for {
+		if _, err := os.Lstat(existing); err == nil || existing == s.root {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", p, err)
+	}
+	if real != s.root && !strings.HasPrefix(real, s.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the sandbox", p)
+	}
+	return full, nil
+}
+
+// newFileTools returns read_file, list_files and (unless read-only) write_file bound to the sandbox
+func newFileTools(cfg FilesConfig) ([]tool.BaseTool, error) {
+	if cfg.Root == "" {
+		return nil, fmt.Errorf("root is required")
+	}
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create sandbox %s: %w", root, err)
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, err
+	}
+	sb := &sandbox{root: root, maxBytes: cfg.MaxBytes}
+	if sb.maxBytes <= 0 {
+		sb.maxBytes = defaultMaxBytes
+	}
+
+	readTool, err := utils.InferTool("read_file", "Read a text file from the sandbox directory.",
+		reportErrors(func(ctx context.Context, in *readFileInput) (string, error) {
+			full, err := sb.resolve(in.Path)
+			if err != nil {
+				return "", err
+			}
+			data, err := os.ReadFile(full)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", in.Path, err)
+			}
+			return truncate(string(data), sb.maxBytes), nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	listTool, err := utils.InferTool("list_files", "List the entries of a directory in the sandbox directory.",
+		reportErrors(func(ctx context.Context, in *listFilesInput) (string, error) {
+			full, err := sb.resolve(in.Path)
+			if err != nil {
+				return "", err
+			}
+			entries, err := os.ReadDir(full)
+			if err != nil {
+				return "", fmt.Errorf("failed to list %s: %w", in.Path, err)
+			}
+			var listing strings.Builder
+			for _, e := range entries {
+				name := e.Name()
+				if e.IsDir() {
+					name += "/"
+				}
+				listing.WriteString(name + "\n")
+			}
+			if listing.Len() == 0 {
+				return "(empty directory)", nil
+			}
+			return listing.String(), nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	result := []tool.BaseTool{readTool, listTool}
+	if cfg.ReadOnly {
+		return result, nil
+	}
+
+	writeTool, err := utils.InferTool("write_file", "Create or overwrite a text file in the sandbox directory.",
+		reportErrors(func(ctx context.Context, in *writeFileInput) (string, error) {
+			if len(in.Content) > sb.maxBytes {
+				return "", fmt.Errorf("content exceeds the %d byte limit", sb.maxBytes)
+			}
+			full, err := sb.resolve(in.Path)
+			if err != nil {
+				return "", err
+			}
+			if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+				return "", fmt.Errorf("failed to create directory for %s: %w", in.Path, err)
+			}
+			if err := os.WriteFile(full, []byte(in.Content), 0o644); err != nil {
+				return "", fmt.Errorf("failed to write %s: %w", in.Path, err)
+			}
+			return fmt.Sprintf("Wrote %d bytes to %s", len(in.Content), in.Path), nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+	return append(result, mutatingTool{writeTool}), nil
+}
@@ -0,0 +1,169 @@
+// Package tools provides optional built-in local tools that run without an MCP server.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const httpFetchToolName = "http_fetch"
+
+type httpFetchInput struct {
+	URL string `json:"url" jsonschema:"description=http or https URL to fetch with GET"`
+}
+
+// newHTTPFetchTool returns a tool fetching the body of a URL
+func newHTTPFetchTool(cfg HTTPFetchConfig) (tool.BaseTool, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		// No proxy, so the dial check sees the real target address
+		Transport: &http.Transport{
+			DialContext:         fetchDialContext(cfg.AllowedHosts),
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// Every redirect hop must pass the same checks as the requested URL
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			if err := checkFetchURL(cfg.AllowedHosts, req.URL); err != nil {
+				return fmt.Errorf("redirect refused: %w", err)
+			}
+			return nil
+		},
+	}
+
+	return utils.InferTool(httpFetchToolName,
+		"Fetch a web page or API response with an HTTP GET request and return the status and body.",
+		reportErrors(func(ctx context.Context, in *httpFetchInput) (string, error) {
+			u, err := url.Parse(in.URL)
+			if err != nil {
+				return "", fmt.Errorf("invalid URL %q: only http and https URLs are supported", in.URL)
+			}
+			if err := checkFetchURL(cfg.AllowedHosts, u); err != nil {
+				return "", err
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+			if err != nil {
+				return "", fmt.Errorf("failed to create request: %w", err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("request failed: %w", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+			if err != nil {
+				return "", fmt.Errorf("failed to read response: %w", err)
+			}
+			return fmt.Sprintf("HTTP %s\nContent-Type: %s\n\n%s",
+				resp.Status, resp.Header.Get("Content-Type"), truncate(string(body), maxBytes)), nil
+		}))
+}
+
+// checkFetchURL rejects URLs that are not http or https or whose host is not allowed
+func checkFetchURL(allowedHosts []string, u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: only http and https URLs are supported", u.String())
+	}
+	if !hostAllowed(allowedHosts, u.Hostname()) {
+		return fmt.Errorf("host %s is not allowed", u.Hostname())
+	}
+	return nil
+}
+
+// fetchDialContext dials like net.Dialer but refuses loopback, private, link-local and unspecified
+// addresses unless an allowed_hosts entry names the host exactly. The check runs on the resolved
+// address of every connection, so it also covers redirects and DNS rebinding.
+func fetchDialContext(allowedHosts []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		internalAllowed := hostNamed(allowedHosts, host)
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control: func(_, address string, _ syscall.RawConn) error {
+				ap, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return fmt.Errorf("unexpected dial address %q: %w", address, err)
+				}
+				if !internalAllowed && internalAddr(ap.Addr()) {
+					return fmt.Errorf("host %s resolves to internal address %s, which is not allowed", host, ap.Addr())
+				}
+				return nil
+			},
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// internalAddr reports whether addr is a loopback, private, link-local, unspecified or shared (CGNAT) address,
+// including in IPv4-mapped and NAT64 form
+func internalAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		// The well-known NAT64 prefix embeds the IPv4 target in its last four bytes
+		b := addr.As16()
+		addr = netip.AddrFrom4([4]byte(b[12:]))
+	}
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsUnspecified() || sharedPrefix.Contains(addr)
+}
+
+var (
+	// sharedPrefix is the shared address space of carrier-grade NAT, also used for cloud and Kubernetes internal addresses
+	sharedPrefix = netip.MustParsePrefix("100.64.0.0/10")
+	// nat64Prefix is the well-known prefix of IPv4 addresses translated by NAT64
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+)
+
+// hostNamed reports whether one of the patterns names host literally, without wildcards
+func hostNamed(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if !strings.ContainsAny(p, "*?[") && strings.EqualFold(strings.Trim(p, "[]"), strings.Trim(host, "[]")) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowed reports whether host matches one of the patterns; no patterns allow every host
+func hostAllowed(patterns []string, host string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), host); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// Package tools provides optional built-in local tools that run without an MCP server.
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+
+	"github.com/fourhu/eino-ai-agent/internal/logger"
+)
+
+const shellToolName = "run_command"
+
+type shellInput struct {
+	Command string   `json:"command" jsonschema:"description=executable to run (no shell syntax such as pipes or redirects)"`
+	Args    []string `json:"args,omitempty" jsonschema:"description=command arguments"`
+}
+
+// newShellTool returns a tool running allowlisted commands without a shell
+func newShellTool(cfg ShellConfig) (tool.BaseTool, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	maxBytes := cfg.MaxOutputBytes
+	if maxBytes <= 0 {
+		maxBytes = 64 << 10
+	}
+
+	t, err := utils.InferTool(shellToolName,
+		fmt.Sprintf("Run a local command and return its combined output. Allowed commands: %s.", strings.Join(cfg.AllowedCommands, ", ")),
+		reportErrors(func(ctx context.Context, in *shellInput) (string, error) {
+			if in.Command != filepath.Base(in.Command) || !slices.Contains(cfg.AllowedCommands, in.Command) {
+				return "", fmt.Errorf("command %q is not allowed", in.Command)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			logger.Infof("[Tool:%s] Running %s %s", shellToolName, in.Command, strings.Join(in.Args, " "))
+			cmd := exec.CommandContext(ctx, in.Command, in.Args...)
+			cmd.Dir = cfg.WorkDir
+			var out bytes.Buffer
+			cmd.Stdout = &out
+			cmd.Stderr = &out
+
+			err := cmd.Run()
+			output := truncate(out.String(), maxBytes)
+			var exitErr *exec.ExitError
+			switch {
+			case ctx.Err() == context.DeadlineExceeded:
+				return "", fmt.Errorf("command timed out after %s", timeout)
+			case errors.As(err, &exitErr):
+				return fmt.Sprintf("exit code %d\n%s", exitErr.ExitCode(), output), nil
+			case err != nil:
+				return "", fmt.Errorf("failed to run %s: %w", in.Command, err)
+			}
+			return output, nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+	return mutatingTool{t}, nil
+}
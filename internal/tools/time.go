@@ -0,0 +1,34 @@
+// Package tools provides optional built-in local tools that run without an MCP server.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const timeToolName = "current_time"
+
+type timeInput struct {
+	Timezone string `json:"timezone,omitempty" jsonschema:"description=IANA timezone such as Europe/Berlin (default UTC)"`
+}
+
+// newTimeTool returns a tool reporting the current date and time
+func newTimeTool() (tool.BaseTool, error) {
+	return utils.InferTool(timeToolName,
+		"Get the current date and time, optionally in a specific timezone.",
+		reportErrors(func(ctx context.Context, in *timeInput) (string, error) {
+			loc := time.UTC
+			if in.Timezone != "" {
+				var err error
+				if loc, err = time.LoadLocation(in.Timezone); err != nil {
+					return "", fmt.Errorf("unknown timezone %q", in.Timezone)
+				}
+			}
+			now := time.Now().In(loc)
+			return fmt.Sprintf("%s (%s)", now.Format(time.RFC3339), now.Weekday()), nil
+		}))
+}
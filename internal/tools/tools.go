@@ -0,0 +1,125 @@
+// Package tools provides optional built-in local tools that run without an MCP server.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Config selects the built-in tools to enable
+type Config struct {
+	Time       bool            `json:"time" yaml:"time"`             // current_time
+	Calculator bool            `json:"calculator" yaml:"calculator"` // calculate
+	HTTPFetch  HTTPFetchConfig `json:"http_fetch" yaml:"http_fetch"` // http_fetch
+	Shell      ShellConfig     `json:"shell" yaml:"shell"`           // run_command
+	Files      FilesConfig     `json:"files" yaml:"files"`           // read_file, write_file, list_files
+}
+
+// HTTPFetchConfig configures the http_fetch tool
+type HTTPFetchConfig struct {
+	Enabled      bool          `json:"enabled" yaml:"enabled"`
+	AllowedHosts []string      `json:"allowed_hosts,omitempty" yaml:"allowed_hosts,omitempty"` // Host globs (empty = any public host); internal addresses need an entry naming the host exactly
+	Timeout      time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`             // Request timeout (default 30s)
+	MaxBytes     int           `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`         // Response body limit (default 1MiB)
+}
+
+// ShellConfig configures the run_command tool
+type ShellConfig struct {
+	Enabled         bool          `json:"enabled" yaml:"enabled"`
+	AllowedCommands []string      `json:"allowed_commands" yaml:"allowed_commands"`                     // Executables that may be run, e.g. ["ls", "kubectl"]
+	WorkDir         string        `json:"work_dir,omitempty" yaml:"work_dir,omitempty"`                 // Working directory (default current directory)
+	Timeout         time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`                   // Per-command timeout (default 30s)
+	MaxOutputBytes  int           `json:"max_output_bytes,omitempty" yaml:"max_output_bytes,omitempty"` // Output limit (default 64KiB)
+}
+
+// FilesConfig configures the file tools
+type FilesConfig struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Root     string `json:"root" yaml:"root"`                               // Sandbox directory; paths outside it are rejected
+	ReadOnly bool   `json:"read_only,omitempty" yaml:"read_only,omitempty"` // Do not register write_file
+	MaxBytes int    `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"` // Read/write size limit (default 1MiB)
+}
+
+const (
+	defaultTimeout  = 30 * time.Second
+	defaultMaxBytes = 1 << 20
+)
+
+// New creates the enabled built-in tools
+func New(cfg *Config) ([]tool.BaseTool, error) {
+	var result []tool.BaseTool
+	add := func(name string, t tool.BaseTool, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to create built-in tool %s: %w", name, err)
+		}
+		result = append(result, t)
+		return nil
+	}
+
+	if cfg.Time {
+		t, err := newTimeTool()
+		if err := add(timeToolName, t, err); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Calculator {
+		t, err := newCalculatorTool()
+		if err := add(calculatorToolName, t, err); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.HTTPFetch.Enabled {
+		t, err := newHTTPFetchTool(cfg.HTTPFetch)
+		if err := add(httpFetchToolName, t, err); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Shell.Enabled {
+		if len(cfg.Shell.AllowedCommands) == 0 {
+			return nil, fmt.Errorf("built-in tool %s: allowed_commands must not be empty", shellToolName)
+		}
+		t, err := newShellTool(cfg.Shell)
+		if err := add(shellToolName, t, err); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Files.Enabled {
+		fileTools, err := newFileTools(cfg.Files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create built-in file tools: %w", err)
+		}
+		result = append(result, fileTools...)
+	}
+	return result, nil
+}
+
+// mutatingTool marks a tool that changes its environment, so read-only sessions block it
+type mutatingTool struct {
+	tool.InvokableTool
+}
+
+func (mutatingTool) Mutating() bool {
+	return true
+}
+
+// reportErrors turns tool failures into results so the model can correct its call instead of the run failing
+func reportErrors[T any](fn func(ctx context.Context, in *T) (string, error)) func(ctx context.Context, in *T) (string, error) {
+	return func(ctx context.Context, in *T) (string, error) {
+		result, err := fn(ctx, in)
+		if err != nil {
+			return "Error: " + err.Error(), nil
+		}
+		return result, nil
+	}
+}
+
+// truncate cuts s to at most max bytes and marks the cut
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + fmt.Sprintf("\n... [truncated, %d bytes total]", len(s))
+}